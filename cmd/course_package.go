@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cvzamannow/E-Learning-API/config"
+	"github.com/cvzamannow/E-Learning-API/repository"
+	"github.com/cvzamannow/E-Learning-API/service"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func CoursePackageCMD() cli.Command {
+	sourceFlag := cli.StringFlag{
+		Name:  "source",
+		Usage: "DSN of the database, default built from the DB_* environment variables of the server",
+	}
+
+	return cli.Command{
+		Name:  "course-package",
+		Usage: "Export a course to a course package or import a course package",
+		Subcommands: []cli.Command{
+			{
+				Name:  "export",
+				Usage: "Export the draft of a course with its assets to a zip file",
+				Flags: []cli.Flag{
+					sourceFlag,
+					cli.StringFlag{Name: "course", Usage: "ID of the course"},
+					cli.StringFlag{Name: "out", Usage: "Path of the zip file, default <course>.zip"},
+				},
+				Action: func(c *cli.Context) error {
+					courseID := c.String("course")
+					if courseID == "" {
+						return fmt.Errorf("--course is required")
+					}
+
+					out := c.String("out")
+					if out == "" {
+						out = courseID + ".zip"
+					}
+
+					db := openCoursePackageDB(c.String("source"))
+
+					file, err := os.Create(out)
+					if err != nil {
+						return err
+					}
+					defer file.Close()
+
+					packager := service.NewCoursePackager(repository.NewCourseRepository(db), coursePackageStorage())
+
+					pkg, err := packager.Export(courseID, file)
+					if err != nil {
+						os.Remove(out)
+						return err
+					}
+
+					logrus.Infof("[course-package] Exported course %s with %d assets to %s", pkg.Course.Title, len(pkg.Assets), out)
+					return nil
+				},
+			},
+			{
+				Name:  "import",
+				Usage: "Import a course package as a draft course of a teacher",
+				Flags: []cli.Flag{
+					sourceFlag,
+					cli.StringFlag{Name: "file", Usage: "Path of the zip file"},
+					cli.StringFlag{Name: "teacher", Usage: "ID of the teacher owning the imported course"},
+					cli.StringFlag{Name: "on-conflict", Value: "fail", Usage: "fail or rename when the slug is already used"},
+				},
+				Action: func(c *cli.Context) error {
+					filename, teacherID := c.String("file"), c.String("teacher")
+					if filename == "" || teacherID == "" {
+						return fmt.Errorf("--file and --teacher are required")
+					}
+
+					policy := service.CONFLICT_POLICY(strings.ToUpper(c.String("on-conflict")))
+					if policy != service.CONFLICT_FAIL && policy != service.CONFLICT_RENAME {
+						return fmt.Errorf("--on-conflict must be fail or rename")
+					}
+
+					data, err := os.ReadFile(filename)
+					if err != nil {
+						return err
+					}
+
+					db := openCoursePackageDB(c.String("source"))
+
+					teacher, err := repository.NewAuthRepository(db).FindTeacher(map[string]interface{}{
+						"id": teacherID,
+					})
+					if err != nil {
+						return fmt.Errorf("teacher %s: %w", teacherID, err)
+					}
+
+					// Slug dicek dan course dibuat di sekolah teacher
+					tenant := repository.Tenant{}
+					if teacher.SchoolsID != nil {
+						tenant.SchoolID = *teacher.SchoolsID
+					}
+					courses := repository.NewCourseRepository(db).WithContext(repository.WithTenant(context.Background(), tenant))
+
+					course, err := service.NewCoursePackager(courses, coursePackageStorage()).Import(data, teacher.ID, policy)
+					if err != nil {
+						return err
+					}
+
+					logrus.Infof("[course-package] Imported course %s as %s with slug %s", course.Title, course.ID, course.Slug)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func openCoursePackageDB(source string) *gorm.DB {
+	if source == "" {
+		confDB := config.DBConfigFromEnv()
+		source = confDB.DSN()
+	}
+
+	db, err := gorm.Open(postgres.Open(source), &gorm.Config{})
+	if err != nil {
+		logrus.Fatalf("[course-package] Failed to connect database source %s \n", err.Error())
+	}
+
+	if err := repository.RegisterTenantScope(db); err != nil {
+		logrus.Fatalf("[course-package] Failed to register tenant scope because %s \n", err.Error())
+	}
+
+	return db
+}
+
+// coursePackageStorage is the R2 storage of the assets, nil when R2_BUCKET is not set.
+func coursePackageStorage() *service.R2Stub {
+	if os.Getenv("R2_BUCKET") == "" {
+		return nil
+	}
+
+	return service.NewR2Stub(&service.R2Cloudlfare{
+		Bucket:       os.Getenv("R2_BUCKET"),
+		AccountID:    os.Getenv("R2_ACCOUNT_ID"),
+		Key:          os.Getenv("R2_KEY"),
+		Secret:       os.Getenv("R2_SECRET"),
+		PubBucketUrl: os.Getenv("R2_PUB"),
+	})
+}
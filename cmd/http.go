@@ -2,8 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/cvzamannow/E-Learning-API/config"
 	"github.com/cvzamannow/E-Learning-API/http/handlers"
@@ -13,23 +14,49 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
 func HTTPGatewayServer(port int) {
-	app := fiber.New()
-
-	confDB := config.DBConfig{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     os.Getenv("DB_PORT"),
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   os.Getenv("DB_NAME"),
-		SSLMode:  os.Getenv("DB_SSLMODE"),
+	// BODY_LIMIT_MB menaikkan batas ukuran request, misalnya untuk upload SCORM package
+	bodyLimit := fiber.DefaultBodyLimit
+	if limit, err := strconv.Atoi(os.Getenv("BODY_LIMIT_MB")); err == nil && limit > 0 {
+		bodyLimit = limit << 20
 	}
 
+	app := fiber.New(fiber.Config{
+		BodyLimit: bodyLimit,
+	})
+
+	confDB := config.DBConfigFromEnv()
+
 	JWT_SECRET := os.Getenv("JWT_SECRET")
 
+	// JWT_KEYS berisi kunci RS256/EdDSA yang dirotasi, JWT_SECRET (HS256) hanya dipakai
+	// selama belum ada kunci yang aktif
+	jwtKeys, err := middleware.LoadKeySet(os.Getenv("JWT_KEYS"), []byte(JWT_SECRET))
+	if err != nil {
+		logrus.Fatalf("[http] Failed to load JWT keys because %s \n", err.Error())
+	}
+
+	// JWT_LEGACY_HS256_UNTIL (RFC3339) menerima token HS256 lama sampai waktu tersebut setelah
+	// kunci RS256/EdDSA aktif, tanpa nilai token HS256 langsung ditolak
+	if until := os.Getenv("JWT_LEGACY_HS256_UNTIL"); until != "" {
+		jwtKeys.LegacyUntil, err = time.Parse(time.RFC3339, until)
+		if err != nil {
+			logrus.Fatalf("[http] Invalid JWT_LEGACY_HS256_UNTIL because %s \n", err.Error())
+		}
+	}
+
+	if len(jwtKeys.Keys) > 0 && len(jwtKeys.Secret) > 0 {
+		if jwtKeys.LegacyUntil.IsZero() {
+			logrus.Infoln("[http] HS256 tokens are rejected once a JWT key signs")
+		} else {
+			logrus.Infoln("[http] HS256 tokens are accepted until", jwtKeys.LegacyUntil.Format(time.RFC3339))
+		}
+	}
+
 	// S3 Cloudflare Provider
 	r2Cloudflare := service.NewR2Stub(&service.R2Cloudlfare{
 		Bucket:       os.Getenv("R2_BUCKET"),
@@ -41,6 +68,12 @@ func HTTPGatewayServer(port int) {
 
 	// Dependency Injection
 	newDB := config.NewDBConfig(&confDB)
+
+	// Membatasi query repository ke sekolah user yang login
+	if err := repository.RegisterTenantScope(newDB); err != nil {
+		logrus.Fatalf("[http] Failed to register tenant scope because %s \n", err.Error())
+	}
+
 	courseRepos := repository.NewCourseRepository(newDB)
 
 	// Dependency Injection auth / user
@@ -58,18 +91,43 @@ func HTTPGatewayServer(port int) {
 	// Grades
 	gradesRepos := repository.NewGradesRepository(newDB)
 
+	// Percobaan login disimpan di Postgres agar terbagi antar instance,
+	// LOGIN_ATTEMPT_STORE=memory cukup untuk satu instance
+	var loginAttempts middleware.LoginAttemptStore = userRepos
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "memory" {
+		loginAttempts = middleware.NewMemoryLoginAttempts()
+	}
+
+	// TWO_FACTOR_REQUIRED_ROLES=SUPER_ADMIN,ADMIN mewajibkan TOTP untuk role tersebut
+	twoFactorRoles, err := handlers.ParseTwoFactorRoles(os.Getenv("TWO_FACTOR_REQUIRED_ROLES"))
+	if err != nil {
+		logrus.Fatalf("[http] Failed to parse TWO_FACTOR_REQUIRED_ROLES because %s \n", err.Error())
+	}
+
 	handlersDep := handlers.Handlers{
 		R2Cloudflare:     r2Cloudflare,
 		UserRepository:   userRepos,
 		CourseRepository: courseRepos,
-		JWT_SECRET:       []byte(JWT_SECRET),
 		Middleware: middleware.Middleware{
-			JwtSecret: []byte(JWT_SECRET),
+			Keys:     jwtKeys,
+			Sessions: userRepos,
+			// Sesi yang dicabut di instance lain paling lama terlihat aktif selama TTL cache
+			SessionCache: middleware.NewSessionCache(30 * time.Second),
+			Permissions:  handlers.Permissions,
+			LoginGuard:   middleware.NewLoginGuard(loginAttempts),
+			// Token user dengan password sementara hanya bisa dipakai untuk mengganti password
+			PasswordChangeRoutes: handlers.PasswordChangeRoutes,
+			// Token user yang wajib 2FA hanya bisa dipakai untuk mendaftarkan TOTP
+			TwoFactorEnrollRoutes: handlers.TwoFactorEnrollRoutes,
 		},
 		SchoolRepository: schoolsRepos,
 		QuizRepository:   quizRepos,
 		GradesRepository: gradesRepos,
 		CertificateRepo: certificateRepos,
+		// Registrasi terbuka hanya untuk development, user lain masuk lewat undangan
+		OpenRegistration: os.Getenv("OPEN_REGISTRATION") == "true",
+		InvitationURL:    os.Getenv("INVITATION_URL"),
+		TwoFactorRoles:   twoFactorRoles,
 	}
 
 	// Setup global middleware
@@ -82,33 +140,12 @@ func HTTPGatewayServer(port int) {
 		AllowMethods: "GET,POST,PUT,DELETE",
 	}))
 
-	handlersDep.RouteCertificates(app)
-
-	// Setup Course Route
-	handlersDep.RouteCourses(app)
-
-	// Setup Auth Route
-	handlersDep.RouteAuth(app)
+	handlersDep.Routes(app)
 
-	// Setup User Management Route
-	handlersDep.RouterUserManagemet(app)
-
-	// Setup Schools Route
-	handlersDep.RouterSchool(app)
-
-	// Setup Quiz Route
-	handlersDep.RouterQuiz(app)
-	// R2 Storage Route
-	handlersDep.RouteStorage(app)
-
-	// grades router
-	handlersDep.RouteGrades(app)
-
-	app.Get("/api/v1", func(c *fiber.Ctx) error {
-		return c.Status(http.StatusOK).JSON(map[string]string{
-			"message": "E Learning API Version 1.0.0",
-		})
-	})
+	// Setiap route wajib terdaftar di permission matrix
+	if err := handlers.Permissions.Check(app.GetRoutes(true)); err != nil {
+		logrus.Fatalf("[http] Failed to start server because %s \n", err.Error())
+	}
 
 	app.Listen(fmt.Sprintf(":%d", port))
 }
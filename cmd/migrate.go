@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"os"
+	"time"
 
+	"github.com/cvzamannow/E-Learning-API/helper"
 	"github.com/cvzamannow/E-Learning-API/model"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func DoMigrateUpCMD() cli.Command {
@@ -45,9 +48,27 @@ func DoMigrateUpCMD() cli.Command {
 				&model.Schools{},
 				&model.AdminSchool{},
 				&model.Quiz{},
+				&model.QuestionBank{},
 				&model.Quizes{},
 				&model.QuizAnswer{},
 				&model.QuizAnswerStudent{},
+				&model.QuizAttempt{},
+				&model.QuizAttemptQuestion{},
+				&model.QuizDrawRule{},
+				&model.QuizGradeAudit{},
+				&model.UserSession{},
+				&model.RefreshToken{},
+				&model.CourseTeacher{},
+				&model.LoginAttempt{},
+				&model.Invitation{},
+				&model.UserTOTP{},
+				&model.RecoveryCode{},
+				&model.MaterialPrerequisite{},
+				&model.CourseVersion{},
+				&model.CourseEnrollment{},
+				&model.CourseTemplate{},
+				&model.ScormPackage{},
+				&model.ScormRegistration{},
 				&model.ActiveStudentCourse{},
 				&model.CompleteCourse{},
 				&model.Certificate{},
@@ -58,9 +79,100 @@ func DoMigrateUpCMD() cli.Command {
 				os.Exit(1)
 			}
 
+			// Akun dari /register lama bisa tersimpan tanpa status, login hanya menerima user ACTIVE
+			activated := db.Model(&model.User{}).
+				Where("status IS NULL OR status = ''").
+				Update("status", model.USER_ACTIVE)
+
+			if activated.Error != nil {
+				logrus.Fatalf("[migrate-up] Failed to activate users without status because %s \n", activated.Error.Error())
+				os.Exit(1)
+			}
+
+			logrus.Infof("[migrate-up] Activated %d users without status", activated.RowsAffected)
+
+			backfilled, err := backfillQuizAttempts(db)
+
+			if err != nil {
+				logrus.Fatalf("[migrate-up] Failed to backfill quiz attempts because %s \n", err.Error())
+				os.Exit(1)
+			}
+
+			logrus.Infof("[migrate-up] Backfilled %d quiz attempts from legacy quiz answers", backfilled)
+
 			logrus.Info("[migrate-up] Successfuly migrate up database...")
 
 			return nil
 		},
 	}
 }
+
+// backfillQuizAttempts converts the quiz answers stored before quiz attempts existed into one
+// finished attempt per quiz and student, grades are read from attempts only. Each legacy answer
+// carries the running score of its submission, so the last one holds the grade of the quiz.
+// Every legacy question was worth 1 point. Converted answers are linked to their attempt,
+// running the migration again skips them.
+func backfillQuizAttempts(db *gorm.DB) (int, error) {
+	legacy := "quiz_attempt_id IS NULL OR quiz_attempt_id = ''"
+
+	var groups []struct {
+		QuizID          string
+		ActiveStudentID string
+		TotalQuestions  int
+		StartedAt       time.Time
+		FinishedAt      time.Time
+	}
+
+	if err := db.Model(&model.QuizAnswerStudent{}).
+		Select("quiz_id, active_student_id, COUNT(DISTINCT quizes_id) AS total_questions, MIN(created_at) AS started_at, MAX(created_at) AS finished_at").
+		Where(legacy).
+		Group("quiz_id, active_student_id").
+		Scan(&groups).Error; err != nil {
+		return 0, err
+	}
+
+	for _, group := range groups {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			answers := func() *gorm.DB {
+				return tx.Model(&model.QuizAnswerStudent{}).
+					Where("quiz_id = ? AND active_student_id = ?", group.QuizID, group.ActiveStudentID).
+					Where(legacy)
+			}
+
+			var last model.QuizAnswerStudent
+			if err := answers().Order("created_at DESC").First(&last).Error; err != nil {
+				return err
+			}
+
+			id, err := helper.GenerateNanoId()
+			if err != nil {
+				return err
+			}
+
+			finishedAt := group.FinishedAt
+			attempt := model.QuizAttempt{
+				ID:              id,
+				QuizID:          group.QuizID,
+				ActiveStudentID: group.ActiveStudentID,
+				Score:           float64(last.Score),
+				Grades:          last.Grades,
+				TotalQuestions:  group.TotalQuestions,
+				TotalPoints:     group.TotalQuestions,
+				StartedAt:       group.StartedAt,
+				FinishedAt:      &finishedAt,
+			}
+
+			if err := tx.Omit(clause.Associations).Create(&attempt).Error; err != nil {
+				return err
+			}
+
+			return answers().Update("quiz_attempt_id", id).Error
+		})
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(groups), nil
+}
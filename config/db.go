@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/cvzamannow/E-Learning-API/model"
 	"github.com/sirupsen/logrus"
@@ -18,8 +19,21 @@ type DBConfig struct {
 	SSLMode  string
 }
 
-func NewDBConfig(source *DBConfig) *gorm.DB {
-	dsn := fmt.Sprintf(
+// DBConfigFromEnv reads the database connection from the DB_* environment variables.
+func DBConfigFromEnv() DBConfig {
+	return DBConfig{
+		Host:     os.Getenv("DB_HOST"),
+		Port:     os.Getenv("DB_PORT"),
+		User:     os.Getenv("DB_USER"),
+		Password: os.Getenv("DB_PASSWORD"),
+		DBName:   os.Getenv("DB_NAME"),
+		SSLMode:  os.Getenv("DB_SSLMODE"),
+	}
+}
+
+// DSN returns the Postgres connection string of the config.
+func (source *DBConfig) DSN() string {
+	return fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=Asia/Jakarta",
 
 		source.Host,
@@ -29,6 +43,10 @@ func NewDBConfig(source *DBConfig) *gorm.DB {
 		source.Port,
 		source.SSLMode,
 	)
+}
+
+func NewDBConfig(source *DBConfig) *gorm.DB {
+	dsn := source.DSN()
 
 	logrus.Printf("[config][func: NewDBConfig] DB DSN: %s", dsn)
 
@@ -53,9 +71,27 @@ func NewDBConfig(source *DBConfig) *gorm.DB {
 				&model.Schools{},
 				&model.AdminSchool{},
 				&model.Quiz{},
+				&model.QuestionBank{},
 				&model.Quizes{},
 				&model.QuizAnswer{},
 				&model.QuizAnswerStudent{},
+				&model.QuizAttempt{},
+				&model.QuizAttemptQuestion{},
+				&model.QuizDrawRule{},
+				&model.QuizGradeAudit{},
+				&model.UserSession{},
+				&model.RefreshToken{},
+				&model.CourseTeacher{},
+				&model.LoginAttempt{},
+				&model.Invitation{},
+				&model.UserTOTP{},
+				&model.RecoveryCode{},
+				&model.MaterialPrerequisite{},
+				&model.CourseVersion{},
+				&model.CourseEnrollment{},
+				&model.CourseTemplate{},
+				&model.ScormPackage{},
+				&model.ScormRegistration{},
 				&model.ActiveStudentCourse{},
 				&model.CompleteCourse{},
 			)
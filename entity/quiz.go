@@ -0,0 +1,46 @@
+package entity
+
+// QuizItemAnalysis is the item analysis of a quiz computed from the finished attempts.
+type QuizItemAnalysis struct {
+	Summary           QuizAnalysisSummary
+	Questions         []QuizQuestionAnalysis
+	Distractors       []QuizDistractorAnalysis
+	ScoreDistribution []QuizScoreBucket
+}
+
+type QuizAnalysisSummary struct {
+	Attempts       int
+	Students       int
+	AverageGrades  float64
+	AverageSeconds float64
+}
+
+// QuizQuestionAnalysis describes how a question performed. Given counts the attempts
+// the question was in, unanswered questions count as incorrect. Discrimination is the share
+// of correct answers in the upper 27% attempts minus the lower 27%, between -1 and 1.
+type QuizQuestionAnalysis struct {
+	QuizesID       string
+	Quiz           string
+	Type           string
+	Points         int
+	Given          int
+	Answered       int
+	Correct        int
+	PercentCorrect float64
+	AverageScore   float64
+	Discrimination float64
+}
+
+type QuizDistractorAnalysis struct {
+	QuizesID     string
+	QuizAnswerID string
+	Answer       string
+	IsCorrect    bool
+	Selected     int
+}
+
+type QuizScoreBucket struct {
+	MinGrades int
+	MaxGrades int
+	Total     int
+}
@@ -0,0 +1,852 @@
+package helper
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/gosimple/slug"
+)
+
+// A course package is a zip moving a course between installs of the API. manifest.json describes
+// the course tree, the uploaded assets the course refers to are stored under assets/.
+const (
+	CoursePackageFormat = "simaku-course"
+	// CoursePackageVersion is the manifest version written by WriteCoursePackage. Packages of
+	// a newer version are rejected, older versions stay readable.
+	CoursePackageVersion = 1
+
+	coursePackageManifest = "manifest.json"
+	coursePackageAssets   = "assets/"
+
+	// coursePackageMaxFileSize limits a single file read from a course package, coursePackageMaxSize
+	// all of them.
+	coursePackageMaxFileSize = 50 << 20
+	coursePackageMaxSize     = 500 << 20
+	coursePackageMaxFiles    = 5000
+)
+
+var (
+	ErrInvalidCoursePackage     = errors.New("file is not a valid course package")
+	ErrUnsupportedCoursePackage = fmt.Errorf("course package version is not supported, the latest version is %d", CoursePackageVersion)
+)
+
+// coursePackageURL matches the URLs in the text of a course.
+var coursePackageURL = regexp.MustCompile(`https?://[^\s"'<>()\[\]]+`)
+
+type CoursePackage struct {
+	Format        string               `json:"format"`
+	FormatVersion int                  `json:"format_version"`
+	ExportedAt    time.Time            `json:"exported_at"`
+	Course        CoursePackageCourse  `json:"course"`
+	Assets        []CoursePackageAsset `json:"assets"`
+}
+
+// CoursePackageCourse is the course tree of a package. The IDs are the IDs of the exported
+// install, they are only used to resolve the references inside the package.
+type CoursePackageCourse struct {
+	ID               string                      `json:"id"`
+	Title            string                      `json:"title"`
+	Slug             string                      `json:"slug"`
+	Description      string                      `json:"description"`
+	Detail           string                      `json:"detail"`
+	EstimationHour   string                      `json:"estimation_hour"`
+	EstimationMinute string                      `json:"estimation_minute"`
+	ThumbnailImg     string                      `json:"thumbnail_img"`
+	Classes          []string                    `json:"classes"`
+	Chapters         []CoursePackageChapter      `json:"chapters"`
+	QuestionBanks    []CoursePackageQuestionBank `json:"question_banks,omitempty"`
+}
+
+type CoursePackageChapter struct {
+	ID        string                  `json:"id"`
+	Title     string                  `json:"title"`
+	Slug      string                  `json:"slug"`
+	Position  int                     `json:"position"`
+	Materials []CoursePackageMaterial `json:"materials"`
+}
+
+// CoursePackageMaterial has the Theory, Submission brief, Quiz or Scorm package of its Type.
+type CoursePackageMaterial struct {
+	ID            string                      `json:"id"`
+	Title         string                      `json:"title"`
+	Type          string                      `json:"type"`
+	Slug          string                      `json:"slug"`
+	Position      int                         `json:"position"`
+	Theory        *string                     `json:"theory,omitempty"`
+	Submission    *string                     `json:"submission,omitempty"`
+	Quiz          *CoursePackageQuiz          `json:"quiz,omitempty"`
+	Scorm         *CoursePackageScorm         `json:"scorm,omitempty"`
+	Prerequisites []CoursePackagePrerequisite `json:"prerequisites,omitempty"`
+}
+
+type CoursePackageQuiz struct {
+	Title            string                  `json:"title"`
+	Description      string                  `json:"description"`
+	MaxAttempts      int                     `json:"max_attempts"`
+	ScoringPolicy    model.SCORING_POLICY    `json:"scoring_policy"`
+	TimeLimit        int                     `json:"time_limit"`
+	OpenAt           *time.Time              `json:"open_at,omitempty"`
+	CloseAt          *time.Time              `json:"close_at,omitempty"`
+	LateSubmission   model.LATE_SUBMISSION   `json:"late_submission"`
+	ReviewPolicy     model.REVIEW_POLICY     `json:"review_policy"`
+	ShuffleQuestions bool                    `json:"shuffle_questions"`
+	ShuffleAnswers   bool                    `json:"shuffle_answers"`
+	Questions        []CoursePackageQuestion `json:"questions"`
+	DrawRules        []CoursePackageDrawRule `json:"draw_rules,omitempty"`
+}
+
+// CoursePackageScorm is a SCORM package. Its files are not part of the course package, the
+// imported material launches them from BaseURL.
+type CoursePackageScorm struct {
+	Identifier   string   `json:"identifier"`
+	Title        string   `json:"title"`
+	LaunchPath   string   `json:"launch_path"`
+	LaunchURL    string   `json:"launch_url"`
+	BaseURL      string   `json:"base_url"`
+	MasteryScore *float64 `json:"mastery_score,omitempty"`
+	LaunchData   string   `json:"launch_data,omitempty"`
+	SHA256       string   `json:"sha256"`
+	Size         int64    `json:"size"`
+	Files        int      `json:"files"`
+}
+
+type CoursePackageQuestion struct {
+	Question   string                    `json:"question"`
+	Type       model.QUESTION_TYPE       `json:"type"`
+	ImgURL     string                    `json:"img_url,omitempty"`
+	Points     int                       `json:"points"`
+	Topic      string                    `json:"topic,omitempty"`
+	Difficulty model.QUESTION_DIFFICULTY `json:"difficulty,omitempty"`
+	Answers    []CoursePackageAnswer     `json:"answers"`
+}
+
+type CoursePackageAnswer struct {
+	Answer    string  `json:"answer"`
+	IsCorrect bool    `json:"is_correct"`
+	Tolerance float64 `json:"tolerance,omitempty"`
+	Position  int     `json:"position,omitempty"`
+}
+
+type CoursePackageQuestionBank struct {
+	ID          string                  `json:"id"`
+	ChapterID   string                  `json:"chapter_id,omitempty"`
+	Title       string                  `json:"title"`
+	Description string                  `json:"description"`
+	Questions   []CoursePackageQuestion `json:"questions"`
+}
+
+type CoursePackageDrawRule struct {
+	QuestionBankID string                    `json:"question_bank_id"`
+	Topic          string                    `json:"topic,omitempty"`
+	Difficulty     model.QUESTION_DIFFICULTY `json:"difficulty,omitempty"`
+	Count          int                       `json:"count"`
+}
+
+// CoursePackagePrerequisite requires the material MaterialID of the package.
+type CoursePackagePrerequisite struct {
+	Type       model.PREREQUISITE_TYPE `json:"type"`
+	MaterialID string                  `json:"material_id"`
+	MinGrades  int                     `json:"min_grades,omitempty"`
+}
+
+// CoursePackageAsset is an uploaded file referenced by URL in the course, stored at Path in the package.
+type CoursePackageAsset struct {
+	URL    string `json:"url"`
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// NewCoursePackage returns the package of a course. The chapters of course must be loaded with their
+// materials, theory, submission and prerequisites, quizzes are the quizzes of the materials.
+func NewCoursePackage(course model.Course, quizzes []model.Quiz, banks []model.QuestionBank) *CoursePackage {
+	pkg := &CoursePackage{
+		Format:        CoursePackageFormat,
+		FormatVersion: CoursePackageVersion,
+		ExportedAt:    time.Now(),
+		Course: CoursePackageCourse{
+			ID:               course.ID,
+			Title:            course.Title,
+			Slug:             course.Slug,
+			Description:      course.Description,
+			Detail:           course.Detail,
+			EstimationHour:   course.EstimationHour,
+			EstimationMinute: course.EstimationMinute,
+			ThumbnailImg:     course.ThumbnailImg,
+			Classes:          []string{},
+			Chapters:         []CoursePackageChapter{},
+		},
+		Assets: []CoursePackageAsset{},
+	}
+
+	for _, class := range course.CourseClasses {
+		pkg.Course.Classes = append(pkg.Course.Classes, class.Class)
+	}
+
+	quizOf := make(map[string]model.Quiz, len(quizzes))
+	for _, quiz := range quizzes {
+		quizOf[quiz.MaterialID] = quiz
+	}
+
+	for _, chapter := range course.Chapters {
+		packageChapter := CoursePackageChapter{
+			ID:        chapter.ID,
+			Title:     chapter.Title,
+			Slug:      chapter.Slug,
+			Position:  chapter.Position,
+			Materials: []CoursePackageMaterial{},
+		}
+
+		for _, material := range chapter.Materials {
+			packageMaterial := CoursePackageMaterial{
+				ID:       material.ID,
+				Title:    material.Title,
+				Type:     material.Type,
+				Slug:     material.Slug,
+				Position: material.Position,
+			}
+
+			if material.Theory.ID != "" {
+				content := material.Theory.Content
+				packageMaterial.Theory = &content
+			}
+
+			if material.Submission.ID != "" {
+				content := material.Submission.Content
+				packageMaterial.Submission = &content
+			}
+
+			if quiz, ok := quizOf[material.ID]; ok {
+				packageMaterial.Quiz = coursePackageQuiz(quiz)
+			}
+
+			if material.Scorm.ID != "" {
+				packageMaterial.Scorm = &CoursePackageScorm{
+					Identifier:   material.Scorm.Identifier,
+					Title:        material.Scorm.Title,
+					LaunchPath:   material.Scorm.LaunchPath,
+					LaunchURL:    material.Scorm.LaunchURL,
+					BaseURL:      material.Scorm.BaseURL,
+					MasteryScore: material.Scorm.MasteryScore,
+					LaunchData:   material.Scorm.LaunchData,
+					SHA256:       material.Scorm.SHA256,
+					Size:         material.Scorm.Size,
+					Files:        material.Scorm.Files,
+				}
+			}
+
+			for _, rule := range material.Prerequisites {
+				packageMaterial.Prerequisites = append(packageMaterial.Prerequisites, CoursePackagePrerequisite{
+					Type:       rule.Type,
+					MaterialID: rule.RequiredMaterialID,
+					MinGrades:  rule.MinGrades,
+				})
+			}
+
+			packageChapter.Materials = append(packageChapter.Materials, packageMaterial)
+		}
+
+		pkg.Course.Chapters = append(pkg.Course.Chapters, packageChapter)
+	}
+
+	for _, bank := range banks {
+		packageBank := CoursePackageQuestionBank{
+			ID:          bank.ID,
+			Title:       bank.Title,
+			Description: bank.Description,
+			Questions:   coursePackageQuestions(bank.Questions),
+		}
+
+		if bank.ChapterID != nil {
+			packageBank.ChapterID = *bank.ChapterID
+		}
+
+		pkg.Course.QuestionBanks = append(pkg.Course.QuestionBanks, packageBank)
+	}
+
+	return pkg
+}
+
+func coursePackageQuiz(quiz model.Quiz) *CoursePackageQuiz {
+	packageQuiz := &CoursePackageQuiz{
+		Title:            quiz.Title,
+		Description:      quiz.Description,
+		MaxAttempts:      quiz.MaxAttempts,
+		ScoringPolicy:    quiz.ScoringPolicy,
+		TimeLimit:        quiz.TimeLimit,
+		OpenAt:           quiz.OpenAt,
+		CloseAt:          quiz.CloseAt,
+		LateSubmission:   quiz.LateSubmission,
+		ReviewPolicy:     quiz.ReviewPolicy,
+		ShuffleQuestions: quiz.ShuffleQuestions,
+		ShuffleAnswers:   quiz.ShuffleAnswers,
+		Questions:        coursePackageQuestions(quiz.Quizes),
+	}
+
+	for _, rule := range quiz.DrawRules {
+		packageQuiz.DrawRules = append(packageQuiz.DrawRules, CoursePackageDrawRule{
+			QuestionBankID: rule.QuestionBankID,
+			Topic:          rule.Topic,
+			Difficulty:     rule.Difficulty,
+			Count:          rule.Count,
+		})
+	}
+
+	return packageQuiz
+}
+
+func coursePackageQuestions(questions []model.Quizes) []CoursePackageQuestion {
+	result := []CoursePackageQuestion{}
+
+	for _, question := range questions {
+		packageQuestion := CoursePackageQuestion{
+			Question:   question.Quiz,
+			Type:       question.Type,
+			ImgURL:     question.ImgURL,
+			Points:     question.Points,
+			Topic:      question.Topic,
+			Difficulty: question.Difficulty,
+			Answers:    []CoursePackageAnswer{},
+		}
+
+		for _, answer := range question.QuizAnswers {
+			packageQuestion.Answers = append(packageQuestion.Answers, CoursePackageAnswer{
+				Answer:    answer.Answer,
+				IsCorrect: answer.IsCorrect,
+				Tolerance: answer.Tolerance,
+				Position:  answer.Position,
+			})
+		}
+
+		result = append(result, packageQuestion)
+	}
+
+	return result
+}
+
+// texts calls fn with every text of the course that can refer to an uploaded asset.
+func (pkg *CoursePackage) texts(fn func(text *string)) {
+	course := &pkg.Course
+	fn(&course.ThumbnailImg)
+	fn(&course.Description)
+	fn(&course.Detail)
+
+	questions := func(questions []CoursePackageQuestion) {
+		for i := range questions {
+			fn(&questions[i].Question)
+			fn(&questions[i].ImgURL)
+			for j := range questions[i].Answers {
+				fn(&questions[i].Answers[j].Answer)
+			}
+		}
+	}
+
+	for i := range course.Chapters {
+		for j := range course.Chapters[i].Materials {
+			material := &course.Chapters[i].Materials[j]
+			if material.Theory != nil {
+				fn(material.Theory)
+			}
+			if material.Submission != nil {
+				fn(material.Submission)
+			}
+			if material.Quiz != nil {
+				fn(&material.Quiz.Description)
+				questions(material.Quiz.Questions)
+			}
+		}
+	}
+
+	for i := range course.QuestionBanks {
+		questions(course.QuestionBanks[i].Questions)
+	}
+}
+
+// AssetURLs returns the URLs of the course starting with one of prefixes, in the order they
+// first appear.
+func (pkg *CoursePackage) AssetURLs(prefixes ...string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+
+	pkg.texts(func(text *string) {
+		for _, url := range coursePackageURL.FindAllString(*text, -1) {
+			if seen[url] {
+				continue
+			}
+
+			for _, prefix := range prefixes {
+				if prefix != "" && strings.HasPrefix(url, prefix) {
+					seen[url] = true
+					urls = append(urls, url)
+					break
+				}
+			}
+		}
+	})
+
+	return urls
+}
+
+// ReplaceURLs replaces the URLs of the course found in urls, used to point the assets of an
+// imported course to their new upload.
+func (pkg *CoursePackage) ReplaceURLs(urls map[string]string) {
+	if len(urls) == 0 {
+		return
+	}
+
+	pkg.texts(func(text *string) {
+		*text = coursePackageURL.ReplaceAllStringFunc(*text, func(url string) string {
+			if replaced, ok := urls[url]; ok {
+				return replaced
+			}
+			return url
+		})
+	})
+}
+
+// AddAsset stores data as the asset of url and returns its path in the package.
+func (pkg *CoursePackage) AddAsset(url string, data []byte) CoursePackageAsset {
+	sum := sha256.Sum256(data)
+
+	ext := path.Ext(strings.SplitN(url, "?", 2)[0])
+	if len(ext) > 10 {
+		ext = ""
+	}
+
+	asset := CoursePackageAsset{
+		URL:    url,
+		Path:   fmt.Sprintf("%s%d%s", coursePackageAssets, len(pkg.Assets)+1, ext),
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+	pkg.Assets = append(pkg.Assets, asset)
+
+	return asset
+}
+
+// WriteCoursePackage writes the zip of a package, assets are the files of pkg.Assets by path.
+func WriteCoursePackage(w io.Writer, pkg *CoursePackage, assets map[string][]byte) error {
+	archive := zip.NewWriter(w)
+
+	manifest, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err := archive.Create(coursePackageManifest)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(manifest); err != nil {
+		return err
+	}
+
+	for _, asset := range pkg.Assets {
+		file, err := archive.Create(asset.Path)
+		if err != nil {
+			return err
+		}
+
+		if _, err := file.Write(assets[asset.Path]); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// ReadCoursePackage reads the zip of a package and returns it with its assets by path. The
+// manifest is validated, assets missing from the zip, listed twice or not matching their SHA256
+// are rejected, as are packages over coursePackageMaxFiles files or coursePackageMaxSize bytes.
+func ReadCoursePackage(data []byte) (*CoursePackage, map[string][]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, ErrInvalidCoursePackage
+	}
+
+	if len(archive.File) > coursePackageMaxFiles {
+		return nil, nil, fmt.Errorf("%w: package has more than %d files", ErrInvalidCoursePackage, coursePackageMaxFiles)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	manifestFile, ok := files[coursePackageManifest]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s not found", ErrInvalidCoursePackage, coursePackageManifest)
+	}
+
+	manifest, err := readCoursePackageFile(manifestFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	total := uint64(len(manifest))
+
+	var pkg CoursePackage
+	if err := json.Unmarshal(manifest, &pkg); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidCoursePackage, err.Error())
+	}
+
+	if pkg.Format != CoursePackageFormat {
+		return nil, nil, ErrInvalidCoursePackage
+	}
+
+	if pkg.FormatVersion < 1 || pkg.FormatVersion > CoursePackageVersion {
+		return nil, nil, ErrUnsupportedCoursePackage
+	}
+
+	assets := make(map[string][]byte, len(pkg.Assets))
+	for _, asset := range pkg.Assets {
+		if path.Clean(asset.Path) != asset.Path || !strings.HasPrefix(asset.Path, coursePackageAssets) {
+			return nil, nil, fmt.Errorf("%w: asset path %s is invalid", ErrInvalidCoursePackage, asset.Path)
+		}
+
+		// Satu file zip tidak boleh dibaca berkali-kali lewat asset yang sama
+		if _, ok := assets[asset.Path]; ok {
+			return nil, nil, fmt.Errorf("%w: asset %s is listed more than once", ErrInvalidCoursePackage, asset.Path)
+		}
+
+		file, ok := files[asset.Path]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: asset %s not found", ErrInvalidCoursePackage, asset.Path)
+		}
+
+		content, err := readCoursePackageFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Ukuran di header zip tidak bisa dipercaya, yang dihitung isi file yang terbaca
+		total += uint64(len(content))
+		if total > coursePackageMaxSize {
+			return nil, nil, fmt.Errorf("%w: package is too large", ErrInvalidCoursePackage)
+		}
+
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != asset.SHA256 {
+			return nil, nil, fmt.Errorf("%w: asset %s is corrupted", ErrInvalidCoursePackage, asset.Path)
+		}
+
+		assets[asset.Path] = content
+	}
+
+	return &pkg, assets, nil
+}
+
+func readCoursePackageFile(file *zip.File) ([]byte, error) {
+	content, err := readZipFile(file, coursePackageMaxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidCoursePackage, file.Name, err.Error())
+	}
+
+	return content, nil
+}
+
+// CoursePackageModels returns the course, quizzes and question banks of a package with new IDs for
+// the teacher. The references inside the package (prerequisites, draw rules and the chapters of
+// question banks) are remapped to the new IDs and must point into the package.
+func CoursePackageModels(pkg *CoursePackage, teacherID string) (*model.Course, []model.Quiz, []model.QuestionBank, error) {
+	newID := func(ids map[string]string, oldID string) (string, error) {
+		id, err := GenerateNanoId()
+		if err != nil {
+			return "", err
+		}
+
+		if oldID != "" {
+			if _, ok := ids[oldID]; ok {
+				return "", fmt.Errorf("%w: id %s is used more than once", ErrInvalidCoursePackage, oldID)
+			}
+			ids[oldID] = id
+		}
+
+		return id, nil
+	}
+
+	courseID, err := GenerateNanoId()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	course := &model.Course{
+		ID:               courseID,
+		TeacherID:        teacherID,
+		Title:            pkg.Course.Title,
+		Slug:             pkg.Course.Slug,
+		Description:      pkg.Course.Description,
+		Detail:           pkg.Course.Detail,
+		EstimationHour:   pkg.Course.EstimationHour,
+		EstimationMinute: pkg.Course.EstimationMinute,
+		ThumbnailImg:     pkg.Course.ThumbnailImg,
+		IsDraft:          true,
+	}
+
+	for _, class := range pkg.Course.Classes {
+		id, err := GenerateNanoId()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		course.CourseClasses = append(course.CourseClasses, model.CourseClass{
+			ID:       id,
+			CourseID: courseID,
+			Class:    class,
+			Slug:     slug.Make(class),
+		})
+	}
+
+	chapterIDs := make(map[string]string)
+	materialIDs := make(map[string]string)
+	bankIDs := make(map[string]string)
+
+	// ID baru dibuat dulu agar referensi ke material berikutnya bisa diubah
+	for _, chapter := range pkg.Course.Chapters {
+		if _, err := newID(chapterIDs, chapter.ID); err != nil {
+			return nil, nil, nil, err
+		}
+
+		for _, material := range chapter.Materials {
+			if _, err := newID(materialIDs, material.ID); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+
+	for _, bank := range pkg.Course.QuestionBanks {
+		if _, err := newID(bankIDs, bank.ID); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var quizzes []model.Quiz
+
+	for _, chapter := range pkg.Course.Chapters {
+		chapterID := chapterIDs[chapter.ID]
+		if chapter.ID == "" {
+			if chapterID, err = GenerateNanoId(); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+
+		modelChapter := model.Chapter{
+			ID:        chapterID,
+			CourseID:  courseID,
+			LineageID: chapterID,
+			Title:     chapter.Title,
+			Slug:      chapter.Slug,
+			Position:  chapter.Position,
+		}
+
+		for _, material := range chapter.Materials {
+			materialID := materialIDs[material.ID]
+			if material.ID == "" {
+				if materialID, err = GenerateNanoId(); err != nil {
+					return nil, nil, nil, err
+				}
+			}
+
+			modelMaterial := model.Material{
+				ID:        materialID,
+				ChapterID: chapterID,
+				CourseID:  courseID,
+				LineageID: materialID,
+				Title:     material.Title,
+				Type:      material.Type,
+				Slug:      material.Slug,
+				Position:  material.Position,
+			}
+
+			if material.Theory != nil {
+				id, err := GenerateNanoId()
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				modelMaterial.Theory = model.Theory{ID: id, MaterialID: materialID, Content: *material.Theory}
+			}
+
+			if material.Submission != nil {
+				id, err := GenerateNanoId()
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				modelMaterial.Submission = model.Submission{ID: id, MaterialID: materialID, Content: *material.Submission}
+			}
+
+			if scorm := material.Scorm; scorm != nil {
+				id, err := GenerateNanoId()
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				modelMaterial.Scorm = model.ScormPackage{
+					ID:           id,
+					MaterialID:   materialID,
+					Identifier:   scorm.Identifier,
+					Title:        scorm.Title,
+					LaunchPath:   scorm.LaunchPath,
+					LaunchURL:    scorm.LaunchURL,
+					BaseURL:      scorm.BaseURL,
+					MasteryScore: scorm.MasteryScore,
+					LaunchData:   scorm.LaunchData,
+					SHA256:       scorm.SHA256,
+					Size:         scorm.Size,
+					Files:        scorm.Files,
+				}
+			}
+
+			for _, rule := range material.Prerequisites {
+				requiredID, ok := materialIDs[rule.MaterialID]
+				if !ok {
+					return nil, nil, nil, fmt.Errorf("%w: prerequisite material %s is not in the package", ErrInvalidCoursePackage, rule.MaterialID)
+				}
+
+				id, err := GenerateNanoId()
+				if err != nil {
+					return nil, nil, nil, err
+				}
+
+				modelMaterial.Prerequisites = append(modelMaterial.Prerequisites, model.MaterialPrerequisite{
+					ID:                 id,
+					MaterialID:         materialID,
+					Type:               rule.Type,
+					RequiredMaterialID: requiredID,
+					MinGrades:          rule.MinGrades,
+				})
+			}
+
+			if material.Quiz != nil {
+				// Quiz memakai ID yang sama dengan material nya
+				quiz := model.Quiz{
+					ID:               materialID,
+					ChapterID:        chapterID,
+					MaterialID:       materialID,
+					Title:            material.Quiz.Title,
+					Description:      material.Quiz.Description,
+					MaxAttempts:      material.Quiz.MaxAttempts,
+					ScoringPolicy:    material.Quiz.ScoringPolicy,
+					TimeLimit:        material.Quiz.TimeLimit,
+					OpenAt:           material.Quiz.OpenAt,
+					CloseAt:          material.Quiz.CloseAt,
+					LateSubmission:   material.Quiz.LateSubmission,
+					ReviewPolicy:     material.Quiz.ReviewPolicy,
+					ShuffleQuestions: material.Quiz.ShuffleQuestions,
+					ShuffleAnswers:   material.Quiz.ShuffleAnswers,
+				}
+
+				if quiz.Quizes, err = coursePackageModelQuestions(material.Quiz.Questions); err != nil {
+					return nil, nil, nil, err
+				}
+
+				for _, rule := range material.Quiz.DrawRules {
+					bankID, ok := bankIDs[rule.QuestionBankID]
+					if !ok {
+						return nil, nil, nil, fmt.Errorf("%w: question bank %s is not in the package", ErrInvalidCoursePackage, rule.QuestionBankID)
+					}
+
+					id, err := GenerateNanoId()
+					if err != nil {
+						return nil, nil, nil, err
+					}
+
+					quiz.DrawRules = append(quiz.DrawRules, model.QuizDrawRule{
+						ID:             id,
+						QuizID:         quiz.ID,
+						QuestionBankID: bankID,
+						Topic:          rule.Topic,
+						Difficulty:     rule.Difficulty,
+						Count:          rule.Count,
+					})
+				}
+
+				quizzes = append(quizzes, quiz)
+			}
+
+			modelChapter.Materials = append(modelChapter.Materials, modelMaterial)
+		}
+
+		course.Chapters = append(course.Chapters, modelChapter)
+	}
+
+	var banks []model.QuestionBank
+
+	for _, bank := range pkg.Course.QuestionBanks {
+		modelBank := model.QuestionBank{
+			ID:          bankIDs[bank.ID],
+			CourseID:    courseID,
+			Title:       bank.Title,
+			Description: bank.Description,
+		}
+
+		if bank.ChapterID != "" {
+			chapterID, ok := chapterIDs[bank.ChapterID]
+			if !ok {
+				return nil, nil, nil, fmt.Errorf("%w: chapter %s is not in the package", ErrInvalidCoursePackage, bank.ChapterID)
+			}
+			modelBank.ChapterID = &chapterID
+		}
+
+		if modelBank.Questions, err = coursePackageModelQuestions(bank.Questions); err != nil {
+			return nil, nil, nil, err
+		}
+
+		banks = append(banks, modelBank)
+	}
+
+	return course, quizzes, banks, nil
+}
+
+func coursePackageModelQuestions(questions []CoursePackageQuestion) ([]model.Quizes, error) {
+	var result []model.Quizes
+
+	for i, question := range questions {
+		id, err := GenerateNanoId()
+		if err != nil {
+			return nil, err
+		}
+
+		modelQuestion := model.Quizes{
+			ID:         id,
+			Quiz:       question.Question,
+			Type:       question.Type,
+			ImgURL:     question.ImgURL,
+			Points:     question.Points,
+			Topic:      question.Topic,
+			Difficulty: question.Difficulty,
+		}
+
+		for _, answer := range question.Answers {
+			answerID, err := GenerateNanoId()
+			if err != nil {
+				return nil, err
+			}
+
+			modelQuestion.QuizAnswers = append(modelQuestion.QuizAnswers, model.QuizAnswer{
+				ID:        answerID,
+				QuizesID:  id,
+				Answer:    answer.Answer,
+				IsCorrect: answer.IsCorrect,
+				Tolerance: answer.Tolerance,
+				Position:  answer.Position,
+			})
+		}
+
+		if err := ValidateQuizQuestion(modelQuestion); err != nil {
+			return nil, fmt.Errorf("%w: question %d: %s", ErrInvalidCoursePackage, i+1, err.Error())
+		}
+
+		result = append(result, modelQuestion)
+	}
+
+	return result, nil
+}
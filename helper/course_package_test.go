@@ -0,0 +1,107 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// coursePackageZip zips files with the manifest of pkg, a nil pkg leaves the manifest out.
+func coursePackageZip(t *testing.T, pkg *CoursePackage, files map[string][]byte) []byte {
+	t.Helper()
+
+	contents := make(map[string]string, len(files)+1)
+	for name, content := range files {
+		contents[name] = string(content)
+	}
+
+	if pkg != nil {
+		manifest, err := json.Marshal(pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[coursePackageManifest] = string(manifest)
+	}
+
+	return zipFixture(t, contents)
+}
+
+func TestReadCoursePackage(t *testing.T) {
+	pkg := &CoursePackage{
+		Format:        CoursePackageFormat,
+		FormatVersion: CoursePackageVersion,
+		Course:        CoursePackageCourse{Title: "Sejarah", ThumbnailImg: "https://cdn.example.com/a.png"},
+	}
+	logo := []byte("png")
+	asset := pkg.AddAsset("https://cdn.example.com/a.png", logo)
+
+	var buffer bytes.Buffer
+	if err := WriteCoursePackage(&buffer, pkg, map[string][]byte{asset.Path: logo}); err != nil {
+		t.Fatal(err)
+	}
+
+	read, assets, err := ReadCoursePackage(buffer.Bytes())
+	if err != nil {
+		t.Fatalf("ReadCoursePackage() error = %v", err)
+	}
+	if read.Course.Title != "Sejarah" || len(read.Assets) != 1 {
+		t.Errorf("ReadCoursePackage() = %+v", read)
+	}
+	if asset.Path != "assets/1.png" || !bytes.Equal(assets[asset.Path], logo) {
+		t.Errorf("ReadCoursePackage() assets = %v, want %s", assets, asset.Path)
+	}
+}
+
+func TestReadCoursePackageErrors(t *testing.T) {
+	data := []byte("png")
+	valid := func() *CoursePackage {
+		pkg := &CoursePackage{Format: CoursePackageFormat, FormatVersion: CoursePackageVersion}
+		pkg.AddAsset("https://cdn.example.com/a.png", data)
+		return pkg
+	}
+	withAsset := func(assetPath string) *CoursePackage {
+		pkg := valid()
+		pkg.Assets[0].Path = assetPath
+		return pkg
+	}
+	duplicated := func() *CoursePackage {
+		pkg := valid()
+		pkg.Assets = append(pkg.Assets, pkg.Assets[0])
+		return pkg
+	}
+	tooManyFiles := make(map[string][]byte)
+	for i := 0; i < coursePackageMaxFiles; i++ {
+		tooManyFiles[fmt.Sprintf("assets/%d.png", i)] = data
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"not a zip", []byte("not a zip"), ErrInvalidCoursePackage},
+		{"missing manifest", coursePackageZip(t, nil, map[string][]byte{"assets/1.png": data}), ErrInvalidCoursePackage},
+		{"malformed manifest", coursePackageZip(t, nil, map[string][]byte{coursePackageManifest: []byte("{")}), ErrInvalidCoursePackage},
+		{"other format", coursePackageZip(t, &CoursePackage{Format: "other", FormatVersion: 1}, map[string][]byte{}), ErrInvalidCoursePackage},
+		{"newer version", coursePackageZip(t, &CoursePackage{Format: CoursePackageFormat, FormatVersion: CoursePackageVersion + 1}, map[string][]byte{}), ErrUnsupportedCoursePackage},
+		{"missing version", coursePackageZip(t, &CoursePackage{Format: CoursePackageFormat}, map[string][]byte{}), ErrUnsupportedCoursePackage},
+		{"missing asset", coursePackageZip(t, valid(), map[string][]byte{}), ErrInvalidCoursePackage},
+		{"checksum mismatch", coursePackageZip(t, valid(), map[string][]byte{"assets/1.png": []byte("changed")}), ErrInvalidCoursePackage},
+		{"parent directory asset", coursePackageZip(t, withAsset("assets/../../etc/passwd"), map[string][]byte{"assets/../../etc/passwd": data}), ErrInvalidCoursePackage},
+		{"asset outside assets", coursePackageZip(t, withAsset(coursePackageManifest), map[string][]byte{}), ErrInvalidCoursePackage},
+		{"absolute asset", coursePackageZip(t, withAsset("/assets/1.png"), map[string][]byte{"/assets/1.png": data}), ErrInvalidCoursePackage},
+		{"duplicate asset", coursePackageZip(t, duplicated(), map[string][]byte{"assets/1.png": data}), ErrInvalidCoursePackage},
+		{"too many files", coursePackageZip(t, valid(), tooManyFiles), ErrInvalidCoursePackage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ReadCoursePackage(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadCoursePackage() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	PasswordMinLength = 8
+	// PasswordMaxLength is the limit of bcrypt, longer passwords can not be hashed.
+	PasswordMaxLength = 72
+)
+
+var (
+	ErrPasswordTooShort    = errors.New("password must be at least 8 characters")
+	ErrPasswordTooLong     = errors.New("password must be at most 72 characters")
+	ErrPasswordHasUsername = errors.New("password must not contain the username")
+	ErrPasswordCommon      = errors.New("password is too common, choose another password")
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = func() map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+
+	return passwords
+}()
+
+// ValidatePassword checks a new password against the password policy: its length, the username
+// and the list of common passwords bundled in common_passwords.txt.
+func ValidatePassword(password, username string) error {
+	if len(password) < PasswordMinLength {
+		return ErrPasswordTooShort
+	}
+
+	if len(password) > PasswordMaxLength {
+		return ErrPasswordTooLong
+	}
+
+	lower := strings.ToLower(password)
+	if len(username) >= 3 && strings.Contains(lower, strings.ToLower(username)) {
+		return ErrPasswordHasUsername
+	}
+
+	if _, ok := commonPasswords[lower]; ok {
+		return ErrPasswordCommon
+	}
+
+	return nil
+}
+
+// temporaryPasswordChars leaves out characters that are easily mixed up, e.g. 0 and O.
+const temporaryPasswordChars = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+
+// GenerateTemporaryPassword returns a random password given by an admin, the user has to
+// change it at the next login.
+func GenerateTemporaryPassword() (string, error) {
+	password := make([]byte, 12)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(temporaryPasswordChars))))
+		if err != nil {
+			return "", err
+		}
+
+		password[i] = temporaryPasswordChars[n.Int64()]
+	}
+
+	return string(password), nil
+}
@@ -0,0 +1,363 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+)
+
+// RecordedQuizAttempt picks the attempt that counts as the quiz result of a student.
+// Attempts must be ordered from the newest. For SCORE_AVERAGE the newest attempt
+// is returned carrying the average Score and Grades of every attempt.
+func RecordedQuizAttempt(policy model.SCORING_POLICY, attempts []model.QuizAttempt) *model.QuizAttempt {
+	if len(attempts) == 0 {
+		return nil
+	}
+
+	recorded := attempts[0]
+
+	switch policy {
+	case model.SCORE_BEST:
+		for _, attempt := range attempts {
+			if attempt.Grades >= recorded.Grades {
+				recorded = attempt
+			}
+		}
+	case model.SCORE_AVERAGE:
+		var score float64
+		var grades int
+		for _, attempt := range attempts {
+			score += attempt.Score
+			grades += attempt.Grades
+		}
+
+		total := float64(len(attempts))
+		recorded.Score = math.Round(score/total*100) / 100
+		recorded.Grades = int(math.Round(float64(grades) / total))
+	}
+
+	return &recorded
+}
+
+// ValidScoringPolicy reports whether policy is one of the supported scoring policies.
+func ValidScoringPolicy(policy model.SCORING_POLICY) bool {
+	return policy == model.SCORE_BEST || policy == model.SCORE_LAST || policy == model.SCORE_AVERAGE
+}
+
+// QuizSubmissionGrace tolerates the delay between a student submitting and the request reaching the server.
+const QuizSubmissionGrace = 30 * time.Second
+
+// QuizAttemptDeadline returns when an attempt started at startedAt must be submitted,
+// which is the earliest of the time limit and the closing time of the quiz.
+func QuizAttemptDeadline(quiz model.Quiz, startedAt time.Time) *time.Time {
+	var deadline *time.Time
+
+	if quiz.TimeLimit > 0 {
+		limit := startedAt.Add(time.Duration(quiz.TimeLimit) * time.Minute)
+		deadline = &limit
+	}
+
+	if quiz.CloseAt != nil && (deadline == nil || quiz.CloseAt.Before(*deadline)) {
+		closeAt := *quiz.CloseAt
+		deadline = &closeAt
+	}
+
+	return deadline
+}
+
+// IsLateSubmission reports whether a submission at now is past the deadline and its grace period.
+func IsLateSubmission(deadline *time.Time, now time.Time) bool {
+	return deadline != nil && now.After(deadline.Add(QuizSubmissionGrace))
+}
+
+// ValidLateSubmission reports whether policy is one of the supported late submission policies.
+func ValidLateSubmission(policy model.LATE_SUBMISSION) bool {
+	return policy == model.LATE_REJECT || policy == model.LATE_GRADE
+}
+
+// QuizGrades converts the points earned into grades between 0 and 100 of the total points of the quiz.
+func QuizGrades(score float64, totalPoints int) int {
+	if totalPoints <= 0 {
+		return 0
+	}
+
+	return int(score / float64(totalPoints) * 100)
+}
+
+// ValidReviewPolicy reports whether policy is one of the supported review policies.
+func ValidReviewPolicy(policy model.REVIEW_POLICY) bool {
+	return policy == model.REVIEW_NEVER || policy == model.REVIEW_AFTER_SUBMIT || policy == model.REVIEW_AFTER_CLOSE
+}
+
+// CanReviewQuiz reports whether a student may see the answer key and the feedback of a quiz.
+// submitted tells whether the student has finished an attempt of the quiz and attemptsUsed how
+// many attempts the student has started. Under AFTER_SUBMIT the key is only shown once the
+// student can't start another attempt, every attempt is used or the quiz is closed, so it can't
+// be used to answer the next attempt.
+func CanReviewQuiz(quiz model.Quiz, submitted bool, attemptsUsed int, now time.Time) bool {
+	closed := quiz.CloseAt != nil && now.After(*quiz.CloseAt)
+
+	switch quiz.ReviewPolicy {
+	case model.REVIEW_AFTER_SUBMIT:
+		exhausted := quiz.MaxAttempts > 0 && attemptsUsed >= quiz.MaxAttempts
+		return submitted && (exhausted || closed)
+	case model.REVIEW_AFTER_CLOSE:
+		return closed
+	}
+
+	return false
+}
+
+// ValidQuestionDifficulty reports whether difficulty is empty or one of the supported difficulties.
+func ValidQuestionDifficulty(difficulty model.QUESTION_DIFFICULTY) bool {
+	switch difficulty {
+	case "", model.DIFFICULTY_EASY, model.DIFFICULTY_MEDIUM, model.DIFFICULTY_HARD:
+		return true
+	}
+
+	return false
+}
+
+// ValidQuestionType reports whether questionType is one of the supported question types.
+func ValidQuestionType(questionType model.QUESTION_TYPE) bool {
+	switch questionType {
+	case model.SINGLE_CHOICE, model.MULTIPLE_CHOICE, model.TRUE_FALSE, model.SHORT_ANSWER, model.NUMERIC, model.ORDERING:
+		return true
+	}
+
+	return false
+}
+
+// ValidateQuizQuestion checks that a question and its answers form a gradable question of its type.
+func ValidateQuizQuestion(question model.Quizes) error {
+	if !ValidQuestionType(question.Type) {
+		return fmt.Errorf("unknown question type '%s'", question.Type)
+	}
+
+	if question.Points < 1 {
+		return errors.New("points of a question must be at least 1")
+	}
+
+	var correct int
+	for _, answer := range question.QuizAnswers {
+		if strings.TrimSpace(answer.Answer) == "" {
+			return errors.New("answer must not be empty")
+		}
+		if answer.IsCorrect {
+			correct++
+		}
+	}
+
+	total := len(question.QuizAnswers)
+
+	switch question.Type {
+	case model.SINGLE_CHOICE:
+		if total < 2 || correct != 1 {
+			return errors.New("a SINGLE_CHOICE question needs at least 2 options with exactly 1 correct answer")
+		}
+	case model.TRUE_FALSE:
+		if total != 2 || correct != 1 {
+			return errors.New("a TRUE_FALSE question needs 2 options with exactly 1 correct answer")
+		}
+	case model.MULTIPLE_CHOICE:
+		if total < 2 || correct < 1 {
+			return errors.New("a MULTIPLE_CHOICE question needs at least 2 options with at least 1 correct answer")
+		}
+	case model.SHORT_ANSWER:
+		if total < 1 {
+			return errors.New("a SHORT_ANSWER question needs at least 1 accepted answer")
+		}
+	case model.NUMERIC:
+		if total < 1 {
+			return errors.New("a NUMERIC question needs at least 1 accepted answer")
+		}
+		for _, answer := range question.QuizAnswers {
+			if _, err := parseNumericAnswer(answer.Answer); err != nil {
+				return fmt.Errorf("NUMERIC answer '%s' is not a number", answer.Answer)
+			}
+			if answer.Tolerance < 0 {
+				return errors.New("tolerance must not be negative")
+			}
+		}
+	case model.ORDERING:
+		if total < 2 {
+			return errors.New("an ORDERING question needs at least 2 items")
+		}
+	}
+
+	return nil
+}
+
+// GradeQuizQuestion returns the credit earned for a response between 0 and 1.
+// selectedIDs are the picked options (or the items in the student's order for ORDERING)
+// and text is the typed answer of SHORT_ANSWER and NUMERIC questions.
+func GradeQuizQuestion(question model.Quizes, selectedIDs []string, text string) float64 {
+	switch question.Type {
+	case model.MULTIPLE_CHOICE:
+		return gradeMultipleChoice(question, selectedIDs)
+	case model.SHORT_ANSWER:
+		for _, answer := range question.QuizAnswers {
+			if NormalizeShortAnswer(answer.Answer) == NormalizeShortAnswer(text) {
+				return 1
+			}
+		}
+		return 0
+	case model.NUMERIC:
+		value, err := parseNumericAnswer(text)
+		if err != nil {
+			return 0
+		}
+		for _, answer := range question.QuizAnswers {
+			expected, err := parseNumericAnswer(answer.Answer)
+			if err == nil && math.Abs(value-expected) <= answer.Tolerance {
+				return 1
+			}
+		}
+		return 0
+	case model.ORDERING:
+		return gradeOrdering(question, selectedIDs)
+	default:
+		// SINGLE_CHOICE and TRUE_FALSE
+		if len(selectedIDs) != 1 {
+			return 0
+		}
+		for _, answer := range question.QuizAnswers {
+			if answer.ID == selectedIDs[0] && answer.IsCorrect {
+				return 1
+			}
+		}
+		return 0
+	}
+}
+
+// NormalizeShortAnswer lowercases an answer and collapses its whitespace for comparison.
+func NormalizeShortAnswer(answer string) string {
+	return strings.Join(strings.Fields(strings.ToLower(answer)), " ")
+}
+
+// OrderedQuizAnswers returns the items of an ORDERING question in the correct order.
+func OrderedQuizAnswers(question model.Quizes) []model.QuizAnswer {
+	items := append([]model.QuizAnswer{}, question.QuizAnswers...)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Position < items[j].Position
+	})
+
+	return items
+}
+
+// gradeMultipleChoice gives partial credit, every wrong pick cancels a correct one.
+func gradeMultipleChoice(question model.Quizes, selectedIDs []string) float64 {
+	selected := make(map[string]bool)
+	for _, id := range selectedIDs {
+		selected[id] = true
+	}
+
+	var correct, hit, wrong int
+	for _, answer := range question.QuizAnswers {
+		if answer.IsCorrect {
+			correct++
+		}
+		if !selected[answer.ID] {
+			continue
+		}
+		if answer.IsCorrect {
+			hit++
+		} else {
+			wrong++
+		}
+	}
+
+	if correct == 0 || hit <= wrong {
+		return 0
+	}
+
+	return float64(hit-wrong) / float64(correct)
+}
+
+// gradeOrdering gives credit for every item placed at its correct position.
+func gradeOrdering(question model.Quizes, selectedIDs []string) float64 {
+	items := OrderedQuizAnswers(question)
+	if len(items) == 0 || len(selectedIDs) != len(items) {
+		return 0
+	}
+
+	var placed int
+	for i, item := range items {
+		if selectedIDs[i] == item.ID {
+			placed++
+		}
+	}
+
+	return float64(placed) / float64(len(items))
+}
+
+// parseNumericAnswer accepts both '.' and ',' as decimal separator.
+func parseNumericAnswer(answer string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(answer), ",", "."), 64)
+}
+
+// QuizAttemptQuestions lays out the questions of a new attempt in the order they are given.
+// Questions are shuffled when the quiz shuffles questions, options are shuffled when the
+// quiz shuffles answers. The items of an ORDERING question are always shuffled so their
+// order never gives the answer away.
+func QuizAttemptQuestions(quiz model.Quiz, questions []model.Quizes) []model.QuizAttemptQuestion {
+	if quiz.ShuffleQuestions {
+		rand.Shuffle(len(questions), func(i, j int) {
+			questions[i], questions[j] = questions[j], questions[i]
+		})
+	}
+
+	var result []model.QuizAttemptQuestion
+	for position, question := range questions {
+		var order []string
+		for _, answer := range question.QuizAnswers {
+			order = append(order, answer.ID)
+		}
+
+		if quiz.ShuffleAnswers || question.Type == model.ORDERING {
+			rand.Shuffle(len(order), func(i, j int) {
+				order[i], order[j] = order[j], order[i]
+			})
+		}
+
+		result = append(result, model.QuizAttemptQuestion{
+			QuizesID:    question.ID,
+			Position:    position,
+			AnswerOrder: order,
+		})
+	}
+
+	return result
+}
+
+// ArrangeQuizAnswers orders the options of a question as they were shown in an attempt.
+// Options added after the attempt started are put last.
+func ArrangeQuizAnswers(question model.Quizes, order []string) model.Quizes {
+	position := make(map[string]int)
+	for i, id := range order {
+		position[id] = i
+	}
+
+	answers := append([]model.QuizAnswer{}, question.QuizAnswers...)
+	sort.SliceStable(answers, func(i, j int) bool {
+		pi, ok := position[answers[i].ID]
+		if !ok {
+			pi = len(order)
+		}
+		pj, ok := position[answers[j].ID]
+		if !ok {
+			pj = len(order)
+		}
+		return pi < pj
+	})
+
+	question.QuizAnswers = answers
+	return question
+}
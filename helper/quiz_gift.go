@@ -0,0 +1,569 @@
+package helper
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+)
+
+// QuizImportError is a problem found in an imported quiz file. Line is the line the
+// problem was found at, File is set when the import is a package of several files.
+type QuizImportError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e QuizImportError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s line %d: %s", e.File, e.Line, e.Message)
+	}
+
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Answers of an imported TRUE_FALSE question.
+const (
+	quizAnswerTrue  = "Benar"
+	quizAnswerFalse = "Salah"
+)
+
+// trueFalseAnswer reports whether answer means true or false, ok is false when it means neither.
+func trueFalseAnswer(answer string) (value bool, ok bool) {
+	switch NormalizeShortAnswer(answer) {
+	case "true", "benar", "t":
+		return true, true
+	case "false", "salah", "f":
+		return false, true
+	}
+
+	return false, false
+}
+
+// giftBlock is the text of a single GIFT question and the line it starts at.
+type giftBlock struct {
+	line int
+	text string
+}
+
+// giftToken is an answer of a GIFT question with the '=' or '~' it is marked with.
+type giftToken struct {
+	marker byte
+	text   string
+	offset int
+}
+
+// ParseGIFT reads the questions of a quiz written in the Moodle GIFT format. Every
+// question is validated with ValidateQuizQuestion, the problems are returned with the
+// line of the question. Essay, matching and description questions are not supported.
+func ParseGIFT(text string) ([]model.Quizes, []QuizImportError) {
+	var questions []model.Quizes
+	var errs []QuizImportError
+
+	for _, block := range giftBlocks(text) {
+		question, err := parseGIFTQuestion(block)
+		if err != nil {
+			errs = append(errs, *err)
+			continue
+		}
+
+		if err := ValidateQuizQuestion(question); err != nil {
+			errs = append(errs, QuizImportError{Line: block.line, Message: err.Error()})
+			continue
+		}
+
+		questions = append(questions, question)
+	}
+
+	return questions, errs
+}
+
+// giftBlocks splits a GIFT file into questions, questions are separated by blank lines.
+// Comments and category lines are left out.
+func giftBlocks(text string) []giftBlock {
+	text = strings.TrimPrefix(text, "\uFEFF")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var blocks []giftBlock
+	var lines []string
+	var start int
+
+	flush := func() {
+		if len(lines) > 0 {
+			blocks = append(blocks, giftBlock{line: start, text: strings.Join(lines, "\n")})
+			lines = nil
+		}
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "//"), strings.HasPrefix(trimmed, "$CATEGORY:"):
+			continue
+		default:
+			if len(lines) == 0 {
+				start = i + 1
+			}
+			lines = append(lines, line)
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+func parseGIFTQuestion(block giftBlock) (model.Quizes, *QuizImportError) {
+	text := block.text
+	fail := func(offset int, message string) (model.Quizes, *QuizImportError) {
+		return model.Quizes{}, &QuizImportError{
+			Line:    block.line + strings.Count(text[:offset], "\n"),
+			Message: message,
+		}
+	}
+
+	open := giftIndex(text, '{', 0)
+	if open < 0 {
+		return fail(0, "question has no answer block {...}")
+	}
+
+	end := giftIndex(text, '}', open+1)
+	if end < 0 {
+		return fail(open, "answer block is not closed with }")
+	}
+
+	if giftIndex(text, '{', end+1) >= 0 {
+		return fail(end, "question may only have one answer block {...}")
+	}
+
+	// Soal "missing word" ditulis dengan jawaban di tengah kalimat
+	stem := giftStem(text[:open])
+	if after := strings.TrimSpace(giftUnescape(text[end+1:])); after != "" {
+		stem = strings.TrimSpace(stem + " _____ " + after)
+	}
+
+	if stem == "" {
+		return fail(0, "question text must not be empty")
+	}
+
+	question := model.Quizes{
+		Quiz:   stem,
+		Points: 1,
+	}
+
+	body := text[open+1 : end]
+	trimmed := strings.TrimSpace(body)
+
+	switch {
+	case trimmed == "":
+		return fail(open, "essay questions are not supported")
+	case strings.HasPrefix(trimmed, "#"):
+		answers, offset, message := giftNumericAnswers(body[strings.Index(body, "#")+1:])
+		if message != "" {
+			return fail(open+1+strings.Index(body, "#")+1+offset, message)
+		}
+		question.Type = model.NUMERIC
+		question.QuizAnswers = answers
+		return question, nil
+	}
+
+	if value, ok := giftTrueFalse(trimmed); ok {
+		question.Type = model.TRUE_FALSE
+		question.QuizAnswers = []model.QuizAnswer{
+			{Answer: quizAnswerTrue, IsCorrect: value, Position: 0},
+			{Answer: quizAnswerFalse, IsCorrect: !value, Position: 1},
+		}
+		return question, nil
+	}
+
+	tokens, offset, message := giftTokens(body)
+	if message != "" {
+		return fail(open+1+offset, message)
+	}
+
+	var wrong, correct, weighted int
+	weights := make([]float64, len(tokens))
+	texts := make([]string, len(tokens))
+
+	for i, token := range tokens {
+		answer := token.text
+		if feedback := giftIndex(answer, '#', 0); feedback >= 0 {
+			answer = answer[:feedback]
+		}
+
+		if strings.Contains(answer, "->") {
+			return fail(open+1+token.offset, "matching questions are not supported")
+		}
+
+		weights[i] = 100
+		if token.marker == '~' {
+			weights[i] = 0
+			wrong++
+		}
+
+		answer = strings.TrimSpace(answer)
+		if strings.HasPrefix(answer, "%") {
+			closing := strings.Index(answer[1:], "%")
+			if closing < 0 {
+				return fail(open+1+token.offset, "answer weight must be written as %weight%")
+			}
+
+			weight, err := strconv.ParseFloat(answer[1:closing+1], 64)
+			if err != nil {
+				return fail(open+1+token.offset, fmt.Sprintf("answer weight '%s' is not a number", answer[1:closing+1]))
+			}
+
+			weights[i] = weight
+			weighted++
+			answer = answer[closing+2:]
+		}
+
+		if weights[i] > 0 {
+			correct++
+		}
+		texts[i] = strings.TrimSpace(giftUnescape(answer))
+	}
+
+	// Hanya jawaban '=' tanpa pilihan salah berarti soal SHORT_ANSWER
+	if wrong == 0 && weighted == 0 {
+		question.Type = model.SHORT_ANSWER
+		for i, answer := range texts {
+			question.QuizAnswers = append(question.QuizAnswers, model.QuizAnswer{
+				Answer:    answer,
+				IsCorrect: true,
+				Position:  i,
+			})
+		}
+		return question, nil
+	}
+
+	question.Type = model.MULTIPLE_CHOICE
+	if correct == 1 && weighted == 0 {
+		question.Type = model.SINGLE_CHOICE
+	}
+
+	for i, answer := range texts {
+		question.QuizAnswers = append(question.QuizAnswers, model.QuizAnswer{
+			Answer:    answer,
+			IsCorrect: weights[i] > 0,
+			Position:  i,
+		})
+	}
+
+	return question, nil
+}
+
+// giftStem returns the text of a question without its title and text format.
+func giftStem(text string) string {
+	text = strings.TrimSpace(text)
+
+	if strings.HasPrefix(text, "::") {
+		if end := strings.Index(text[2:], "::"); end >= 0 {
+			text = strings.TrimSpace(text[end+4:])
+		}
+	}
+
+	for _, format := range []string{"[html]", "[moodle]", "[plain]", "[markdown]"} {
+		if strings.HasPrefix(strings.ToLower(text), format) {
+			text = text[len(format):]
+			break
+		}
+	}
+
+	return strings.TrimSpace(giftUnescape(text))
+}
+
+// giftTrueFalse reads the answer of a GIFT TRUE_FALSE question, {T}, {TRUE}, {F} or {FALSE}.
+func giftTrueFalse(body string) (bool, bool) {
+	if feedback := giftIndex(body, '#', 0); feedback >= 0 {
+		body = body[:feedback]
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(body)) {
+	case "T", "TRUE":
+		return true, true
+	case "F", "FALSE":
+		return false, true
+	}
+
+	return false, false
+}
+
+// giftTokens splits the answers of a question at every unescaped '=' and '~'.
+func giftTokens(body string) ([]giftToken, int, string) {
+	var tokens []giftToken
+
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			i++
+		case '=', '~':
+			tokens = append(tokens, giftToken{marker: body[i], offset: i})
+		default:
+			if len(tokens) == 0 && !isGIFTSpace(body[i]) {
+				return nil, i, "answer must start with = or ~"
+			}
+		}
+	}
+
+	for i := range tokens {
+		end := len(body)
+		if i+1 < len(tokens) {
+			end = tokens[i+1].offset
+		}
+		tokens[i].text = body[tokens[i].offset+1 : end]
+	}
+
+	if len(tokens) == 0 {
+		return nil, 0, "question has no answers"
+	}
+
+	return tokens, 0, ""
+}
+
+// giftNumericAnswers reads the accepted answers of a NUMERIC question, written as
+// value, value:tolerance or min..max. Answers with partial credit are left out.
+func giftNumericAnswers(body string) ([]model.QuizAnswer, int, string) {
+	tokens := []giftToken{{marker: '=', text: body}}
+	if strings.ContainsAny(strings.TrimSpace(body), "=~") {
+		var offset int
+		var message string
+		if tokens, offset, message = giftTokens(body); message != "" {
+			return nil, offset, message
+		}
+	}
+
+	var answers []model.QuizAnswer
+	for _, token := range tokens {
+		if token.marker == '~' {
+			continue
+		}
+
+		value := token.text
+		if feedback := giftIndex(value, '#', 0); feedback >= 0 {
+			value = value[:feedback]
+		}
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, "%") {
+			closing := strings.Index(value[1:], "%")
+			if closing < 0 {
+				return nil, token.offset, "answer weight must be written as %weight%"
+			}
+			if weight, err := strconv.ParseFloat(value[1:closing+1], 64); err != nil || weight != 100 {
+				continue
+			}
+			value = strings.TrimSpace(value[closing+2:])
+		}
+
+		answer, tolerance, err := giftNumber(value)
+		if err != nil {
+			return nil, token.offset, fmt.Sprintf("NUMERIC answer '%s' is not a number", value)
+		}
+
+		answers = append(answers, model.QuizAnswer{
+			Answer:    answer,
+			IsCorrect: true,
+			Tolerance: tolerance,
+			Position:  len(answers),
+		})
+	}
+
+	return answers, 0, ""
+}
+
+// giftNumber reads value, value:tolerance or min..max into a value and its tolerance.
+func giftNumber(text string) (string, float64, error) {
+	if parts := strings.SplitN(text, "..", 2); len(parts) == 2 {
+		min, err := parseNumericAnswer(parts[0])
+		if err != nil {
+			return "", 0, err
+		}
+		max, err := parseNumericAnswer(parts[1])
+		if err != nil {
+			return "", 0, err
+		}
+
+		return strconv.FormatFloat((min+max)/2, 'f', -1, 64), math.Abs(max-min) / 2, nil
+	}
+
+	var tolerance float64
+	if parts := strings.SplitN(text, ":", 2); len(parts) == 2 {
+		value, err := parseNumericAnswer(parts[1])
+		if err != nil {
+			return "", 0, err
+		}
+		text, tolerance = parts[0], value
+	}
+
+	text = strings.TrimSpace(text)
+	if _, err := parseNumericAnswer(text); err != nil {
+		return "", 0, err
+	}
+
+	return text, tolerance, nil
+}
+
+// giftIndex returns the index of the first unescaped c in text from the index from, or -1.
+func giftIndex(text string, c byte, from int) int {
+	for i := from; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func isGIFTSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func giftUnescape(text string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			i++
+			if text[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(text[i])
+	}
+
+	return b.String()
+}
+
+func giftEscape(text string) string {
+	var b strings.Builder
+
+	for _, r := range text {
+		switch r {
+		case '~', '=', '#', '{', '}', ':', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString("\\n")
+		case '\r':
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// FormatGIFT writes the questions of a quiz in the Moodle GIFT format. GIFT has no
+// ORDERING questions and no points, ORDERING questions are written as a comment.
+func FormatGIFT(quiz model.Quiz, questions []model.Quizes) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "// %s\n", strings.Join(strings.Fields(quiz.Title), " "))
+	if description := strings.Join(strings.Fields(quiz.Description), " "); description != "" {
+		fmt.Fprintf(&b, "// %s\n", description)
+	}
+	b.WriteString("\n")
+
+	for i, question := range questions {
+		if question.Type == model.ORDERING {
+			fmt.Fprintf(&b, "// Question %d (ORDERING) is not supported by GIFT: %s\n\n", i+1, strings.Join(strings.Fields(question.Quiz), " "))
+			continue
+		}
+
+		fmt.Fprintf(&b, "::Question %d:: %s {", i+1, giftEscape(question.Quiz))
+
+		switch question.Type {
+		case model.TRUE_FALSE:
+			if answer, ok := giftTrueFalseAnswer(question); ok {
+				b.WriteString(answer)
+				break
+			}
+			writeGIFTChoices(&b, question)
+		case model.SHORT_ANSWER:
+			b.WriteString("\n")
+			for _, answer := range question.QuizAnswers {
+				fmt.Fprintf(&b, "\t=%s\n", giftEscape(answer.Answer))
+			}
+		case model.NUMERIC:
+			if len(question.QuizAnswers) == 1 {
+				fmt.Fprintf(&b, "#%s", giftNumericAnswer(question.QuizAnswers[0]))
+				break
+			}
+			b.WriteString("#\n")
+			for _, answer := range question.QuizAnswers {
+				fmt.Fprintf(&b, "\t=%s\n", giftNumericAnswer(answer))
+			}
+		default:
+			writeGIFTChoices(&b, question)
+		}
+
+		b.WriteString("}\n\n")
+	}
+
+	return []byte(b.String())
+}
+
+// writeGIFTChoices writes the options of a choice question. Options of a MULTIPLE_CHOICE
+// question are weighted so a wrong pick cancels a correct one, as in gradeMultipleChoice.
+func writeGIFTChoices(b *strings.Builder, question model.Quizes) {
+	var correct int
+	for _, answer := range question.QuizAnswers {
+		if answer.IsCorrect {
+			correct++
+		}
+	}
+
+	b.WriteString("\n")
+	for _, answer := range question.QuizAnswers {
+		switch {
+		case question.Type == model.MULTIPLE_CHOICE && correct > 0:
+			weight := strconv.FormatFloat(math.Round(100/float64(correct)*100000)/100000, 'f', -1, 64)
+			if answer.IsCorrect {
+				fmt.Fprintf(b, "\t~%%%s%%%s\n", weight, giftEscape(answer.Answer))
+			} else {
+				fmt.Fprintf(b, "\t~%%-%s%%%s\n", weight, giftEscape(answer.Answer))
+			}
+		case answer.IsCorrect:
+			fmt.Fprintf(b, "\t=%s\n", giftEscape(answer.Answer))
+		default:
+			fmt.Fprintf(b, "\t~%s\n", giftEscape(answer.Answer))
+		}
+	}
+}
+
+// giftTrueFalseAnswer returns T or F when the options of a TRUE_FALSE question are true and false.
+func giftTrueFalseAnswer(question model.Quizes) (string, bool) {
+	for _, answer := range question.QuizAnswers {
+		value, ok := trueFalseAnswer(answer.Answer)
+		if !ok {
+			return "", false
+		}
+		if answer.IsCorrect {
+			if value {
+				return "T", true
+			}
+			return "F", true
+		}
+	}
+
+	return "", false
+}
+
+func giftNumericAnswer(answer model.QuizAnswer) string {
+	value := strings.ReplaceAll(strings.TrimSpace(answer.Answer), ",", ".")
+	if answer.Tolerance == 0 {
+		return value
+	}
+
+	return value + ":" + strconv.FormatFloat(answer.Tolerance, 'f', -1, 64)
+}
@@ -0,0 +1,189 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+)
+
+func TestParseGIFT(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantType model.QUESTION_TYPE
+		wantQuiz string
+		answers  []model.QuizAnswer
+	}{
+		{
+			name:     "single choice with title",
+			text:     "::Ibukota:: Ibukota Indonesia adalah {=Jakarta ~Bandung ~Surabaya}",
+			wantType: model.SINGLE_CHOICE,
+			wantQuiz: "Ibukota Indonesia adalah",
+			answers: []model.QuizAnswer{
+				{Answer: "Jakarta", IsCorrect: true},
+				{Answer: "Bandung"},
+				{Answer: "Surabaya"},
+			},
+		},
+		{
+			name:     "multiple choice with weights",
+			text:     "Bilangan prima {~%50%2 ~%50%3 ~%-100%4}",
+			wantType: model.MULTIPLE_CHOICE,
+			wantQuiz: "Bilangan prima",
+			answers: []model.QuizAnswer{
+				{Answer: "2", IsCorrect: true},
+				{Answer: "3", IsCorrect: true},
+				{Answer: "4"},
+			},
+		},
+		{
+			name:     "true false",
+			text:     "Bumi itu bulat {T}",
+			wantType: model.TRUE_FALSE,
+			wantQuiz: "Bumi itu bulat",
+			answers: []model.QuizAnswer{
+				{Answer: quizAnswerTrue, IsCorrect: true},
+				{Answer: quizAnswerFalse},
+			},
+		},
+		{
+			name:     "short answer",
+			text:     "Presiden pertama Indonesia {=Soekarno =Ir. Soekarno}",
+			wantType: model.SHORT_ANSWER,
+			wantQuiz: "Presiden pertama Indonesia",
+			answers: []model.QuizAnswer{
+				{Answer: "Soekarno", IsCorrect: true},
+				{Answer: "Ir. Soekarno", IsCorrect: true},
+			},
+		},
+		{
+			name:     "missing word",
+			text:     "Proklamasi dibacakan tahun {=1945} di Jakarta",
+			wantType: model.SHORT_ANSWER,
+			wantQuiz: "Proklamasi dibacakan tahun _____ di Jakarta",
+			answers: []model.QuizAnswer{
+				{Answer: "1945", IsCorrect: true},
+			},
+		},
+		{
+			name:     "numeric with tolerance",
+			text:     "Nilai pi {#3.14:0.01}",
+			wantType: model.NUMERIC,
+			wantQuiz: "Nilai pi",
+			answers: []model.QuizAnswer{
+				{Answer: "3.14", IsCorrect: true, Tolerance: 0.01},
+			},
+		},
+		{
+			name:     "numeric range",
+			text:     "Antara satu dan tiga {#1..3}",
+			wantType: model.NUMERIC,
+			wantQuiz: "Antara satu dan tiga",
+			answers: []model.QuizAnswer{
+				{Answer: "2", IsCorrect: true, Tolerance: 1},
+			},
+		},
+		{
+			name:     "escaped characters",
+			text:     `Hasil dari 1 \= 1 {=benar \{ya\} ~salah}`,
+			wantType: model.SINGLE_CHOICE,
+			wantQuiz: "Hasil dari 1 = 1",
+			answers: []model.QuizAnswer{
+				{Answer: "benar {ya}", IsCorrect: true},
+				{Answer: "salah"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			questions, errs := ParseGIFT(tt.text)
+			if len(errs) > 0 {
+				t.Fatalf("ParseGIFT() errors = %v", errs)
+			}
+			if len(questions) != 1 {
+				t.Fatalf("ParseGIFT() returned %d questions, want 1", len(questions))
+			}
+
+			question := questions[0]
+			if question.Type != tt.wantType || question.Quiz != tt.wantQuiz {
+				t.Errorf("ParseGIFT() = %s %q, want %s %q", question.Type, question.Quiz, tt.wantType, tt.wantQuiz)
+			}
+			if len(question.QuizAnswers) != len(tt.answers) {
+				t.Fatalf("ParseGIFT() answers = %+v, want %+v", question.QuizAnswers, tt.answers)
+			}
+			for i, want := range tt.answers {
+				got := question.QuizAnswers[i]
+				if got.Answer != want.Answer || got.IsCorrect != want.IsCorrect || got.Tolerance != want.Tolerance {
+					t.Errorf("answer %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseGIFTErrors(t *testing.T) {
+	text := `// soal latihan
+$CATEGORY: latihan
+
+Soal tanpa jawaban
+
+Essay {}
+
+Pasangkan {=a -> 1 =b -> 2}
+
+Bobot {~%abc%satu ~dua}
+
+Pilihan kosong {=satu ~}
+
+Soal benar {=ya ~tidak}`
+
+	questions, errs := ParseGIFT(text)
+
+	if len(questions) != 1 || questions[0].Quiz != "Soal benar" {
+		t.Errorf("ParseGIFT() questions = %+v, want only the valid question", questions)
+	}
+
+	wantLines := []int{4, 6, 8, 10, 12}
+	if len(errs) != len(wantLines) {
+		t.Fatalf("ParseGIFT() errors = %v, want %d errors", errs, len(wantLines))
+	}
+	for i, line := range wantLines {
+		if errs[i].Line != line {
+			t.Errorf("error %d at line %d, want line %d: %s", i, errs[i].Line, line, errs[i].Message)
+		}
+	}
+}
+
+func TestFormatGIFTRoundTrip(t *testing.T) {
+	questions := []model.Quizes{
+		{Quiz: "Ibukota {Indonesia}", Type: model.SINGLE_CHOICE, Points: 1, QuizAnswers: []model.QuizAnswer{
+			{Answer: "Jakarta", IsCorrect: true},
+			{Answer: "Bandung = kota"},
+		}},
+		{Quiz: "Nilai pi", Type: model.NUMERIC, Points: 1, QuizAnswers: []model.QuizAnswer{
+			{Answer: "3.14", IsCorrect: true, Tolerance: 0.01},
+		}},
+	}
+
+	parsed, errs := ParseGIFT(string(FormatGIFT(model.Quiz{Title: "Latihan"}, questions)))
+	if len(errs) > 0 {
+		t.Fatalf("ParseGIFT() errors = %v", errs)
+	}
+	if len(parsed) != len(questions) {
+		t.Fatalf("ParseGIFT() returned %d questions, want %d", len(parsed), len(questions))
+	}
+
+	for i, want := range questions {
+		got := parsed[i]
+		if got.Quiz != want.Quiz || got.Type != want.Type || len(got.QuizAnswers) != len(want.QuizAnswers) {
+			t.Errorf("question %d = %+v, want %+v", i, got, want)
+			continue
+		}
+		for j := range want.QuizAnswers {
+			if got.QuizAnswers[j].Answer != want.QuizAnswers[j].Answer || got.QuizAnswers[j].IsCorrect != want.QuizAnswers[j].IsCorrect {
+				t.Errorf("question %d answer %d = %+v, want %+v", i, j, got.QuizAnswers[j], want.QuizAnswers[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,875 @@
+package helper
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+)
+
+const (
+	qtiNamespace         = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	qtiManifestNamespace = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	qtiMatchCorrect      = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+	qtiMapResponse       = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/map_response"
+	qtiResponse          = "RESPONSE"
+
+	// qtiMaxFileSize limits a single file read from a QTI package.
+	qtiMaxFileSize = 10 << 20
+)
+
+// qtiNode is any element of an imported QTI item.
+type qtiNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Inner    string     `xml:",innerxml"`
+	Children []qtiNode  `xml:",any"`
+}
+
+func (n *qtiNode) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+func (n *qtiNode) child(name string) *qtiNode {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == name {
+			return &n.Children[i]
+		}
+	}
+
+	return nil
+}
+
+// find returns every descendant of the node that matches, in document order.
+func (n *qtiNode) find(match func(*qtiNode) bool) []*qtiNode {
+	var found []*qtiNode
+	for i := range n.Children {
+		if match(&n.Children[i]) {
+			found = append(found, &n.Children[i])
+		}
+		found = append(found, n.Children[i].find(match)...)
+	}
+
+	return found
+}
+
+func (n *qtiNode) values() []string {
+	var values []string
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == "value" {
+			values = append(values, qtiText(n.Children[i].Inner))
+		}
+	}
+
+	return values
+}
+
+type qtiManifest struct {
+	XMLName       xml.Name            `xml:"manifest"`
+	Xmlns         string              `xml:"xmlns,attr"`
+	Identifier    string              `xml:"identifier,attr"`
+	Metadata      qtiManifestMetadata `xml:"metadata"`
+	Organizations struct{}            `xml:"organizations"`
+	Resources     []qtiResource       `xml:"resources>resource"`
+}
+
+type qtiManifestMetadata struct {
+	Schema        string `xml:"schema"`
+	SchemaVersion string `xml:"schemaversion"`
+}
+
+type qtiResource struct {
+	Identifier   string          `xml:"identifier,attr"`
+	Type         string          `xml:"type,attr"`
+	Href         string          `xml:"href,attr"`
+	Files        []qtiFile       `xml:"file"`
+	Dependencies []qtiDependency `xml:"dependency"`
+}
+
+type qtiFile struct {
+	Href string `xml:"href,attr"`
+}
+
+type qtiDependency struct {
+	IdentifierRef string `xml:"identifierref,attr"`
+}
+
+type qtiAssessmentTest struct {
+	XMLName    xml.Name    `xml:"assessmentTest"`
+	Xmlns      string      `xml:"xmlns,attr"`
+	Identifier string      `xml:"identifier,attr"`
+	Title      string      `xml:"title,attr"`
+	TestPart   qtiTestPart `xml:"testPart"`
+}
+
+type qtiTestPart struct {
+	Identifier     string     `xml:"identifier,attr"`
+	NavigationMode string     `xml:"navigationMode,attr"`
+	SubmissionMode string     `xml:"submissionMode,attr"`
+	Section        qtiSection `xml:"assessmentSection"`
+}
+
+type qtiSection struct {
+	Identifier string       `xml:"identifier,attr"`
+	Title      string       `xml:"title,attr"`
+	Visible    bool         `xml:"visible,attr"`
+	ItemRefs   []qtiItemRef `xml:"assessmentItemRef"`
+}
+
+type qtiItemRef struct {
+	Identifier string `xml:"identifier,attr"`
+	Href       string `xml:"href,attr"`
+}
+
+type qtiAssessmentItem struct {
+	XMLName             xml.Name                `xml:"assessmentItem"`
+	Xmlns               string                  `xml:"xmlns,attr"`
+	Identifier          string                  `xml:"identifier,attr"`
+	Title               string                  `xml:"title,attr"`
+	Adaptive            bool                    `xml:"adaptive,attr"`
+	TimeDependent       bool                    `xml:"timeDependent,attr"`
+	ResponseDeclaration qtiResponseDeclaration  `xml:"responseDeclaration"`
+	OutcomeDeclarations []qtiOutcomeDeclaration `xml:"outcomeDeclaration"`
+	ItemBody            qtiItemBody             `xml:"itemBody"`
+	ResponseProcessing  qtiResponseProcessing   `xml:"responseProcessing"`
+}
+
+type qtiResponseDeclaration struct {
+	Identifier      string      `xml:"identifier,attr"`
+	Cardinality     string      `xml:"cardinality,attr"`
+	BaseType        string      `xml:"baseType,attr"`
+	CorrectResponse *qtiValues  `xml:"correctResponse,omitempty"`
+	Mapping         *qtiMapping `xml:"mapping,omitempty"`
+}
+
+type qtiOutcomeDeclaration struct {
+	Identifier   string     `xml:"identifier,attr"`
+	Cardinality  string     `xml:"cardinality,attr"`
+	BaseType     string     `xml:"baseType,attr"`
+	DefaultValue *qtiValues `xml:"defaultValue,omitempty"`
+}
+
+type qtiValues struct {
+	Values []string `xml:"value"`
+}
+
+type qtiMapping struct {
+	LowerBound   *float64      `xml:"lowerBound,attr,omitempty"`
+	UpperBound   *float64      `xml:"upperBound,attr,omitempty"`
+	DefaultValue float64       `xml:"defaultValue,attr"`
+	Entries      []qtiMapEntry `xml:"mapEntry"`
+}
+
+type qtiMapEntry struct {
+	MapKey        string  `xml:"mapKey,attr"`
+	MappedValue   float64 `xml:"mappedValue,attr"`
+	CaseSensitive *bool   `xml:"caseSensitive,attr,omitempty"`
+}
+
+type qtiItemBody struct {
+	Paragraphs  []qtiParagraph  `xml:"p"`
+	Interaction *qtiInteraction `xml:",omitempty"`
+}
+
+type qtiParagraph struct {
+	Text      string        `xml:",chardata"`
+	Img       *qtiImg       `xml:"img,omitempty"`
+	TextEntry *qtiTextEntry `xml:"textEntryInteraction,omitempty"`
+}
+
+type qtiImg struct {
+	Src string `xml:"src,attr"`
+	Alt string `xml:"alt,attr"`
+}
+
+type qtiTextEntry struct {
+	ResponseIdentifier string `xml:"responseIdentifier,attr"`
+}
+
+// qtiInteraction is a choiceInteraction or an orderInteraction.
+type qtiInteraction struct {
+	XMLName            xml.Name
+	ResponseIdentifier string            `xml:"responseIdentifier,attr"`
+	Shuffle            bool              `xml:"shuffle,attr"`
+	MaxChoices         *int              `xml:"maxChoices,attr,omitempty"`
+	Prompt             string            `xml:"prompt"`
+	Choices            []qtiSimpleChoice `xml:"simpleChoice"`
+}
+
+type qtiSimpleChoice struct {
+	Identifier string `xml:"identifier,attr"`
+	Text       string `xml:",chardata"`
+}
+
+type qtiResponseProcessing struct {
+	Template  string                `xml:"template,attr,omitempty"`
+	Condition *qtiResponseCondition `xml:"responseCondition,omitempty"`
+}
+
+type qtiResponseCondition struct {
+	If qtiResponseIf `xml:"responseIf"`
+}
+
+type qtiResponseIf struct {
+	Equals          []qtiEqual         `xml:"or>equal"`
+	SetOutcomeValue qtiSetOutcomeValue `xml:"setOutcomeValue"`
+}
+
+type qtiEqual struct {
+	ToleranceMode string       `xml:"toleranceMode,attr"`
+	Tolerance     string       `xml:"tolerance,attr,omitempty"`
+	Variable      qtiVariable  `xml:"variable"`
+	BaseValue     qtiBaseValue `xml:"baseValue"`
+}
+
+type qtiVariable struct {
+	Identifier string `xml:"identifier,attr"`
+}
+
+type qtiBaseValue struct {
+	BaseType string `xml:"baseType,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type qtiSetOutcomeValue struct {
+	Identifier string       `xml:"identifier,attr"`
+	BaseValue  qtiBaseValue `xml:"baseValue"`
+}
+
+// ParseQTI reads the questions of a quiz from an IMS QTI 2.1 content package (zip) or
+// from a single assessmentItem XML file. Items are read in the order of the manifest
+// and validated with ValidateQuizQuestion. The title of the assessmentTest of the
+// package is returned when there is one.
+func ParseQTI(data []byte, filename string) (string, []model.Quizes, []QuizImportError) {
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		question, err := parseQTIItem(data, filename)
+		if err != nil {
+			return "", nil, []QuizImportError{*err}
+		}
+		return "", []model.Quizes{question}, nil
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", nil, []QuizImportError{{File: filename, Message: "zip file is invalid"}}
+	}
+
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[path.Clean(file.Name)] = file
+	}
+
+	var title string
+	var items []string
+
+	if manifest, ok := files["imsmanifest.xml"]; ok {
+		content, err := readQTIFile(manifest)
+		if err != nil {
+			return "", nil, []QuizImportError{{File: manifest.Name, Message: err.Error()}}
+		}
+
+		var request qtiManifest
+		if err := xml.Unmarshal(content, &request); err != nil {
+			return "", nil, []QuizImportError{qtiSyntaxError(manifest.Name, err)}
+		}
+
+		for _, resource := range request.Resources {
+			href := path.Clean(resource.Href)
+
+			switch {
+			case strings.HasPrefix(resource.Type, "imsqti_item"):
+				items = append(items, href)
+			case strings.HasPrefix(resource.Type, "imsqti_test") && title == "":
+				if file, ok := files[href]; ok {
+					if content, err := readQTIFile(file); err == nil {
+						var test qtiAssessmentTest
+						if xml.Unmarshal(content, &test) == nil {
+							title = test.Title
+						}
+					}
+				}
+			}
+		}
+	} else {
+		// Tanpa manifest, setiap file xml dibaca sebagai item
+		for name := range files {
+			if strings.HasSuffix(strings.ToLower(name), ".xml") {
+				items = append(items, name)
+			}
+		}
+		sort.Strings(items)
+	}
+
+	if len(items) == 0 {
+		return title, nil, []QuizImportError{{File: filename, Message: "package has no assessmentItem"}}
+	}
+
+	var questions []model.Quizes
+	var errs []QuizImportError
+
+	for _, name := range items {
+		file, ok := files[name]
+		if !ok {
+			errs = append(errs, QuizImportError{File: name, Message: "file not found in the package"})
+			continue
+		}
+
+		content, err := readQTIFile(file)
+		if err != nil {
+			errs = append(errs, QuizImportError{File: name, Message: err.Error()})
+			continue
+		}
+
+		question, importErr := parseQTIItem(content, name)
+		if importErr != nil {
+			errs = append(errs, *importErr)
+			continue
+		}
+
+		questions = append(questions, question)
+	}
+
+	return title, questions, errs
+}
+
+func readQTIFile(file *zip.File) ([]byte, error) {
+	content, err := readZipFile(file, qtiMaxFileSize)
+	if errors.Is(err, errZipFileTooLarge) {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, errors.New("file can't be read")
+	}
+
+	return content, nil
+}
+
+func qtiSyntaxError(file string, err error) QuizImportError {
+	var syntax *xml.SyntaxError
+	if errors.As(err, &syntax) {
+		return QuizImportError{File: file, Line: syntax.Line, Message: syntax.Msg}
+	}
+
+	return QuizImportError{File: file, Message: err.Error()}
+}
+
+// parseQTIItem reads a question from an assessmentItem, problems are reported at the
+// line of the assessmentItem element.
+func parseQTIItem(data []byte, file string) (model.Quizes, *QuizImportError) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Entity = xml.HTMLEntity
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return model.Quizes{}, &QuizImportError{File: file, Message: "assessmentItem not found"}
+		}
+		if err != nil {
+			importErr := qtiSyntaxError(file, err)
+			return model.Quizes{}, &importErr
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		line, _ := decoder.InputPos()
+		if start.Name.Local != "assessmentItem" {
+			return model.Quizes{}, &QuizImportError{File: file, Line: line, Message: fmt.Sprintf("element %s is not an assessmentItem", start.Name.Local)}
+		}
+
+		var item qtiNode
+		if err := decoder.DecodeElement(&item, &start); err != nil {
+			importErr := qtiSyntaxError(file, err)
+			return model.Quizes{}, &importErr
+		}
+
+		question, err := qtiQuestion(&item)
+		if err == nil {
+			err = ValidateQuizQuestion(question)
+		}
+		if err != nil {
+			return model.Quizes{}, &QuizImportError{File: file, Line: line, Message: err.Error()}
+		}
+
+		return question, nil
+	}
+}
+
+// qtiQuestion maps an assessmentItem with a single choiceInteraction, orderInteraction
+// or textEntryInteraction into a question.
+func qtiQuestion(item *qtiNode) (model.Quizes, error) {
+	body := item.child("itemBody")
+	if body == nil {
+		return model.Quizes{}, errors.New("itemBody not found")
+	}
+
+	interactions := body.find(func(n *qtiNode) bool {
+		return strings.HasSuffix(n.XMLName.Local, "Interaction")
+	})
+	if len(interactions) != 1 {
+		return model.Quizes{}, errors.New("item must have exactly 1 interaction")
+	}
+	interaction := interactions[0]
+
+	var declaration *qtiNode
+	for _, node := range item.find(func(n *qtiNode) bool { return n.XMLName.Local == "responseDeclaration" }) {
+		if node.attr("identifier") == interaction.attr("responseIdentifier") {
+			declaration = node
+		}
+	}
+	if declaration == nil {
+		return model.Quizes{}, fmt.Errorf("responseDeclaration '%s' not found", interaction.attr("responseIdentifier"))
+	}
+
+	// Jawaban benar diambil dari correctResponse, atau dari mapping yang bernilai positif
+	var correct []string
+	if response := declaration.child("correctResponse"); response != nil {
+		correct = response.values()
+	}
+	if mapping := declaration.child("mapping"); mapping != nil {
+		for _, entry := range mapping.find(func(n *qtiNode) bool { return n.XMLName.Local == "mapEntry" }) {
+			if value, err := strconv.ParseFloat(entry.attr("mappedValue"), 64); err == nil && value > 0 && !containsQuizValue(correct, entry.attr("mapKey")) {
+				correct = append(correct, entry.attr("mapKey"))
+			}
+		}
+	}
+
+	// Kotak jawaban di akhir soal bukan bagian dari teks soal
+	text := strings.TrimSpace(strings.TrimSuffix(qtiBodyText(body.Inner), "_____"))
+	if prompt := interaction.child("prompt"); prompt != nil {
+		text = strings.TrimSpace(text + " " + qtiText(prompt.Inner))
+	}
+	if text == "" {
+		return model.Quizes{}, errors.New("question text must not be empty")
+	}
+
+	question := model.Quizes{
+		Quiz:   text,
+		Points: qtiPoints(item),
+	}
+
+	for _, img := range body.find(func(n *qtiNode) bool { return n.XMLName.Local == "img" }) {
+		if src := img.attr("src"); strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+			question.ImgURL = src
+			break
+		}
+	}
+
+	choices := interaction.find(func(n *qtiNode) bool { return n.XMLName.Local == "simpleChoice" })
+
+	switch interaction.XMLName.Local {
+	case "choiceInteraction":
+		question.Type = model.MULTIPLE_CHOICE
+		if declaration.attr("cardinality") == "single" || interaction.attr("maxChoices") == "1" {
+			question.Type = model.SINGLE_CHOICE
+		}
+
+		for i, choice := range choices {
+			question.QuizAnswers = append(question.QuizAnswers, model.QuizAnswer{
+				Answer:    qtiText(choice.Inner),
+				IsCorrect: containsQuizValue(correct, choice.attr("identifier")),
+				Position:  i,
+			})
+		}
+
+		if question.Type == model.SINGLE_CHOICE && len(question.QuizAnswers) == 2 {
+			_, first := trueFalseAnswer(question.QuizAnswers[0].Answer)
+			_, second := trueFalseAnswer(question.QuizAnswers[1].Answer)
+			if first && second {
+				question.Type = model.TRUE_FALSE
+			}
+		}
+	case "orderInteraction":
+		question.Type = model.ORDERING
+		if len(correct) != len(choices) {
+			return model.Quizes{}, errors.New("correctResponse of orderInteraction must list every item")
+		}
+
+		for _, id := range correct {
+			for _, choice := range choices {
+				if choice.attr("identifier") == id {
+					question.QuizAnswers = append(question.QuizAnswers, model.QuizAnswer{
+						Answer:   qtiText(choice.Inner),
+						Position: len(question.QuizAnswers),
+					})
+				}
+			}
+		}
+	case "textEntryInteraction":
+		question.Type = model.SHORT_ANSWER
+		if baseType := declaration.attr("baseType"); baseType == "float" || baseType == "integer" {
+			question.Type = model.NUMERIC
+		}
+
+		answers := make(map[string]float64)
+		for _, value := range correct {
+			answers[value] = 0
+		}
+
+		// Toleransi jawaban NUMERIC ditulis sebagai equal pada responseProcessing
+		if processing := item.child("responseProcessing"); processing != nil && question.Type == model.NUMERIC {
+			for _, equal := range processing.find(func(n *qtiNode) bool { return n.XMLName.Local == "equal" }) {
+				value := equal.child("baseValue")
+				if value == nil {
+					continue
+				}
+
+				var tolerance float64
+				if equal.attr("toleranceMode") == "absolute" {
+					if fields := strings.Fields(equal.attr("tolerance")); len(fields) > 0 {
+						tolerance, _ = strconv.ParseFloat(fields[0], 64)
+					}
+				}
+
+				key := qtiText(value.Inner)
+				if !containsQuizValue(correct, key) {
+					correct = append(correct, key)
+				}
+				answers[key] = tolerance
+			}
+		}
+
+		for _, value := range correct {
+			question.QuizAnswers = append(question.QuizAnswers, model.QuizAnswer{
+				Answer:    value,
+				IsCorrect: true,
+				Tolerance: answers[value],
+				Position:  len(question.QuizAnswers),
+			})
+		}
+	default:
+		return model.Quizes{}, fmt.Errorf("%s is not supported", interaction.XMLName.Local)
+	}
+
+	return question, nil
+}
+
+// qtiPoints reads the points of an item from the default value of its MAXSCORE outcome.
+func qtiPoints(item *qtiNode) int {
+	for _, outcome := range item.find(func(n *qtiNode) bool { return n.XMLName.Local == "outcomeDeclaration" }) {
+		if outcome.attr("identifier") != "MAXSCORE" {
+			continue
+		}
+
+		if value := outcome.child("defaultValue"); value != nil {
+			if values := value.values(); len(values) > 0 {
+				if points, err := strconv.ParseFloat(values[0], 64); err == nil && points >= 1 {
+					return int(math.Round(points))
+				}
+			}
+		}
+	}
+
+	return 1
+}
+
+// qtiText returns the text of an XML fragment without its markup.
+func qtiText(inner string) string {
+	return qtiFragmentText(inner, false)
+}
+
+// qtiBodyText returns the text of an itemBody without its interactions,
+// a textEntryInteraction is replaced by a blank.
+func qtiBodyText(inner string) string {
+	return qtiFragmentText(inner, true)
+}
+
+func qtiFragmentText(inner string, skipInteractions bool) string {
+	decoder := xml.NewDecoder(strings.NewReader(inner))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	var parts []string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if !skipInteractions || !strings.HasSuffix(t.Name.Local, "Interaction") {
+				parts = append(parts, " ")
+				continue
+			}
+			if t.Name.Local == "textEntryInteraction" {
+				parts = append(parts, " _____ ")
+			}
+			decoder.Skip()
+		case xml.EndElement:
+			parts = append(parts, " ")
+		case xml.CharData:
+			parts = append(parts, string(t))
+		}
+	}
+
+	return strings.Join(strings.Fields(strings.Join(parts, "")), " ")
+}
+
+func containsQuizValue(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FormatQTI writes the questions of a quiz as an IMS QTI 2.1 content package: a manifest,
+// an assessmentTest and an assessmentItem for every question. The points of a question
+// are the default value of the MAXSCORE outcome of its item.
+func FormatQTI(quiz model.Quiz, questions []model.Quizes) ([]byte, error) {
+	manifest := qtiManifest{
+		Xmlns:      qtiManifestNamespace,
+		Identifier: "MANIFEST-" + quiz.ID,
+		Metadata: qtiManifestMetadata{
+			Schema:        "QTIv2.1 Package",
+			SchemaVersion: "1.0.0",
+		},
+	}
+
+	test := qtiAssessmentTest{
+		Xmlns:      qtiNamespace,
+		Identifier: "TEST-" + quiz.ID,
+		Title:      quiz.Title,
+		TestPart: qtiTestPart{
+			Identifier:     "PART-1",
+			NavigationMode: "nonlinear",
+			SubmissionMode: "simultaneous",
+			Section: qtiSection{
+				Identifier: "SECTION-1",
+				Title:      quiz.Title,
+				Visible:    true,
+			},
+		},
+	}
+
+	testResource := qtiResource{
+		Identifier: "RES-TEST-" + quiz.ID,
+		Type:       "imsqti_test_xmlv2p1",
+		Href:       "assessment.xml",
+		Files:      []qtiFile{{Href: "assessment.xml"}},
+	}
+
+	files := make(map[string]interface{})
+	var names []string
+
+	for i, question := range questions {
+		name := "items/" + question.ID + ".xml"
+		identifier := "ITEM-" + question.ID
+
+		files[name] = qtiItem(question, identifier, fmt.Sprintf("Question %d", i+1), quiz.ShuffleAnswers)
+		names = append(names, name)
+
+		test.TestPart.Section.ItemRefs = append(test.TestPart.Section.ItemRefs, qtiItemRef{
+			Identifier: identifier,
+			Href:       name,
+		})
+		testResource.Dependencies = append(testResource.Dependencies, qtiDependency{IdentifierRef: "RES-" + identifier})
+		manifest.Resources = append(manifest.Resources, qtiResource{
+			Identifier: "RES-" + identifier,
+			Type:       "imsqti_item_xmlv2p1",
+			Href:       name,
+			Files:      []qtiFile{{Href: name}},
+		})
+	}
+
+	manifest.Resources = append([]qtiResource{testResource}, manifest.Resources...)
+	files["imsmanifest.xml"] = manifest
+	files["assessment.xml"] = test
+	names = append([]string{"imsmanifest.xml", "assessment.xml"}, names...)
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	for _, name := range names {
+		content, err := xml.MarshalIndent(files[name], "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		writer, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := writer.Write(append([]byte(xml.Header), content...)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// qtiItem maps a question into an assessmentItem. MULTIPLE_CHOICE options are mapped so a
+// wrong pick cancels a correct one and SHORT_ANSWER answers are not case sensitive, as in
+// GradeQuizQuestion.
+func qtiItem(question model.Quizes, identifier string, title string, shuffle bool) qtiAssessmentItem {
+	points := strconv.Itoa(question.Points)
+	item := qtiAssessmentItem{
+		Xmlns:      qtiNamespace,
+		Identifier: identifier,
+		Title:      title,
+		ResponseDeclaration: qtiResponseDeclaration{
+			Identifier:  qtiResponse,
+			Cardinality: "single",
+			BaseType:    "identifier",
+		},
+		OutcomeDeclarations: []qtiOutcomeDeclaration{
+			{
+				Identifier:   "SCORE",
+				Cardinality:  "single",
+				BaseType:     "float",
+				DefaultValue: &qtiValues{Values: []string{"0"}},
+			},
+			{
+				Identifier:   "MAXSCORE",
+				Cardinality:  "single",
+				BaseType:     "float",
+				DefaultValue: &qtiValues{Values: []string{points}},
+			},
+		},
+		ResponseProcessing: qtiResponseProcessing{Template: qtiMatchCorrect},
+	}
+
+	if question.ImgURL != "" {
+		item.ItemBody.Paragraphs = append(item.ItemBody.Paragraphs, qtiParagraph{
+			Img: &qtiImg{Src: question.ImgURL, Alt: title},
+		})
+	}
+
+	correct := &qtiValues{}
+	interaction := &qtiInteraction{
+		XMLName:            xml.Name{Local: "choiceInteraction"},
+		ResponseIdentifier: qtiResponse,
+		Shuffle:            shuffle,
+		Prompt:             question.Quiz,
+	}
+
+	switch question.Type {
+	case model.SHORT_ANSWER, model.NUMERIC:
+		item.ResponseDeclaration.BaseType = "string"
+		item.ItemBody.Paragraphs = append(item.ItemBody.Paragraphs,
+			qtiParagraph{Text: question.Quiz},
+			qtiParagraph{TextEntry: &qtiTextEntry{ResponseIdentifier: qtiResponse}},
+		)
+
+		for _, answer := range question.QuizAnswers {
+			value := strings.TrimSpace(answer.Answer)
+			if question.Type == model.NUMERIC {
+				value = strings.ReplaceAll(value, ",", ".")
+			}
+			correct.Values = append(correct.Values, value)
+		}
+		if len(correct.Values) > 0 {
+			item.ResponseDeclaration.CorrectResponse = &qtiValues{Values: correct.Values[:1]}
+		}
+
+		if question.Type == model.NUMERIC {
+			item.ResponseDeclaration.BaseType = "float"
+			condition := &qtiResponseCondition{
+				If: qtiResponseIf{
+					SetOutcomeValue: qtiSetOutcomeValue{
+						Identifier: "SCORE",
+						BaseValue:  qtiBaseValue{BaseType: "float", Value: "1"},
+					},
+				},
+			}
+
+			for i, answer := range question.QuizAnswers {
+				tolerance := strconv.FormatFloat(answer.Tolerance, 'f', -1, 64)
+				condition.If.Equals = append(condition.If.Equals, qtiEqual{
+					ToleranceMode: "absolute",
+					Tolerance:     tolerance + " " + tolerance,
+					Variable:      qtiVariable{Identifier: qtiResponse},
+					BaseValue:     qtiBaseValue{BaseType: "float", Value: correct.Values[i]},
+				})
+			}
+
+			item.ResponseProcessing = qtiResponseProcessing{Condition: condition}
+			return item
+		}
+
+		caseSensitive := false
+		mapping := &qtiMapping{}
+		for _, value := range correct.Values {
+			mapping.Entries = append(mapping.Entries, qtiMapEntry{MapKey: value, MappedValue: 1, CaseSensitive: &caseSensitive})
+		}
+		item.ResponseDeclaration.Mapping = mapping
+		item.ResponseProcessing.Template = qtiMapResponse
+		return item
+	case model.ORDERING:
+		interaction.XMLName = xml.Name{Local: "orderInteraction"}
+		interaction.Shuffle = true
+		item.ResponseDeclaration.Cardinality = "ordered"
+
+		for _, answer := range OrderedQuizAnswers(question) {
+			correct.Values = append(correct.Values, "CHOICE-"+answer.ID)
+		}
+	default:
+		maxChoices := 1
+		if question.Type == model.MULTIPLE_CHOICE {
+			maxChoices = 0
+			item.ResponseDeclaration.Cardinality = "multiple"
+		}
+		interaction.MaxChoices = &maxChoices
+
+		for _, answer := range question.QuizAnswers {
+			if answer.IsCorrect {
+				correct.Values = append(correct.Values, "CHOICE-"+answer.ID)
+			}
+		}
+
+		if question.Type == model.MULTIPLE_CHOICE && len(correct.Values) > 0 {
+			weight := 1 / float64(len(correct.Values))
+			lower, upper := 0.0, 1.0
+			mapping := &qtiMapping{LowerBound: &lower, UpperBound: &upper}
+
+			for _, answer := range question.QuizAnswers {
+				value := -weight
+				if answer.IsCorrect {
+					value = weight
+				}
+				mapping.Entries = append(mapping.Entries, qtiMapEntry{MapKey: "CHOICE-" + answer.ID, MappedValue: value})
+			}
+
+			item.ResponseDeclaration.Mapping = mapping
+			item.ResponseProcessing.Template = qtiMapResponse
+		}
+	}
+
+	for _, answer := range question.QuizAnswers {
+		interaction.Choices = append(interaction.Choices, qtiSimpleChoice{
+			Identifier: "CHOICE-" + answer.ID,
+			Text:       answer.Answer,
+		})
+	}
+
+	item.ResponseDeclaration.CorrectResponse = correct
+	item.ItemBody.Interaction = interaction
+
+	return item
+}
@@ -0,0 +1,198 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+)
+
+const qtiChoiceItem = `<?xml version="1.0" encoding="UTF-8"?>
+<assessmentItem xmlns="http://www.imsglobal.org/xsd/imsqti_v2p1" identifier="ITEM-1" title="Soal 1">
+  <responseDeclaration identifier="RESPONSE" cardinality="single" baseType="identifier">
+    <correctResponse><value>B</value></correctResponse>
+  </responseDeclaration>
+  <outcomeDeclaration identifier="MAXSCORE" cardinality="single" baseType="float">
+    <defaultValue><value>3</value></defaultValue>
+  </outcomeDeclaration>
+  <itemBody>
+    <choiceInteraction responseIdentifier="RESPONSE" maxChoices="1">
+      <prompt>Ibukota Indonesia adalah</prompt>
+      <simpleChoice identifier="A">Bandung</simpleChoice>
+      <simpleChoice identifier="B">Jakarta</simpleChoice>
+    </choiceInteraction>
+  </itemBody>
+</assessmentItem>`
+
+const qtiNumericItem = `<assessmentItem identifier="ITEM-2">
+  <responseDeclaration identifier="RESPONSE" cardinality="single" baseType="float">
+    <correctResponse><value>3.14</value></correctResponse>
+  </responseDeclaration>
+  <itemBody>
+    <p>Nilai pi <textEntryInteraction responseIdentifier="RESPONSE"/></p>
+  </itemBody>
+  <responseProcessing>
+    <responseCondition>
+      <responseIf>
+        <equal toleranceMode="absolute" tolerance="0.01 0.01">
+          <variable identifier="RESPONSE"/>
+          <baseValue baseType="float">3.14</baseValue>
+        </equal>
+      </responseIf>
+    </responseCondition>
+  </responseProcessing>
+</assessmentItem>`
+
+func TestParseQTI(t *testing.T) {
+	manifest := `<manifest xmlns="http://www.imsglobal.org/xsd/imscp_v1p1">
+  <resources>
+    <resource identifier="RES-TEST" type="imsqti_test_xmlv2p1" href="assessment.xml"/>
+    <resource identifier="RES-2" type="imsqti_item_xmlv2p1" href="items/2.xml"/>
+    <resource identifier="RES-1" type="imsqti_item_xmlv2p1" href="items/1.xml"/>
+  </resources>
+</manifest>`
+	test := `<assessmentTest identifier="TEST" title="Latihan"/>`
+
+	tests := []struct {
+		name      string
+		data      []byte
+		wantTitle string
+		wantTypes []model.QUESTION_TYPE
+		wantErrs  int
+	}{
+		{
+			name:      "single item",
+			data:      []byte(qtiChoiceItem),
+			wantTypes: []model.QUESTION_TYPE{model.SINGLE_CHOICE},
+		},
+		{
+			name: "package in manifest order",
+			data: zipFixture(t, map[string]string{
+				"imsmanifest.xml": manifest,
+				"assessment.xml":  test,
+				"items/1.xml":     qtiChoiceItem,
+				"items/2.xml":     qtiNumericItem,
+			}),
+			wantTitle: "Latihan",
+			wantTypes: []model.QUESTION_TYPE{model.NUMERIC, model.SINGLE_CHOICE},
+		},
+		{
+			name: "package without manifest",
+			data: zipFixture(t, map[string]string{
+				"b.xml": qtiNumericItem,
+				"a.xml": qtiChoiceItem,
+			}),
+			wantTypes: []model.QUESTION_TYPE{model.SINGLE_CHOICE, model.NUMERIC},
+		},
+		{
+			name: "item missing from package",
+			data: zipFixture(t, map[string]string{
+				"imsmanifest.xml": manifest,
+				"items/1.xml":     qtiChoiceItem,
+			}),
+			wantTypes: []model.QUESTION_TYPE{model.SINGLE_CHOICE},
+			wantErrs:  1,
+		},
+		{
+			name:     "not an assessment item",
+			data:     []byte(`<assessmentTest identifier="TEST"/>`),
+			wantErrs: 1,
+		},
+		{
+			name:     "malformed xml",
+			data:     []byte("<assessmentItem>\n<itemBody>"),
+			wantErrs: 1,
+		},
+		{
+			name:     "package without items",
+			data:     zipFixture(t, map[string]string{"readme.txt": "kosong"}),
+			wantErrs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, questions, errs := ParseQTI(tt.data, "quiz.zip")
+
+			if title != tt.wantTitle {
+				t.Errorf("ParseQTI() title = %q, want %q", title, tt.wantTitle)
+			}
+			if len(errs) != tt.wantErrs {
+				t.Errorf("ParseQTI() errors = %v, want %d errors", errs, tt.wantErrs)
+			}
+			if len(questions) != len(tt.wantTypes) {
+				t.Fatalf("ParseQTI() returned %d questions, want %d", len(questions), len(tt.wantTypes))
+			}
+			for i, want := range tt.wantTypes {
+				if questions[i].Type != want {
+					t.Errorf("question %d type = %s, want %s", i, questions[i].Type, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseQTIItem(t *testing.T) {
+	_, questions, errs := ParseQTI([]byte(qtiChoiceItem), "item.xml")
+	if len(errs) > 0 {
+		t.Fatalf("ParseQTI() errors = %v", errs)
+	}
+
+	question := questions[0]
+	if question.Quiz != "Ibukota Indonesia adalah" || question.Points != 3 {
+		t.Errorf("ParseQTI() = %q with %d points, want %q with 3 points", question.Quiz, question.Points, "Ibukota Indonesia adalah")
+	}
+	if len(question.QuizAnswers) != 2 || question.QuizAnswers[0].IsCorrect || !question.QuizAnswers[1].IsCorrect {
+		t.Errorf("ParseQTI() answers = %+v, want Jakarta correct", question.QuizAnswers)
+	}
+
+	_, questions, errs = ParseQTI([]byte(qtiNumericItem), "item.xml")
+	if len(errs) > 0 {
+		t.Fatalf("ParseQTI() errors = %v", errs)
+	}
+	if answer := questions[0].QuizAnswers[0]; answer.Answer != "3.14" || answer.Tolerance != 0.01 {
+		t.Errorf("ParseQTI() numeric answer = %+v, want 3.14 with tolerance 0.01", answer)
+	}
+}
+
+func TestFormatQTIRoundTrip(t *testing.T) {
+	questions := []model.Quizes{
+		{ID: "q1", Quiz: "Bilangan prima", Type: model.MULTIPLE_CHOICE, Points: 2, QuizAnswers: []model.QuizAnswer{
+			{ID: "a1", Answer: "2", IsCorrect: true},
+			{ID: "a2", Answer: "3", IsCorrect: true},
+			{ID: "a3", Answer: "4"},
+		}},
+		{ID: "q2", Quiz: "Urutkan", Type: model.ORDERING, Points: 1, QuizAnswers: []model.QuizAnswer{
+			{ID: "b1", Answer: "satu", Position: 0},
+			{ID: "b2", Answer: "dua", Position: 1},
+		}},
+		{ID: "q3", Quiz: "Presiden pertama", Type: model.SHORT_ANSWER, Points: 1, QuizAnswers: []model.QuizAnswer{
+			{ID: "c1", Answer: "Soekarno", IsCorrect: true},
+		}},
+	}
+
+	data, err := FormatQTI(model.Quiz{ID: "quiz", Title: "Latihan"}, questions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	title, parsed, errs := ParseQTI(data, "quiz.zip")
+	if len(errs) > 0 {
+		t.Fatalf("ParseQTI() errors = %v", errs)
+	}
+	if title != "Latihan" || len(parsed) != len(questions) {
+		t.Fatalf("ParseQTI() = %q with %d questions, want %q with %d", title, len(parsed), "Latihan", len(questions))
+	}
+
+	for i, want := range questions {
+		got := parsed[i]
+		if got.Quiz != want.Quiz || got.Type != want.Type || got.Points != want.Points || len(got.QuizAnswers) != len(want.QuizAnswers) {
+			t.Errorf("question %d = %+v, want %+v", i, got, want)
+			continue
+		}
+		for j := range want.QuizAnswers {
+			if got.QuizAnswers[j].Answer != want.QuizAnswers[j].Answer || got.QuizAnswers[j].IsCorrect != want.QuizAnswers[j].IsCorrect {
+				t.Errorf("question %d answer %d = %+v, want %+v", i, j, got.QuizAnswers[j], want.QuizAnswers[j])
+			}
+		}
+	}
+}
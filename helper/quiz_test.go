@@ -0,0 +1,254 @@
+package helper
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+)
+
+func TestGradeQuizQuestion(t *testing.T) {
+	single := model.Quizes{
+		Type: model.SINGLE_CHOICE,
+		QuizAnswers: []model.QuizAnswer{
+			{ID: "a", Answer: "Jakarta", IsCorrect: true},
+			{ID: "b", Answer: "Bandung"},
+		},
+	}
+	multiple := model.Quizes{
+		Type: model.MULTIPLE_CHOICE,
+		QuizAnswers: []model.QuizAnswer{
+			{ID: "a", Answer: "2", IsCorrect: true},
+			{ID: "b", Answer: "3", IsCorrect: true},
+			{ID: "c", Answer: "4"},
+			{ID: "d", Answer: "5", IsCorrect: true},
+		},
+	}
+	short := model.Quizes{
+		Type: model.SHORT_ANSWER,
+		QuizAnswers: []model.QuizAnswer{
+			{ID: "a", Answer: "Sumpah Pemuda"},
+		},
+	}
+	numeric := model.Quizes{
+		Type: model.NUMERIC,
+		QuizAnswers: []model.QuizAnswer{
+			{ID: "a", Answer: "3,14", Tolerance: 0.01},
+		},
+	}
+	ordering := model.Quizes{
+		Type: model.ORDERING,
+		QuizAnswers: []model.QuizAnswer{
+			{ID: "c", Answer: "third", Position: 2},
+			{ID: "a", Answer: "first", Position: 0},
+			{ID: "b", Answer: "second", Position: 1},
+			{ID: "d", Answer: "fourth", Position: 3},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		question model.Quizes
+		selected []string
+		text     string
+		want     float64
+	}{
+		{"single correct", single, []string{"a"}, "", 1},
+		{"single wrong", single, []string{"b"}, "", 0},
+		{"single without answer", single, nil, "", 0},
+		{"single with two picks", single, []string{"a", "b"}, "", 0},
+		{"true false correct", model.Quizes{Type: model.TRUE_FALSE, QuizAnswers: single.QuizAnswers}, []string{"a"}, "", 1},
+		{"multiple all correct", multiple, []string{"a", "b", "d"}, "", 1},
+		{"multiple partial credit", multiple, []string{"a", "b"}, "", 2.0 / 3},
+		{"multiple wrong pick cancels a correct one", multiple, []string{"a", "b", "c"}, "", 1.0 / 3},
+		{"multiple as many wrong as correct picks", multiple, []string{"a", "c"}, "", 0},
+		{"multiple everything picked", multiple, []string{"a", "b", "c", "d"}, "", 2.0 / 3},
+		{"short answer ignores case and whitespace", short, nil, "  sumpah   PEMUDA ", 1},
+		{"short answer wrong", short, nil, "Proklamasi", 0},
+		{"numeric within tolerance", numeric, nil, "3.141", 1},
+		{"numeric with comma", numeric, nil, "3,15", 1},
+		{"numeric outside tolerance", numeric, nil, "3.2", 0},
+		{"numeric not a number", numeric, nil, "pi", 0},
+		{"ordering correct", ordering, []string{"a", "b", "c", "d"}, "", 1},
+		{"ordering partial credit", ordering, []string{"a", "b", "d", "c"}, "", 0.5},
+		{"ordering missing items", ordering, []string{"a", "b"}, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GradeQuizQuestion(tt.question, tt.selected, tt.text)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("GradeQuizQuestion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateQuizQuestion(t *testing.T) {
+	options := func(correct ...bool) []model.QuizAnswer {
+		var answers []model.QuizAnswer
+		for _, isCorrect := range correct {
+			answers = append(answers, model.QuizAnswer{Answer: "option", IsCorrect: isCorrect})
+		}
+		return answers
+	}
+
+	tests := []struct {
+		name     string
+		question model.Quizes
+		wantErr  bool
+	}{
+		{"single choice", model.Quizes{Type: model.SINGLE_CHOICE, Points: 1, QuizAnswers: options(true, false)}, false},
+		{"single choice with two correct", model.Quizes{Type: model.SINGLE_CHOICE, Points: 1, QuizAnswers: options(true, true)}, true},
+		{"single choice with one option", model.Quizes{Type: model.SINGLE_CHOICE, Points: 1, QuizAnswers: options(true)}, true},
+		{"true false with three options", model.Quizes{Type: model.TRUE_FALSE, Points: 1, QuizAnswers: options(true, false, false)}, true},
+		{"multiple choice", model.Quizes{Type: model.MULTIPLE_CHOICE, Points: 2, QuizAnswers: options(true, true, false)}, false},
+		{"multiple choice without correct", model.Quizes{Type: model.MULTIPLE_CHOICE, Points: 1, QuizAnswers: options(false, false)}, true},
+		{"short answer without accepted answer", model.Quizes{Type: model.SHORT_ANSWER, Points: 1}, true},
+		{"numeric", model.Quizes{Type: model.NUMERIC, Points: 1, QuizAnswers: []model.QuizAnswer{{Answer: "2,5", Tolerance: 0.1}}}, false},
+		{"numeric not a number", model.Quizes{Type: model.NUMERIC, Points: 1, QuizAnswers: []model.QuizAnswer{{Answer: "two"}}}, true},
+		{"numeric negative tolerance", model.Quizes{Type: model.NUMERIC, Points: 1, QuizAnswers: []model.QuizAnswer{{Answer: "2", Tolerance: -1}}}, true},
+		{"ordering with one item", model.Quizes{Type: model.ORDERING, Points: 1, QuizAnswers: options(false)}, true},
+		{"empty answer", model.Quizes{Type: model.SINGLE_CHOICE, Points: 1, QuizAnswers: []model.QuizAnswer{{Answer: " ", IsCorrect: true}, {Answer: "b"}}}, true},
+		{"zero points", model.Quizes{Type: model.SINGLE_CHOICE, Points: 0, QuizAnswers: options(true, false)}, true},
+		{"unknown type", model.Quizes{Type: "ESSAY", Points: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQuizQuestion(tt.question)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQuizQuestion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecordedQuizAttempt(t *testing.T) {
+	// Newest first, the second attempt was overridden by the teacher after a regrade
+	attempts := []model.QuizAttempt{
+		{ID: "third", Score: 6, Grades: 60},
+		{ID: "second", Score: 9.5, Grades: 95, ScoreOverridden: true},
+		{ID: "first", Score: 4, Grades: 40},
+	}
+
+	tests := []struct {
+		name       string
+		policy     model.SCORING_POLICY
+		attempts   []model.QuizAttempt
+		wantID     string
+		wantScore  float64
+		wantGrades int
+	}{
+		{"best", model.SCORE_BEST, attempts, "second", 9.5, 95},
+		{"best prefers the older attempt on a tie", model.SCORE_BEST, []model.QuizAttempt{{ID: "new", Grades: 80}, {ID: "old", Grades: 80}}, "old", 0, 80},
+		{"last", model.SCORE_LAST, attempts, "third", 6, 60},
+		{"average", model.SCORE_AVERAGE, attempts, "third", 6.5, 65},
+		{"average rounds", model.SCORE_AVERAGE, []model.QuizAttempt{{ID: "b", Score: 1, Grades: 33}, {ID: "a", Score: 2, Grades: 34}}, "b", 1.5, 34},
+		{"single attempt", model.SCORE_AVERAGE, attempts[:1], "third", 6, 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RecordedQuizAttempt(tt.policy, tt.attempts)
+			if got == nil {
+				t.Fatal("RecordedQuizAttempt() = nil")
+			}
+			if got.ID != tt.wantID || !sameScore(got.Score, tt.wantScore) || got.Grades != tt.wantGrades {
+				t.Errorf("RecordedQuizAttempt() = %s %v/%d, want %s %v/%d", got.ID, got.Score, got.Grades, tt.wantID, tt.wantScore, tt.wantGrades)
+			}
+		})
+	}
+
+	if got := RecordedQuizAttempt(model.SCORE_BEST, nil); got != nil {
+		t.Errorf("RecordedQuizAttempt() without attempts = %+v, want nil", got)
+	}
+	if attempts[0].Score != 6 {
+		t.Error("RecordedQuizAttempt() changed the attempts")
+	}
+}
+
+func TestQuizGrades(t *testing.T) {
+	tests := []struct {
+		score       float64
+		totalPoints int
+		want        int
+	}{
+		{10, 10, 100},
+		{7.5, 10, 75},
+		{2, 3, 66},
+		{0, 10, 0},
+		{5, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := QuizGrades(tt.score, tt.totalPoints); got != tt.want {
+			t.Errorf("QuizGrades(%v, %d) = %d, want %d", tt.score, tt.totalPoints, got, tt.want)
+		}
+	}
+}
+
+func TestLateSubmission(t *testing.T) {
+	startedAt := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	closeAt := startedAt.Add(20 * time.Minute)
+
+	tests := []struct {
+		name        string
+		quiz        model.Quiz
+		submittedAt time.Time
+		wantLate    bool
+	}{
+		{"untimed quiz", model.Quiz{}, startedAt.Add(24 * time.Hour), false},
+		{"within the time limit", model.Quiz{TimeLimit: 30}, startedAt.Add(29 * time.Minute), false},
+		{"within the grace period", model.Quiz{TimeLimit: 30}, startedAt.Add(30*time.Minute + QuizSubmissionGrace), false},
+		{"after the grace period", model.Quiz{TimeLimit: 30}, startedAt.Add(30*time.Minute + QuizSubmissionGrace + time.Second), true},
+		{"closing before the time limit", model.Quiz{TimeLimit: 30, CloseAt: &closeAt}, startedAt.Add(25 * time.Minute), true},
+		{"closing without time limit", model.Quiz{CloseAt: &closeAt}, startedAt.Add(20 * time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadline := QuizAttemptDeadline(tt.quiz, startedAt)
+			if got := IsLateSubmission(deadline, tt.submittedAt); got != tt.wantLate {
+				t.Errorf("IsLateSubmission(%v, %v) = %v, want %v", deadline, tt.submittedAt, got, tt.wantLate)
+			}
+		})
+	}
+}
+
+func TestCanReviewQuiz(t *testing.T) {
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	closed := now.Add(-time.Hour)
+	open := now.Add(time.Hour)
+
+	tests := []struct {
+		name         string
+		quiz         model.Quiz
+		submitted    bool
+		attemptsUsed int
+		want         bool
+	}{
+		{"after submit with attempts left", model.Quiz{ReviewPolicy: model.REVIEW_AFTER_SUBMIT, MaxAttempts: 3}, true, 1, false},
+		{"after submit with every attempt used", model.Quiz{ReviewPolicy: model.REVIEW_AFTER_SUBMIT, MaxAttempts: 3}, true, 3, true},
+		{"after submit with unlimited attempts", model.Quiz{ReviewPolicy: model.REVIEW_AFTER_SUBMIT}, true, 5, false},
+		{"after submit once closed", model.Quiz{ReviewPolicy: model.REVIEW_AFTER_SUBMIT, CloseAt: &closed}, true, 1, true},
+		{"after submit before closing", model.Quiz{ReviewPolicy: model.REVIEW_AFTER_SUBMIT, CloseAt: &open}, true, 1, false},
+		{"after submit without a finished attempt", model.Quiz{ReviewPolicy: model.REVIEW_AFTER_SUBMIT, MaxAttempts: 1, CloseAt: &closed}, false, 1, false},
+		{"after close once closed", model.Quiz{ReviewPolicy: model.REVIEW_AFTER_CLOSE, CloseAt: &closed}, false, 0, true},
+		{"after close before closing", model.Quiz{ReviewPolicy: model.REVIEW_AFTER_CLOSE, CloseAt: &open}, true, 1, false},
+		{"never", model.Quiz{ReviewPolicy: model.REVIEW_NEVER, MaxAttempts: 1, CloseAt: &closed}, true, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanReviewQuiz(tt.quiz, tt.submitted, tt.attemptsUsed, now); got != tt.want {
+				t.Errorf("CanReviewQuiz() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func sameScore(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,762 @@
+package helper
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+)
+
+// A SCORM 1.2 package is a zip with imsmanifest.xml at its root. The manifest lists the items of
+// the package, the first SCO of the default organization is launched as the material.
+const (
+	scormManifest = "imsmanifest.xml"
+
+	// scormMaxFileSize limits a single file read from a SCORM package, scormMaxPackageSize all of them.
+	scormMaxFileSize    = 100 << 20
+	scormMaxPackageSize = 500 << 20
+	scormMaxFiles       = 5000
+)
+
+var (
+	ErrInvalidScormPackage     = errors.New("file is not a valid SCORM package")
+	ErrUnsupportedScormPackage = errors.New("package is not supported, only SCORM 1.2 is supported")
+)
+
+type scormManifestXML struct {
+	Identifier string `xml:"identifier,attr"`
+	Metadata   struct {
+		Schema        string `xml:"schema"`
+		SchemaVersion string `xml:"schemaversion"`
+	} `xml:"metadata"`
+	Organizations struct {
+		Default       string              `xml:"default,attr"`
+		Organizations []scormOrganization `xml:"organization"`
+	} `xml:"organizations"`
+	Resources struct {
+		Base      string          `xml:"base,attr"`
+		Resources []scormResource `xml:"resource"`
+	} `xml:"resources"`
+}
+
+type scormOrganization struct {
+	Identifier string      `xml:"identifier,attr"`
+	Title      string      `xml:"title"`
+	Items      []scormItem `xml:"item"`
+}
+
+type scormItem struct {
+	Identifier    string      `xml:"identifier,attr"`
+	IdentifierRef string      `xml:"identifierref,attr"`
+	Parameters    string      `xml:"parameters,attr"`
+	Title         string      `xml:"title"`
+	MasteryScore  string      `xml:"masteryscore"`
+	DataFromLMS   string      `xml:"datafromlms"`
+	Items         []scormItem `xml:"item"`
+}
+
+type scormResource struct {
+	Identifier string `xml:"identifier,attr"`
+	Href       string `xml:"href,attr"`
+	Base       string `xml:"base,attr"`
+	// SCORM 1.2 menulis scormtype, sebagian authoring tool menulis scormType
+	ScormType      string `xml:"scormtype,attr"`
+	ScormTypeCamel string `xml:"scormType,attr"`
+}
+
+func (r scormResource) isSCO() bool {
+	return strings.EqualFold(r.ScormType, "sco") || strings.EqualFold(r.ScormTypeCamel, "sco")
+}
+
+// ScormManifest is what a SCORM material needs from the manifest of its package. LaunchPath is the
+// path of the launched file in the package, with the parameters of its item.
+type ScormManifest struct {
+	Identifier   string
+	Title        string
+	LaunchPath   string
+	MasteryScore *float64
+	LaunchData   string
+	SHA256       string
+	Size         int64
+}
+
+// ReadScormPackage validates the zip of a SCORM 1.2 package and returns its manifest with the files
+// of the package by path. cmi5 and SCORM 2004 packages are rejected with ErrUnsupportedScormPackage.
+func ReadScormPackage(data []byte) (*ScormManifest, map[string][]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, ErrInvalidScormPackage
+	}
+
+	if len(archive.File) > scormMaxFiles {
+		return nil, nil, fmt.Errorf("%w: package has more than %d files", ErrInvalidScormPackage, scormMaxFiles)
+	}
+
+	files := make(map[string][]byte, len(archive.File))
+	var total uint64
+
+	for _, file := range archive.File {
+		name := strings.ReplaceAll(file.Name, "\\", "/")
+		if strings.HasSuffix(name, "/") || strings.HasPrefix(name, "__MACOSX/") {
+			continue
+		}
+
+		clean := path.Clean(name)
+		if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return nil, nil, fmt.Errorf("%w: path %s is invalid", ErrInvalidScormPackage, file.Name)
+		}
+
+		total += file.UncompressedSize64
+		if total > scormMaxPackageSize {
+			return nil, nil, fmt.Errorf("%w: package is too large", ErrInvalidScormPackage)
+		}
+
+		content, err := readScormFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files[clean] = content
+	}
+
+	manifestData, ok := files[scormManifest]
+	if !ok {
+		if _, ok := files["cmi5.xml"]; ok {
+			return nil, nil, fmt.Errorf("%w: package cmi5", ErrUnsupportedScormPackage)
+		}
+
+		return nil, nil, fmt.Errorf("%w: %s not found in the root of the zip", ErrInvalidScormPackage, scormManifest)
+	}
+
+	var manifest scormManifestXML
+	if err := xml.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidScormPackage, err.Error())
+	}
+
+	version := strings.TrimSpace(manifest.Metadata.SchemaVersion)
+	if version != "" && version != "1.2" {
+		return nil, nil, fmt.Errorf("%w: schemaversion %s", ErrUnsupportedScormPackage, version)
+	}
+
+	result, err := scormLaunch(&manifest, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	result.SHA256 = hex.EncodeToString(sum[:])
+	result.Size = int64(len(data))
+
+	return result, files, nil
+}
+
+func readScormFile(file *zip.File) ([]byte, error) {
+	content, err := readZipFile(file, scormMaxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidScormPackage, file.Name, err.Error())
+	}
+
+	return content, nil
+}
+
+// scormLaunch picks the launched item of the default organization, the first SCO or, in a package
+// without SCOs, the first item with a resource.
+func scormLaunch(manifest *scormManifestXML, files map[string][]byte) (*ScormManifest, error) {
+	organizations := manifest.Organizations.Organizations
+	if len(organizations) == 0 {
+		return nil, fmt.Errorf("%w: manifest has no organization", ErrInvalidScormPackage)
+	}
+
+	organization := organizations[0]
+	for _, o := range organizations {
+		if o.Identifier == manifest.Organizations.Default {
+			organization = o
+			break
+		}
+	}
+
+	resources := make(map[string]scormResource, len(manifest.Resources.Resources))
+	for _, resource := range manifest.Resources.Resources {
+		resources[resource.Identifier] = resource
+	}
+
+	var walk func(list []scormItem) []scormItem
+	walk = func(list []scormItem) []scormItem {
+		var found []scormItem
+		for _, item := range list {
+			if resource, ok := resources[item.IdentifierRef]; ok && resource.Href != "" {
+				found = append(found, item)
+			}
+			found = append(found, walk(item.Items)...)
+		}
+		return found
+	}
+	items := walk(organization.Items)
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: there is no item to launch", ErrInvalidScormPackage)
+	}
+
+	launch := items[0]
+	for _, item := range items {
+		if resources[item.IdentifierRef].isSCO() {
+			launch = item
+			break
+		}
+	}
+
+	resource := resources[launch.IdentifierRef]
+	if strings.Contains(resource.Href, "://") {
+		return nil, fmt.Errorf("%w: launch %s is not a file of the package", ErrInvalidScormPackage, resource.Href)
+	}
+
+	href := path.Join(manifest.Resources.Base, resource.Base, resource.Href)
+	file := href
+	if i := strings.IndexAny(file, "?#"); i >= 0 {
+		file = file[:i]
+	}
+
+	if _, ok := files[path.Clean(file)]; !ok {
+		return nil, fmt.Errorf("%w: launch file %s not found", ErrInvalidScormPackage, file)
+	}
+
+	if parameters := strings.TrimSpace(launch.Parameters); parameters != "" {
+		if !strings.HasPrefix(parameters, "?") && !strings.HasPrefix(parameters, "#") {
+			separator := "?"
+			if strings.Contains(href, "?") {
+				separator = "&"
+			}
+			parameters = separator + parameters
+		}
+		href += parameters
+	}
+
+	result := &ScormManifest{
+		Identifier: manifest.Identifier,
+		Title:      strings.TrimSpace(organization.Title),
+		LaunchPath: href,
+		LaunchData: strings.TrimSpace(launch.DataFromLMS),
+	}
+
+	if result.Title == "" {
+		result.Title = strings.TrimSpace(launch.Title)
+	}
+
+	if mastery := strings.TrimSpace(launch.MasteryScore); mastery != "" {
+		score, err := strconv.ParseFloat(mastery, 64)
+		if err != nil || score < 0 || score > 100 {
+			return nil, fmt.Errorf("%w: masteryscore %s is invalid", ErrInvalidScormPackage, mastery)
+		}
+		result.MasteryScore = &score
+	}
+
+	return result, nil
+}
+
+// SCORM_ERROR is a SCORM 1.2 runtime error code, returned to the SCO by LMSGetLastError.
+type SCORM_ERROR string
+
+const (
+	SCORM_INVALID_ARGUMENT    SCORM_ERROR = "201"
+	SCORM_NOT_IMPLEMENTED     SCORM_ERROR = "401"
+	SCORM_KEYWORD             SCORM_ERROR = "402"
+	SCORM_READ_ONLY           SCORM_ERROR = "403"
+	SCORM_INCORRECT_DATA_TYPE SCORM_ERROR = "405"
+)
+
+const (
+	scormSessionTime = "cmi.core.session_time"
+	scormComments    = "cmi.comments"
+	scormMaxString   = 255
+	scormMaxData     = 4096
+)
+
+var (
+	scormTimespan    = regexp.MustCompile(`^(\d{2,4}):(\d{2}):(\d{2})(\.\d{1,2})?$`)
+	scormTime        = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d:[0-5]\d(\.\d{1,2})?$`)
+	scormIdentifier  = regexp.MustCompile(`^[^\s]{1,255}$`)
+	scormObjective   = regexp.MustCompile(`^cmi\.objectives\.(\d+)\.(id|score\.raw|score\.min|score\.max|status)$`)
+	scormInteraction = regexp.MustCompile(
+		`^cmi\.interactions\.(\d+)\.(id|objectives\.(\d+)\.id|time|type|correct_responses\.(\d+)\.pattern|weighting|student_response|result|latency)$`)
+)
+
+var scormReadOnly = map[string]bool{
+	"cmi.core.student_id":                  true,
+	"cmi.core.student_name":                true,
+	"cmi.core.credit":                      true,
+	"cmi.core.entry":                       true,
+	"cmi.core.total_time":                  true,
+	"cmi.core.lesson_mode":                 true,
+	"cmi.launch_data":                      true,
+	"cmi.comments_from_lms":                true,
+	"cmi.student_data.mastery_score":       true,
+	"cmi.student_data.max_time_allowed":    true,
+	"cmi.student_data.time_limit_action":   true,
+	"cmi.objectives._count":                true,
+	"cmi.interactions._count":              true,
+	"cmi.core._children":                   true,
+	"cmi.core.score._children":             true,
+	"cmi.objectives._children":             true,
+	"cmi.student_data._children":           true,
+	"cmi.student_preference._children":     true,
+	"cmi.interactions._children":           true,
+	"cmi.objectives.n.score._children":     true,
+	"cmi.interactions.n.objectives._count": true,
+}
+
+// scormPreferenceLimits are the ranges of the numeric cmi.student_preference elements.
+var scormPreferenceLimits = map[string][2]int{
+	"cmi.student_preference.audio": {-1, 100},
+	"cmi.student_preference.speed": {-100, 100},
+	"cmi.student_preference.text":  {-1, 1},
+}
+
+// ScormRuntimeValues returns the elements of the SCORM 1.2 data model the SCO can read through
+// LMSGetValue. Interactions are write-only, only their _count is returned.
+func ScormRuntimeValues(registration *model.ScormRegistration, pkg model.ScormPackage, studentID, studentName string) map[string]string {
+	values := map[string]string{
+		"cmi.core._children":                 "student_id,student_name,lesson_location,credit,lesson_status,entry,score,total_time,lesson_mode,exit,session_time",
+		"cmi.core.student_id":                studentID,
+		"cmi.core.student_name":              studentName,
+		"cmi.core.lesson_location":           registration.LessonLocation,
+		"cmi.core.credit":                    "credit",
+		"cmi.core.lesson_status":             string(registration.LessonStatus),
+		"cmi.core.entry":                     registration.Entry,
+		"cmi.core.score._children":           "raw,min,max",
+		"cmi.core.score.raw":                 scormDecimal(registration.ScoreRaw),
+		"cmi.core.score.min":                 scormDecimal(registration.ScoreMin),
+		"cmi.core.score.max":                 scormDecimal(registration.ScoreMax),
+		"cmi.core.total_time":                registration.TotalTime,
+		"cmi.core.lesson_mode":               "normal",
+		"cmi.suspend_data":                   registration.SuspendData,
+		"cmi.launch_data":                    pkg.LaunchData,
+		"cmi.comments":                       registration.Data[scormComments],
+		"cmi.comments_from_lms":              "",
+		"cmi.objectives._children":           "id,score,status",
+		"cmi.objectives._count":              strconv.Itoa(scormCount(registration.Data, "cmi.objectives.")),
+		"cmi.student_data._children":         "mastery_score,max_time_allowed,time_limit_action",
+		"cmi.student_data.mastery_score":     scormDecimal(pkg.MasteryScore),
+		"cmi.student_data.max_time_allowed":  "",
+		"cmi.student_data.time_limit_action": "continue,no message",
+		"cmi.student_preference._children":   "audio,language,speed,text",
+		"cmi.student_preference.audio":       "0",
+		"cmi.student_preference.language":    "",
+		"cmi.student_preference.speed":       "0",
+		"cmi.student_preference.text":        "0",
+		"cmi.interactions._children":         "id,objectives,time,type,correct_responses,weighting,student_response,result,latency",
+		"cmi.interactions._count":            strconv.Itoa(scormCount(registration.Data, "cmi.interactions.")),
+	}
+
+	if values["cmi.core.total_time"] == "" {
+		values["cmi.core.total_time"] = formatScormTimespan(0)
+	}
+
+	for element, value := range registration.Data {
+		if strings.HasPrefix(element, "cmi.objectives.") || strings.HasPrefix(element, "cmi.student_preference.") {
+			values[element] = value
+		}
+	}
+
+	for i := 0; i < scormCount(registration.Data, "cmi.objectives."); i++ {
+		values[fmt.Sprintf("cmi.objectives.%d.score._children", i)] = "raw,min,max"
+	}
+
+	return values
+}
+
+// StartScormSession starts a session of the SCO, for LMSInitialize. Entry is ab-initio on the
+// first session and resume after a session the SCO suspended.
+func StartScormSession(registration *model.ScormRegistration) {
+	if registration.LessonStatus == "" {
+		registration.LessonStatus = model.SCORM_NOT_ATTEMPTED
+	}
+
+	if registration.TotalTime == "" {
+		registration.TotalTime = formatScormTimespan(0)
+	}
+
+	switch {
+	case registration.Sessions == 0:
+		registration.Entry = "ab-initio"
+	case registration.Exit == "suspend":
+		registration.Entry = "resume"
+	default:
+		registration.Entry = ""
+	}
+
+	registration.Exit = ""
+	registration.Sessions++
+	delete(registration.Data, scormSessionTime)
+}
+
+// SetScormValues sets the elements of an LMSSetValue batch, in their order in the data model, and
+// returns the error of every element that was rejected. Rejected elements are left unchanged.
+func SetScormValues(registration *model.ScormRegistration, values map[string]string) map[string]SCORM_ERROR {
+	if registration.Data == nil {
+		registration.Data = make(map[string]string)
+	}
+
+	elements := make([]string, 0, len(values))
+	for element := range values {
+		elements = append(elements, element)
+	}
+	sort.Slice(elements, func(i, j int) bool { return scormElementLess(elements[i], elements[j]) })
+
+	errs := make(map[string]SCORM_ERROR)
+	for _, element := range elements {
+		if code := setScormValue(registration, element, values[element]); code != "" {
+			errs[element] = code
+		}
+	}
+
+	return errs
+}
+
+func setScormValue(registration *model.ScormRegistration, element string, value string) SCORM_ERROR {
+	switch element {
+	case "cmi.core.lesson_location":
+		if len(value) > scormMaxString {
+			return SCORM_INCORRECT_DATA_TYPE
+		}
+		registration.LessonLocation = value
+
+	case "cmi.core.lesson_status":
+		status := model.SCORM_STATUS(value)
+		switch status {
+		case model.SCORM_PASSED, model.SCORM_COMPLETED, model.SCORM_FAILED, model.SCORM_INCOMPLETE, model.SCORM_BROWSED:
+			registration.LessonStatus = status
+		default:
+			return SCORM_INCORRECT_DATA_TYPE
+		}
+
+	case "cmi.core.score.raw", "cmi.core.score.min", "cmi.core.score.max":
+		score, ok := parseScormScore(value)
+		if !ok {
+			return SCORM_INCORRECT_DATA_TYPE
+		}
+
+		switch element {
+		case "cmi.core.score.raw":
+			registration.ScoreRaw = score
+		case "cmi.core.score.min":
+			registration.ScoreMin = score
+		default:
+			registration.ScoreMax = score
+		}
+
+	case "cmi.core.exit":
+		switch value {
+		case "time-out", "suspend", "logout", "":
+			registration.Exit = value
+		default:
+			return SCORM_INCORRECT_DATA_TYPE
+		}
+
+	case scormSessionTime:
+		if _, ok := parseScormTimespan(value); !ok {
+			return SCORM_INCORRECT_DATA_TYPE
+		}
+		registration.Data[element] = value
+
+	case "cmi.suspend_data":
+		if len(value) > scormMaxData {
+			return SCORM_INCORRECT_DATA_TYPE
+		}
+		registration.SuspendData = value
+
+	case scormComments:
+		// cmi.comments ditambahkan ke komentar sebelumnya
+		comments := registration.Data[element] + value
+		if len(comments) > scormMaxData {
+			return SCORM_INCORRECT_DATA_TYPE
+		}
+		registration.Data[element] = comments
+
+	case "cmi.student_preference.audio", "cmi.student_preference.speed", "cmi.student_preference.text":
+		limits := scormPreferenceLimits[element]
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < limits[0] || n > limits[1] {
+			return SCORM_INCORRECT_DATA_TYPE
+		}
+		registration.Data[element] = value
+
+	case "cmi.student_preference.language":
+		if len(value) > scormMaxString {
+			return SCORM_INCORRECT_DATA_TYPE
+		}
+		registration.Data[element] = value
+
+	default:
+		return setScormListValue(registration, element, value)
+	}
+
+	return ""
+}
+
+// setScormListValue sets an element of cmi.objectives or cmi.interactions. A new entry of a list
+// must be the next one, n equal to the _count of the list.
+func setScormListValue(registration *model.ScormRegistration, element string, value string) SCORM_ERROR {
+	if match := scormObjective.FindStringSubmatch(element); match != nil {
+		if !scormNextIndex(registration.Data, "cmi.objectives.", match[1]) {
+			return SCORM_INVALID_ARGUMENT
+		}
+
+		switch match[2] {
+		case "id":
+			if !scormIdentifier.MatchString(value) {
+				return SCORM_INCORRECT_DATA_TYPE
+			}
+		case "status":
+			switch model.SCORM_STATUS(value) {
+			case model.SCORM_PASSED, model.SCORM_COMPLETED, model.SCORM_FAILED, model.SCORM_INCOMPLETE,
+				model.SCORM_BROWSED, model.SCORM_NOT_ATTEMPTED:
+			default:
+				return SCORM_INCORRECT_DATA_TYPE
+			}
+		default:
+			if _, ok := parseScormScore(value); !ok {
+				return SCORM_INCORRECT_DATA_TYPE
+			}
+		}
+
+		registration.Data[element] = value
+		return ""
+	}
+
+	if match := scormInteraction.FindStringSubmatch(element); match != nil {
+		if !scormNextIndex(registration.Data, "cmi.interactions.", match[1]) {
+			return SCORM_INVALID_ARGUMENT
+		}
+
+		field := match[2]
+		switch {
+		case field == "id" || strings.HasPrefix(field, "objectives."):
+			if !scormIdentifier.MatchString(value) {
+				return SCORM_INCORRECT_DATA_TYPE
+			}
+		case field == "time":
+			if !scormTime.MatchString(value) {
+				return SCORM_INCORRECT_DATA_TYPE
+			}
+		case field == "type":
+			switch value {
+			case "true-false", "choice", "fill-in", "matching", "performance", "sequencing", "likert", "numeric":
+			default:
+				return SCORM_INCORRECT_DATA_TYPE
+			}
+		case field == "weighting":
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return SCORM_INCORRECT_DATA_TYPE
+			}
+		case field == "result":
+			switch value {
+			case "correct", "wrong", "unanticipated", "neutral":
+			default:
+				if _, err := strconv.ParseFloat(value, 64); err != nil {
+					return SCORM_INCORRECT_DATA_TYPE
+				}
+			}
+		case field == "latency":
+			if _, ok := parseScormTimespan(value); !ok {
+				return SCORM_INCORRECT_DATA_TYPE
+			}
+		default:
+			if len(value) > scormMaxString {
+				return SCORM_INCORRECT_DATA_TYPE
+			}
+		}
+
+		registration.Data[element] = value
+		return ""
+	}
+
+	if scormReadOnly[scormListElement(element)] {
+		if strings.HasSuffix(element, "._children") || strings.HasSuffix(element, "._count") {
+			return SCORM_KEYWORD
+		}
+		return SCORM_READ_ONLY
+	}
+
+	if strings.HasPrefix(element, "cmi.") {
+		return SCORM_NOT_IMPLEMENTED
+	}
+
+	return SCORM_INVALID_ARGUMENT
+}
+
+// CommitScormSession applies the rules of the LMS after the SCO committed its values. With a mastery
+// score the raw score decides between passed and failed. On LMSFinish a lesson without status is
+// completed and the session time is added to the total time.
+func CommitScormSession(registration *model.ScormRegistration, pkg model.ScormPackage, finish bool, now time.Time) {
+	if pkg.MasteryScore != nil && registration.ScoreRaw != nil {
+		if *registration.ScoreRaw >= *pkg.MasteryScore {
+			registration.LessonStatus = model.SCORM_PASSED
+		} else {
+			registration.LessonStatus = model.SCORM_FAILED
+		}
+	}
+
+	if finish {
+		if registration.LessonStatus == "" || registration.LessonStatus == model.SCORM_NOT_ATTEMPTED {
+			registration.LessonStatus = model.SCORM_COMPLETED
+		}
+
+		total, _ := parseScormTimespan(registration.TotalTime)
+		session, _ := parseScormTimespan(registration.Data[scormSessionTime])
+		registration.TotalTime = formatScormTimespan(total + session)
+		delete(registration.Data, scormSessionTime)
+	}
+
+	if IsScormCompleted(registration.LessonStatus) && registration.CompletedAt == nil {
+		registration.CompletedAt = &now
+	}
+}
+
+// IsScormCompleted reports whether a lesson status completes the material.
+func IsScormCompleted(status model.SCORM_STATUS) bool {
+	return status == model.SCORM_PASSED || status == model.SCORM_COMPLETED
+}
+
+// ScormGrade is the raw score of a registration scaled from score.min-score.max to 0-100, ok is
+// false while the SCO did not report a score.
+func ScormGrade(registration model.ScormRegistration) (int, bool) {
+	if registration.ScoreRaw == nil {
+		return 0, false
+	}
+
+	min, max := 0.0, 100.0
+	if registration.ScoreMin != nil {
+		min = *registration.ScoreMin
+	}
+	if registration.ScoreMax != nil {
+		max = *registration.ScoreMax
+	}
+
+	grade := *registration.ScoreRaw
+	if max > min {
+		grade = (grade - min) / (max - min) * 100
+	}
+
+	return int(math.Round(math.Max(0, math.Min(100, grade)))), true
+}
+
+func parseScormScore(value string) (*float64, bool) {
+	if value == "" {
+		return nil, true
+	}
+
+	score, err := strconv.ParseFloat(value, 64)
+	if err != nil || score < 0 || score > 100 {
+		return nil, false
+	}
+
+	return &score, true
+}
+
+func scormDecimal(value *float64) string {
+	if value == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+func parseScormTimespan(value string) (time.Duration, bool) {
+	match := scormTimespan.FindStringSubmatch(value)
+	if match == nil {
+		return 0, false
+	}
+
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	seconds, _ := strconv.Atoi(match[3])
+	if minutes > 59 || seconds > 59 {
+		return 0, false
+	}
+
+	duration := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	if match[4] != "" {
+		fraction, _ := strconv.ParseFloat(match[4], 64)
+		duration += time.Duration(fraction * float64(time.Second))
+	}
+
+	return duration, true
+}
+
+func formatScormTimespan(duration time.Duration) string {
+	centiseconds := int64(duration / (10 * time.Millisecond))
+	hours := centiseconds / 360000
+	if hours > 9999 {
+		return "9999:59:59.99"
+	}
+
+	return fmt.Sprintf("%04d:%02d:%02d.%02d", hours, centiseconds/6000%60, centiseconds/100%60, centiseconds%100)
+}
+
+// scormCount is the _count of a list, the number of entries set in data.
+func scormCount(data map[string]string, list string) int {
+	count := 0
+	for element := range data {
+		if !strings.HasPrefix(element, list) {
+			continue
+		}
+
+		index, _, _ := strings.Cut(strings.TrimPrefix(element, list), ".")
+		if n, err := strconv.Atoi(index); err == nil && n+1 > count {
+			count = n + 1
+		}
+	}
+
+	return count
+}
+
+func scormNextIndex(data map[string]string, list string, index string) bool {
+	n, err := strconv.Atoi(index)
+	return err == nil && n <= scormCount(data, list)
+}
+
+// scormListElement replaces the index of a list element by n, cmi.objectives.0.id is cmi.objectives.n.id.
+func scormListElement(element string) string {
+	parts := strings.Split(element, ".")
+	for i, part := range parts {
+		if _, err := strconv.Atoi(part); err == nil {
+			parts[i] = "n"
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// scormElementLess orders elements by their name, comparing the indexes of lists as numbers.
+func scormElementLess(a, b string) bool {
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		if partsA[i] == partsB[i] {
+			continue
+		}
+
+		numberA, errA := strconv.Atoi(partsA[i])
+		numberB, errB := strconv.Atoi(partsB[i])
+		if errA == nil && errB == nil {
+			return numberA < numberB
+		}
+
+		return partsA[i] < partsB[i]
+	}
+
+	return len(partsA) < len(partsB)
+}
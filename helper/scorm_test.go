@@ -0,0 +1,264 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+)
+
+const scormTestManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest identifier="com.example.sejarah" xmlns:adlcp="http://www.adlnet.org/xsd/adlcp_rootv1p2">
+  <metadata>
+    <schema>ADL SCORM</schema>
+    <schemaversion>1.2</schemaversion>
+  </metadata>
+  <organizations default="ORG-1">
+    <organization identifier="ORG-0">
+      <title>Lain</title>
+      <item identifier="ITEM-0" identifierref="RES-ASSET"><title>Aset</title></item>
+    </organization>
+    <organization identifier="ORG-1">
+      <title>Sejarah Indonesia</title>
+      <item identifier="ITEM-1" identifierref="RES-ASSET"><title>Pengantar</title></item>
+      <item identifier="ITEM-2" identifierref="RES-SCO" parameters="lang=id">
+        <title>Pelajaran</title>
+        <adlcp:masteryscore>80</adlcp:masteryscore>
+        <adlcp:datafromlms>hello</adlcp:datafromlms>
+      </item>
+    </organization>
+  </organizations>
+  <resources>
+    <resource identifier="RES-ASSET" type="webcontent" adlcp:scormtype="asset" href="intro.html"/>
+    <resource identifier="RES-SCO" type="webcontent" adlcp:scormtype="sco" xml:base="shared/" href="index.html"/>
+  </resources>
+</manifest>`
+
+func TestReadScormPackage(t *testing.T) {
+	manifest, files, err := ReadScormPackage(zipFixture(t, map[string]string{
+		"imsmanifest.xml":    scormTestManifest,
+		"intro.html":         "intro",
+		"shared/index.html":  "sco",
+		"__MACOSX/._foo":     "resource fork",
+		"shared/css/app.css": "body{}",
+	}))
+	if err != nil {
+		t.Fatalf("ReadScormPackage() error = %v", err)
+	}
+
+	if manifest.Identifier != "com.example.sejarah" || manifest.Title != "Sejarah Indonesia" {
+		t.Errorf("ReadScormPackage() = %q %q", manifest.Identifier, manifest.Title)
+	}
+	if manifest.LaunchPath != "shared/index.html?lang=id" {
+		t.Errorf("LaunchPath = %q, want the first SCO with its parameters", manifest.LaunchPath)
+	}
+	if manifest.MasteryScore == nil || *manifest.MasteryScore != 80 || manifest.LaunchData != "hello" {
+		t.Errorf("MasteryScore = %v, LaunchData = %q", manifest.MasteryScore, manifest.LaunchData)
+	}
+	if len(manifest.SHA256) != 64 || manifest.Size == 0 {
+		t.Errorf("SHA256 = %q, Size = %d", manifest.SHA256, manifest.Size)
+	}
+	if _, ok := files["__MACOSX/._foo"]; ok || len(files) != 4 {
+		t.Errorf("ReadScormPackage() files = %d, want the 4 files of the package", len(files))
+	}
+}
+
+func TestReadScormPackageErrors(t *testing.T) {
+	withManifest := func(manifest string, extra map[string]string) map[string]string {
+		files := map[string]string{"imsmanifest.xml": manifest, "intro.html": "intro", "shared/index.html": "sco"}
+		for name, content := range extra {
+			files[name] = content
+		}
+		return files
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"not a zip", []byte("not a zip"), ErrInvalidScormPackage},
+		{"parent directory entry", zipFixture(t, withManifest(scormTestManifest, map[string]string{"../evil.html": "x"})), ErrInvalidScormPackage},
+		{"nested parent directory entry", zipFixture(t, withManifest(scormTestManifest, map[string]string{"shared/../../evil.html": "x"})), ErrInvalidScormPackage},
+		{"windows parent directory entry", zipFixture(t, withManifest(scormTestManifest, map[string]string{`..\evil.html`: "x"})), ErrInvalidScormPackage},
+		{"absolute entry", zipFixture(t, withManifest(scormTestManifest, map[string]string{"/etc/passwd": "x"})), ErrInvalidScormPackage},
+		{"manifest not at the root", zipFixture(t, map[string]string{"course/imsmanifest.xml": scormTestManifest}), ErrInvalidScormPackage},
+		{"cmi5", zipFixture(t, map[string]string{"cmi5.xml": "<courseStructure/>"}), ErrUnsupportedScormPackage},
+		{"SCORM 2004", zipFixture(t, withManifest(`<manifest><metadata><schemaversion>2004 4th Edition</schemaversion></metadata></manifest>`, nil)), ErrUnsupportedScormPackage},
+		{"malformed manifest", zipFixture(t, withManifest("<manifest>", nil)), ErrInvalidScormPackage},
+		{"missing launch file", zipFixture(t, map[string]string{"imsmanifest.xml": scormTestManifest, "intro.html": "intro"}), ErrInvalidScormPackage},
+		{"remote launch", zipFixture(t, withManifest(`<manifest><organizations><organization><item identifierref="R"/></organization></organizations>
+<resources><resource identifier="R" scormtype="sco" href="https://example.com/index.html"/></resources></manifest>`, nil)), ErrInvalidScormPackage},
+		{"invalid mastery score", zipFixture(t, withManifest(`<manifest><organizations><organization><item identifierref="R"><masteryscore>120</masteryscore></item></organization></organizations>
+<resources><resource identifier="R" scormtype="sco" href="intro.html"/></resources></manifest>`, nil)), ErrInvalidScormPackage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ReadScormPackage(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadScormPackage() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetScormValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		element string
+		value   string
+		want    SCORM_ERROR
+	}{
+		{"lesson status", nil, "cmi.core.lesson_status", "incomplete", ""},
+		{"not attempted can't be set", nil, "cmi.core.lesson_status", "not attempted", SCORM_INCORRECT_DATA_TYPE},
+		{"raw score", nil, "cmi.core.score.raw", "85", ""},
+		{"raw score out of range", nil, "cmi.core.score.raw", "101", SCORM_INCORRECT_DATA_TYPE},
+		{"raw score not a number", nil, "cmi.core.score.raw", "high", SCORM_INCORRECT_DATA_TYPE},
+		{"exit", nil, "cmi.core.exit", "suspend", ""},
+		{"unknown exit", nil, "cmi.core.exit", "quit", SCORM_INCORRECT_DATA_TYPE},
+		{"session time", nil, "cmi.core.session_time", "0000:10:05.5", ""},
+		{"session time with 60 minutes", nil, "cmi.core.session_time", "0000:60:00", SCORM_INCORRECT_DATA_TYPE},
+		{"suspend data too long", nil, "cmi.suspend_data", string(make([]byte, scormMaxData+1)), SCORM_INCORRECT_DATA_TYPE},
+		{"preference in range", nil, "cmi.student_preference.speed", "-100", ""},
+		{"preference out of range", nil, "cmi.student_preference.text", "2", SCORM_INCORRECT_DATA_TYPE},
+		{"first objective", nil, "cmi.objectives.0.id", "obj-1", ""},
+		{"next objective", map[string]string{"cmi.objectives.0.id": "obj-1"}, "cmi.objectives.1.id", "obj-2", ""},
+		{"objective skipping an index", nil, "cmi.objectives.10.id", "obj-1", SCORM_INVALID_ARGUMENT},
+		{"objective id with spaces", nil, "cmi.objectives.0.id", "obj 1", SCORM_INCORRECT_DATA_TYPE},
+		{"interaction", nil, "cmi.interactions.0.type", "choice", ""},
+		{"interaction time", nil, "cmi.interactions.0.time", "25:00:00", SCORM_INCORRECT_DATA_TYPE},
+		{"read only", nil, "cmi.core.student_id", "someone", SCORM_READ_ONLY},
+		{"read only of a list", nil, "cmi.objectives._count", "3", SCORM_KEYWORD},
+		{"children", nil, "cmi.core._children", "x", SCORM_KEYWORD},
+		{"unknown cmi element", nil, "cmi.foo", "x", SCORM_NOT_IMPLEMENTED},
+		{"not a cmi element", nil, "adl.nav.request", "x", SCORM_INVALID_ARGUMENT},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registration := &model.ScormRegistration{Data: map[string]string{}}
+			for element, value := range tt.data {
+				registration.Data[element] = value
+			}
+
+			errs := SetScormValues(registration, map[string]string{tt.element: tt.value})
+			if got := errs[tt.element]; got != tt.want {
+				t.Errorf("SetScormValues(%s = %q) error = %q, want %q", tt.element, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetScormValuesOrder(t *testing.T) {
+	registration := &model.ScormRegistration{}
+
+	// Objective 10 is only valid after 0 to 9, which are set in the same batch
+	values := map[string]string{}
+	for _, index := range []string{"10", "2", "0", "1", "9", "3", "4", "5", "6", "7", "8"} {
+		values["cmi.objectives."+index+".id"] = "obj-" + index
+	}
+
+	if errs := SetScormValues(registration, values); len(errs) > 0 {
+		t.Errorf("SetScormValues() errors = %v", errs)
+	}
+	if count := ScormRuntimeValues(registration, model.ScormPackage{}, "", "")["cmi.objectives._count"]; count != "11" {
+		t.Errorf("cmi.objectives._count = %s, want 11", count)
+	}
+}
+
+func TestScormSession(t *testing.T) {
+	mastery := 80.0
+	pkg := model.ScormPackage{MasteryScore: &mastery, LaunchData: "hello"}
+	registration := &model.ScormRegistration{}
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	StartScormSession(registration)
+	if registration.Entry != "ab-initio" || registration.LessonStatus != model.SCORM_NOT_ATTEMPTED || registration.Sessions != 1 {
+		t.Fatalf("first session = %+v", registration)
+	}
+
+	values := ScormRuntimeValues(registration, pkg, "s-1", "Budi")
+	if values["cmi.core.student_id"] != "s-1" || values["cmi.launch_data"] != "hello" || values["cmi.student_data.mastery_score"] != "80" {
+		t.Errorf("ScormRuntimeValues() = %v", values)
+	}
+
+	SetScormValues(registration, map[string]string{
+		"cmi.core.score.raw":    "70",
+		"cmi.core.session_time": "0000:10:05.5",
+		"cmi.core.exit":         "suspend",
+	})
+	CommitScormSession(registration, pkg, true, now)
+
+	if registration.LessonStatus != model.SCORM_FAILED || registration.CompletedAt != nil {
+		t.Errorf("below the mastery score = %s, completed at %v", registration.LessonStatus, registration.CompletedAt)
+	}
+	if registration.TotalTime != "0000:10:05.50" {
+		t.Errorf("TotalTime = %s, want 0000:10:05.50", registration.TotalTime)
+	}
+
+	StartScormSession(registration)
+	if registration.Entry != "resume" || registration.Sessions != 2 {
+		t.Errorf("second session entry = %q, sessions = %d", registration.Entry, registration.Sessions)
+	}
+
+	SetScormValues(registration, map[string]string{
+		"cmi.core.score.raw":    "85",
+		"cmi.core.session_time": "0001:00:00",
+	})
+	CommitScormSession(registration, pkg, true, now)
+
+	if registration.LessonStatus != model.SCORM_PASSED || registration.CompletedAt == nil || !registration.CompletedAt.Equal(now) {
+		t.Errorf("above the mastery score = %s, completed at %v", registration.LessonStatus, registration.CompletedAt)
+	}
+	if registration.TotalTime != "0001:10:05.50" {
+		t.Errorf("TotalTime = %s, want 0001:10:05.50", registration.TotalTime)
+	}
+	if grade, ok := ScormGrade(*registration); !ok || grade != 85 {
+		t.Errorf("ScormGrade() = %d, %v, want 85", grade, ok)
+	}
+}
+
+func TestCommitScormSessionWithoutStatus(t *testing.T) {
+	registration := &model.ScormRegistration{}
+	StartScormSession(registration)
+
+	CommitScormSession(registration, model.ScormPackage{}, false, time.Now())
+	if registration.LessonStatus != model.SCORM_NOT_ATTEMPTED {
+		t.Errorf("LMSCommit changed the status to %s", registration.LessonStatus)
+	}
+
+	CommitScormSession(registration, model.ScormPackage{}, true, time.Now())
+	if registration.LessonStatus != model.SCORM_COMPLETED {
+		t.Errorf("LMSFinish without status = %s, want completed", registration.LessonStatus)
+	}
+}
+
+func TestScormGrade(t *testing.T) {
+	score := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name   string
+		raw    *float64
+		min    *float64
+		max    *float64
+		want   int
+		wantOK bool
+	}{
+		{"no score", nil, nil, nil, 0, false},
+		{"default range", score(72.4), nil, nil, 72, true},
+		{"scaled range", score(15), score(10), score(20), 50, true},
+		{"raw above max", score(30), score(0), score(20), 100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ScormGrade(model.ScormRegistration{ScoreRaw: tt.raw, ScoreMin: tt.min, ScoreMax: tt.max})
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ScormGrade() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateRefreshToken returns a random refresh token, only its HashRefreshToken is stored.
+func GenerateRefreshToken() (string, error) {
+	return randomToken()
+}
+
+// HashRefreshToken returns the SHA-256 hash of a refresh token in hex.
+func HashRefreshToken(token string) string {
+	return hashToken(token)
+}
+
+// GenerateInvitationToken returns a random one-time invitation token, only its
+// HashInvitationToken is stored.
+func GenerateInvitationToken() (string, error) {
+	return randomToken()
+}
+
+// HashInvitationToken returns the SHA-256 hash of an invitation token in hex.
+func HashInvitationToken(token string) string {
+	return hashToken(token)
+}
@@ -0,0 +1,146 @@
+package helper
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 secret of the test vectors of RFC 6238, "12345678901234567890" in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The last 6 digits of the 8 digit codes of RFC 6238 appendix B
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode() at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode() with an invalid secret, want error")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := TOTPStep(now)
+
+	code := func(step int64) string {
+		code, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfc6238Secret, code(step), step, true},
+		{"previous step within skew", rfc6238Secret, code(step - 1), step - 1, true},
+		{"next step within skew", rfc6238Secret, code(step + 1), step + 1, true},
+		{"two steps behind", rfc6238Secret, code(step - 2), 0, false},
+		{"two steps ahead", rfc6238Secret, code(step + 2), 0, false},
+		{"surrounding spaces", rfc6238Secret, " " + code(step) + " ", step, true},
+		{"lowercase secret", strings.ToLower(rfc6238Secret), code(step), step, true},
+		{"wrong code", rfc6238Secret, "000000", 0, false},
+		{"too short", rfc6238Secret, code(step)[:5], 0, false},
+		{"invalid secret", "not base32!", code(step), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(tt.secret, tt.code, now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP() = %d, %v, want %d, %v", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := TOTPCode(secret, 1)
+	if err != nil || len(code) != TOTPDigits {
+		t.Errorf("TOTPCode() of a generated secret = %q, %v", code, err)
+	}
+
+	uri, err := url.Parse(TOTPProvisioningURI("E-Learning", "budi", secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Query().Get("secret") != secret || uri.Query().Get("period") != "30" {
+		t.Errorf("TOTPProvisioningURI() = %s", uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("GenerateRecoveryCodes() returned %d codes, want 10", len(codes))
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("recovery code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("recovery code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+
+	tests := []struct {
+		name  string
+		code  string
+		match bool
+	}{
+		{"same code", "abcde-fghij", true},
+		{"uppercase", "ABCDE-FGHIJ", true},
+		{"without dash", "abcdefghij", true},
+		{"with spaces", " abcde fghij ", true},
+		{"other code", "abcde-fghik", false},
+	}
+
+	hash := HashRecoveryCode("abcde-fghij")
+	if hash == "abcde-fghij" || len(hash) != 64 {
+		t.Fatalf("HashRecoveryCode() = %q, want a SHA-256 hex hash", hash)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HashRecoveryCode(tt.code) == hash; got != tt.match {
+				t.Errorf("HashRecoveryCode(%q) matches = %v, want %v", tt.code, got, tt.match)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package helper
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+)
+
+// errZipFileTooLarge is returned by readZipFile for a file over its size limit.
+var errZipFileTooLarge = errors.New("file is too large")
+
+// readZipFile reads a file of a package zip. The size in the zip header can't be trusted,
+// a file with more than limit bytes of content is rejected instead of being cut off.
+func readZipFile(file *zip.File, limit int64) ([]byte, error) {
+	if file.UncompressedSize64 > uint64(limit) {
+		return nil, errZipFileTooLarge
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(content)) > limit {
+		return nil, errZipFileTooLarge
+	}
+
+	return content, nil
+}
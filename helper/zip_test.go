@@ -0,0 +1,58 @@
+package helper
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// zipFixture zips files by name for the package readers.
+func zipFixture(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for name, content := range files {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestReadZipFile(t *testing.T) {
+	data := zipFixture(t, map[string]string{
+		"small.txt": strings.Repeat("a", 8),
+		"large.txt": strings.Repeat("a", 9),
+	})
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range archive.File {
+		content, err := readZipFile(file, 8)
+
+		switch file.Name {
+		case "small.txt":
+			if err != nil || len(content) != 8 {
+				t.Errorf("readZipFile(%s) = %d bytes, %v, want 8 bytes", file.Name, len(content), err)
+			}
+		case "large.txt":
+			if !errors.Is(err, errZipFileTooLarge) {
+				t.Errorf("readZipFile(%s) error = %v, want %v", file.Name, err, errZipFileTooLarge)
+			}
+		}
+	}
+}
@@ -1,25 +1,55 @@
 package handlers
 
 import (
+	"errors"
+	"math"
+	"strconv"
 	"time"
 
+	"github.com/cvzamannow/E-Learning-API/helper"
 	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
 	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/cvzamannow/E-Learning-API/repository"
 	"github.com/golang-jwt/jwt/v5"
 	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+const (
+	// Access tokens are short lived, clients get a new one from /refresh with their refresh token.
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
 func (h *Handlers) RouteAuth(app *fiber.App) {
+	app.Get("/.well-known/jwks.json", h.JWKSHandler)
+
 	v1 := app.Group("/api/v1")
 	v1.Post("/login", h.LoginHandler)
-	v1.Get("/verify", h.Middleware.Protected(), h.Verify)
+	v1.Post("/refresh", h.RefreshHandler)
+	v1.Post("/logout", h.Middleware.Protected(), h.tenant((*Handlers).LogoutHandler))
+	v1.Get("/verify", h.Middleware.Protected(), h.tenant((*Handlers).Verify))
 	v1.Post("/register", h.RegisterHandler)
+	v1.Post("/admin/unlock-login", h.Middleware.Protected(), h.tenant((*Handlers).UnlockLoginHandler))
+	v1.Put("/me/password", h.Middleware.Protected(), h.tenant((*Handlers).ChangePasswordHandler))
+	v1.Post("/admin/users/:id/reset-password", h.Middleware.Protected(), h.tenant((*Handlers).ResetPasswordHandler))
 }
 
+// RegisterHandler is disabled unless OPEN_REGISTRATION=true, users are onboarded with
+// invitations instead. Even then it can only register students and teachers.
 func (h *Handlers) RegisterHandler(c *fiber.Ctx) error {
+	if !h.OpenRegistration {
+		return c.Status(403).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Registration is disabled, please ask the admin of your school for an invitation!",
+			Data:    nil,
+		})
+	}
+
 	var request http.Register
 
 	err := c.BodyParser(&request)
@@ -32,11 +62,33 @@ func (h *Handlers) RegisterHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	// Admin hanya bisa dibuat lewat undangan atau oleh SUPER_ADMIN
+	if request.Role != model.STUDENT && request.Role != model.TEACHER {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "role must be STUDENT or TEACHER",
+			Data:    nil,
+		})
+	}
+
+	if err := helper.ValidatePassword(request.Password, request.Username); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
 	id, _ := gonanoid.New(20)
 
 	// Hash Password
 	hash, _ := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 
+	// User tanpa status dianggap aktif, hanya user ACTIVE yang bisa login
+	if request.Status == "" {
+		request.Status = model.USER_ACTIVE
+	}
+
 	res, err := h.UserRepository.CreateUser(model.User{
 		ID:       id,
 		Username: request.Username,
@@ -91,11 +143,32 @@ func (h *Handlers) LoginHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	ip := c.IP()
+	wait, err := h.Middleware.LoginGuard.Check(requestLogin.Username, ip)
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("login attempt", "check"),
+			Data:    nil,
+		})
+	}
+
+	if wait > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		return c.Status(429).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Too many failed login attempts, please try again later!",
+			Data:    nil,
+		})
+	}
+
 	response, err := h.UserRepository.FindUser(map[string]interface{}{
 		"username": requestLogin.Username,
 	})
 
 	if err != nil {
+		h.Middleware.LoginGuard.Fail(requestLogin.Username, ip, "user_not_found")
 		return c.JSON(&http.WebResponse{
 			Status:  "error",
 			Message: "User is not registered!",
@@ -103,12 +176,203 @@ func (h *Handlers) LoginHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	err = bcrypt.CompareHashAndPassword([]byte(response.Password), []byte(requestLogin.Password))
+
+	if err != nil {
+		h.Middleware.LoginGuard.Fail(requestLogin.Username, ip, "wrong_password")
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Password is incorrect!",
+			Data:    nil,
+		})
+	}
+
+	if response.Status != model.USER_ACTIVE {
+		return c.Status(401).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "User is not active!",
+			Data:    nil,
+		})
+	}
+
+	// User dengan 2FA harus mengirim kode TOTP ke /login/2fa sebelum sesi dibuat
+	challenge, err := h.twoFactorChallenge(response)
+
+	if err != nil {
+		return h.authErrorResponse(c, err)
+	}
+
+	if challenge != nil {
+		return c.JSON(&http.WebResponse{
+			Status:  "success",
+			Message: "Two-factor authentication code is required",
+			Data:    challenge,
+		})
+	}
+
+	tokens, err := h.createSession(c, response)
+
+	if err != nil {
+		return h.authErrorResponse(c, err)
+	}
+
+	h.Middleware.LoginGuard.Succeed(requestLogin.Username)
+
+	return c.JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "Credentials is valid and Login successfuly!",
+		Data:    tokens,
+	})
+
+}
+
+// JWKSHandler publishes the public keys verifying the access tokens, so other services can
+// verify them without sharing a secret.
+func (h *Handlers) JWKSHandler(c *fiber.Ctx) error {
+	// Verifier boleh menyimpan key set sebentar, kunci baru sudah dipublikasi sebelum dipakai
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.JSON(h.Middleware.Keys.JWKS())
+}
+
+// RefreshHandler exchanges a refresh token for a new access token and a new refresh token.
+// The old refresh token can not be used again, reusing it revokes the session.
+func (h *Handlers) RefreshHandler(c *fiber.Ctx) error {
+	var request http.RefreshToken
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	if request.RefreshToken == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "refresh_token is required",
+			Data:    nil,
+		})
+	}
+
+	refreshToken, refreshTokenID, err := h.newRefreshToken()
+
+	if err != nil {
+		return h.authErrorResponse(c, err)
+	}
+
+	session, err := h.UserRepository.RotateRefreshToken(helper.HashRefreshToken(request.RefreshToken), model.RefreshToken{
+		ID:        refreshTokenID,
+		TokenHash: helper.HashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+
+	switch {
+	case errors.Is(err, repository.ErrRefreshTokenReused):
+		return c.Status(401).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Refresh token has already been used, please login again!",
+			Data:    nil,
+		})
+	case errors.Is(err, repository.ErrRefreshTokenInvalid):
+		return c.Status(401).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Refresh token is invalid or expired!",
+			Data:    nil,
+		})
+	case err != nil:
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("session", "refresh"),
+			Data:    nil,
+		})
+	}
+
+	claims, err := h.accessTokenClaims(&session.User, session.ID)
+
+	if err != nil {
+		return h.authErrorResponse(c, err)
+	}
+
+	token, err := h.Middleware.Keys.Sign(claims)
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generate jwt token",
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Token", "refreshed"),
+		Data:    tokenResponse(token, refreshToken, &session.User, claims),
+	})
+}
+
+// LogoutHandler revokes the session of the access token, its refresh token stops working too.
+func (h *Handlers) LogoutHandler(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+
+	err := h.UserRepository.RevokeUserSession(map[string]interface{}{
+		"id":      principal.SessionID,
+		"user_id": principal.UserID,
+	}, model.SESSION_LOGOUT)
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("session", "revoke"),
+			Data:    nil,
+		})
+	}
+
+	h.Middleware.SessionCache.InvalidateSession(principal.SessionID)
+
+	return c.JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Session", "revoked"),
+		Data:    nil,
+	})
+}
+
+// accessTokenClaims builds the claims of an access token of the session.
+// Students must be an active student and also get the name of their school.
+// The school_id claim limits the repositories to the school of the user, see Handlers.tenant.
+// The teacher, student and active student IDs become the middleware.Principal of the requests.
+func (h *Handlers) accessTokenClaims(user *model.User, sessionID string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{
+		"username": user.Username,
+		"user_id":  user.ID,
+		"role":     user.Role,
+		"sid":      sessionID,
+		"exp":      time.Now().Add(accessTokenTTL).Unix(),
+	}
+
+	// Token user yang wajib mengganti password hanya bisa dipakai untuk mengganti password
+	if user.MustChangePassword {
+		claims["must_change_password"] = true
+	}
+
+	// Role yang wajib 2FA hanya bisa mendaftarkan TOTP sebelum memakai API lainnya
+	if h.TwoFactorRoles[user.Role] {
+		totp, err := h.UserRepository.FindUserTOTP(user.ID)
+		if err != nil {
+			return nil, fiber.NewError(500, h.errorInternal("two-factor", "check"))
+		}
+
+		if totp == nil || totp.ConfirmedAt == nil {
+			claims["must_enroll_two_factor"] = true
+		}
+	}
+
 	// search shcool_name
 	resultstudent, _ := h.UserRepository.FindStudent(map[string]interface{}{
-		"user_id": response.ID,
+		"user_id": user.ID,
 	})
 
-	var claims *jwt.Token
 	if resultstudent != nil {
 		// mengecek apakah student id nya sudah terdaftar di active student atau belum
 		activeStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
@@ -116,19 +380,11 @@ func (h *Handlers) LoginHandler(c *fiber.Ctx) error {
 		})
 
 		if err != nil {
-			return c.Status(404).JSON(&http.WebResponse{
-				Status:  "error",
-				Message: "Active student not found",
-				Data:    nil,
-			})
+			return nil, fiber.NewError(404, "Active student not found")
 		}
 
 		if activeStudent == nil {
-			return c.Status(400).JSON(&http.WebResponse{
-				Status:  "error",
-				Message: "Active student not found",
-				Data:    nil,
-			})
+			return nil, fiber.NewError(400, "Active student not found")
 		}
 
 		// mencari sekolah
@@ -137,46 +393,245 @@ func (h *Handlers) LoginHandler(c *fiber.Ctx) error {
 		})
 
 		if err != nil {
+			return nil, fiber.NewError(404, "School is not found")
+		}
+
+		claims["name"] = resultstudent.Name
+		claims["school_name"] = school.Name
+		claims["school_id"] = resultstudent.SchoolsID
+		claims["student_id"] = resultstudent.ID
+		claims["active_student_id"] = activeStudent.ID
+		return claims, nil
+	}
+
+	if user.Role == model.STUDENT {
+		return nil, fiber.NewError(404, "Student not found")
+	}
+
+	// Find Teacher Name
+	teacher := ""
+
+	teacherQuery, _ := h.UserRepository.FindTeacher(map[string]interface{}{
+		"user_id": user.ID,
+	})
+
+	if teacherQuery != nil {
+		teacher = teacherQuery.Name
+		claims["teacher_id"] = teacherQuery.ID
+
+		if teacherQuery.SchoolsID != nil {
+			claims["school_id"] = *teacherQuery.SchoolsID
+		}
+	}
+
+	if user.Role == model.TEACHER && teacherQuery == nil {
+		return nil, fiber.NewError(404, "Teacher not found")
+	}
+
+	adminQuery, _ := h.UserRepository.FindAdminSchool(map[string]interface{}{
+		"user_id": user.ID,
+	})
+
+	if adminQuery != nil {
+		claims["school_id"] = adminQuery.SchoolID
+	}
+
+	claims["name"] = teacher
+	return claims, nil
+}
+
+// newRefreshToken generates a refresh token and the id of its record.
+func (h *Handlers) newRefreshToken() (string, string, error) {
+	token, err := helper.GenerateRefreshToken()
+	if err != nil {
+		return "", "", fiber.NewError(500, h.errorInternal("session", "generate refresh token"))
+	}
+
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return "", "", fiber.NewError(500, h.errorInternal("session", "generate id"))
+	}
+
+	return token, id, nil
+}
+
+// authErrorResponse writes an error of accessTokenClaims, newRefreshToken or createSession.
+func (h *Handlers) authErrorResponse(c *fiber.Ctx, err error) error {
+	code := 500
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		code = fiberErr.Code
+	}
+
+	return c.Status(code).JSON(&http.WebResponse{
+		Status:  "error",
+		Message: err.Error(),
+		Data:    nil,
+	})
+}
+
+// createSession starts a new session of the user and returns its tokens, see tokenResponse.
+func (h *Handlers) createSession(c *fiber.Ctx, user *model.User) (map[string]interface{}, error) {
+	sessionID, err := helper.GenerateNanoId()
+	if err != nil {
+		return nil, fiber.NewError(500, h.errorInternal("session", "generate id"))
+	}
+
+	claims, err := h.accessTokenClaims(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshTokenID, err := h.newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = h.UserRepository.CreateUserSession(model.UserSession{
+		ID:         sessionID,
+		UserID:     user.ID,
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+		IPAddress:  c.IP(),
+		LastUsedAt: time.Now(),
+	}, model.RefreshToken{
+		ID:        refreshTokenID,
+		TokenHash: helper.HashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, fiber.NewError(500, h.errorInternal("session", "create"))
+	}
+
+	// create jwt token from the request username
+	token, err := h.Middleware.Keys.Sign(claims)
+	if err != nil {
+		return nil, fiber.NewError(500, "Error generate jwt token")
+	}
+
+	return tokenResponse(token, refreshToken, user, claims), nil
+}
+
+func tokenResponse(token, refreshToken string, user *model.User, claims jwt.MapClaims) map[string]interface{} {
+	return map[string]interface{}{
+		"token":                  token,
+		"refresh_token":          refreshToken,
+		"expires_in":             int(accessTokenTTL.Seconds()),
+		"role":                   user.Role,
+		"must_change_password":   user.MustChangePassword,
+		"must_enroll_two_factor": claims["must_enroll_two_factor"] == true,
+	}
+}
+
+func (h *Handlers) Verify(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "User verified",
+		Data: http.VerifyResponseHTTP{
+			UserID:          principal.UserID,
+			Username:        principal.Username,
+			Name:            principal.Name,
+			Role:            principal.Role,
+			SchoolID:        principal.SchoolID,
+			TeacherID:       principal.TeacherID,
+			StudentID:       principal.StudentID,
+			ActiveStudentID: principal.ActiveStudentID,
+		},
+	})
+}
+
+// UnlockLoginHandler clears the failed logins and the lockout of a username or an IP address.
+// ADMIN can only unlock the users of its school, IP addresses are shared by every school so
+// only SUPER_ADMIN can unlock them.
+func (h *Handlers) UnlockLoginHandler(c *fiber.Ctx) error {
+	var request http.UnlockLogin
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	if request.Username == "" && request.IPAddress == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "username or ip_address is required",
+			Data:    nil,
+		})
+	}
+
+	if request.IPAddress != "" && !middleware.PrincipalFrom(c).IsSuperAdmin() {
+		return c.Status(401).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Only SUPER_ADMIN can unlock an IP address",
+			Data:    nil,
+		})
+	}
+
+	if request.Username != "" {
+		if _, err := h.UserRepository.FindUser(map[string]interface{}{
+			"username": request.Username,
+		}); err != nil {
 			return c.Status(404).JSON(&http.WebResponse{
 				Status:  "error",
-				Message: "School is not found",
+				Message: "User is not registered!",
 				Data:    nil,
 			})
 		}
+	}
 
-		claims = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"username":    response.Username,
-			"name": resultstudent.Name,
-			"user_id":     response.ID,
-			"role":        response.Role,
-			"school_name": school.Name,
-			"exp":         time.Now().Add(time.Hour * 24).Unix(),
+	if err := h.Middleware.LoginGuard.Unlock(request.Username, request.IPAddress); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("login attempt", "unlock"),
+			Data:    nil,
 		})
+	}
 
-	} else {
-		// Find Teacher Name 
-		teacher := ""
+	logrus.WithFields(logrus.Fields{
+		"event":       "login_unlocked",
+		"username":    request.Username,
+		"ip":          request.IPAddress,
+		"unlocked_by": middleware.PrincipalFrom(c).UserID,
+	}).Infoln("[auth] Login unlocked")
 
-		teacherQuery, _ := h.UserRepository.FindTeacher(map[string]interface{}{
-			"user_id": response.ID,
-		})
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("login", "unlocked"),
+		Data:    request,
+	})
+}
 
-		if teacherQuery != nil {
-			teacher = teacherQuery.Name
-		}
+// ChangePasswordHandler changes the password of the logged in user. Every session of the user
+// is revoked, the response carries the tokens of a new session.
+func (h *Handlers) ChangePasswordHandler(c *fiber.Ctx) error {
+	var request http.ChangePassword
 
-		claims = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"username": response.Username,
-			"name": teacher,
-			"user_id":  response.ID,
-			"role":     response.Role,
-			"exp":      time.Now().Add(time.Hour * 24).Unix(),
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
 		})
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(response.Password), []byte(requestLogin.Password))
+	user, err := h.UserRepository.FindUser(map[string]interface{}{
+		"id": middleware.PrincipalFrom(c).UserID,
+	})
 
 	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "User is not registered!",
+			Data:    nil,
+		})
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.CurrentPassword)); err != nil {
 		return c.Status(400).JSON(&http.WebResponse{
 			Status:  "error",
 			Message: "Password is incorrect!",
@@ -184,45 +639,128 @@ func (h *Handlers) LoginHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// create jwt token from the request username
+	if request.NewPassword == request.CurrentPassword {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "New password must be different from the old password",
+			Data:    nil,
+		})
+	}
+
+	if err := helper.ValidatePassword(request.NewPassword, user.Username); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
 
-	token, err := claims.SignedString(h.JWT_SECRET)
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), bcrypt.DefaultCost)
 
 	if err != nil {
-		return c.JSON(&http.WebResponse{
+		return c.Status(500).JSON(&http.WebResponse{
 			Status:  "error",
-			Message: "Error generate jwt token",
+			Message: h.errorInternal("password", "generate password hash"),
 			Data:    nil,
 		})
 	}
 
-	return c.JSON(&http.WebResponse{
+	user, err = h.UserRepository.ChangePassword(user.ID, string(hash), false)
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("password", "change"),
+			Data:    nil,
+		})
+	}
+
+	h.Middleware.SessionCache.InvalidateUser(user.ID)
+
+	tokens, err := h.createSession(c, user)
+
+	if err != nil {
+		return h.authErrorResponse(c, err)
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
 		Status:  "success",
-		Message: "Credentials is valid and Login successfuly!",
-		Data: map[string]interface{}{
-			"token": token,
-			"role":  response.Role,
-		},
+		Message: h.successResponse("password", "changed"),
+		Data:    tokens,
 	})
-
 }
 
-func (h *Handlers) Verify(c *fiber.Ctx) error {
-	user := c.Locals("user").(*jwt.Token)
-	claims := user.Claims.(jwt.MapClaims)
+// ResetPasswordHandler gives a user a temporary password, the user has to change it at the
+// next login. ADMIN can only reset the passwords of the students and teachers of its school.
+func (h *Handlers) ResetPasswordHandler(c *fiber.Ctx) error {
+	user, err := h.UserRepository.FindUser(map[string]interface{}{
+		"id": c.Params("id"),
+	})
 
-	if claims == nil {
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	if !middleware.PrincipalFrom(c).IsSuperAdmin() && user.Role != model.STUDENT && user.Role != model.TEACHER {
 		return c.Status(401).JSON(&http.WebResponse{
 			Status:  "error",
-			Message: "Unauthorized",
+			Message: "ADMIN can only reset the password of students and teachers",
+			Data:    nil,
+		})
+	}
+
+	temporaryPassword, err := helper.GenerateTemporaryPassword()
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("password", "generate temporary password"),
 			Data:    nil,
 		})
 	}
 
+	hash, err := bcrypt.GenerateFromPassword([]byte(temporaryPassword), bcrypt.DefaultCost)
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("password", "generate password hash"),
+			Data:    nil,
+		})
+	}
+
+	if _, err := h.UserRepository.ChangePassword(user.ID, string(hash), true); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("password", "reset"),
+			Data:    nil,
+		})
+	}
+
+	h.Middleware.SessionCache.InvalidateUser(user.ID)
+
+	// Password baru juga membuka login yang terkunci
+	if err := h.Middleware.LoginGuard.Unlock(user.Username, ""); err != nil {
+		logrus.Warnln("[auth] Couldn't unlock login after password reset because error:", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"event":    "password_reset",
+		"user_id":  user.ID,
+		"reset_by": middleware.PrincipalFrom(c).UserID,
+	}).Infoln("[auth] Password reset")
+
 	return c.Status(200).JSON(&http.WebResponse{
 		Status:  "success",
-		Message: "User verified",
-		Data:    claims,
+		Message: h.successResponse("password", "reset"),
+		Data: http.ResetPasswordHTTP{
+			UserID:            user.ID,
+			Username:          user.Username,
+			TemporaryPassword: temporaryPassword,
+		},
 	})
-
 }
@@ -6,7 +6,9 @@ import (
 
 	"github.com/cvzamannow/E-Learning-API/helper"
 	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
 	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/cvzamannow/E-Learning-API/repository"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gosimple/slug"
 	gonanoid "github.com/matoous/go-nanoid/v2"
@@ -29,52 +31,69 @@ import (
 func (h *Handlers) RouteCourses(app *fiber.App) {
 	v1 := app.Group("/api/v1")
 	// Course Routes
-	v1.Post("/courses", h.Middleware.Protected(), h.AddCourse)
-	v1.Get("/courses", h.Middleware.Protected(), h.GetCourses)
-	v1.Get("/courses/detail/:id", h.Middleware.Protected(), h.GetDetailCourseByID)
-	v1.Put("/courses/:id", h.Middleware.Protected(), h.EditCourse)
-	v1.Delete("/courses/:id", h.Middleware.Protected(), h.DeleteCourse)
+	v1.Post("/courses", h.Middleware.Protected(), h.tenant((*Handlers).AddCourse))
+	v1.Get("/courses", h.Middleware.Protected(), h.tenant((*Handlers).GetCourses))
+	v1.Get("/courses/detail/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetDetailCourseByID))
+	v1.Put("/courses/:id", h.Middleware.Protected(), h.tenant((*Handlers).EditCourse))
+	v1.Delete("/courses/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteCourse))
+
+	// Course Teachers
+	v1.Get("/courses/:id/teachers", h.Middleware.Protected(), h.tenant((*Handlers).GetCourseTeachers))
+	v1.Post("/courses/:id/teachers", h.Middleware.Protected(), h.tenant((*Handlers).AddCourseTeacher))
+	v1.Delete("/courses/:id/teachers/:teacher_id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteCourseTeacher))
+
+	// Course Order Routes
+	v1.Put("/courses/:id/order", h.Middleware.Protected(), h.tenant((*Handlers).ReorderCourse))
+	v1.Get("/materials/:id/prerequisites", h.Middleware.Protected(), h.tenant((*Handlers).GetMaterialPrerequisites))
+	v1.Put("/materials/:id/prerequisites", h.Middleware.Protected(), h.tenant((*Handlers).SaveMaterialPrerequisites))
+
+	// Course Version Routes
+	v1.Get("/courses/:id/versions", h.Middleware.Protected(), h.tenant((*Handlers).GetCourseVersions))
+	v1.Post("/courses/:id/publish", h.Middleware.Protected(), h.tenant((*Handlers).PublishCourse))
+	v1.Get("/courses/:id/versions/diff", h.Middleware.Protected(), h.tenant((*Handlers).DiffCourseVersions))
+	v1.Post("/courses/:id/versions/:version/rollback", h.Middleware.Protected(), h.tenant((*Handlers).RollbackCourse))
+	v1.Post("/courses/:id/versions/:version/migrate", h.Middleware.Protected(), h.tenant((*Handlers).MigrateCourseEnrollments))
 
 	// Chapter Routes
-	v1.Post("/chapters", h.Middleware.Protected(), h.CreateChapter)
-	v1.Get("/chapters/:id", h.FindChapter)
-	v1.Put("/chapters/:id", h.Middleware.Protected(), h.UpdateChapter)
-	v1.Delete("/chapters/:id", h.Middleware.Protected(), h.DeleteChapter)
+	v1.Post("/chapters", h.Middleware.Protected(), h.tenant((*Handlers).CreateChapter))
+	v1.Get("/chapters/:id", h.Middleware.Protected(), h.tenant((*Handlers).FindChapter))
+	v1.Put("/chapters/:id", h.Middleware.Protected(), h.tenant((*Handlers).UpdateChapter))
+	v1.Delete("/chapters/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteChapter))
 
 	// Theories
-	v1.Post("/theories", h.Middleware.Protected(), h.CreateTheory)
-	v1.Get("/theories/:id", h.Middleware.Protected(), h.FindTheory)
-	v1.Delete("/theories/:id", h.Middleware.Protected(), h.DeleteTheory)
-	v1.Put("/theories/:id", h.Middleware.Protected(), h.EditTheory)
+	v1.Post("/theories", h.Middleware.Protected(), h.tenant((*Handlers).CreateTheory))
+	v1.Get("/theories/:id", h.Middleware.Protected(), h.tenant((*Handlers).FindTheory))
+	v1.Delete("/theories/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteTheory))
+	v1.Put("/theories/:id", h.Middleware.Protected(), h.tenant((*Handlers).EditTheory))
 
 	// Submission
-	v1.Post("/submission", h.Middleware.Protected(), h.CreateSubmission)
-	v1.Get("/submission/:id", h.Middleware.Protected(), h.FindSubmission)
-	v1.Put("/submission/:id", h.Middleware.Protected(), h.EditSubmission)
-	v1.Delete("/submission/:id", h.Middleware.Protected(), h.DeleteSubmission)
-	v1.Get("/submission/detail/:id", h.Middleware.Protected(), h.GetDetailSubmissionTeacher)
+	v1.Post("/submission", h.Middleware.Protected(), h.tenant((*Handlers).CreateSubmission))
+	v1.Get("/submission/:id", h.Middleware.Protected(), h.tenant((*Handlers).FindSubmission))
+	v1.Put("/submission/:id", h.Middleware.Protected(), h.tenant((*Handlers).EditSubmission))
+	v1.Delete("/submission/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteSubmission))
+	v1.Get("/submission/detail/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetDetailSubmissionTeacher))
 
 	// Submission Student
-	v1.Post("/submission-student", h.Middleware.Protected(), h.CreateSubmissionStudent)
-	v1.Get("/submission-student/:student_id", h.Middleware.Protected(), h.FindStudentSubmission)
-	v1.Get("/submission-student/detail/:id", h.Middleware.Protected(), h.GetDetailSubmission)
-	v1.Get("/submission-student", h.Middleware.Protected(), h.ListingSubmission)
-	v1.Put("/reset-submission", h.Middleware.Protected(), h.ResetSubmission)
-	v1.Get("/placeholder/submission-student", h.Middleware.Protected(), h.GetSubmissionPlaceholder)
+	v1.Post("/submission-student", h.Middleware.Protected(), h.tenant((*Handlers).CreateSubmissionStudent))
+	v1.Get("/submission-student/:student_id", h.Middleware.Protected(), h.tenant((*Handlers).FindStudentSubmission))
+	v1.Get("/submission-student/detail/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetDetailSubmission))
+	v1.Get("/submission-student", h.Middleware.Protected(), h.tenant((*Handlers).ListingSubmission))
+	v1.Put("/reset-submission", h.Middleware.Protected(), h.tenant((*Handlers).ResetSubmission))
+	v1.Get("/placeholder/submission-student", h.Middleware.Protected(), h.tenant((*Handlers).GetSubmissionPlaceholder))
 
 	// Submission Student Approve and Reject
-	v1.Put("/submission-student/approve/:student_id", h.Middleware.Protected(), h.Approvesubmisson)
-	v1.Put("/submission-student/reject/:student_id", h.Middleware.Protected(), h.Rejectsubmisson)
+	v1.Put("/submission-student/approve/:student_id", h.Middleware.Protected(), h.tenant((*Handlers).Approvesubmisson))
+	v1.Put("/submission-student/reject/:student_id", h.Middleware.Protected(), h.tenant((*Handlers).Rejectsubmisson))
 
 	// Mencari kelas student yang login entah itu sudah kelas 11 atau kelas 10
-	v1.Get("/class/student", h.Middleware.Protected(), h.FindClassStudent)
+	v1.Get("/class/student", h.Middleware.Protected(), h.tenant((*Handlers).FindClassStudent))
 
 	// Update Progress
-	v1.Put("/progress", h.Middleware.Protected(), h.UpdateProgress)
-	// v1.Get("/enroll-courses/:slug", h.Middleware.Protected(), h.GetEnrollCourse)
+	v1.Put("/progress", h.Middleware.Protected(), h.tenant((*Handlers).UpdateProgress))
+	// v1.Get("/enroll-courses/:slug", h.Middleware.Protected(), h.tenant((*Handlers).GetEnrollCourse))
 
 	// Complete Course
-	v1.Put("/complete-course", h.Middleware.Protected(), h.SaveCompleteCourse)
+	v1.Put("/complete-course", h.Middleware.Protected(), h.tenant((*Handlers).SaveCompleteCourse))
 }
 
 /*
@@ -87,17 +106,7 @@ func (h *Handlers) AddCourse(c *fiber.Ctx) error {
 	var request http.CourseHTTP
 	c.BodyParser(&request)
 
-	userID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
-
-	if role != "TEACHER" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "Couldn't access resource because unauthorized request!",
-			Data:    nil,
-		})
-	}
-
+	principal := middleware.PrincipalFrom(c)
 	id, _ := gonanoid.New(20)
 
 	var class []model.CourseClass
@@ -121,19 +130,6 @@ func (h *Handlers) AddCourse(c *fiber.Ctx) error {
 		request.ThumbnailImg = "https://pub-c883652fcf2a4a4f9b0d7321a986a773.r2.dev/Thumbnail.png"
 	}
 
-	// Find Teacher to return the response.
-	findTeacher, err := h.UserRepository.FindTeacher(map[string]interface{}{
-		"user_id": userID,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "fail",
-			Message: "Couldn't create course because internal error",
-			Data:    nil,
-		})
-	}
-
 	res, err := h.CourseRepository.CreateCourse(model.Course{
 		ID:               id,
 		Title:            request.Title,
@@ -144,7 +140,7 @@ func (h *Handlers) AddCourse(c *fiber.Ctx) error {
 		IsDraft:          isDraft,
 		EstimationMinute: request.EstimationMinute,
 		Slug:             slug.Make(request.Title),
-		TeacherID:        findTeacher.ID,
+		TeacherID:        principal.TeacherID,
 		CourseClasses:    class,
 	})
 
@@ -173,7 +169,7 @@ func (h *Handlers) AddCourse(c *fiber.Ctx) error {
 		Detail:           res.Detail,
 		Slug:             res.Slug,
 		Classes:          classResp,
-		Teacher:          &findTeacher.Name,
+		Teacher:          &principal.Name,
 	}
 
 	return c.Status(201).JSON(&http.WebResponse{
@@ -209,26 +205,10 @@ func (h *Handlers) GetCourses(c *fiber.Ctx) error {
 		isComplete = false
 	}
 
-	if c.Locals("role").(string) == "STUDENT" {
-		// Find Active StudentID
-		student, err := h.UserRepository.FindStudent(map[string]interface{}{
-			"user_id": c.Locals("user_id"),
-		})
-
-		// If not found then Default Value is not changing
-		if err != nil {
-			activeStudent = ""
-		}
+	principal := middleware.PrincipalFrom(c)
 
-		activeStudentData, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
-			"student_id": student.ID,
-		})
-
-		if err != nil {
-			activeStudent = ""
-		}
-
-		activeStudent = activeStudentData.ID
+	if principal.Role == model.STUDENT {
+		activeStudent = principal.ActiveStudentID
 
 		queryIsActive := c.Query("is_active")
 
@@ -346,18 +326,6 @@ func (h *Handlers) GetCourses(c *fiber.Ctx) error {
 		})
 	}
 
-	findTeacher, err := h.UserRepository.FindTeacher(map[string]interface{}{
-		"user_id": c.Locals("user_id").(string),
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("course", "retrieve"),
-			Data:    []interface{}{},
-		})
-	}
-
 	pagination, data := h.CourseRepository.FindCourses(
 		page,
 		limit,
@@ -366,7 +334,7 @@ func (h *Handlers) GetCourses(c *fiber.Ctx) error {
 		"",
 		false,
 		"",
-		findTeacher.ID,
+		principal.TeacherID,
 		false,
 	)
 
@@ -467,9 +435,9 @@ func (h *Handlers) GetDetailCourseByID(c *fiber.Ctx) error {
 		})
 	}
 
-	role := c.Locals("role").(string)
+	principal := middleware.PrincipalFrom(c)
 
-	if role == "TEACHER" {
+	if principal.Role == model.TEACHER {
 		res, err := h.CourseRepository.FindCourse(map[string]interface{}{
 			"id": id,
 		}, false, "")
@@ -554,6 +522,7 @@ func (h *Handlers) GetDetailCourseByID(c *fiber.Ctx) error {
 						ChapterID: el.ChapterID,
 						Title:     el.Title,
 						Slug:      el.Slug,
+						Position:  el.Position,
 						Type:      el.Type,
 						CreatedAt: el.CreatedAt,
 						UpdatedAt: el.UpdatedAt,
@@ -566,6 +535,7 @@ func (h *Handlers) GetDetailCourseByID(c *fiber.Ctx) error {
 				ID:        chap.ID,
 				Title:     chap.Title,
 				Slug:      chap.Slug,
+				Position:  chap.Position,
 				CourseID:  chap.CourseID,
 				Materials: materials,
 				CreatedAt: res.CreatedAt,
@@ -609,19 +579,9 @@ func (h *Handlers) GetDetailCourseByID(c *fiber.Ctx) error {
 
 	}
 
-	userID := c.Locals("user_id")
-
-	student, err := h.UserRepository.FindStudent(map[string]interface{}{
-		"user_id": userID,
-	})
-
-	activeStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
-		"student_id": student.ID,
-	})
-
 	res, err := h.CourseRepository.FindCourse(map[string]interface{}{
 		"id": id,
-	}, true, activeStudent.ID)
+	}, true, principal.ActiveStudentID)
 
 	if err != nil {
 		return c.Status(404).JSON(&http.WebResponse{
@@ -703,7 +663,8 @@ func (h *Handlers) GetDetailCourseByID(c *fiber.Ctx) error {
 				// FALSE = not complete
 				var statusProgress bool
 
-				defaultLock := true
+				// Status lock sudah dihitung dari urutan dan prerequisite di FindCourse
+				isLock := el.IsLocked
 
 				if len(el.Progress) > 0 {
 					statusProgress = true
@@ -716,8 +677,9 @@ func (h *Handlers) GetDetailCourseByID(c *fiber.Ctx) error {
 					ChapterID:  el.ChapterID,
 					Title:      el.Title,
 					Slug:       el.Slug,
+					Position:   el.Position,
 					IsComplete: &statusProgress,
-					IsLock:     &defaultLock,
+					IsLock:     &isLock,
 					Type:       el.Type,
 					CreatedAt:  el.CreatedAt,
 					UpdatedAt:  el.UpdatedAt,
@@ -730,6 +692,7 @@ func (h *Handlers) GetDetailCourseByID(c *fiber.Ctx) error {
 			ID:        chap.ID,
 			Title:     chap.Title,
 			Slug:      chap.Slug,
+			Position:  chap.Position,
 			CourseID:  chap.CourseID,
 			Materials: materials,
 			CreatedAt: res.CreatedAt,
@@ -749,46 +712,6 @@ func (h *Handlers) GetDetailCourseByID(c *fiber.Ctx) error {
 		totalChapter += len(res.Chapters)
 	}
 
-	for indexChap := 0; indexChap < len(chaptersResponse); indexChap++ {
-		for indexMaterial := 0; indexMaterial < len(chaptersResponse[indexChap].Materials); indexMaterial++ {
-			next := indexMaterial + 1
-			current := indexMaterial
-
-			if *chaptersResponse[indexChap].Materials[current].IsComplete {
-				isLock := false
-				chaptersResponse[indexChap].Materials[current].IsLock = &isLock
-			}
-
-			if next < len(chaptersResponse[indexChap].Materials) {
-				if !*chaptersResponse[indexChap].Materials[next].IsComplete && *chaptersResponse[indexChap].Materials[current].IsComplete {
-					isLock := false
-					chaptersResponse[indexChap].Materials[next].IsLock = &isLock
-				} else {
-					isLock := true
-					chaptersResponse[indexChap].Materials[next].IsLock = &isLock
-				}
-			}
-		}
-
-		currentIndexChapter := indexChap
-		nextChapterIndex := indexChap + 1
-
-		if nextChapterIndex < len(chaptersResponse) {
-			m := chaptersResponse[currentIndexChapter].Materials
-			lastMaterialIndex := len(m) - 1
-			lastMaterial := chaptersResponse[currentIndexChapter].Materials[lastMaterialIndex]
-
-			if *lastMaterial.IsComplete {
-				nextMaterials := chaptersResponse[nextChapterIndex].Materials
-				if len(nextMaterials) > 0 {
-					isLock := false
-					nextMaterials[0].IsLock = &isLock
-				}
-			}
-		}
-
-	}
-
 	var complete *bool
 
 	if len(res.CompleteCourses) > 0 {
@@ -832,6 +755,10 @@ func (h *Handlers) EditCourse(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, idParams); err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
 	var request http.CourseHTTP
 	err := c.BodyParser(&request)
 
@@ -937,6 +864,11 @@ func (h *Handlers) DeleteCourse(c *fiber.Ctx) error {
 		})
 	}
 
+	// Hanya pemilik course yang boleh menghapus course
+	if _, err := h.authorizeCourseOwner(c, idParams); err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
 	// find chapter by id course
 	findChapter, err := h.CourseRepository.FindChapter(map[string]interface{}{
 		"course_id": idParams,
@@ -1297,6 +1229,10 @@ func (h *Handlers) CreateChapter(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, request.CourseID); err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
 	id, _ := gonanoid.New(20)
 	res, err := h.CourseRepository.CreateChapter(model.Chapter{
 		ID:       id,
@@ -1321,6 +1257,7 @@ func (h *Handlers) CreateChapter(c *fiber.Ctx) error {
 			Title:     res.Title,
 			CourseID:  res.CourseID,
 			Slug:      res.Slug,
+			Position:  res.Position,
 			CreatedAt: res.CreatedAt,
 			UpdatedAt: res.UpdatedAt,
 		},
@@ -1358,6 +1295,7 @@ func (h *Handlers) FindChapter(c *fiber.Ctx) error {
 			Title:     res.Title,
 			Slug:      res.Slug,
 			CourseID:  res.CourseID,
+			Position:  res.Position,
 			CreatedAt: res.CreatedAt,
 			UpdatedAt: res.UpdatedAt,
 		},
@@ -1386,6 +1324,10 @@ func (h *Handlers) UpdateChapter(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_CHAPTER, id); err != nil {
+		return h.courseAccessError(c, "chapter", err)
+	}
+
 	res, err := h.CourseRepository.UpdateChapter(id, model.Chapter{
 		Title: request.Title,
 		Slug:  slug.Make(request.Title),
@@ -1407,6 +1349,7 @@ func (h *Handlers) UpdateChapter(c *fiber.Ctx) error {
 			Title:     res.Title,
 			Slug:      res.Slug,
 			CourseID:  res.CourseID,
+			Position:  res.Position,
 			CreatedAt: res.CreatedAt,
 			UpdatedAt: res.UpdatedAt,
 		},
@@ -1424,6 +1367,10 @@ func (h *Handlers) DeleteChapter(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_CHAPTER, id); err != nil {
+		return h.courseAccessError(c, "chapter", err)
+	}
+
 	res, err := h.CourseRepository.DeleteChapter(map[string]interface{}{
 		"id": id,
 	})
@@ -1463,6 +1410,10 @@ func (h *Handlers) CreateTheory(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_CHAPTER, request.ChapterID); err != nil {
+		return h.courseAccessError(c, "chapter", err)
+	}
+
 	idMaterial, _ := gonanoid.New(20)
 
 	m, err := h.CourseRepository.CreateMaterial(model.Material{
@@ -1537,6 +1488,10 @@ func (h *Handlers) FindTheory(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := h.authorizeStudentMaterial(c, res.ID); err != nil {
+		return h.courseAccessError(c, "theory", err)
+	}
+
 	t, err := h.CourseRepository.FindTheory(map[string]interface{}{
 		"material_id": res.ID,
 	})
@@ -1607,6 +1562,10 @@ func (h *Handlers) FindTheory(c *fiber.Ctx) error {
 func (h *Handlers) DeleteMaterial(c *fiber.Ctx) error {
 	id := c.Query("id")
 
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_MATERIAL, id); err != nil {
+		return h.courseAccessError(c, "material", err)
+	}
+
 	res, err := h.CourseRepository.DeleteMaterial(map[string]interface{}{
 		"id": id,
 	})
@@ -1647,6 +1606,15 @@ func (h *Handlers) CreateSubmission(c *fiber.Ctx) error {
 		})
 	}
 
+	chapterID := ""
+	if request.ChapterID != nil {
+		chapterID = *request.ChapterID
+	}
+
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_CHAPTER, chapterID); err != nil {
+		return h.courseAccessError(c, "chapter", err)
+	}
+
 	idMaterial, err := gonanoid.New(20)
 
 	if err != nil {
@@ -1703,19 +1671,8 @@ func (h *Handlers) CreateSubmission(c *fiber.Ctx) error {
 
 func (h *Handlers) CreateSubmissionStudent(c *fiber.Ctx) error {
 
-	studentID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
-
-	if role != "STUDENT" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	findActiveStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
-		"student_id": studentID,
+		"id": middleware.PrincipalFrom(c).ActiveStudentID,
 	})
 
 	if err != nil {
@@ -1735,6 +1692,10 @@ func (h *Handlers) CreateSubmissionStudent(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := h.authorizeStudentMaterial(c, request.MaterialID); err != nil {
+		return h.courseAccessError(c, "submission", err)
+	}
+
 	id, err := helper.GenerateNanoId()
 
 	if err != nil {
@@ -1824,6 +1785,10 @@ func (h *Handlers) FindSubmission(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := h.authorizeStudentMaterial(c, m.ID); err != nil {
+		return h.courseAccessError(c, "submission", err)
+	}
+
 	res, err := h.CourseRepository.FindSubmission(map[string]interface{}{
 		"material_id": m.ID,
 	})
@@ -1873,23 +1838,14 @@ func (h *Handlers) FindSubmission(c *fiber.Ctx) error {
 		}
 	}
 
-	role := c.Locals("role").(string)
+	principal := middleware.PrincipalFrom(c)
 
 	var submitted *http.SubmittedSubmissionHTTP
 	var historySubmission []http.HistorySubmissionHTTP = nil
 
-	if role == "STUDENT" {
-		userID := c.Locals("user_id")
-		findStudent, _ := h.UserRepository.FindStudent(map[string]interface{}{
-			"user_id": userID,
-		})
-
-		activeStudent, _ := h.UserRepository.FindActiveStudent(map[string]interface{}{
-			"student_id": findStudent.ID,
-		})
-
+	if principal.Role == model.STUDENT {
 		submit, _ := h.CourseRepository.FindSubmissionStudent(map[string]interface{}{
-			"active_student_id": activeStudent.ID,
+			"active_student_id": principal.ActiveStudentID,
 			"material_id":       m.ID,
 		}, true)
 
@@ -1918,7 +1874,7 @@ func (h *Handlers) FindSubmission(c *fiber.Ctx) error {
 		}
 
 		history, _ := h.CourseRepository.FindSubmissionStudents(map[string]interface{}{
-			"active_student_id": activeStudent.ID,
+			"active_student_id": principal.ActiveStudentID,
 			"material_id":       m.ID,
 			"status":            "REJECTED",
 		})
@@ -1982,6 +1938,10 @@ func (h *Handlers) EditSubmission(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_MATERIAL, id); err != nil {
+		return h.courseAccessError(c, "submission", err)
+	}
+
 	m, err := h.CourseRepository.FindMaterial(map[string]interface{}{
 		"id": id,
 	})
@@ -2046,6 +2006,10 @@ func (h *Handlers) DeleteSubmission(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_MATERIAL, id); err != nil {
+		return h.courseAccessError(c, "submission", err)
+	}
+
 	f, err := h.CourseRepository.FindMaterial(map[string]interface{}{
 		"id": id,
 	})
@@ -2235,18 +2199,9 @@ func (h *Handlers) FindStudentSubmission(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) Approvesubmisson(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-	teacherID := c.Locals("user_id").(string)
+	teacherID := middleware.PrincipalFrom(c).TeacherID
 	student_id := c.Params("student_id")
 
-	if role != "TEACHER" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	var request http.SubmissionStudentAprrove
 
 	if err := c.BodyParser(&request); err != nil {
@@ -2257,6 +2212,10 @@ func (h *Handlers) Approvesubmisson(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_SUBMISSION_STUDENT, request.SubmissionID); err != nil {
+		return h.courseAccessError(c, "submission student", err)
+	}
+
 	// find active stuednt
 	findActiveStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
 		"student_id": student_id,
@@ -2270,23 +2229,10 @@ func (h *Handlers) Approvesubmisson(c *fiber.Ctx) error {
 		})
 	}
 
-	// find teacher
-	findTeacher, err := h.UserRepository.FindTeacher(map[string]interface{}{
-		"user_id": teacherID,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("teacher", "retrieve"),
-			Data:    nil,
-		})
-	}
-
 	result, err := h.CourseRepository.ApproveSubmission(map[string]interface{}{
 		"id":                request.SubmissionID,
 		"active_student_id": findActiveStudent.ID,
-	}, request.Grade, findTeacher.ID)
+	}, request.Grade, teacherID)
 
 	if err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
@@ -2318,18 +2264,9 @@ func (h *Handlers) Approvesubmisson(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) Rejectsubmisson(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-	teacherID := c.Locals("user_id").(string)
+	teacherID := middleware.PrincipalFrom(c).TeacherID
 	student_id := c.Params("student_id")
 
-	if role != "TEACHER" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	var request http.SubmissionStudentReject
 
 	if err := c.BodyParser(&request); err != nil {
@@ -2340,6 +2277,10 @@ func (h *Handlers) Rejectsubmisson(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_SUBMISSION_STUDENT, request.SubmissionID); err != nil {
+		return h.courseAccessError(c, "submission student", err)
+	}
+
 	findActiveStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
 		"student_id": student_id,
 	})
@@ -2352,23 +2293,10 @@ func (h *Handlers) Rejectsubmisson(c *fiber.Ctx) error {
 		})
 	}
 
-	// find teacher
-	findTeacher, err := h.UserRepository.FindTeacher(map[string]interface{}{
-		"user_id": teacherID,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("teacher", "retrieve"),
-			Data:    nil,
-		})
-	}
-
 	result, err := h.CourseRepository.RejectionsSubmission(map[string]interface{}{
 		"id":                request.SubmissionID,
 		"active_student_id": findActiveStudent.ID,
-	}, &request.Comment, findTeacher.ID)
+	}, &request.Comment, teacherID)
 
 	if err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
@@ -2402,24 +2330,12 @@ func (h *Handlers) Rejectsubmisson(c *fiber.Ctx) error {
 // Find Class at active student by user id
 
 func (h *Handlers) FindClassStudent(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
+	principal := middleware.PrincipalFrom(c)
 	var response []http.StudentClassHTTP
 
-	student, err := h.UserRepository.FindStudent(map[string]interface{}{
-		"user_id": userID,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("student", "retrieve"),
-			Data:    nil,
-		})
-	}
-
 	// find school by student id
 	findSchool, err := h.SchoolRepository.FindSchool(map[string]interface{}{
-		"id": student.SchoolsID,
+		"id": principal.SchoolID,
 	})
 
 	if err != nil {
@@ -2431,7 +2347,7 @@ func (h *Handlers) FindClassStudent(c *fiber.Ctx) error {
 	}
 
 	activeStudent, err := h.UserRepository.FindActiveStudents(map[string]interface{}{
-		"student_id":  student.ID,
+		"student_id":  principal.StudentID,
 		"school_year": findSchool.SchoolYear,
 	})
 
@@ -2459,7 +2375,7 @@ func (h *Handlers) FindClassStudent(c *fiber.Ctx) error {
 
 func (h *Handlers) UpdateProgress(c *fiber.Ctx) error {
 	var request http.EnrollCourseHTTP
-	user_id := c.Locals("user_id").(string)
+	activeStudentID := middleware.PrincipalFrom(c).ActiveStudentID
 
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
@@ -2469,17 +2385,8 @@ func (h *Handlers) UpdateProgress(c *fiber.Ctx) error {
 		})
 	}
 
-	// find Active student
-	findActiveStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
-		"student_id": user_id,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("active student", "retrieve"),
-			Data:    nil,
-		})
+	if err := h.authorizeStudentMaterial(c, request.MaterialID); err != nil {
+		return h.courseAccessError(c, "material", err)
 	}
 
 	// find course
@@ -2499,7 +2406,7 @@ func (h *Handlers) UpdateProgress(c *fiber.Ctx) error {
 
 	// Check if existing enrolled course
 	_, err = h.CourseRepository.FindEnrollCourse(map[string]interface{}{
-		"active_student_id": findActiveStudent.ID,
+		"active_student_id": activeStudentID,
 		"course_id":         findCourse.ID,
 		"material_id":       request.MaterialID,
 	})
@@ -2515,7 +2422,7 @@ func (h *Handlers) UpdateProgress(c *fiber.Ctx) error {
 
 	_, err = h.CourseRepository.EnrollCourse(model.ActiveStudentCourse{
 		ID:              id,
-		ActiveStudentID: findActiveStudent.ID,
+		ActiveStudentID: activeStudentID,
 		CourseID:        findCourse.ID,
 		MaterialID:      request.MaterialID,
 	})
@@ -2537,14 +2444,14 @@ func (h *Handlers) UpdateProgress(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) GetEnrollCourse(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
+	principal := middleware.PrincipalFrom(c)
 	slugCourse := c.Params("slug")
 
 	var response []http.EnrollCourseResponseHTTP
 
-	// find active student by userId
+	// find active student
 	findAtiveStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
-		"student_id": userID,
+		"id": principal.ActiveStudentID,
 	})
 
 	if err != nil {
@@ -2557,7 +2464,7 @@ func (h *Handlers) GetEnrollCourse(c *fiber.Ctx) error {
 
 	// Find Student
 	findStudent, err := h.UserRepository.FindStudent(map[string]interface{}{
-		"user_id": userID,
+		"id": principal.StudentID,
 	})
 
 	if err != nil {
@@ -2637,6 +2544,10 @@ func (h *Handlers) EditTheory(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_MATERIAL, id); err != nil {
+		return h.courseAccessError(c, "theory", err)
+	}
+
 	editM, err := h.CourseRepository.UpdateMaterial(map[string]interface{}{
 		"id": id,
 	}, model.Material{
@@ -2691,6 +2602,10 @@ func (h *Handlers) DeleteTheory(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_MATERIAL, id); err != nil {
+		return h.courseAccessError(c, "theory", err)
+	}
+
 	m, err := h.CourseRepository.FindMaterial(map[string]interface{}{
 		"id": id,
 	})
@@ -2755,49 +2670,12 @@ func (h *Handlers) SaveCompleteCourse(c *fiber.Ctx) error {
 		})
 	}
 
-	role := c.Locals("role")
-	userID := c.Locals("user_id")
-
-	if role != "STUDENT" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "Unauthorized",
-			Data:    nil,
-		})
-	}
-
-	// Find Student
-	student, err := h.UserRepository.FindStudent(map[string]interface{}{
-		"user_id": userID,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("complete course", "save"),
-			Data:    nil,
-		})
-	}
-
-	// Find Active Student
-	activeStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
-		"student_id": student.ID,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("complete course", "save"),
-			Data:    nil,
-		})
-	}
-
 	// Insert Complete Course
 	id, _ := gonanoid.New(20)
 	res, err := h.CourseRepository.SaveCompleteCourse(model.CompleteCourse{
 		ID:              id,
 		CourseID:        request["course_id"],
-		ActiveStudentID: activeStudent.ID,
+		ActiveStudentID: middleware.PrincipalFrom(c).ActiveStudentID,
 	})
 
 	if err != nil {
@@ -2819,27 +2697,14 @@ func (h *Handlers) SaveCompleteCourse(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) ListingSubmission(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-	user_id := c.Locals("user_id")
-
-	logrus.Infoln("[handler] Listing Submission within POV:", role)
-	if role == "TEACHER" {
-		teacher, err := h.UserRepository.FindTeacher(map[string]interface{}{
-			"user_id": user_id.(string),
-		})
-
-		if err != nil {
-			return c.Status(500).JSON(&http.WebResponse{
-				Status:  "error",
-				Message: h.errorInternal("submission", "retrieve"),
-				Data:    nil,
-			})
-		}
+	principal := middleware.PrincipalFrom(c)
 
+	logrus.Infoln("[handler] Listing Submission within POV:", principal.Role)
+	if principal.Role == model.TEACHER {
 		materialID := c.Query("material_id")
 		class := c.Query("class")
 
-		submission, err := h.CourseRepository.FindSubmissionPreload(teacher.ID, "", "", "", materialID, class)
+		submission, err := h.CourseRepository.FindSubmissionPreload(principal.TeacherID, "", "", "", materialID, class)
 		if err != nil {
 			return c.Status(500).JSON(&http.WebResponse{
 				Status:  "error",
@@ -2929,34 +2794,9 @@ func (h *Handlers) ListingSubmission(c *fiber.Ctx) error {
 
 	}
 
-	// Find Current StudentIDt
-	student, err := h.UserRepository.FindStudent(map[string]interface{}{
-		"user_id": user_id.(string),
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("submission", "retrieve"),
-			Data:    nil,
-		})
-	}
-
-	activeStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
-		"student_id": student.ID,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("submission", "retrieve"),
-			Data:    nil,
-		})
-	}
-
 	filterTeacher := c.Query("teacher_id")
 	filterCourseID := c.Query("course_id")
-	submission, err := h.CourseRepository.FindSubmissionPreload("", activeStudent.ID, filterTeacher, filterCourseID, "", "")
+	submission, err := h.CourseRepository.FindSubmissionPreload("", principal.ActiveStudentID, filterTeacher, filterCourseID, "", "")
 
 	if err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
@@ -3040,43 +2880,8 @@ func (h *Handlers) ListingSubmission(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) ResetSubmission(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-	userID := c.Locals("user_id").(string)
-
-	if role != "STUDENT" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "Unauthorized",
-			Data:    nil,
-		})
-	}
-
-	student, err := h.UserRepository.FindStudent(map[string]interface{}{
-		"user_id": userID,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("submission", "retrieve"),
-			Data:    nil,
-		})
-	}
-
-	activeStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
-		"student_id": student.ID,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("submission", "retrieve"),
-			Data:    nil,
-		})
-	}
-
 	var request http.ResetSubmittedRequestHTTP
-	err = c.BodyParser(&request)
+	err := c.BodyParser(&request)
 
 	if err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
@@ -3087,7 +2892,7 @@ func (h *Handlers) ResetSubmission(c *fiber.Ctx) error {
 	}
 
 	err = h.CourseRepository.ResetSubmittedSubmission(map[string]interface{}{
-		"active_student_id": activeStudent.ID,
+		"active_student_id": middleware.PrincipalFrom(c).ActiveStudentID,
 		"id":                request.SubmittedID,
 	})
 
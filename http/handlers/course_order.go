@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/cvzamannow/E-Learning-API/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReorderCourse saves the drag-and-drop order of the chapters and materials of a course.
+// Materials can be moved to another chapter of the same course.
+func (h *Handlers) ReorderCourse(c *fiber.Ctx) error {
+	courseID, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	var request http.CourseOrder
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	var chapters []repository.ChapterOrder
+	for _, chapter := range request.Chapters {
+		chapters = append(chapters, repository.ChapterOrder{
+			ChapterID:   chapter.ID,
+			MaterialIDs: chapter.Materials,
+		})
+	}
+
+	if err := h.CourseRepository.ReorderCourse(courseID, chapters); err != nil {
+		if errors.Is(err, repository.ErrInvalidCourseOrder) {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: "Order must list every chapter and material of the course exactly once",
+				Data:    nil,
+			})
+		}
+
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course order", "update"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course order", "updated"),
+		Data:    request,
+	})
+}
+
+// GetMaterialPrerequisites lists the rules to unlock a material.
+func (h *Handlers) GetMaterialPrerequisites(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_MATERIAL, id); err != nil {
+		return h.courseAccessError(c, "material", err)
+	}
+
+	prerequisites, err := h.CourseRepository.FindMaterialPrerequisites(id)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("material prerequisites", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("material prerequisites", "retrieve"),
+		Data:    h.materialPrerequisitesResponse(prerequisites),
+	})
+}
+
+// SaveMaterialPrerequisites replaces the rules to unlock a material, an empty list
+// brings back unlocking after the material before it.
+func (h *Handlers) SaveMaterialPrerequisites(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	courseID, err := h.authorizeDraftEdit(c, repository.RESOURCE_MATERIAL, id)
+	if err != nil {
+		return h.courseAccessError(c, "material", err)
+	}
+
+	var request http.MaterialPrerequisites
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	var prerequisites []model.MaterialPrerequisite
+	seen := make(map[string]bool)
+
+	for i, rule := range request.Prerequisites {
+		rule.Type = strings.ToUpper(strings.TrimSpace(rule.Type))
+
+		if err := h.validateMaterialPrerequisite(id, courseID, rule); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: fmt.Sprintf("prerequisites[%d]: %s", i, err.Error()),
+				Data:    nil,
+			})
+		}
+
+		// Aturan yang sama cukup disimpan sekali
+		key := rule.Type + "/" + rule.MaterialID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		prerequisiteID, err := helper.GenerateNanoId()
+		if err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: "Error generating nano ID",
+				Data:    nil,
+			})
+		}
+
+		prerequisite := model.MaterialPrerequisite{
+			ID:                 prerequisiteID,
+			Type:               model.PREREQUISITE_TYPE(rule.Type),
+			RequiredMaterialID: rule.MaterialID,
+		}
+
+		if prerequisite.Type == model.PREREQUISITE_QUIZ_SCORE {
+			prerequisite.MinGrades = rule.MinGrades
+		}
+
+		prerequisites = append(prerequisites, prerequisite)
+	}
+
+	result, err := h.CourseRepository.SaveMaterialPrerequisites(id, prerequisites)
+	if err != nil {
+		if errors.Is(err, repository.ErrPrerequisiteCycle) {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: "Prerequisites would make materials wait for each other",
+				Data:    nil,
+			})
+		}
+
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("material prerequisites", "update"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("material prerequisites", "updated"),
+		Data:    h.materialPrerequisitesResponse(result),
+	})
+}
+
+// validateMaterialPrerequisite checks that a rule of a material requires another material
+// of the same course with the type the rule checks.
+func (h *Handlers) validateMaterialPrerequisite(materialID, courseID string, rule http.MaterialPrerequisite) error {
+	if strings.TrimSpace(rule.MaterialID) == "" {
+		return errors.New("material_id is required")
+	}
+
+	if rule.MaterialID == materialID {
+		return errors.New("material can't be a prerequisite of itself")
+	}
+
+	// Material quiz lama tidak punya course_id, jadi course nya dicari lewat chapter
+	requiredCourseID, err := h.CourseRepository.FindCourseIDOf(repository.RESOURCE_MATERIAL, rule.MaterialID)
+	if err != nil || requiredCourseID != courseID {
+		return errors.New("material_id must be a material of the same course")
+	}
+
+	required, err := h.CourseRepository.FindMaterial(map[string]interface{}{
+		"id": rule.MaterialID,
+	})
+	if err != nil {
+		return errors.New("material_id must be a material of the same course")
+	}
+
+	// Material yang diedit selalu di draft, versi yang sudah dipublish tidak boleh dirujuk
+	if isDraft, err := h.CourseRepository.IsDraftResource(repository.RESOURCE_MATERIAL, rule.MaterialID); err != nil || !isDraft {
+		return errors.New("material_id must be a material of the draft of the course")
+	}
+
+	switch model.PREREQUISITE_TYPE(rule.Type) {
+	case model.PREREQUISITE_COMPLETED:
+	case model.PREREQUISITE_QUIZ_SCORE:
+		if required.Type != "QUIZ" {
+			return errors.New("QUIZ_SCORE requires a QUIZ material")
+		}
+
+		if rule.MinGrades < 0 || rule.MinGrades > 100 {
+			return errors.New("min_grades must be between 0 and 100")
+		}
+	case model.PREREQUISITE_SUBMISSION_APPROVED:
+		if required.Type != "SUBMISSION" {
+			return errors.New("SUBMISSION_APPROVED requires a SUBMISSION material")
+		}
+	default:
+		return errors.New("type must be COMPLETED, QUIZ_SCORE or SUBMISSION_APPROVED")
+	}
+
+	return nil
+}
+
+func (h *Handlers) materialPrerequisitesResponse(prerequisites []model.MaterialPrerequisite) []http.MaterialPrerequisiteHTTP {
+	response := []http.MaterialPrerequisiteHTTP{}
+
+	for _, prerequisite := range prerequisites {
+		response = append(response, http.MaterialPrerequisiteHTTP{
+			ID:            prerequisite.ID,
+			Type:          string(prerequisite.Type),
+			MaterialID:    prerequisite.RequiredMaterialID,
+			MaterialTitle: prerequisite.RequiredMaterial.Title,
+			MaterialType:  prerequisite.RequiredMaterial.Type,
+			MinGrades:     prerequisite.MinGrades,
+		})
+	}
+
+	return response
+}
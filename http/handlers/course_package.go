@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
+	"github.com/cvzamannow/E-Learning-API/repository"
+	"github.com/cvzamannow/E-Learning-API/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gosimple/slug"
+)
+
+func (h *Handlers) RouteCoursePackages(app *fiber.App) {
+	v1 := app.Group("/api/v1")
+	v1.Get("/courses/:id/export", h.Middleware.Protected(), h.tenant((*Handlers).ExportCourse))
+	v1.Post("/courses/import", h.Middleware.Protected(), h.tenant((*Handlers).ImportCourse))
+}
+
+// ExportCourse downloads the draft of a course as a course package zip.
+func (h *Handlers) ExportCourse(c *fiber.Ctx) error {
+	courseID, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	pkg, content, err := service.NewCoursePackager(h.CourseRepository, h.R2Cloudflare).ExportBytes(courseID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course", "export"),
+			Data:    nil,
+		})
+	}
+
+	filename := slug.Make(pkg.Course.Title)
+	if filename == "" {
+		filename = courseID
+	}
+
+	c.Attachment(filename + "-course.zip")
+	return c.Status(200).Send(content)
+}
+
+// ImportCourse creates a course of the teacher from a course package. The course is imported
+// as a draft. on_conflict=rename imports a package whose slug is already used under the next
+// free slug instead of failing.
+func (h *Handlers) ImportCourse(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	policy := service.CONFLICT_POLICY(strings.ToUpper(c.FormValue("on_conflict", string(service.CONFLICT_FAIL))))
+	if policy != service.CONFLICT_FAIL && policy != service.CONFLICT_RENAME {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "on_conflict must be FAIL or RENAME",
+			Data:    nil,
+		})
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Course package", "read"),
+			Data:    nil,
+		})
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Course package", "read"),
+			Data:    nil,
+		})
+	}
+
+	principal := middleware.PrincipalFrom(c)
+
+	course, err := service.NewCoursePackager(h.CourseRepository, h.R2Cloudflare).Import(content, principal.TeacherID, policy)
+	if err != nil {
+		switch {
+		case errors.Is(err, helper.ErrInvalidCoursePackage), errors.Is(err, helper.ErrUnsupportedCoursePackage):
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			})
+		case errors.Is(err, service.ErrCoursePackageConflict):
+			return c.Status(409).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			})
+		}
+
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course", "import"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course", "imported"),
+		Data:    h.clonedCourseResponse(c, course),
+	})
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/cvzamannow/E-Learning-API/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	errNotCourseEditor = errors.New("You are not allowed to edit this course")
+	errNotCourseOwner  = errors.New("Only the owner of the course can perform this action")
+	errNotCourseDraft  = errors.New("Only the draft of the course can be edited, published versions are read-only")
+)
+
+// authorizeCourseEdit resolves the course a resource belongs to (material -> chapter -> course)
+// and checks that the logged in teacher owns it or was granted edit rights on it.
+// It returns the ID of the course.
+func (h *Handlers) authorizeCourseEdit(c *fiber.Ctx, resource repository.CourseResource, id string) (string, error) {
+	if id == "" {
+		return "", repository.ErrCourseResourceNotFound
+	}
+
+	teacherID := middleware.PrincipalFrom(c).TeacherID
+	if teacherID == "" {
+		return "", errNotCourseEditor
+	}
+
+	courseID, err := h.CourseRepository.FindCourseIDOf(resource, id)
+	if err != nil {
+		return "", err
+	}
+
+	canEdit, err := h.CourseRepository.CanEditCourse(courseID, teacherID)
+	if err != nil {
+		return "", err
+	}
+
+	if !canEdit {
+		return "", errNotCourseEditor
+	}
+
+	return courseID, nil
+}
+
+// authorizeDraftEdit is authorizeCourseEdit for the content of a course, it also checks that the
+// chapter, material or quiz belongs to the draft of the course and not to a published version.
+func (h *Handlers) authorizeDraftEdit(c *fiber.Ctx, resource repository.CourseResource, id string) (string, error) {
+	courseID, err := h.authorizeCourseEdit(c, resource, id)
+	if err != nil {
+		return "", err
+	}
+
+	isDraft, err := h.CourseRepository.IsDraftResource(resource, id)
+	if err != nil {
+		return "", err
+	}
+
+	if !isDraft {
+		return "", errNotCourseDraft
+	}
+
+	return courseID, nil
+}
+
+// authorizeCourseOwner checks that the logged in teacher owns the course.
+// Co-teachers can't delete the course or manage its teachers.
+func (h *Handlers) authorizeCourseOwner(c *fiber.Ctx, courseID string) (*model.Course, error) {
+	teacherID := middleware.PrincipalFrom(c).TeacherID
+	if teacherID == "" {
+		return nil, errNotCourseOwner
+	}
+
+	if _, err := h.CourseRepository.FindCourseIDOf(repository.RESOURCE_COURSE, courseID); err != nil {
+		return nil, err
+	}
+
+	course, err := h.CourseRepository.FindCourse(map[string]interface{}{
+		"id": courseID,
+	}, false, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if course.TeacherID != teacherID {
+		return nil, errNotCourseOwner
+	}
+
+	return course, nil
+}
+
+// authorizeStudentMaterial checks that a student can open a material: it must be in the version
+// of the course the student is pinned to and unlocked by its prerequisites. Teachers and admins
+// aren't restricted.
+func (h *Handlers) authorizeStudentMaterial(c *fiber.Ctx, materialID string) error {
+	principal := middleware.PrincipalFrom(c)
+	if principal.Role != model.STUDENT {
+		return nil
+	}
+
+	return h.CourseRepository.CheckStudentMaterial(materialID, principal.ActiveStudentID)
+}
+
+// courseAccessError responds to an error of authorizeCourseEdit, authorizeCourseOwner or
+// authorizeStudentMaterial.
+func (h *Handlers) courseAccessError(c *fiber.Ctx, resource string, err error) error {
+	switch {
+	case errors.Is(err, errNotCourseEditor), errors.Is(err, errNotCourseOwner):
+		return c.Status(401).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	case errors.Is(err, errNotCourseDraft):
+		return c.Status(409).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	case errors.Is(err, repository.ErrMaterialLocked):
+		return c.Status(403).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "This material is locked until you meet its prerequisites",
+			Data:    nil,
+		})
+	case errors.Is(err, repository.ErrMaterialNotAvailable):
+		return c.Status(403).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "This material is not part of the version of the course you follow",
+			Data:    nil,
+		})
+	case errors.Is(err, repository.ErrCourseResourceNotFound):
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("Couldn't find %s because it's not found!", resource),
+			Data:    nil,
+		})
+	default:
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal(resource, "authorize"),
+			Data:    nil,
+		})
+	}
+}
+
+// GetCourseTeachers lists the owner and the co-teachers of a course.
+func (h *Handlers) GetCourseTeachers(c *fiber.Ctx) error {
+	courseID, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	course, err := h.CourseRepository.FindCourse(map[string]interface{}{
+		"id": courseID,
+	}, false, "")
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Course Teachers", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	owner, err := h.UserRepository.FindTeacher(map[string]interface{}{
+		"id": course.TeacherID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Course Teachers", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	courseTeachers, err := h.CourseRepository.FindCourseTeachers(map[string]interface{}{
+		"course_id": course.ID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Course Teachers", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	response := []http.CourseTeacherHTTP{{
+		CourseID:  course.ID,
+		TeacherID: owner.ID,
+		Name:      owner.Name,
+		IsOwner:   true,
+		CreatedAt: course.CreatedAt,
+	}}
+
+	for _, courseTeacher := range courseTeachers {
+		response = append(response, h.courseTeacherResponse(courseTeacher))
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Course Teachers", "retrieved"),
+		Data:    response,
+	})
+}
+
+// AddCourseTeacher grants a teacher of the same school edit rights on a course.
+func (h *Handlers) AddCourseTeacher(c *fiber.Ctx) error {
+	course, err := h.authorizeCourseOwner(c, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	var request http.CourseTeacher
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	if strings.TrimSpace(request.TeacherID) == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "teacher_id is required",
+			Data:    nil,
+		})
+	}
+
+	// Pemilik course sudah punya hak edit
+	if request.TeacherID == course.TeacherID {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Teacher already owns this course",
+			Data:    nil,
+		})
+	}
+
+	teacher, err := h.UserRepository.FindTeacher(map[string]interface{}{
+		"id": request.TeacherID,
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("teacher_id"),
+			Data:    nil,
+		})
+	}
+
+	existing, err := h.CourseRepository.FindCourseTeachers(map[string]interface{}{
+		"course_id":  course.ID,
+		"teacher_id": teacher.ID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Course Teacher", "create"),
+			Data:    nil,
+		})
+	}
+
+	if len(existing) > 0 {
+		return c.Status(409).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Teacher is already a co-teacher of this course",
+			Data:    nil,
+		})
+	}
+
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generating nano ID",
+			Data:    nil,
+		})
+	}
+
+	result, err := h.CourseRepository.CreateCourseTeacher(model.CourseTeacher{
+		ID:        id,
+		CourseID:  course.ID,
+		TeacherID: teacher.ID,
+		GrantedBy: course.TeacherID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Course Teacher", "create"),
+			Data:    nil,
+		})
+	}
+
+	result.Teacher = *teacher
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Course Teacher", "created"),
+		Data:    h.courseTeacherResponse(*result),
+	})
+}
+
+// DeleteCourseTeacher revokes the edit rights of a co-teacher on a course.
+func (h *Handlers) DeleteCourseTeacher(c *fiber.Ctx) error {
+	course, err := h.authorizeCourseOwner(c, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	result, err := h.CourseRepository.DeleteCourseTeacher(map[string]interface{}{
+		"course_id":  course.ID,
+		"teacher_id": c.Params("teacher_id"),
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("teacher_id"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Course Teacher", "deleted"),
+		Data:    h.courseTeacherResponse(*result),
+	})
+}
+
+func (h *Handlers) courseTeacherResponse(courseTeacher model.CourseTeacher) http.CourseTeacherHTTP {
+	return http.CourseTeacherHTTP{
+		ID:        courseTeacher.ID,
+		CourseID:  courseTeacher.CourseID,
+		TeacherID: courseTeacher.TeacherID,
+		Name:      courseTeacher.Teacher.Name,
+		GrantedBy: courseTeacher.GrantedBy,
+		CreatedAt: courseTeacher.CreatedAt,
+	}
+}
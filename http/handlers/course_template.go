@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/cvzamannow/E-Learning-API/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gosimple/slug"
+)
+
+func (h *Handlers) RouteCourseTemplates(app *fiber.App) {
+	v1 := app.Group("/api/v1")
+	v1.Post("/courses/:id/clone", h.Middleware.Protected(), h.tenant((*Handlers).CloneCourse))
+
+	// Template library
+	v1.Get("/course-templates", h.Middleware.Protected(), h.tenant((*Handlers).GetCourseTemplates))
+	v1.Post("/course-templates/:id/clone", h.Middleware.Protected(), h.tenant((*Handlers).CloneCourseTemplate))
+	v1.Post("/super-admin/course-templates", h.Middleware.Protected(), h.tenant((*Handlers).CreateCourseTemplate))
+	v1.Delete("/super-admin/course-templates/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteCourseTemplate))
+}
+
+// CloneCourse copies a course the teacher can edit into a new course of the teacher, for
+// example for a new school year. The clone starts as a draft with the classes of the course
+// unless other classes are given.
+func (h *Handlers) CloneCourse(c *fiber.Ctx) error {
+	courseID, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	var request http.CloneCourse
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorParseBodyRequest(),
+				Data:    nil,
+			})
+		}
+	}
+
+	if strings.TrimSpace(request.Title) == "" {
+		source, err := h.CourseRepository.FindCourse(map[string]interface{}{
+			"id": courseID,
+		}, false, "")
+		if err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorInternal("course", "clone"),
+				Data:    nil,
+			})
+		}
+
+		request.Title = source.Title + " (Copy)"
+	}
+
+	data, err := h.cloneCourseData(c, request)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generating nano ID",
+			Data:    nil,
+		})
+	}
+
+	result, err := h.CourseRepository.CloneCourse(courseID, data)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course", "clone"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course", "cloned"),
+		Data:    h.clonedCourseResponse(c, result),
+	})
+}
+
+// GetCourseTemplates lists the template library, which is shared by every school.
+func (h *Handlers) GetCourseTemplates(c *fiber.Ctx) error {
+	templates, err := h.CourseRepository.FindCourseTemplates()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course templates", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	response := []http.CourseTemplateHTTP{}
+	for _, template := range templates {
+		response = append(response, h.courseTemplateResponse(template))
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course templates", "retrieve"),
+		Data:    response,
+	})
+}
+
+// CloneCourseTemplate copies the published version of a template into a new course of the
+// teacher, in the school of the teacher.
+func (h *Handlers) CloneCourseTemplate(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	template, err := h.CourseRepository.FindCourseTemplate(map[string]interface{}{
+		"id": id,
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find course template because it's not found!",
+			Data:    nil,
+		})
+	}
+
+	var request http.CloneCourse
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorParseBodyRequest(),
+				Data:    nil,
+			})
+		}
+	}
+
+	if strings.TrimSpace(request.Title) == "" {
+		request.Title = template.Course.Title
+	}
+
+	data, err := h.cloneCourseData(c, request)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generating nano ID",
+			Data:    nil,
+		})
+	}
+
+	result, err := h.CourseRepository.CloneCourseTemplate(template.ID, data)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course template", "clone"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course template", "cloned"),
+		Data:    h.clonedCourseResponse(c, result),
+	})
+}
+
+// CreateCourseTemplate adds a course of any school to the template library.
+func (h *Handlers) CreateCourseTemplate(c *fiber.Ctx) error {
+	var request http.CourseTemplate
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	if strings.TrimSpace(request.CourseID) == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("course_id"),
+			Data:    nil,
+		})
+	}
+
+	course, err := h.CourseRepository.FindCourse(map[string]interface{}{
+		"id": request.CourseID,
+	}, false, "")
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find course because it's not found!",
+			Data:    nil,
+		})
+	}
+
+	if strings.TrimSpace(request.Title) == "" {
+		request.Title = course.Title
+	}
+
+	if strings.TrimSpace(request.Description) == "" {
+		request.Description = course.Description
+	}
+
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generating nano ID",
+			Data:    nil,
+		})
+	}
+
+	template, err := h.CourseRepository.CreateCourseTemplate(model.CourseTemplate{
+		ID:          id,
+		CourseID:    course.ID,
+		Title:       request.Title,
+		Description: request.Description,
+		CreatedBy:   middleware.PrincipalFrom(c).UserID,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrCourseTemplateExists) {
+			return c.Status(409).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: "Course is already in the template library",
+				Data:    nil,
+			})
+		}
+
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course template", "create"),
+			Data:    nil,
+		})
+	}
+	template.Course = *course
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course template", "created"),
+		Data:    h.courseTemplateResponse(*template),
+	})
+}
+
+// DeleteCourseTemplate removes a course from the template library. The course and the
+// courses cloned from it are kept.
+func (h *Handlers) DeleteCourseTemplate(c *fiber.Ctx) error {
+	if _, err := h.CourseRepository.FindCourseTemplate(map[string]interface{}{
+		"id": c.Params("id"),
+	}); err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find course template because it's not found!",
+			Data:    nil,
+		})
+	}
+
+	template, err := h.CourseRepository.DeleteCourseTemplate(c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course template", "delete"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course template", "deleted"),
+		Data:    h.courseTemplateResponse(*template),
+	})
+}
+
+// cloneCourseData is the new course of a clone for the logged in teacher.
+func (h *Handlers) cloneCourseData(c *fiber.Ctx, request http.CloneCourse) (model.Course, error) {
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return model.Course{}, err
+	}
+
+	data := model.Course{
+		ID:        id,
+		Title:     request.Title,
+		Slug:      slug.Make(request.Title),
+		TeacherID: middleware.PrincipalFrom(c).TeacherID,
+	}
+
+	for _, class := range request.Classes {
+		classID, err := helper.GenerateNanoId()
+		if err != nil {
+			return model.Course{}, err
+		}
+
+		data.CourseClasses = append(data.CourseClasses, model.CourseClass{
+			ID:    classID,
+			Class: class,
+			Slug:  slug.Make(class),
+		})
+	}
+
+	return data, nil
+}
+
+func (h *Handlers) clonedCourseResponse(c *fiber.Ctx, course *model.Course) http.CourseHTTP {
+	var classes []string
+	for _, class := range course.CourseClasses {
+		classes = append(classes, class.Class)
+	}
+
+	return http.CourseHTTP{
+		ID:               course.ID,
+		Title:            course.Title,
+		Description:      course.Description,
+		ThumbnailImg:     course.ThumbnailImg,
+		EstimationHour:   course.EstimationHour,
+		EstimationMinute: course.EstimationMinute,
+		IsDraft:          course.IsDraft,
+		Detail:           course.Detail,
+		Slug:             course.Slug,
+		Classes:          classes,
+		Teacher:          &middleware.PrincipalFrom(c).Name,
+	}
+}
+
+func (h *Handlers) courseTemplateResponse(template model.CourseTemplate) http.CourseTemplateHTTP {
+	return http.CourseTemplateHTTP{
+		ID:               template.ID,
+		CourseID:         template.CourseID,
+		Title:            template.Title,
+		Description:      template.Description,
+		ThumbnailImg:     template.Course.ThumbnailImg,
+		EstimationHour:   template.Course.EstimationHour,
+		EstimationMinute: template.Course.EstimationMinute,
+		CreatedAt:        template.CreatedAt,
+	}
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
+	"github.com/cvzamannow/E-Learning-API/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCourseVersions lists the published versions of a course with the number of students
+// pinned to each of them.
+func (h *Handlers) GetCourseVersions(c *fiber.Ctx) error {
+	courseID, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	course, err := h.CourseRepository.FindCourse(map[string]interface{}{
+		"id": courseID,
+	}, false, "")
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course versions", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	versions, err := h.CourseRepository.FindCourseVersions(courseID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course versions", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	enrollments, err := h.CourseRepository.FindCourseEnrollments(map[string]interface{}{
+		"course_id": courseID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course versions", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	students := make(map[int]int)
+	for _, enrollment := range enrollments {
+		students[enrollment.Version]++
+	}
+
+	response := http.CourseVersionsResponse{
+		DraftVersion:     course.DraftVersion,
+		PublishedVersion: course.PublishedVersion,
+		Versions:         []http.CourseVersionHTTP{},
+	}
+
+	for _, version := range versions {
+		response.Versions = append(response.Versions, http.CourseVersionHTTP{
+			Version:      version.Version,
+			Note:         version.Note,
+			PublishedBy:  version.PublishedBy,
+			PublishedAt:  version.PublishedAt,
+			IsPublished:  version.Version == course.PublishedVersion,
+			TotalStudent: students[version.Version],
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course versions", "retrieve"),
+		Data:    response,
+	})
+}
+
+// PublishCourse publishes the draft of a course as a new version. New students get the new
+// version, students already enrolled keep theirs until they are migrated.
+func (h *Handlers) PublishCourse(c *fiber.Ctx) error {
+	courseID, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	var request http.PublishCourse
+	// Body boleh kosong, note tidak wajib
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorParseBodyRequest(),
+				Data:    nil,
+			})
+		}
+	}
+
+	version, err := h.CourseRepository.PublishCourse(courseID, middleware.PrincipalFrom(c).TeacherID, request.Note)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course", "publish"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course", "published"),
+		Data: http.CourseVersionHTTP{
+			Version:     version.Version,
+			Note:        version.Note,
+			PublishedBy: version.PublishedBy,
+			PublishedAt: version.PublishedAt,
+			IsPublished: true,
+		},
+	})
+}
+
+// DiffCourseVersions compares two versions of a course, by default the published version
+// with the draft.
+func (h *Handlers) DiffCourseVersions(c *fiber.Ctx) error {
+	courseID, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	course, err := h.CourseRepository.FindCourse(map[string]interface{}{
+		"id": courseID,
+	}, false, "")
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course versions", "compare"),
+			Data:    nil,
+		})
+	}
+
+	from, to := course.PublishedVersion, course.DraftVersion
+	if c.Query("from") != "" {
+		if from, err = strconv.Atoi(c.Query("from")); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: "from must be a version number",
+				Data:    nil,
+			})
+		}
+	}
+
+	if c.Query("to") != "" {
+		if to, err = strconv.Atoi(c.Query("to")); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: "to must be a version number",
+				Data:    nil,
+			})
+		}
+	}
+
+	changes, err := h.CourseRepository.DiffCourseVersions(courseID, from, to)
+	if err != nil {
+		return h.courseVersionError(c, "compare", err)
+	}
+
+	response := http.CourseDiffResponse{
+		From:    from,
+		To:      to,
+		Changes: []http.CourseChangeHTTP{},
+	}
+
+	for _, change := range changes {
+		response.Changes = append(response.Changes, http.CourseChangeHTTP{
+			Resource:  string(change.Resource),
+			LineageID: change.LineageID,
+			Title:     change.Title,
+			Change:    string(change.Change),
+			Fields:    change.Fields,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course versions", "compare"),
+		Data:    response,
+	})
+}
+
+// RollbackCourse replaces the draft of a course with a published version, the draft must
+// be published again to reach the students.
+func (h *Handlers) RollbackCourse(c *fiber.Ctx) error {
+	courseID, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("version"),
+			Data:    nil,
+		})
+	}
+
+	if err := h.CourseRepository.RollbackCourse(courseID, version); err != nil {
+		return h.courseVersionError(c, "rollback", err)
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course", "rolled back"),
+		Data:    nil,
+	})
+}
+
+// MigrateCourseEnrollments moves students to a published version of a course, every enrolled
+// student when active_student_ids is empty. Their progress follows the materials kept by the version.
+func (h *Handlers) MigrateCourseEnrollments(c *fiber.Ctx) error {
+	courseID, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, c.Params("id"))
+	if err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("version"),
+			Data:    nil,
+		})
+	}
+
+	var request http.MigrateCourseEnrollments
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorParseBodyRequest(),
+				Data:    nil,
+			})
+		}
+	}
+
+	migrated, err := h.CourseRepository.MigrateCourseEnrollments(courseID, version, request.ActiveStudentIDs)
+	if err != nil {
+		return h.courseVersionError(c, "migrate", err)
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("course enrollments", "migrated"),
+		Data: http.MigrateCourseEnrollmentsResponse{
+			Version:  version,
+			Migrated: migrated,
+		},
+	})
+}
+
+func (h *Handlers) courseVersionError(c *fiber.Ctx, action string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrCourseVersionNotFound):
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find course version because it's not found!",
+			Data:    nil,
+		})
+	case errors.Is(err, repository.ErrCourseWithoutDraft):
+		return c.Status(409).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Course was never published, there is no version to roll back to",
+			Data:    nil,
+		})
+	default:
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("course versions", action),
+			Data:    nil,
+		})
+	}
+}
@@ -3,16 +3,17 @@ package handlers
 import (
 	"strconv"
 
+	"github.com/cvzamannow/E-Learning-API/helper"
 	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
 	"github.com/gofiber/fiber/v2"
-	"github.com/sirupsen/logrus"
 )
 
 func (h *Handlers) RouteGrades(app *fiber.App) {
 	v1 := app.Group("/api/v1")
 
-	v1.Get("/grades-student", h.Middleware.Protected(), h.GetGradesStudent)
-	v1.Get("/grades-teacher", h.Middleware.Protected(), h.GetGradesTeacher)
+	v1.Get("/grades-student", h.Middleware.Protected(), h.tenant((*Handlers).GetGradesStudent))
+	v1.Get("/grades-teacher", h.Middleware.Protected(), h.tenant((*Handlers).GetGradesTeacher))
 }
 
 // grades student
@@ -22,35 +23,11 @@ func (h *Handlers) GetGradesStudent(c *fiber.Ctx) error {
 
 	schoolYear := c.Query("school_year")
 
-	// role := c.Locals("role").(string)
-	studentID := c.Locals("user_id").(string)
-
-	findActiveStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
-		"student_id": studentID,
-	})
-	if err != nil {
-		return c.Status(404).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "Active Student not found",
-			Data:    nil,
-		})
-	}
-
-	findStudent, err := h.UserRepository.FindStudent(map[string]interface{}{
-		"id": findActiveStudent.StudentID,
-	})
-
-	if err != nil {
-		return c.Status(404).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "Student not found",
-			Data:    nil,
-		})
-	}
+	principal := middleware.PrincipalFrom(c)
 
 	result, err := h.GradesRepository.GetGradesStudent(map[string]interface{}{
-		"active_student_id": findActiveStudent.ID,
-	}, class, schoolYear, findStudent.SchoolsID)
+		"active_student_id": principal.ActiveStudentID,
+	}, class, schoolYear, principal.SchoolID)
 
 	if err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
@@ -65,7 +42,7 @@ func (h *Handlers) GetGradesStudent(c *fiber.Ctx) error {
 	for _, item := range *result {
 		// find quiz answer student
 		resultAnswerStudent, err := h.GradesRepository.GetQuizGradesStudent(map[string]interface{}{
-			"active_student_id": findActiveStudent.ID,
+			"active_student_id": principal.ActiveStudentID,
 		})
 
 		if err != nil {
@@ -81,7 +58,7 @@ func (h *Handlers) GetGradesStudent(c *fiber.Ctx) error {
 				ID:         quiz.ID,
 				Course:     item.Course,
 				Grade:      quiz.Grades,
-				StudentID:  findActiveStudent.StudentID,
+				StudentID:  principal.StudentID,
 				Date:       quiz.CreatedAt.Format("02 January 2006"),
 				Material:   quiz.Quiz.Material.Title,
 				Type:       quiz.Quiz.Material.Type,
@@ -93,7 +70,7 @@ func (h *Handlers) GetGradesStudent(c *fiber.Ctx) error {
 			ID:         item.ID,
 			Course:     item.Course,
 			Grade:      item.Grade,
-			StudentID:  findActiveStudent.StudentID,
+			StudentID:  principal.StudentID,
 			Date:       item.CreatedAt.Format("02 January 2006"),
 			Material:   item.Material.Title,
 			Type:       item.Material.Type,
@@ -102,8 +79,36 @@ func (h *Handlers) GetGradesStudent(c *fiber.Ctx) error {
 
 	}
 
+	// Nilai SCORM dilaporkan oleh SCO lewat cmi.core.score
+	resultScorm, err := h.GradesRepository.GetScormGradesStudent(map[string]interface{}{
+		"active_student_id": principal.ActiveStudentID,
+	})
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error retrieving SCORM grades: " + err.Error(),
+			Data:    nil,
+		})
+	}
+
+	for _, registration := range *resultScorm {
+		grade, _ := helper.ScormGrade(registration)
+
+		response = append(response, http.GradeStudents{
+			ID:         registration.ID,
+			Course:     registration.Course.Title,
+			Grade:      grade,
+			StudentID:  principal.StudentID,
+			Date:       registration.UpdatedAt.Format("02 January 2006"),
+			Material:   registration.Material.Title,
+			Type:       registration.Material.Type,
+			MaterialID: registration.Material.ID,
+		})
+	}
+
 	resultSchoolYear, err := h.GradesRepository.GetAvailableSchoolYears(map[string]interface{}{
-		"school_id": findActiveStudent.Student.SchoolsID,
+		"school_id": principal.SchoolID,
 	})
 
 	if err != nil {
@@ -115,7 +120,7 @@ func (h *Handlers) GetGradesStudent(c *fiber.Ctx) error {
 	}
 
 	resultClass, err := h.GradesRepository.GetAvailableClasses(map[string]interface{}{
-		"school_id": findActiveStudent.Student.SchoolsID,
+		"school_id": principal.SchoolID,
 	})
 
 	if err != nil {
@@ -143,28 +148,12 @@ func (h *Handlers) GetGradesStudent(c *fiber.Ctx) error {
 // GetGradesTeacher handles the request for retrieving grades by teacher
 func (h *Handlers) GetGradesTeacher(c *fiber.Ctx) error {
 
-	teacherID := c.Locals("user_id").(string)
-
-	// // find teacher
-	findTeacher, err := h.UserRepository.FindTeacher(map[string]interface{}{
-		"user_id": teacherID,
-	})
-
-	logrus.Println(findTeacher)
-	logrus.Println(teacherID)
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: h.errorInternal("teacher", "retrieve"),
-			Data:    nil,
-		})
-	}
+	principal := middleware.PrincipalFrom(c)
 
 	schoolYear := c.Query("school_year")
 	class := c.Query("class")
 
-	result, err := h.GradesRepository.GetGradesTeacher(schoolYear, class, *findTeacher.SchoolsID)
+	result, err := h.GradesRepository.GetGradesTeacher(schoolYear, class, principal.SchoolID)
 	if err != nil {
 		return c.JSON(&http.WebResponse{
 			Status:  "error",
@@ -251,7 +240,7 @@ func (h *Handlers) GetGradesTeacher(c *fiber.Ctx) error {
 	}
 
 	resultSchoolYear, err := h.GradesRepository.GetAvailableSchoolYears(map[string]interface{}{
-		"school_id": findTeacher.SchoolsID,
+		"school_id": principal.SchoolID,
 	})
 
 	if err != nil {
@@ -263,7 +252,7 @@ func (h *Handlers) GetGradesTeacher(c *fiber.Ctx) error {
 	}
 
 	resultClass, err := h.GradesRepository.GetAvailableClasses(map[string]interface{}{
-		"school_id": findTeacher.SchoolsID,
+		"school_id": principal.SchoolID,
 	})
 
 	if err != nil {
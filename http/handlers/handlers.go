@@ -4,20 +4,27 @@ import (
 	"fmt"
 
 	"github.com/cvzamannow/E-Learning-API/middleware"
+	"github.com/cvzamannow/E-Learning-API/model"
 	"github.com/cvzamannow/E-Learning-API/repository"
 	"github.com/cvzamannow/E-Learning-API/service"
+	"github.com/gofiber/fiber/v2"
 )
 
 type Handlers struct {
 	CourseRepository repository.CourseRepository
 	UserRepository   repository.UserRepository
-	JWT_SECRET       []byte
 	Middleware       middleware.Middleware
 	SchoolRepository repository.SchoolRepository
 	QuizRepository repository.QuizRepository
 	R2Cloudflare     *service.R2Stub
 	GradesRepository repository.GradesRepository
 	CertificateRepo repository.CertificateRepository
+	// OpenRegistration enables POST /api/v1/register, users are onboarded with invitations otherwise.
+	OpenRegistration bool
+	// InvitationURL is the page of the frontend accepting invitations, the token is added as query.
+	InvitationURL string
+	// TwoFactorRoles are the roles that must log in with TOTP, the other roles may enable it.
+	TwoFactorRoles map[model.ROLE]bool
 }
 
 // This is a reusable response message
@@ -47,3 +54,23 @@ func (h *Handlers) errorParseBodyRequest() string {
 func (h *Handlers) successResponse(resource, action string) string {
 	return fmt.Sprintf("%s has been %s", resource, action)
 }
+
+// tenantHandler is a handler method, e.g. (*Handlers).AddCourse.
+type tenantHandler func(h *Handlers, c *fiber.Ctx) error
+
+// tenant runs fn with a copy of Handlers whose repositories only see the school of the caller.
+// The school is put in the request context by Middleware.Protected, so it must come after it.
+func (h *Handlers) tenant(fn tenantHandler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+
+		scoped := *h
+		scoped.CourseRepository = h.CourseRepository.WithContext(ctx)
+		scoped.UserRepository = h.UserRepository.WithContext(ctx)
+		scoped.SchoolRepository = h.SchoolRepository.WithContext(ctx)
+		scoped.QuizRepository = h.QuizRepository.WithContext(ctx)
+		scoped.GradesRepository = h.GradesRepository.WithContext(ctx)
+
+		return fn(&scoped, c)
+	}
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/cvzamannow/E-Learning-API/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultInvitationExpiry = 72 * time.Hour
+	maxInvitationExpiry     = 30 * 24 * time.Hour
+)
+
+// RouteInvitations defines the routes of the invitation based onboarding. Admins invite a user
+// into a school with a role, the invitee creates its own user with the one-time token.
+func (h *Handlers) RouteInvitations(app *fiber.App) {
+	v1 := app.Group("/api/v1")
+
+	v1.Post("/admin/invitations", h.Middleware.Protected(), h.tenant((*Handlers).CreateInvitation))
+	v1.Get("/admin/invitations", h.Middleware.Protected(), h.tenant((*Handlers).GetInvitations))
+	v1.Delete("/admin/invitations/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteInvitation))
+
+	v1.Post("/invitations/verify", h.VerifyInvitation)
+	v1.Post("/invitations/accept", h.AcceptInvitation)
+}
+
+// CreateInvitation invites a user into a school. ADMIN can only invite students and teachers
+// of its own school, SUPER_ADMIN can also invite the admins of any school.
+func (h *Handlers) CreateInvitation(c *fiber.Ctx) error {
+	var request http.Invitation
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	principal := middleware.PrincipalFrom(c)
+	isSuperAdmin := principal.IsSuperAdmin()
+
+	switch request.Role {
+	case model.STUDENT, model.TEACHER:
+	case model.ADMIN:
+		if !isSuperAdmin {
+			return c.Status(401).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: "ADMIN can only invite students and teachers",
+				Data:    nil,
+			})
+		}
+	default:
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "role must be STUDENT, TEACHER or ADMIN",
+			Data:    nil,
+		})
+	}
+
+	// ADMIN hanya bisa mengundang ke sekolahnya sendiri
+	if !isSuperAdmin && request.SchoolID == "" {
+		request.SchoolID = principal.SchoolID
+	}
+
+	if strings.TrimSpace(request.SchoolID) == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "school_id is required",
+			Data:    nil,
+		})
+	}
+
+	// Sekolah di luar tenant ADMIN tidak ditemukan
+	school, err := h.SchoolRepository.FindSchool(map[string]interface{}{
+		"id": request.SchoolID,
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("school_id"),
+			Data:    nil,
+		})
+	}
+
+	expiry := defaultInvitationExpiry
+	if request.ExpiresInHours != 0 {
+		expiry = time.Duration(request.ExpiresInHours) * time.Hour
+	}
+
+	if expiry <= 0 || expiry > maxInvitationExpiry {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "expires_in_hours must be between 1 and 720",
+			Data:    nil,
+		})
+	}
+
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generating nano ID",
+			Data:    nil,
+		})
+	}
+
+	token, err := helper.GenerateInvitationToken()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Invitation", "generate token"),
+			Data:    nil,
+		})
+	}
+
+	invitation, err := h.UserRepository.CreateInvitation(model.Invitation{
+		ID:        id,
+		SchoolID:  school.ID,
+		Role:      request.Role,
+		TokenHash: helper.HashInvitationToken(token),
+		ExpiresAt: time.Now().Add(expiry),
+		CreatedBy: principal.UserID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Invitation", "create"),
+			Data:    nil,
+		})
+	}
+
+	invitation.Schools = *school
+
+	logrus.WithFields(logrus.Fields{
+		"event":      "invitation_created",
+		"id":         invitation.ID,
+		"school_id":  invitation.SchoolID,
+		"role":       invitation.Role,
+		"created_by": principal.UserID,
+	}).Infoln("[auth] Invitation created")
+
+	// Token hanya ditampilkan sekali, yang disimpan hanya hash-nya
+	response := h.invitationResponse(*invitation)
+	response.Token = token
+	response.Link = h.invitationLink(token)
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Invitation", "created"),
+		Data:    response,
+	})
+}
+
+// GetInvitations lists the invitations of the school of the caller, every school for SUPER_ADMIN.
+func (h *Handlers) GetInvitations(c *fiber.Ctx) error {
+	invitations, err := h.UserRepository.FindInvitations(map[string]interface{}{})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Invitations", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	response := []http.InvitationHTTP{}
+	for _, invitation := range invitations {
+		response = append(response, h.invitationResponse(invitation))
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Invitations", "retrieved"),
+		Data:    response,
+	})
+}
+
+// DeleteInvitation revokes an invitation, its token can no longer be accepted.
+func (h *Handlers) DeleteInvitation(c *fiber.Ctx) error {
+	invitation, err := h.UserRepository.DeleteInvitation(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"event":      "invitation_revoked",
+		"id":         invitation.ID,
+		"revoked_by": middleware.PrincipalFrom(c).UserID,
+	}).Infoln("[auth] Invitation revoked")
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Invitation", "deleted"),
+		Data:    h.invitationResponse(*invitation),
+	})
+}
+
+// VerifyInvitation tells the invitee the school and the role of a token before it accepts it.
+func (h *Handlers) VerifyInvitation(c *fiber.Ctx) error {
+	var request http.VerifyInvitation
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	invitation, err := h.UserRepository.FindInvitationByToken(helper.HashInvitationToken(request.Token))
+	if err != nil {
+		return h.invitationErrorResponse(c, "verify", err)
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "Invitation is valid",
+		Data: http.InvitationHTTP{
+			SchoolID:   invitation.SchoolID,
+			SchoolName: invitation.Schools.Name,
+			Role:       invitation.Role,
+			ExpiresAt:  invitation.ExpiresAt,
+		},
+	})
+}
+
+// AcceptInvitation creates the user of the invitee with the role and the school of the invitation.
+// The token can only be used once.
+func (h *Handlers) AcceptInvitation(c *fiber.Ctx) error {
+	var request http.AcceptInvitation
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	request.Username = strings.TrimSpace(request.Username)
+	request.Name = strings.TrimSpace(request.Name)
+
+	if request.Token == "" || request.Username == "" || request.Name == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "token, username and name are required",
+			Data:    nil,
+		})
+	}
+
+	if err := helper.ValidatePassword(request.Password, request.Username); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generating nano ID",
+			Data:    nil,
+		})
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("password", "generate password hash"),
+			Data:    nil,
+		})
+	}
+
+	now := time.Now()
+	user := model.User{
+		ID:                id,
+		Username:          request.Username,
+		Password:          string(hash),
+		Status:            model.USER_ACTIVE,
+		PasswordChangedAt: &now,
+	}
+
+	invitation, err := h.UserRepository.AcceptInvitation(helper.HashInvitationToken(request.Token), user, request.Name, request.IdNumber)
+	if err != nil {
+		return h.invitationErrorResponse(c, "accept", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"event":     "invitation_accepted",
+		"id":        invitation.ID,
+		"user_id":   user.ID,
+		"school_id": invitation.SchoolID,
+		"role":      invitation.Role,
+	}).Infoln("[auth] Invitation accepted")
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("user", "registered"),
+		Data: map[string]interface{}{
+			"user_id":   user.ID,
+			"username":  user.Username,
+			"role":      invitation.Role,
+			"school_id": invitation.SchoolID,
+		},
+	})
+}
+
+// invitationErrorResponse responds to an error of FindInvitationByToken or AcceptInvitation.
+func (h *Handlers) invitationErrorResponse(c *fiber.Ctx, action string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrInvitationInvalid):
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Invitation is invalid, has been used or has expired!",
+			Data:    nil,
+		})
+	case errors.Is(err, repository.ErrUsernameTaken):
+		return c.Status(409).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Username is already registered!",
+			Data:    nil,
+		})
+	default:
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Invitation", action),
+			Data:    nil,
+		})
+	}
+}
+
+// invitationLink is the page of the frontend where the invitee accepts the token,
+// empty when INVITATION_URL is not set.
+func (h *Handlers) invitationLink(token string) string {
+	if h.InvitationURL == "" {
+		return ""
+	}
+
+	separator := "?"
+	if strings.Contains(h.InvitationURL, "?") {
+		separator = "&"
+	}
+
+	return h.InvitationURL + separator + "token=" + url.QueryEscape(token)
+}
+
+func (h *Handlers) invitationResponse(invitation model.Invitation) http.InvitationHTTP {
+	status := "PENDING"
+	switch {
+	case invitation.UsedAt != nil:
+		status = "USED"
+	case !invitation.ExpiresAt.After(time.Now()):
+		status = "EXPIRED"
+	}
+
+	return http.InvitationHTTP{
+		ID:           invitation.ID,
+		SchoolID:     invitation.SchoolID,
+		SchoolName:   invitation.Schools.Name,
+		Role:         invitation.Role,
+		Status:       status,
+		ExpiresAt:    invitation.ExpiresAt,
+		UsedAt:       invitation.UsedAt,
+		UsedByUserID: invitation.UsedByUserID,
+		CreatedBy:    invitation.CreatedBy,
+		CreatedAt:    invitation.CreatedAt,
+	}
+}
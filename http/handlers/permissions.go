@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"github.com/cvzamannow/E-Learning-API/middleware"
+	"github.com/cvzamannow/E-Learning-API/model"
+)
+
+var (
+	publicRoute      = []model.ROLE{}
+	anyRole          = []model.ROLE{model.SUPER_ADMIN, model.ADMIN, model.TEACHER, model.STUDENT}
+	superAdminOnly   = []model.ROLE{model.SUPER_ADMIN}
+	adminOnly        = []model.ROLE{model.ADMIN}
+	teacherOnly      = []model.ROLE{model.TEACHER}
+	studentOnly      = []model.ROLE{model.STUDENT}
+	teacherOrStudent = []model.ROLE{model.TEACHER, model.STUDENT}
+	schoolStaff      = []model.ROLE{model.SUPER_ADMIN, model.ADMIN, model.TEACHER}
+	administrators   = []model.ROLE{model.SUPER_ADMIN, model.ADMIN}
+)
+
+// PasswordChangeRoutes are the only routes a user has access to until it changed the
+// temporary password given by an admin.
+var PasswordChangeRoutes = map[string]bool{
+	"PUT /api/v1/me/password": true,
+	"POST /api/v1/logout":     true,
+	"GET /api/v1/verify":      true,
+}
+
+// TwoFactorEnrollRoutes are the only routes a user of a role requiring 2FA has access to
+// until it enabled TOTP.
+var TwoFactorEnrollRoutes = map[string]bool{
+	"GET /api/v1/me/2fa":          true,
+	"POST /api/v1/me/2fa/enroll":  true,
+	"POST /api/v1/me/2fa/confirm": true,
+	"POST /api/v1/logout":         true,
+	"GET /api/v1/verify":          true,
+}
+
+// Permissions is the permission matrix of every route of the API, it is enforced by
+// Middleware.Protected. The server refuses to start when a route is missing from it.
+var Permissions = middleware.Permissions{
+	"GET /api/v1":                publicRoute,
+	"GET /.well-known/jwks.json": publicRoute,
+
+	// Auth
+	"POST /api/v1/login":    publicRoute,
+	"POST /api/v1/refresh":  publicRoute,
+	"POST /api/v1/register": publicRoute,
+	"POST /api/v1/logout":   anyRole,
+	"GET /api/v1/verify":    anyRole,
+
+	"PUT /api/v1/me/password":                     anyRole,
+	"POST /api/v1/admin/unlock-login":             administrators,
+	"POST /api/v1/admin/users/:id/reset-password": administrators,
+
+	// Two-Factor
+	"POST /api/v1/login/2fa":                 publicRoute,
+	"GET /api/v1/me/2fa":                     anyRole,
+	"POST /api/v1/me/2fa/enroll":             anyRole,
+	"POST /api/v1/me/2fa/confirm":            anyRole,
+	"DELETE /api/v1/me/2fa":                  anyRole,
+	"POST /api/v1/admin/users/:id/reset-2fa": administrators,
+
+	// Invitations
+	"POST /api/v1/admin/invitations":       administrators,
+	"GET /api/v1/admin/invitations":        administrators,
+	"DELETE /api/v1/admin/invitations/:id": administrators,
+	"POST /api/v1/invitations/verify":      publicRoute,
+	"POST /api/v1/invitations/accept":      publicRoute,
+
+	// Courses
+	"POST /api/v1/courses":                                teacherOnly,
+	"GET /api/v1/courses":                                 teacherOrStudent,
+	"GET /api/v1/courses/detail/:id":                      teacherOrStudent,
+	"PUT /api/v1/courses/:id":                             teacherOnly,
+	"DELETE /api/v1/courses/:id":                          teacherOnly,
+	"GET /api/v1/courses/:id/teachers":                    teacherOnly,
+	"POST /api/v1/courses/:id/teachers":                   teacherOnly,
+	"DELETE /api/v1/courses/:id/teachers/:teacher_id":     teacherOnly,
+	"PUT /api/v1/courses/:id/order":                       teacherOnly,
+	"GET /api/v1/materials/:id/prerequisites":             teacherOnly,
+	"PUT /api/v1/materials/:id/prerequisites":             teacherOnly,
+	"GET /api/v1/courses/:id/versions":                    teacherOnly,
+	"POST /api/v1/courses/:id/publish":                    teacherOnly,
+	"GET /api/v1/courses/:id/versions/diff":               teacherOnly,
+	"POST /api/v1/courses/:id/versions/:version/rollback": teacherOnly,
+	"POST /api/v1/courses/:id/versions/:version/migrate":  teacherOnly,
+	"POST /api/v1/courses/:id/clone":                      teacherOnly,
+	"GET /api/v1/course-templates":                        schoolStaff,
+	"POST /api/v1/course-templates/:id/clone":             teacherOnly,
+	"POST /api/v1/super-admin/course-templates":           superAdminOnly,
+	"DELETE /api/v1/super-admin/course-templates/:id":     superAdminOnly,
+	"GET /api/v1/courses/:id/export":                      teacherOnly,
+	"POST /api/v1/courses/import":                         teacherOnly,
+	"POST /api/v1/scorm":                                  teacherOnly,
+	"GET /api/v1/scorm/:id":                               teacherOrStudent,
+	"PUT /api/v1/scorm/:id":                               teacherOnly,
+	"GET /api/v1/scorm/:id/registrations":                 teacherOnly,
+	"POST /api/v1/scorm/:id/initialize":                   studentOnly,
+	"PUT /api/v1/scorm/:id/runtime":                       studentOnly,
+	"POST /api/v1/chapters":                               teacherOnly,
+	"GET /api/v1/chapters/:id":                            teacherOrStudent,
+	"PUT /api/v1/chapters/:id":                            teacherOnly,
+	"DELETE /api/v1/chapters/:id":                         teacherOnly,
+	"POST /api/v1/theories":                               teacherOnly,
+	"GET /api/v1/theories/:id":                            teacherOrStudent,
+	"DELETE /api/v1/theories/:id":                         teacherOnly,
+	"PUT /api/v1/theories/:id":                            teacherOnly,
+	"POST /api/v1/submission":                             teacherOnly,
+	"GET /api/v1/submission/:id":                          teacherOrStudent,
+	"PUT /api/v1/submission/:id":                          teacherOnly,
+	"DELETE /api/v1/submission/:id":                       teacherOnly,
+	"GET /api/v1/submission/detail/:id":                   teacherOnly,
+
+	// Submission student
+	"POST /api/v1/submission-student":                    studentOnly,
+	"GET /api/v1/submission-student/:student_id":         teacherOrStudent,
+	"GET /api/v1/submission-student/detail/:id":          teacherOrStudent,
+	"GET /api/v1/submission-student":                     teacherOrStudent,
+	"PUT /api/v1/reset-submission":                       studentOnly,
+	"GET /api/v1/placeholder/submission-student":         teacherOnly,
+	"PUT /api/v1/submission-student/approve/:student_id": teacherOnly,
+	"PUT /api/v1/submission-student/reject/:student_id":  teacherOnly,
+	"GET /api/v1/class/student":                          studentOnly,
+	"PUT /api/v1/progress":                               studentOnly,
+	"PUT /api/v1/complete-course":                        studentOnly,
+
+	// Grades
+	"GET /api/v1/grades-student": studentOnly,
+	"GET /api/v1/grades-teacher": teacherOnly,
+
+	// Question banks
+	"POST /api/v1/question-banks":                              teacherOnly,
+	"GET /api/v1/question-banks":                               teacherOnly,
+	"GET /api/v1/question-banks/:id":                           teacherOnly,
+	"PUT /api/v1/question-banks/:id":                           teacherOnly,
+	"DELETE /api/v1/question-banks/:id":                        teacherOnly,
+	"POST /api/v1/question-banks/:id/questions":                teacherOnly,
+	"PUT /api/v1/question-banks/:id/questions/:question_id":    teacherOnly,
+	"DELETE /api/v1/question-banks/:id/questions/:question_id": teacherOnly,
+
+	// Quiz
+	"POST /api/v1/quizz/:id":                 teacherOnly,
+	"PUT /api/v1/quizz/:id":                  teacherOnly,
+	"DELETE /api/v1/quizz/:id":               teacherOnly,
+	"GET /api/v1/quizz/:id":                  teacherOrStudent,
+	"POST /api/v1/quizz/answer-student/:id":  studentOnly,
+	"GET /api/v1/quizz/attempts/:id":         studentOnly,
+	"GET /api/v1/quizz/attempt/:id":          studentOnly,
+	"POST /api/v1/quizz/start/:id":           studentOnly,
+	"GET /api/v1/quizz/analysis/:id":         teacherOnly,
+	"POST /api/v1/quizz/import/:id":          teacherOnly,
+	"GET /api/v1/quizz/export/:id":           teacherOnly,
+	"POST /api/v1/quizz/regrade/:id":         teacherOnly,
+	"PUT /api/v1/quizz/attempt/:id/override": teacherOnly,
+	"GET /api/v1/quizz/audit/:id":            teacherOnly,
+
+	// Schools
+	"POST /api/v1/super-admin/schools":       superAdminOnly,
+	"GET /api/v1/super-admin/schools":        superAdminOnly,
+	"GET /api/v1/super-admin/schools/:id":    superAdminOnly,
+	"PUT /api/v1/super-admin/schools/:id":    superAdminOnly,
+	"DELETE /api/v1/super-admin/schools/:id": superAdminOnly,
+	"GET /api/v1/classes":                    schoolStaff,
+
+	// Storage
+	"POST /api/v1/storage": anyRole,
+
+	// User management
+	"POST /api/v1/admin/students":                        adminOnly,
+	"GET /api/v1/admin/students":                         adminOnly,
+	"GET /api/v1/admin/students/:id":                     adminOnly,
+	"PUT /api/v1/admin/students/:id":                     adminOnly,
+	"DELETE /api/v1/admin/students/:id":                  adminOnly,
+	"POST /api/v1/admin/teachers":                        adminOnly,
+	"GET /api/v1/admin/teachers":                         schoolStaff,
+	"GET /api/v1/admin/teachers/:id":                     adminOnly,
+	"PUT /api/v1/admin/teachers/:id":                     adminOnly,
+	"DELETE /api/v1/admin/teachers/:id":                  adminOnly,
+	"GET /api/v1/placeholder/teachers":                   studentOnly,
+	"POST /api/v1/admin/active-students":                 adminOnly,
+	"GET /api/v1/admin/active-students":                  adminOnly,
+	"GET /api/v1/admin/active-students/:id":              adminOnly,
+	"PUT /api/v1/admin/active-students/:id":              adminOnly,
+	"DELETE /api/v1/admin/active-students/:id":           adminOnly,
+	"POST /api/v1/admin/super-admin/admin-schools":       superAdminOnly,
+	"GET /api/v1/admin/super-admin/admin-schools":        superAdminOnly,
+	"GET /api/v1/admin/super-admin/admin-schools/:id":    superAdminOnly,
+	"PUT /api/v1/admin/super-admin/admin-schools/:id":    superAdminOnly,
+	"DELETE /api/v1/admin/super-admin/admin-schools/:id": superAdminOnly,
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPermissionsCoverEveryRoute(t *testing.T) {
+	app := fiber.New()
+	(&Handlers{}).Routes(app)
+
+	if err := Permissions.Check(app.GetRoutes(true)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Roles are only checked inside Middleware.Protected, a route with roles registered
+// without it is open to anyone.
+func TestRoutesWithRolesAreProtected(t *testing.T) {
+	h := &Handlers{}
+	app := fiber.New()
+	h.Routes(app)
+
+	// Setiap handler dari Protected adalah closure yang sama dari jwtware
+	protected := reflect.ValueOf(h.Middleware.Protected()).Pointer()
+
+	for _, route := range app.GetRoutes(true) {
+		roles, _ := Permissions.Roles(route.Method, route.Path)
+		if len(roles) == 0 {
+			continue
+		}
+
+		isProtected := false
+		for _, handler := range route.Handlers {
+			if reflect.ValueOf(handler).Pointer() == protected {
+				isProtected = true
+			}
+		}
+
+		if !isProtected {
+			t.Errorf("%s %s has roles %v but is registered without Middleware.Protected", route.Method, route.Path, roles)
+		}
+	}
+}
@@ -0,0 +1,623 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/cvzamannow/E-Learning-API/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handlers) RouterQuestionBank(app *fiber.App) {
+	v1 := app.Group("/api/v1")
+	v1.Post("/question-banks", h.Middleware.Protected(), h.tenant((*Handlers).CreateQuestionBankHandler))
+	v1.Get("/question-banks", h.Middleware.Protected(), h.tenant((*Handlers).GetQuestionBanksHandler))
+	v1.Get("/question-banks/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetQuestionBankHandler))
+	v1.Put("/question-banks/:id", h.Middleware.Protected(), h.tenant((*Handlers).UpdateQuestionBankHandler))
+	v1.Delete("/question-banks/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteQuestionBankHandler))
+
+	// question bank questions
+	v1.Post("/question-banks/:id/questions", h.Middleware.Protected(), h.tenant((*Handlers).CreateBankQuestionHandler))
+	v1.Put("/question-banks/:id/questions/:question_id", h.Middleware.Protected(), h.tenant((*Handlers).UpdateBankQuestionHandler))
+	v1.Delete("/question-banks/:id/questions/:question_id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteBankQuestionHandler))
+}
+
+// CreateQuestionBankHandler creates a question bank for a course, optionally for one of its chapters.
+func (h *Handlers) CreateQuestionBankHandler(c *fiber.Ctx) error {
+	var request http.QuestionBank
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	if strings.TrimSpace(request.Title) == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "title is required",
+			Data:    nil,
+		})
+	}
+
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, request.CourseID); err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	course, err := h.CourseRepository.FindCourse(map[string]interface{}{
+		"id": request.CourseID,
+	}, false, "")
+	if err != nil || course.ID == "" {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("course_id"),
+			Data:    nil,
+		})
+	}
+
+	// Chapter harus milik course bank soal
+	if request.ChapterID != nil && *request.ChapterID != "" {
+		if _, err := h.CourseRepository.FindChapter(map[string]interface{}{
+			"id":        *request.ChapterID,
+			"course_id": course.ID,
+		}); err != nil {
+			return c.Status(404).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorSpecifyResource("chapter_id"),
+				Data:    nil,
+			})
+		}
+	} else {
+		request.ChapterID = nil
+	}
+
+	for _, question := range request.Questions {
+		if err := h.validateBankQuestion(question); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			})
+		}
+	}
+
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generating nano ID",
+			Data:    nil,
+		})
+	}
+
+	result, err := h.QuizRepository.CreateQuestionBank(model.QuestionBank{
+		ID:          id,
+		CourseID:    course.ID,
+		ChapterID:   request.ChapterID,
+		Title:       request.Title,
+		Description: request.Description,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Question Bank", "create"),
+			Data:    nil,
+		})
+	}
+
+	response := h.questionBankResponse(*result)
+	for _, question := range request.Questions {
+		created, err := h.createBankQuestion(result.ID, question)
+		if err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorInternal("Question Bank Questions", "create"),
+				Data:    nil,
+			})
+		}
+
+		response.Questions = append(response.Questions, h.bankQuestionResponse(*created))
+	}
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Question Bank", "created"),
+		Data:    response,
+	})
+}
+
+// GetQuestionBanksHandler lists the question banks of a course, optionally of one chapter.
+func (h *Handlers) GetQuestionBanksHandler(c *fiber.Ctx) error {
+	courseID := c.Query("course_id")
+	if courseID == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Must specify 'course_id' query!",
+			Data:    nil,
+		})
+	}
+
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_COURSE, courseID); err != nil {
+		return h.courseAccessError(c, "course", err)
+	}
+
+	codd := map[string]interface{}{
+		"course_id": courseID,
+	}
+	if chapterID := c.Query("chapter_id"); chapterID != "" {
+		codd["chapter_id"] = chapterID
+	}
+
+	result, err := h.QuizRepository.GetQuestionBanks(codd)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Question Bank", "find"),
+			Data:    nil,
+		})
+	}
+
+	response := []http.QuestionBank{}
+	for _, bank := range *result {
+		response = append(response, h.questionBankResponse(bank))
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "Get question banks successfully",
+		Data:    response,
+	})
+}
+
+// GetQuestionBankHandler shows a question bank with its questions, filterable by topic and difficulty.
+func (h *Handlers) GetQuestionBankHandler(c *fiber.Ctx) error {
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUESTION_BANK, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Question Bank", err)
+	}
+
+	bank, err := h.QuizRepository.FindQuestionBank(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	codd := map[string]interface{}{
+		"question_bank_id": bank.ID,
+	}
+	if topic := c.Query("topic"); topic != "" {
+		codd["topic"] = topic
+	}
+	if difficulty := c.Query("difficulty"); difficulty != "" {
+		codd["difficulty"] = strings.ToUpper(difficulty)
+	}
+
+	questions, err := h.QuizRepository.FindBankQuestions(codd)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Question Bank Questions", "find"),
+			Data:    nil,
+		})
+	}
+
+	response := h.questionBankResponse(*bank)
+	response.Questions = []http.QuizData{}
+	for _, question := range *questions {
+		response.Questions = append(response.Questions, h.bankQuestionResponse(question))
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "Get question bank successfully",
+		Data:    response,
+	})
+}
+
+// UpdateQuestionBankHandler updates the title and description of a question bank.
+func (h *Handlers) UpdateQuestionBankHandler(c *fiber.Ctx) error {
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUESTION_BANK, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Question Bank", err)
+	}
+
+	var request http.QuestionBank
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	if strings.TrimSpace(request.Title) == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "title is required",
+			Data:    nil,
+		})
+	}
+
+	bank, err := h.QuizRepository.FindQuestionBank(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	if _, err := h.QuizRepository.UpdateQuestionBank(bank.ID, model.QuestionBank{
+		Title:       request.Title,
+		Description: request.Description,
+	}); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Question Bank", "update"),
+			Data:    nil,
+		})
+	}
+
+	bank.Title = request.Title
+	bank.Description = request.Description
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Question Bank", "updated"),
+		Data:    h.questionBankResponse(*bank),
+	})
+}
+
+// DeleteQuestionBankHandler deletes a question bank, its questions and the draw rules using it.
+// Attempts that already got its questions keep them.
+func (h *Handlers) DeleteQuestionBankHandler(c *fiber.Ctx) error {
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUESTION_BANK, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Question Bank", err)
+	}
+
+	bank, err := h.QuizRepository.FindQuestionBank(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	if err := h.QuizRepository.DeleteQuestionBank(bank.ID); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Question Bank", "delete"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Question Bank", "deleted"),
+		Data:    nil,
+	})
+}
+
+// CreateBankQuestionHandler adds a question to a question bank.
+func (h *Handlers) CreateBankQuestionHandler(c *fiber.Ctx) error {
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUESTION_BANK, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Question Bank", err)
+	}
+
+	var request http.QuizData
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	bank, err := h.QuizRepository.FindQuestionBank(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	if err := h.validateBankQuestion(request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
+	result, err := h.createBankQuestion(bank.ID, request)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Question Bank Questions", "create"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Question Bank Question", "created"),
+		Data:    h.bankQuestionResponse(*result),
+	})
+}
+
+// UpdateBankQuestionHandler updates a question of a question bank. Answers with an id are
+// updated, answers without id are added and answers marked delete are removed.
+func (h *Handlers) UpdateBankQuestionHandler(c *fiber.Ctx) error {
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUESTION_BANK, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Question Bank", err)
+	}
+
+	var request http.QuizData
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	question, err := h.QuizRepository.FindQuizes(map[string]interface{}{
+		"id":               c.Params("question_id"),
+		"question_bank_id": c.Params("id"),
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("question_id"),
+			Data:    nil,
+		})
+	}
+
+	if err := h.validateBankQuestion(request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
+	updated := h.quizQuestion(request)
+	updated.Topic = request.Topic
+	updated.Difficulty = model.QUESTION_DIFFICULTY(strings.ToUpper(request.Difficulty))
+	if request.ImgURL != nil {
+		updated.ImgURL = *request.ImgURL
+	}
+	updated.QuizAnswers = nil
+
+	if _, err := h.QuizRepository.UpdateQuizes(map[string]interface{}{
+		"id": question.ID,
+	}, updated); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Question Bank Questions", "update"),
+			Data:    nil,
+		})
+	}
+
+	for position, answer := range request.Answers {
+		if answer.Delete != nil && *answer.Delete {
+			err = h.QuizRepository.DeleteQuizAnswer(map[string]interface{}{
+				"id":        answer.ID,
+				"quizes_id": question.ID,
+			})
+		} else if answer.ID != "" {
+			_, err = h.QuizRepository.UpdateQuizAnswer(map[string]interface{}{
+				"id":        answer.ID,
+				"quizes_id": question.ID,
+			}, model.QuizAnswer{
+				Answer:    answer.Answer,
+				IsCorrect: answer.IsCorrect,
+				Tolerance: answer.Tolerance,
+				Position:  position,
+			})
+		} else {
+			err = h.createBankQuestionAnswer(question.ID, answer, position)
+		}
+
+		if err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorInternal("Quiz Answers", "update"),
+				Data:    nil,
+			})
+		}
+	}
+
+	result, err := h.QuizRepository.FindBankQuestions(map[string]interface{}{
+		"id": question.ID,
+	})
+	if err != nil || len(*result) == 0 {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Question Bank Questions", "find"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Question Bank Question", "updated"),
+		Data:    h.bankQuestionResponse((*result)[0]),
+	})
+}
+
+// DeleteBankQuestionHandler removes a question from a question bank.
+// Attempts that already got the question keep it.
+func (h *Handlers) DeleteBankQuestionHandler(c *fiber.Ctx) error {
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUESTION_BANK, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Question Bank", err)
+	}
+
+	question, err := h.QuizRepository.FindQuizes(map[string]interface{}{
+		"id":               c.Params("question_id"),
+		"question_bank_id": c.Params("id"),
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("question_id"),
+			Data:    nil,
+		})
+	}
+
+	if err := h.QuizRepository.DeleteQuizAnswer(map[string]interface{}{
+		"quizes_id": question.ID,
+	}); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz Answers", "delete"),
+			Data:    nil,
+		})
+	}
+
+	if err := h.QuizRepository.DeleteQuizes(map[string]interface{}{
+		"id": question.ID,
+	}); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Question Bank Questions", "delete"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Question Bank Question", "deleted"),
+		Data:    nil,
+	})
+}
+
+// validateBankQuestion checks a bank question like a quiz question, plus its difficulty.
+func (h *Handlers) validateBankQuestion(request http.QuizData) error {
+	if strings.TrimSpace(request.Quiz) == "" {
+		return errors.New("quiz is required")
+	}
+
+	if !helper.ValidQuestionDifficulty(model.QUESTION_DIFFICULTY(strings.ToUpper(request.Difficulty))) {
+		return errors.New("difficulty must be EASY, MEDIUM or HARD")
+	}
+
+	return helper.ValidateQuizQuestion(h.quizQuestion(request))
+}
+
+// createBankQuestion creates a question of a question bank with its answers.
+func (h *Handlers) createBankQuestion(bankID string, request http.QuizData) (*model.Quizes, error) {
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return nil, err
+	}
+
+	question := h.quizQuestion(request)
+	question.ID = id
+	question.QuestionBankID = &bankID
+	question.Topic = request.Topic
+	question.Difficulty = model.QUESTION_DIFFICULTY(strings.ToUpper(request.Difficulty))
+	if request.ImgURL != nil {
+		question.ImgURL = *request.ImgURL
+	}
+	question.QuizAnswers = nil
+
+	result, err := h.QuizRepository.CreateQuizes(question)
+	if err != nil {
+		return nil, err
+	}
+
+	for position, answer := range request.Answers {
+		if answer.Delete != nil && *answer.Delete {
+			continue
+		}
+
+		if err := h.createBankQuestionAnswer(result.ID, answer, position); err != nil {
+			return nil, err
+		}
+	}
+
+	questions, err := h.QuizRepository.FindBankQuestions(map[string]interface{}{
+		"id": result.ID,
+	})
+	if err != nil || len(*questions) == 0 {
+		return result, err
+	}
+
+	return &(*questions)[0], nil
+}
+
+// createBankQuestionAnswer creates an answer of a bank question.
+func (h *Handlers) createBankQuestionAnswer(questionID string, answer http.QuizAnswer, position int) error {
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return err
+	}
+
+	_, err = h.QuizRepository.CreateQuizAnswer(model.QuizAnswer{
+		ID:        id,
+		Answer:    answer.Answer,
+		QuizesID:  questionID,
+		IsCorrect: answer.IsCorrect,
+		Tolerance: answer.Tolerance,
+		Position:  position,
+	})
+
+	return err
+}
+
+// questionBankResponse maps a question bank without its questions.
+func (h *Handlers) questionBankResponse(bank model.QuestionBank) http.QuestionBank {
+	return http.QuestionBank{
+		ID:          bank.ID,
+		CourseID:    bank.CourseID,
+		ChapterID:   bank.ChapterID,
+		Title:       bank.Title,
+		Description: bank.Description,
+	}
+}
+
+// bankQuestionResponse maps a bank question with its answer key for the teacher.
+func (h *Handlers) bankQuestionResponse(question model.Quizes) http.QuizData {
+	questionType := string(question.Type)
+	points := question.Points
+	imgURL := question.ImgURL
+
+	response := http.QuizData{
+		ID:         question.ID,
+		Quiz:       question.Quiz,
+		ImgURL:     &imgURL,
+		Type:       &questionType,
+		Points:     &points,
+		Topic:      question.Topic,
+		Difficulty: string(question.Difficulty),
+		Answers:    []http.QuizAnswer{},
+	}
+
+	for _, answer := range helper.OrderedQuizAnswers(question) {
+		response.Answers = append(response.Answers, http.QuizAnswer{
+			ID:        answer.ID,
+			Answer:    answer.Answer,
+			QuizesID:  question.ID,
+			IsCorrect: answer.IsCorrect,
+			Tolerance: answer.Tolerance,
+		})
+	}
+
+	return response
+}
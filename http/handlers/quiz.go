@@ -1,44 +1,63 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/cvzamannow/E-Learning-API/helper"
 	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
 	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/cvzamannow/E-Learning-API/repository"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gosimple/slug"
-	"github.com/sirupsen/logrus"
 )
 
 func (h *Handlers) RouterQuiz(app *fiber.App) {
 	v1 := app.Group("/api/v1")
-	v1.Post("/quizz/:id", h.Middleware.Protected(), h.CreateQuizHandler)
-	v1.Put("/quizz/:id", h.Middleware.Protected(), h.UpdateQuizHandler)
-	v1.Delete("/quizz/:id", h.Middleware.Protected(), h.DeleteQuizHandler)
-	v1.Get("/quizz/:id", h.Middleware.Protected(), h.GetDetailQuizByIdHandler)
+	v1.Post("/quizz/:id", h.Middleware.Protected(), h.tenant((*Handlers).CreateQuizHandler))
+	v1.Put("/quizz/:id", h.Middleware.Protected(), h.tenant((*Handlers).UpdateQuizHandler))
+	v1.Delete("/quizz/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteQuizHandler))
+	v1.Get("/quizz/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetDetailQuizByIdHandler))
 
 	// quiz answer
-	v1.Post("/quizz/answer-student/:id", h.Middleware.Protected(), h.CreateQuizAnswerHandler)
+	v1.Post("/quizz/answer-student/:id", h.Middleware.Protected(), h.tenant((*Handlers).CreateQuizAnswerHandler))
+	v1.Get("/quizz/attempts/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetQuizAttemptsHandler))
+	v1.Get("/quizz/attempt/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetQuizAttemptHandler))
+	v1.Post("/quizz/start/:id", h.Middleware.Protected(), h.tenant((*Handlers).StartQuizHandler))
+
+	// quiz item analysis
+	v1.Get("/quizz/analysis/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetQuizAnalysisHandler))
+
+	// quiz import & export
+	v1.Post("/quizz/import/:id", h.Middleware.Protected(), h.tenant((*Handlers).ImportQuizHandler))
+	v1.Get("/quizz/export/:id", h.Middleware.Protected(), h.tenant((*Handlers).ExportQuizHandler))
+
+	// regrade & manual override
+	v1.Post("/quizz/regrade/:id", h.Middleware.Protected(), h.tenant((*Handlers).RegradeQuizHandler))
+	v1.Put("/quizz/attempt/:id/override", h.Middleware.Protected(), h.tenant((*Handlers).OverrideQuizAttemptHandler))
+	v1.Get("/quizz/audit/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetQuizGradeAuditsHandler))
 }
 
 // CreateQuizHandler handles HTTP request to create a quiz.
 func (h *Handlers) CreateQuizHandler(c *fiber.Ctx) error {
 	id := c.Params("id")
+
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_CHAPTER, id); err != nil {
+		return h.courseAccessError(c, "chapter", err)
+	}
+
 	chapter, err := h.CourseRepository.FindChapter(map[string]interface{}{
 		"id": id,
 	})
 
 	var quizzDataResponse []http.QuizData
 
-	role := c.Locals("role").(string)
-
-	if role != "TEACHER" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	if err != nil {
 		return c.Status(404).JSON(&http.WebResponse{
 			Status:  "error",
@@ -56,6 +75,32 @@ func (h *Handlers) CreateQuizHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	settings, err := h.quizSettings(request, nil)
+	if err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
+	if err := h.validateQuizQuestions(request.Quizes); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
+	drawRules, err := h.quizDrawRules(chapter.CourseID, request.DrawRules)
+	if err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
 	materialID, err := helper.GenerateNanoId()
 	if err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
@@ -66,10 +111,19 @@ func (h *Handlers) CreateQuizHandler(c *fiber.Ctx) error {
 	}
 	result, err := h.QuizRepository.CreateQuiz(
 		model.Quiz{
-			ID:          string(materialID),
-			Title:       request.Title,
-			Description: request.Description,
-			ChapterID:   string(chapter.ID),
+			ID:               string(materialID),
+			Title:            request.Title,
+			Description:      request.Description,
+			ChapterID:        string(chapter.ID),
+			MaxAttempts:      settings.MaxAttempts,
+			ScoringPolicy:    settings.ScoringPolicy,
+			TimeLimit:        settings.TimeLimit,
+			OpenAt:           settings.OpenAt,
+			CloseAt:          settings.CloseAt,
+			LateSubmission:   settings.LateSubmission,
+			ShuffleQuestions: settings.ShuffleQuestions,
+			ShuffleAnswers:   settings.ShuffleAnswers,
+			ReviewPolicy:     settings.ReviewPolicy,
 			Material: model.Material{
 				ID:        string(materialID),
 				ChapterID: chapter.ID,
@@ -87,6 +141,20 @@ func (h *Handlers) CreateQuizHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if len(drawRules) > 0 {
+		for i := range drawRules {
+			drawRules[i].QuizID = result.ID
+		}
+
+		if err := h.QuizRepository.ReplaceQuizDrawRules(result.ID, drawRules); err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorInternal("Quiz Draw Rules", "create"),
+				Data:    nil,
+			})
+		}
+	}
+
 	for _, quizes := range request.Quizes {
 		quizesID, err := helper.GenerateNanoId()
 		if err != nil {
@@ -98,12 +166,15 @@ func (h *Handlers) CreateQuizHandler(c *fiber.Ctx) error {
 		}
 
 		QuizAnswerResponse := []http.QuizAnswer{}
+		question := h.quizQuestion(quizes)
 
 		quizzData, err := h.QuizRepository.CreateQuizes(model.Quizes{
 			ID:     string(quizesID),
 			Quiz:   quizes.Quiz,
 			QuizID: string(result.ID),
 			ImgURL: *quizes.ImgURL,
+			Type:   question.Type,
+			Points: question.Points,
 		})
 		if err != nil {
 			return c.Status(500).JSON(&http.WebResponse{
@@ -113,7 +184,7 @@ func (h *Handlers) CreateQuizHandler(c *fiber.Ctx) error {
 			})
 		}
 
-		for _, answer := range quizes.Answers {
+		for position, answer := range quizes.Answers {
 			answerID, err := helper.GenerateNanoId()
 			if err != nil {
 				return c.Status(500).JSON(&http.WebResponse{
@@ -128,6 +199,8 @@ func (h *Handlers) CreateQuizHandler(c *fiber.Ctx) error {
 				Answer:    answer.Answer,
 				QuizesID:  string(quizesID),
 				IsCorrect: answer.IsCorrect,
+				Tolerance: answer.Tolerance,
+				Position:  position,
 			})
 
 			if err != nil {
@@ -152,15 +225,20 @@ func (h *Handlers) CreateQuizHandler(c *fiber.Ctx) error {
 					Answer:    quizzAnswer.Answer,
 					QuizesID:  string(quizesID),
 					IsCorrect: quizzAnswer.IsCorrect,
+					Tolerance: quizzAnswer.Tolerance,
 				})
 			}
 		}
 
+		questionType := string(question.Type)
+
 		quizzDataResponse = append(quizzDataResponse, http.QuizData{
 			ID:      quizzData.ID,
 			Quiz:    quizzData.Quiz,
 			QuizID:  string(result.ID),
 			ImgURL:  &quizzData.ImgURL,
+			Type:    &questionType,
+			Points:  &question.Points,
 			Answers: QuizAnswerResponse,
 		})
 	}
@@ -173,8 +251,10 @@ func (h *Handlers) CreateQuizHandler(c *fiber.Ctx) error {
 		Description: request.Description,
 		ChapterID:   id,
 		Type:        &typeOfMaterial,
+		DrawRules:   h.quizDrawRulesResponse(drawRules),
 		Quizes:      quizzDataResponse,
 	}
+	h.quizSettingsResponse(&response, settings)
 
 	return c.Status(200).JSON(&http.WebResponse{
 		Status:  "success",
@@ -186,20 +266,13 @@ func (h *Handlers) CreateQuizHandler(c *fiber.Ctx) error {
 // UpdateQuizHandler menangani permintaan HTTP untuk memperbarui sebuah kuis.
 func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 	// Ambil peran pengguna dari konteks lokal
-	role := c.Locals("role").(string)
-
-	// Cek apakah pengguna adalah TEACHER, jika bukan kembalikan status 401 Unauthorized
-	if role != "TEACHER" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	// Ambil ID kuis dari parameter URL
 	id := c.Params("id")
 
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_QUIZ, id); err != nil {
+		return h.courseAccessError(c, "Quiz", err)
+	}
+
 	// Parse tubuh permintaan menjadi struktur http.Quiz
 	var request http.Quiz
 	if err := c.BodyParser(&request); err != nil {
@@ -219,6 +292,60 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	currentQuiz, err := h.QuizRepository.FindQuiz(map[string]interface{}{
+		"id": id,
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	// Validasi pengaturan attempt dan waktu quiz
+	settings, err := h.quizSettings(request, currentQuiz)
+	if err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
+	// Validasi setiap soal sesuai type nya sebelum ada yang disimpan
+	if err := h.validateQuizQuestions(request.Quizes); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
+	// Draw rules hanya diganti jika dikirim, list kosong menghapus semua draw rules
+	var drawRules []model.QuizDrawRule
+	if request.DrawRules != nil {
+		quizChapter, err := h.CourseRepository.FindChapter(map[string]interface{}{
+			"id": currentQuiz.ChapterID,
+		})
+		if err != nil {
+			return c.Status(404).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorSpecifyResource("chapter_id"),
+				Data:    nil,
+			})
+		}
+
+		drawRules, err = h.quizDrawRules(quizChapter.CourseID, request.DrawRules)
+		if err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			})
+		}
+	}
+
 	// Perbarui kuis di repository
 	result, err := h.QuizRepository.UpdateQuiz(
 		map[string]interface{}{
@@ -242,6 +369,31 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	err = h.QuizRepository.UpdateQuizSettings(id, *settings)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz", "update"),
+			Data:    nil,
+		})
+	}
+
+	if request.DrawRules != nil {
+		for i := range drawRules {
+			drawRules[i].QuizID = id
+		}
+
+		if err := h.QuizRepository.ReplaceQuizDrawRules(id, drawRules); err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorInternal("Quiz Draw Rules", "update"),
+				Data:    nil,
+			})
+		}
+	} else if currentRules, err := h.QuizRepository.GetQuizDrawRules(id); err == nil {
+		drawRules = *currentRules
+	}
+
 	// Jika kuis dalam permintaan kosong, hapus semua kuis terkait
 	if request.Quizes == nil || len(request.Quizes) == 0 {
 		findQuizes, err := h.QuizRepository.GetQuizesByIdQuiz(id)
@@ -329,7 +481,9 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 		CourseID:    findChapterByID.CourseID,
 		Description: result.Description,
 		Type:        &typeQuiz,
+		DrawRules:   h.quizDrawRulesResponse(drawRules),
 	}
+	h.quizSettingsResponse(&response, settings)
 
 	response.Quizes = make([]http.QuizData, len(request.Quizes))
 
@@ -344,6 +498,8 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 			})
 		}
 
+		question := h.quizQuestion(quizes)
+
 		_, err := h.QuizRepository.UpdateQuizes(
 			map[string]interface{}{
 				"id": quizes.ID,
@@ -351,6 +507,8 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 			model.Quizes{
 				Quiz:   quizes.Quiz,
 				ImgURL: *quizes.ImgURL,
+				Type:   question.Type,
+				Points: question.Points,
 			},
 		)
 		if err != nil {
@@ -399,6 +557,9 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 			response.Quizes[i].Quiz = quizes.Quiz
 			response.Quizes[i].QuizID = quizes.QuizID
 			response.Quizes[i].ImgURL = quizes.ImgURL
+			questionType := string(question.Type)
+			response.Quizes[i].Type = &questionType
+			response.Quizes[i].Points = &question.Points
 		}
 		response.Quizes[i].Answers = make([]http.QuizAnswer, len(quizes.Answers))
 
@@ -442,6 +603,8 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 							ID:     string(idQuizes),
 							Quiz:   quizes.Quiz,
 							QuizID: string(id),
+							Type:   question.Type,
+							Points: question.Points,
 						})
 
 						if err != nil {
@@ -468,6 +631,8 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 							Answer:    answer.Answer,
 							QuizesID:  resultQuizID.ID,
 							IsCorrect: answer.IsCorrect,
+							Tolerance: answer.Tolerance,
+							Position:  j,
 						})
 
 						if err != nil {
@@ -500,6 +665,8 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 							Answer:    answer.Answer,
 							QuizesID:  response.Quizes[i].Answers[j].QuizesID,
 							IsCorrect: answer.IsCorrect,
+							Tolerance: answer.Tolerance,
+							Position:  j,
 						})
 
 						if err != nil {
@@ -554,6 +721,8 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 						Answer:    answer.Answer,
 						QuizesID:  findQuizes.ID,
 						IsCorrect: answer.IsCorrect,
+						Tolerance: answer.Tolerance,
+						Position:  j,
 					})
 
 					if err != nil {
@@ -608,6 +777,8 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 					model.QuizAnswer{
 						Answer:    answer.Answer,
 						IsCorrect: answer.IsCorrect,
+						Tolerance: answer.Tolerance,
+						Position:  j,
 					},
 				)
 
@@ -626,6 +797,8 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 						Answer:    answer.Answer,
 						QuizesID:  quizes.ID,
 						IsCorrect: answer.IsCorrect,
+						Tolerance: answer.Tolerance,
+						Position:  j,
 					})
 					response.Quizes[i].Answers[j].ID = result.ID
 				}
@@ -643,6 +816,7 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 				}
 				response.Quizes[i].Answers[j].Answer = answer.Answer
 				response.Quizes[i].Answers[j].IsCorrect = answer.IsCorrect
+				response.Quizes[i].Answers[j].Tolerance = answer.Tolerance
 
 			}
 		}
@@ -661,18 +835,12 @@ func (h *Handlers) UpdateQuizHandler(c *fiber.Ctx) error {
 
 // DeleteQuizHandler handles HTTP request to delete a quiz.
 func (h *Handlers) DeleteQuizHandler(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
+	id := c.Params("id")
 
-	if role != "TEACHER" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_QUIZ, id); err != nil {
+		return h.courseAccessError(c, "Quiz", err)
 	}
 
-	id := c.Params("id")
-
 	err := h.QuizRepository.DeleteQuiz(map[string]interface{}{
 		"id": id,
 	})
@@ -707,11 +875,7 @@ func (h *Handlers) DeleteQuizHandler(c *fiber.Ctx) error {
 
 // GetDetailQuizBySlugHandler handles HTTP request to get detailed quiz information by slug.
 func (h *Handlers) GetDetailQuizByIdHandler(c *fiber.Ctx) error {
-	USERID := c.Locals("user_id").(string)
-
-	findStudentActuveStudent := h.UserRepository.FindActiveStudentNoError(map[string]interface{}{
-		"student_id": USERID,
-	})
+	principal := middleware.PrincipalFrom(c)
 
 	// declare struct response
 	var quizesResponse []http.QuizesResponseHTTP
@@ -731,19 +895,24 @@ func (h *Handlers) GetDetailQuizByIdHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// find quiz answer student
-	resultAnswerStudent, err := h.QuizRepository.FindQuizAnswerStudent(map[string]interface{}{
-		"quiz_id": resultQuiz.ID,
-	})
+	if err := h.authorizeStudentMaterial(c, resultQuiz.MaterialID); err != nil {
+		return h.courseAccessError(c, "quiz", err)
+	}
 
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "Error get quiz answer student",
-			Data:    nil,
-		})
+	// find latest attempt student, nilai yang tercatat mengikuti scoring policy quiz
+	var latestAttempt *model.QuizAttempt
+	var recordedAttempt *model.QuizAttempt
+	var attemptsUsed int
+	if principal.ActiveStudentID != "" {
+		latestAttempt, _ = h.QuizRepository.FindLatestQuizAttempt(resultQuiz.ID, principal.ActiveStudentID)
+		recordedAttempt, _ = h.QuizRepository.FindRecordedQuizAttempt(resultQuiz.ID, principal.ActiveStudentID)
+
+		attemptsUsed = h.quizAttemptsUsed(resultQuiz.ID, principal.ActiveStudentID)
 	}
 
+	// Kunci jawaban selalu tampil untuk teacher, student mengikuti review policy quiz
+	showAnswerKey := principal.Role == model.TEACHER || helper.CanReviewQuiz(*resultQuiz, latestAttempt != nil, attemptsUsed, time.Now())
+
 	// find quizes by id quiz
 	resultQuizes, err := h.QuizRepository.GetQuizesByIdQuiz(resultQuiz.ID)
 	if err != nil {
@@ -758,78 +927,66 @@ func (h *Handlers) GetDetailQuizByIdHandler(c *fiber.Ctx) error {
 
 		// declare struct response for each loop
 		var quizAnswerResponse []http.QuizAnswerHTTP
-		var answerQuizStudent []http.AnswerStuedntResponseHTTP
-
-		// find Answer
-		resultAnswerStudentItem, err := h.QuizRepository.GetQuizAnswerStudentByIdQuiz(id)
+		answerQuizStudent := []http.AnswerStuedntResponseHTTP{}
 
-		if err != nil {
-			return c.Status(500).JSON(&http.WebResponse{
-				Status:  "error",
-				Message: "Error get quiz answer student",
-				Data:    nil,
+		// item ORDERING tidak boleh tampil sesuai urutan jawaban yang benar
+		answers := item.QuizAnswers
+		if item.Type == model.ORDERING {
+			sort.Slice(answers, func(i, j int) bool {
+				return answers[i].ID < answers[j].ID
 			})
 		}
 
+		// jawaban yang diterima soal SHORT_ANSWER dan NUMERIC adalah kunci jawaban, bukan pilihan
+		if !showAnswerKey && (item.Type == model.SHORT_ANSWER || item.Type == model.NUMERIC) {
+			answers = nil
+		}
+
 		// untuk response answer nya
-		for _, answer := range item.QuizAnswers {
-			if answer.QuizesID == item.ID {
-				quizAnswerResponse = append(quizAnswerResponse, http.QuizAnswerHTTP{
-					ID:        answer.ID,
-					Answer:    answer.Answer,
-					IsCorrect: answer.IsCorrect,
-					CreatedAt: &answer.CreatedAt,
-					UpdatedAt: &answer.UpdatedAt,
-				})
+		for _, answer := range answers {
+			answerResponse := http.QuizAnswerHTTP{
+				ID:        answer.ID,
+				Answer:    answer.Answer,
+				CreatedAt: &answer.CreatedAt,
+				UpdatedAt: &answer.UpdatedAt,
 			}
+			if showAnswerKey {
+				isCorrect := answer.IsCorrect
+				answerResponse.IsCorrect = &isCorrect
+			}
+			quizAnswerResponse = append(quizAnswerResponse, answerResponse)
+		}
 
-			if findStudentActuveStudent != nil {
-
-				for _, answerStudent := range *resultAnswerStudentItem {
-					if answerStudent.QuizesID == item.ID {
-						if (len(answerQuizStudent) != 1) &&
-							(answerStudent.QuizAnswer.Answer == answer.Answer) && (answerStudent.ActiveStudentID == findStudentActuveStudent.ID) {
-							answerQuizStudent = append(
-								answerQuizStudent,
-								http.AnswerStuedntResponseHTTP{
-									ID:        answerStudent.ID,
-									Name:      answerStudent.ActiveStudent.Student.Name,
-									Answer:    answerStudent.QuizAnswer.Answer,
-									CreatedAt: &answerStudent.CreatedAt,
-									UpdatedAt: &answerStudent.UpdatedAt,
-								},
-							)
-						}
-					}
+		// jawaban student diambil dari attempt terakhir
+		if latestAttempt != nil {
+			for _, answerStudent := range latestAttempt.Responses {
+				if answerStudent.QuizesID == item.ID {
+					answerQuizStudent = append(
+						answerQuizStudent,
+						http.AnswerStuedntResponseHTTP{
+							ID:        answerStudent.ID,
+							Name:      principal.Name,
+							Answer:    h.quizStudentAnswerText(item, answerStudent),
+							CreatedAt: &answerStudent.CreatedAt,
+							UpdatedAt: &answerStudent.UpdatedAt,
+						},
+					)
 				}
-
 			}
-
 		}
 
-		if findStudentActuveStudent != nil {
-			// untuk response quizes nya
-			quizesResponse = append(quizesResponse, http.QuizesResponseHTTP{
-				ID:            item.ID,
-				Quiz:          item.Quiz,
-				Answer:        quizAnswerResponse,
-				AnswerStudent: answerQuizStudent,
-				ImgURL:        &item.ImgURL,
-				CreatedAt:     &item.CreatedAt,
-				UpdatedAt:     &item.UpdatedAt,
-			})
-		} else {
-			// untuk response quizes nya
-			quizesResponse = append(quizesResponse, http.QuizesResponseHTTP{
-				ID:            item.ID,
-				Quiz:          item.Quiz,
-				Answer:        quizAnswerResponse,
-				ImgURL:        &item.ImgURL,
-				AnswerStudent: []http.AnswerStuedntResponseHTTP{},
-				CreatedAt:     &item.CreatedAt,
-				UpdatedAt:     &item.UpdatedAt,
-			})
-		}
+		// untuk response quizes nya
+		quizesResponse = append(quizesResponse, http.QuizesResponseHTTP{
+			ID:            item.ID,
+			Quiz:          item.Quiz,
+			Type:          string(item.Type),
+			Points:        item.Points,
+			Answer:        quizAnswerResponse,
+			AnswerStudent: answerQuizStudent,
+			ImgURL:        &item.ImgURL,
+			CreatedAt:     &item.CreatedAt,
+			UpdatedAt:     &item.UpdatedAt,
+		})
 
 	}
 
@@ -879,16 +1036,35 @@ func (h *Handlers) GetDetailQuizByIdHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	var drawRules []model.QuizDrawRule
+	if rules, err := h.QuizRepository.GetQuizDrawRules(resultQuiz.ID); err == nil {
+		drawRules = *rules
+	}
+
 	quizResponse = http.QuizResponseHTTP{
-		ID:          resultQuiz.ID,
-		Title:       resultQuiz.Title,
-		Description: resultQuiz.Description,
-		Grades:      resultAnswerStudent.Grades,
-		Score:       resultAnswerStudent.Score,
-		Quiz:        quizesResponse,
-		Next:        nextMaterial,
-		CreatedAt:   &resultQuiz.CreatedAt,
-		UpdatedAt:   &resultQuiz.UpdatedAt,
+		ID:               resultQuiz.ID,
+		Title:            resultQuiz.Title,
+		Description:      resultQuiz.Description,
+		MaxAttempts:      resultQuiz.MaxAttempts,
+		AttemptsUsed:     attemptsUsed,
+		ScoringPolicy:    string(resultQuiz.ScoringPolicy),
+		TimeLimit:        resultQuiz.TimeLimit,
+		OpenAt:           resultQuiz.OpenAt,
+		CloseAt:          resultQuiz.CloseAt,
+		ShuffleQuestions: resultQuiz.ShuffleQuestions,
+		ShuffleAnswers:   resultQuiz.ShuffleAnswers,
+		ReviewPolicy:     string(resultQuiz.ReviewPolicy),
+		ReviewAvailable:  showAnswerKey,
+		DrawRules:        h.quizDrawRulesResponse(drawRules),
+		Quiz:             quizesResponse,
+		Next:             nextMaterial,
+		CreatedAt:        &resultQuiz.CreatedAt,
+		UpdatedAt:        &resultQuiz.UpdatedAt,
+	}
+
+	if recordedAttempt != nil {
+		quizResponse.Grades = recordedAttempt.Grades
+		quizResponse.Score = recordedAttempt.Score
 	}
 
 	// Return success response
@@ -899,151 +1075,1280 @@ func (h *Handlers) GetDetailQuizByIdHandler(c *fiber.Ctx) error {
 	})
 }
 
-// TODO: Create quiz answer
+// CreateQuizAnswerHandler grades the submitted answers as a new quiz attempt of the student.
 func (h *Handlers) CreateQuizAnswerHandler(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
+	activeStudentID := middleware.PrincipalFrom(c).ActiveStudentID
+	var request http.QuizAnswerStudent
+	requestID := c.Params("id")
 
-	if role != "STUDENT" {
-		return c.Status(401).JSON(&http.WebResponse{
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
 			Status:  "error",
-			Message: "You are not authorized to perform this action",
+			Message: h.errorParseBodyRequest(),
 			Data:    nil,
 		})
 	}
 
-	var request http.QuizAnswerStudent
-	requestID := c.Params("id")
-	var score int
-	var grade float64
-	var totalQuestions int
-	var response []http.QuizAnswerStudentResponseHTTP
-
-	findStudentActuveStudent, err := h.UserRepository.FindActiveStudent(map[string]interface{}{
-		"student_id": userID,
+	// Cari materi kuis berdasarkan id
+	material, err := h.CourseRepository.FindMaterial(map[string]interface{}{
+		"id": requestID,
 	})
 
 	if err != nil {
-		return c.Status(400).JSON(&http.WebResponse{
+		return c.Status(404).JSON(&http.WebResponse{
 			Status:  "error",
-			Message: "Active Student not found",
+			Message: h.errorSpecifyResource("id"),
 			Data:    nil,
 		})
 	}
 
-	if err := c.BodyParser(&request); err != nil {
+	if err := h.authorizeStudentMaterial(c, material.ID); err != nil {
+		return h.courseAccessError(c, "quiz", err)
+	}
+
+	resultQuiz, err := h.QuizRepository.GetQuizByMaterialId(material.ID)
+
+	if err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
 			Status:  "error",
-			Message: h.errorParseBodyRequest(),
+			Message: "Error get detail quiz",
 			Data:    nil,
 		})
 	}
 
-	// Cari materi kuis berdasarkan slug
-	material, err := h.CourseRepository.FindMaterial(map[string]interface{}{
-		"id": requestID,
-	})
-
+	attemptID, err := helper.GenerateNanoId()
 	if err != nil {
-		return c.Status(404).JSON(&http.WebResponse{
+		return c.Status(500).JSON(&http.WebResponse{
 			Status:  "error",
-			Message: h.errorSpecifyResource("id"),
+			Message: "Error generating nano ID",
 			Data:    nil,
 		})
 	}
 
+	var responses []model.QuizAnswerStudent
 	for _, item := range request.Answer {
-		var AnswerCorrect string
-
-		findAnswer, err := h.QuizRepository.FindQuizAnswer(map[string]interface{}{
-			"id": item.QuizAnswerID,
-		})
-
-		if err != nil {
-			return c.Status(500).JSON(&http.WebResponse{
-				Status:  "error",
-				Message: h.errorInternal("Quiz Answers", "find"),
-				Data:    nil,
-			})
+		response := model.QuizAnswerStudent{
+			QuizesID:          item.QuizesID,
+			SelectedAnswerIDs: item.QuizAnswerIDs,
+			AnswerText:        item.AnswerText,
 		}
-
-		// Periksa apakah jawaban benar atau tidak
-		if findAnswer.IsCorrect {
-			score++
+		if item.QuizAnswerID != "" {
+			quizAnswerID := item.QuizAnswerID
+			response.QuizAnswerID = &quizAnswerID
 		}
+		responses = append(responses, response)
+	}
 
-		totalQuestions++
-
-		// Hitung nilai dalam bentuk persentase
-		grade = (float64(score) / float64(totalQuestions)) * 100
+	// Attempt dinilai dan disimpan dalam satu transaksi
+	attempt, err := h.QuizRepository.SubmitQuizAttempt(model.QuizAttempt{
+		ID:              attemptID,
+		QuizID:          resultQuiz.ID,
+		ActiveStudentID: activeStudentID,
+		Responses:       responses,
+	})
 
-		resultQuiz, err := h.QuizRepository.GetQuizByMaterialId(material.ID)
+	if errors.Is(err, repository.ErrQuizDeadlinePassed) {
+		return c.Status(403).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "The time for this quiz attempt is over, your answers were not accepted",
+			Data:    h.quizAttemptResponse(attempt, nil),
+		})
+	}
 
-		if err != nil {
-			return c.Status(500).JSON(&http.WebResponse{
-				Status:  "error",
-				Message: "Error get detail quiz",
-				Data:    nil,
-			})
-		}
+	if message, ok := h.quizAttemptError(err, resultQuiz); ok {
+		return c.Status(403).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: message,
+			Data:    nil,
+		})
+	}
 
-		id, err := helper.GenerateNanoId()
-		if err != nil {
-			return c.Status(500).JSON(&http.WebResponse{
-				Status:  "error",
-				Message: h.errorInternal("Quiz Answer", "create"),
-				Data:    nil,
-			})
-		}
+	if err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz Answer", "create"),
+			Data:    nil,
+		})
+	}
 
-		result, err := h.QuizRepository.CreateQuizAnswerStudent(model.QuizAnswerStudent{
-			ID:              string(id),
-			QuizID:          resultQuiz.ID,
-			ActiveStudentID: findStudentActuveStudent.ID,
-			QuizesID:        item.QuizesID,
-			QuizAnswerID:    item.QuizAnswerID,
-			Score:           score,
-			Grades:          int(grade),
+	// Pembahasan hanya ditampilkan jika review policy quiz mengizinkan
+	if !helper.CanReviewQuiz(*resultQuiz, true, h.quizAttemptsUsed(resultQuiz.ID, activeStudentID), time.Now()) {
+		return c.Status(200).JSON(&http.WebResponse{
+			Status:  "success",
+			Message: h.successResponse("Quiz Answer", "created"),
+			Data:    h.quizAttemptResponse(attempt, nil),
 		})
+	}
 
-		if err != nil {
-			return c.Status(500).JSON(&http.WebResponse{
-				Status:  "error",
-				Message: h.errorInternal("Quiz Answer", "create"),
-				Data:    nil,
-			})
-		}
+	// Soal diambil dari attempt agar sesuai dengan yang diterima student
+	attemptQuestions, err := h.QuizRepository.GetQuizAttemptQuestions(attempt.ID)
 
-		findQuizes, _ := h.QuizRepository.FindQuizes(map[string]interface{}{
-			"id": item.QuizesID,
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quizzes", "find"),
+			Data:    nil,
 		})
+	}
 
-		// mencari jawaban yang benar
-		if !findAnswer.IsCorrect {
-			logrus.Println("ITEM ", item.QuizAnswerID)
-			findAnswerCorrect, _ := h.QuizRepository.FindQuizAnswer(map[string]interface{}{
-				"quizes_id": item.QuizesID,
-			})
-			logrus.Println("findAnswerCorrect ", findAnswerCorrect)
-			if findAnswerCorrect.IsCorrect {
-				AnswerCorrect = findAnswerCorrect.Answer
-			}
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Quiz Answer", "created"),
+		Data:    h.quizAttemptResponse(attempt, attemptQuestions),
+	})
+}
 
-		}
+// StartQuizHandler issues the server side start record of a quiz attempt.
+// The deadline of a timed quiz is counted from this record, not from anything the client sends.
+func (h *Handlers) StartQuizHandler(c *fiber.Ctx) error {
+	activeStudentID := middleware.PrincipalFrom(c).ActiveStudentID
 
-		response = append(response, http.QuizAnswerStudentResponseHTTP{
-			ID:            result.ID,
-			QuizID:        result.QuizID,
-			Quiz:          findQuizes.Quiz,
-			QuizesID:      result.QuizesID,
-			Answer:        findAnswer.Answer,
-			AnswerCorrect: &AnswerCorrect,
+	resultQuiz, err := h.QuizRepository.FindQuiz(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
 		})
+	}
 
+	if err := h.authorizeStudentMaterial(c, resultQuiz.MaterialID); err != nil {
+		return h.courseAccessError(c, "quiz", err)
 	}
-	return c.Status(200).JSON(&http.WebResponse{
-		Status:  "success",
-		Message: h.successResponse("Quiz Answer", "created"),
-		Data:    response,
+
+	attemptID, err := helper.GenerateNanoId()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generating nano ID",
+			Data:    nil,
+		})
+	}
+
+	attempt, err := h.QuizRepository.StartQuizAttempt(model.QuizAttempt{
+		ID:              attemptID,
+		QuizID:          resultQuiz.ID,
+		ActiveStudentID: activeStudentID,
 	})
+
+	if message, ok := h.quizAttemptError(err, resultQuiz); ok {
+		return c.Status(403).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: message,
+			Data:    nil,
+		})
+	}
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz Attempt", "start"),
+			Data:    nil,
+		})
+	}
+
+	attemptQuestions, err := h.QuizRepository.GetQuizAttemptQuestions(attempt.ID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quizzes", "find"),
+			Data:    nil,
+		})
+	}
+
+	response := h.quizAttemptResponse(attempt, nil)
+	response.Questions = h.quizPaperResponse(*attemptQuestions)
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Quiz Attempt", "started"),
+		Data:    response,
+	})
+}
+
+// GetQuizAttemptsHandler lists every attempt of the current student for a quiz.
+func (h *Handlers) GetQuizAttemptsHandler(c *fiber.Ctx) error {
+	activeStudentID := middleware.PrincipalFrom(c).ActiveStudentID
+
+	attempts, err := h.QuizRepository.FindQuizAttempts(map[string]interface{}{
+		"quiz_id":           c.Params("id"),
+		"active_student_id": activeStudentID,
+	})
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz Attempt", "find"),
+			Data:    nil,
+		})
+	}
+
+	response := []http.QuizAttemptResponseHTTP{}
+	for _, attempt := range *attempts {
+		response = append(response, h.quizAttemptResponse(&attempt, nil))
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "Get quiz attempts successfully",
+		Data:    response,
+	})
+}
+
+// GetQuizAttemptHandler shows an attempt of the current student with the questions it was given.
+// A finished attempt includes the per-question breakdown when the review policy of the quiz allows it.
+func (h *Handlers) GetQuizAttemptHandler(c *fiber.Ctx) error {
+	activeStudentID := middleware.PrincipalFrom(c).ActiveStudentID
+
+	attempt, err := h.QuizRepository.FindQuizAttempt(map[string]interface{}{
+		"id":                c.Params("id"),
+		"active_student_id": activeStudentID,
+	})
+
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	attemptQuestions, err := h.QuizRepository.GetQuizAttemptQuestions(attempt.ID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quizzes", "find"),
+			Data:    nil,
+		})
+	}
+
+	resultQuiz, err := h.QuizRepository.FindQuiz(map[string]interface{}{
+		"id": attempt.QuizID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz", "find"),
+			Data:    nil,
+		})
+	}
+
+	// Kunci jawaban hanya ditampilkan setelah attempt selesai dan jika review policy quiz mengizinkan
+	var response http.QuizAttemptResponseHTTP
+	if attempt.FinishedAt != nil && helper.CanReviewQuiz(*resultQuiz, true, h.quizAttemptsUsed(resultQuiz.ID, attempt.ActiveStudentID), time.Now()) {
+		response = h.quizAttemptResponse(attempt, attemptQuestions)
+	} else {
+		response = h.quizAttemptResponse(attempt, nil)
+	}
+	response.Questions = h.quizPaperResponse(*attemptQuestions)
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "Get quiz attempt successfully",
+		Data:    response,
+	})
+}
+
+// quizAttemptResponse maps an attempt into its response, the per-question breakdown
+// of every question is only included when the quiz questions are passed. Callers
+// only pass them when the review policy of the quiz allows it.
+func (h *Handlers) quizAttemptResponse(attempt *model.QuizAttempt, quizes *[]model.Quizes) http.QuizAttemptResponseHTTP {
+	response := http.QuizAttemptResponseHTTP{
+		ID:              attempt.ID,
+		QuizID:          attempt.QuizID,
+		Score:           attempt.Score,
+		Grades:          attempt.Grades,
+		TotalQuestions:  attempt.TotalQuestions,
+		TotalPoints:     attempt.TotalPoints,
+		StartedAt:       attempt.StartedAt,
+		DeadlineAt:      attempt.DeadlineAt,
+		FinishedAt:      attempt.FinishedAt,
+		IsLate:          attempt.IsLate,
+		ScoreOverridden: attempt.ScoreOverridden,
+	}
+
+	if quizes == nil {
+		return response
+	}
+	response.ReviewAvailable = true
+
+	results := make(map[string]model.QuizAnswerStudent)
+	for _, result := range attempt.Responses {
+		results[result.QuizesID] = result
+	}
+
+	// Setiap soal quiz ditampilkan, soal yang tidak dijawab bernilai 0
+	for _, question := range *quizes {
+		answerCorrect := h.quizCorrectAnswerText(question)
+		result, answered := results[question.ID]
+
+		response.Answers = append(response.Answers, http.QuizAnswerStudentResponseHTTP{
+			ID:            result.ID,
+			QuizID:        attempt.QuizID,
+			Quiz:          question.Quiz,
+			Type:          string(question.Type),
+			QuizesID:      question.ID,
+			Answer:        h.quizStudentAnswerText(question, result),
+			Answered:      answered,
+			IsCorrect:     result.IsCorrect,
+			Points:        result.Points,
+			MaxPoints:     question.Points,
+			AnswerCorrect: &answerCorrect,
+		})
+	}
+
+	return response
+}
+
+// quizStudentAnswerText describes the response of a student as text according to the question type.
+func (h *Handlers) quizStudentAnswerText(question model.Quizes, result model.QuizAnswerStudent) string {
+	switch question.Type {
+	case model.SHORT_ANSWER, model.NUMERIC:
+		return result.AnswerText
+	case model.MULTIPLE_CHOICE, model.ORDERING:
+		return h.quizAnswerText(question, result.SelectedAnswerIDs)
+	}
+
+	if result.QuizAnswerID == nil {
+		return ""
+	}
+
+	return h.quizAnswerText(question, []string{*result.QuizAnswerID})
+}
+
+// quizCorrectAnswerText describes the answer key of a question as text according to the question type.
+func (h *Handlers) quizCorrectAnswerText(question model.Quizes) string {
+	var ids []string
+
+	switch question.Type {
+	case model.SHORT_ANSWER, model.NUMERIC:
+		if len(question.QuizAnswers) > 0 {
+			return question.QuizAnswers[0].Answer
+		}
+		return ""
+	case model.ORDERING:
+		for _, item := range helper.OrderedQuizAnswers(question) {
+			ids = append(ids, item.ID)
+		}
+	default:
+		for _, item := range question.QuizAnswers {
+			if item.IsCorrect {
+				ids = append(ids, item.ID)
+			}
+		}
+	}
+
+	return h.quizAnswerText(question, ids)
+}
+
+// quizAnswerText joins the text of the given options of a question, keeping the order of ids.
+func (h *Handlers) quizAnswerText(question model.Quizes, ids []string) string {
+	answers := make(map[string]string)
+	for _, item := range question.QuizAnswers {
+		answers[item.ID] = item.Answer
+	}
+
+	var texts []string
+	for _, id := range ids {
+		if answer, ok := answers[id]; ok {
+			texts = append(texts, answer)
+		}
+	}
+
+	return strings.Join(texts, ", ")
+}
+
+// quizQuestion maps a question of the request into the model used for validation,
+// a question without type is a SINGLE_CHOICE question worth 1 point.
+func (h *Handlers) quizQuestion(request http.QuizData) model.Quizes {
+	question := model.Quizes{
+		Quiz:   request.Quiz,
+		Type:   model.SINGLE_CHOICE,
+		Points: 1,
+	}
+
+	if request.Type != nil {
+		question.Type = model.QUESTION_TYPE(*request.Type)
+	}
+
+	if request.Points != nil {
+		question.Points = *request.Points
+	}
+
+	for position, answer := range request.Answers {
+		if answer.Delete != nil && *answer.Delete {
+			continue
+		}
+
+		question.QuizAnswers = append(question.QuizAnswers, model.QuizAnswer{
+			Answer:    answer.Answer,
+			IsCorrect: answer.IsCorrect,
+			Tolerance: answer.Tolerance,
+			Position:  position,
+		})
+	}
+
+	return question
+}
+
+// validateQuizQuestions checks every question of the request that is not being deleted.
+func (h *Handlers) validateQuizQuestions(questions []http.QuizData) error {
+	for i, request := range questions {
+		if request.Delete != nil && *request.Delete {
+			continue
+		}
+
+		if err := helper.ValidateQuizQuestion(h.quizQuestion(request)); err != nil {
+			return fmt.Errorf("question %d: %s", i+1, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// quizAttemptsUsed counts the attempts a student has started on a quiz, 0 when they can't be found.
+func (h *Handlers) quizAttemptsUsed(quizID string, activeStudentID string) int {
+	attempts, err := h.QuizRepository.FindQuizAttempts(map[string]interface{}{
+		"quiz_id":           quizID,
+		"active_student_id": activeStudentID,
+	})
+	if err != nil {
+		return 0
+	}
+
+	return len(*attempts)
+}
+
+// quizAttemptError translates the attempt rules rejected by the repository into a message for the student.
+func (h *Handlers) quizAttemptError(err error, quiz *model.Quiz) (string, bool) {
+	switch {
+	case errors.Is(err, repository.ErrQuizAttemptsExhausted):
+		return fmt.Sprintf("You have used all %d attempts of this quiz", quiz.MaxAttempts), true
+	case errors.Is(err, repository.ErrQuizNotOpen):
+		return fmt.Sprintf("This quiz opens at %s", quiz.OpenAt.Format("02 January 2006 15:04")), true
+	case errors.Is(err, repository.ErrQuizClosed):
+		return "This quiz is already closed", true
+	case errors.Is(err, repository.ErrQuizNotStarted):
+		return "This quiz is timed, start the quiz before submitting answers", true
+	}
+
+	return "", false
+}
+
+// quizSettings validates the attempt and time settings of a quiz request.
+// Attempt settings that are not sent keep the value of the current quiz, or the default when creating.
+// The open window is always replaced by the request so it can be removed.
+func (h *Handlers) quizSettings(request http.Quiz, current *model.Quiz) (*model.Quiz, error) {
+	settings := model.Quiz{
+		ScoringPolicy:  model.SCORE_LAST,
+		LateSubmission: model.LATE_REJECT,
+		ReviewPolicy:   model.REVIEW_AFTER_SUBMIT,
+		OpenAt:         request.OpenAt,
+		CloseAt:        request.CloseAt,
+	}
+
+	if current != nil {
+		settings.MaxAttempts = current.MaxAttempts
+		settings.TimeLimit = current.TimeLimit
+		settings.ShuffleQuestions = current.ShuffleQuestions
+		settings.ShuffleAnswers = current.ShuffleAnswers
+		if current.ScoringPolicy != "" {
+			settings.ScoringPolicy = current.ScoringPolicy
+		}
+		if current.LateSubmission != "" {
+			settings.LateSubmission = current.LateSubmission
+		}
+		if current.ReviewPolicy != "" {
+			settings.ReviewPolicy = current.ReviewPolicy
+		}
+	}
+
+	if request.MaxAttempts != nil {
+		settings.MaxAttempts = *request.MaxAttempts
+	}
+
+	if request.ScoringPolicy != nil {
+		settings.ScoringPolicy = model.SCORING_POLICY(*request.ScoringPolicy)
+	}
+
+	if request.TimeLimit != nil {
+		settings.TimeLimit = *request.TimeLimit
+	}
+
+	if request.LateSubmission != nil {
+		settings.LateSubmission = model.LATE_SUBMISSION(*request.LateSubmission)
+	}
+
+	if request.ReviewPolicy != nil {
+		settings.ReviewPolicy = model.REVIEW_POLICY(*request.ReviewPolicy)
+	}
+
+	if request.ShuffleQuestions != nil {
+		settings.ShuffleQuestions = *request.ShuffleQuestions
+	}
+
+	if request.ShuffleAnswers != nil {
+		settings.ShuffleAnswers = *request.ShuffleAnswers
+	}
+
+	if settings.MaxAttempts < 0 {
+		return nil, errors.New("max_attempts must not be negative")
+	}
+
+	if !helper.ValidScoringPolicy(settings.ScoringPolicy) {
+		return nil, errors.New("scoring_policy must be BEST, LAST or AVERAGE")
+	}
+
+	if settings.TimeLimit < 0 {
+		return nil, errors.New("time_limit must not be negative")
+	}
+
+	if settings.OpenAt != nil && settings.CloseAt != nil && !settings.CloseAt.After(*settings.OpenAt) {
+		return nil, errors.New("close_at must be after open_at")
+	}
+
+	if !helper.ValidLateSubmission(settings.LateSubmission) {
+		return nil, errors.New("late_submission must be REJECT or GRADE")
+	}
+
+	if !helper.ValidReviewPolicy(settings.ReviewPolicy) {
+		return nil, errors.New("review_policy must be NEVER, AFTER_SUBMIT or AFTER_CLOSE")
+	}
+
+	return &settings, nil
+}
+
+// quizSettingsResponse copies the attempt and time settings into a quiz response.
+func (h *Handlers) quizSettingsResponse(response *http.Quiz, settings *model.Quiz) {
+	scoringPolicy := string(settings.ScoringPolicy)
+	lateSubmission := string(settings.LateSubmission)
+	reviewPolicy := string(settings.ReviewPolicy)
+
+	response.MaxAttempts = &settings.MaxAttempts
+	response.ScoringPolicy = &scoringPolicy
+	response.TimeLimit = &settings.TimeLimit
+	response.OpenAt = settings.OpenAt
+	response.CloseAt = settings.CloseAt
+	response.LateSubmission = &lateSubmission
+	response.ReviewPolicy = &reviewPolicy
+	response.ShuffleQuestions = &settings.ShuffleQuestions
+	response.ShuffleAnswers = &settings.ShuffleAnswers
+}
+
+// quizDrawRules validates the draw rules of a quiz request. Every rule must draw from
+// a question bank of the course of the quiz that has enough matching questions.
+func (h *Handlers) quizDrawRules(courseID string, requests []http.QuizDrawRule) ([]model.QuizDrawRule, error) {
+	var rules []model.QuizDrawRule
+
+	for i, request := range requests {
+		difficulty := model.QUESTION_DIFFICULTY(request.Difficulty)
+
+		if request.Count < 1 {
+			return nil, fmt.Errorf("draw rule %d: count must be at least 1", i+1)
+		}
+
+		if !helper.ValidQuestionDifficulty(difficulty) {
+			return nil, fmt.Errorf("draw rule %d: difficulty must be EASY, MEDIUM or HARD", i+1)
+		}
+
+		if _, err := h.QuizRepository.FindQuestionBank(map[string]interface{}{
+			"id":        request.QuestionBankID,
+			"course_id": courseID,
+		}); err != nil {
+			return nil, fmt.Errorf("draw rule %d: question bank not found in this course", i+1)
+		}
+
+		codd := map[string]interface{}{
+			"question_bank_id": request.QuestionBankID,
+		}
+		if request.Topic != "" {
+			codd["topic"] = request.Topic
+		}
+		if difficulty != "" {
+			codd["difficulty"] = difficulty
+		}
+
+		available, err := h.QuizRepository.CountBankQuestions(codd)
+		if err != nil {
+			return nil, err
+		}
+
+		if available < int64(request.Count) {
+			return nil, fmt.Errorf("draw rule %d: question bank only has %d matching questions", i+1, available)
+		}
+
+		id, err := helper.GenerateNanoId()
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, model.QuizDrawRule{
+			ID:             id,
+			QuestionBankID: request.QuestionBankID,
+			Topic:          request.Topic,
+			Difficulty:     difficulty,
+			Count:          request.Count,
+		})
+	}
+
+	return rules, nil
+}
+
+// quizDrawRulesResponse maps the draw rules of a quiz into their response.
+func (h *Handlers) quizDrawRulesResponse(rules []model.QuizDrawRule) []http.QuizDrawRule {
+	response := []http.QuizDrawRule{}
+	for _, rule := range rules {
+		response = append(response, http.QuizDrawRule{
+			ID:             rule.ID,
+			QuestionBankID: rule.QuestionBankID,
+			Topic:          rule.Topic,
+			Difficulty:     string(rule.Difficulty),
+			Count:          rule.Count,
+		})
+	}
+
+	return response
+}
+
+// quizPaperResponse maps the questions of an attempt as the student sees them, in the
+// order they were given and without the answer key. Accepted answers of SHORT_ANSWER
+// and NUMERIC questions are not options so they are left out.
+func (h *Handlers) quizPaperResponse(questions []model.Quizes) []http.QuizesResponseHTTP {
+	response := []http.QuizesResponseHTTP{}
+
+	for _, question := range questions {
+		answers := []http.QuizAnswerHTTP{}
+		if question.Type != model.SHORT_ANSWER && question.Type != model.NUMERIC {
+			for _, answer := range question.QuizAnswers {
+				answers = append(answers, http.QuizAnswerHTTP{
+					ID:     answer.ID,
+					Answer: answer.Answer,
+				})
+			}
+		}
+
+		imgURL := question.ImgURL
+		response = append(response, http.QuizesResponseHTTP{
+			ID:            question.ID,
+			Quiz:          question.Quiz,
+			Type:          string(question.Type),
+			Points:        question.Points,
+			ImgURL:        &imgURL,
+			Answer:        answers,
+			AnswerStudent: []http.AnswerStuedntResponseHTTP{},
+		})
+	}
+
+	return response
+}
+
+// GetQuizAnalysisHandler reports the item analysis of a quiz for teachers, optionally
+// narrowed to the students of a class and school year.
+func (h *Handlers) GetQuizAnalysisHandler(c *fiber.Ctx) error {
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUIZ, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Quiz", err)
+	}
+
+	id := c.Params("id")
+
+	if _, err := h.QuizRepository.FindQuiz(map[string]interface{}{
+		"id": id,
+	}); err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	class := c.Query("class")
+	schoolYear := c.Query("school_year")
+
+	filter := map[string]interface{}{}
+	if class != "" {
+		filter["class"] = class
+	}
+	if schoolYear != "" {
+		filter["school_year"] = schoolYear
+	}
+
+	analysis, err := h.QuizRepository.GetQuizItemAnalysis(id, filter)
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz Analysis", "get"),
+			Data:    nil,
+		})
+	}
+
+	distractors := make(map[string][]http.QuizDistractorAnalysisResponseHTTP)
+	for _, distractor := range analysis.Distractors {
+		distractors[distractor.QuizesID] = append(distractors[distractor.QuizesID], http.QuizDistractorAnalysisResponseHTTP{
+			ID:        distractor.QuizAnswerID,
+			Answer:    distractor.Answer,
+			IsCorrect: distractor.IsCorrect,
+			Selected:  distractor.Selected,
+		})
+	}
+
+	questions := []http.QuizQuestionAnalysisResponseHTTP{}
+	for _, question := range analysis.Questions {
+		questions = append(questions, http.QuizQuestionAnalysisResponseHTTP{
+			ID:             question.QuizesID,
+			Quiz:           question.Quiz,
+			Type:           question.Type,
+			Points:         question.Points,
+			Given:          question.Given,
+			Answered:       question.Answered,
+			Correct:        question.Correct,
+			PercentCorrect: question.PercentCorrect,
+			AverageScore:   question.AverageScore,
+			Discrimination: question.Discrimination,
+			Distractors:    distractors[question.QuizesID],
+		})
+	}
+
+	distribution := []http.QuizScoreBucketResponseHTTP{}
+	for _, bucket := range analysis.ScoreDistribution {
+		distribution = append(distribution, http.QuizScoreBucketResponseHTTP{
+			MinGrades: bucket.MinGrades,
+			MaxGrades: bucket.MaxGrades,
+			Total:     bucket.Total,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "Get quiz analysis successfully",
+		Data: http.QuizAnalysisResponseHTTP{
+			QuizID:            id,
+			Class:             class,
+			SchoolYear:        schoolYear,
+			Attempts:          analysis.Summary.Attempts,
+			Students:          analysis.Summary.Students,
+			AverageGrades:     analysis.Summary.AverageGrades,
+			AverageSeconds:    analysis.Summary.AverageSeconds,
+			Questions:         questions,
+			ScoreDistribution: distribution,
+		},
+	})
+}
+
+// ImportQuizHandler creates a quiz in a chapter from an uploaded GIFT file or QTI 2.1 package.
+// The format is the form value format, or is taken from the extension of the file.
+// Nothing is created when any question of the file is invalid.
+func (h *Handlers) ImportQuizHandler(c *fiber.Ctx) error {
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_CHAPTER, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "chapter", err)
+	}
+
+	chapter, err := h.CourseRepository.FindChapter(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz file", "read"),
+			Data:    nil,
+		})
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz file", "read"),
+			Data:    nil,
+		})
+	}
+
+	format := strings.ToLower(c.FormValue("format"))
+	if format == "" {
+		format = quizFileFormat(file.Filename)
+	}
+
+	var title string
+	var questions []model.Quizes
+	var importErrors []helper.QuizImportError
+
+	switch format {
+	case "gift":
+		questions, importErrors = helper.ParseGIFT(string(content))
+	case "qti":
+		title, questions, importErrors = helper.ParseQTI(content, file.Filename)
+	default:
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "format must be gift or qti",
+			Data:    nil,
+		})
+	}
+
+	if len(importErrors) > 0 {
+		response := []http.QuizImportErrorHTTP{}
+		for _, importError := range importErrors {
+			response = append(response, http.QuizImportErrorHTTP{
+				File:    importError.File,
+				Line:    importError.Line,
+				Message: importError.Message,
+			})
+		}
+
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Quiz file is invalid",
+			Data:    response,
+		})
+	}
+
+	if len(questions) == 0 {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Quiz file has no questions",
+			Data:    nil,
+		})
+	}
+
+	if value := strings.TrimSpace(c.FormValue("title")); value != "" {
+		title = value
+	}
+	if title == "" {
+		title = strings.TrimSuffix(file.Filename, path.Ext(file.Filename))
+	}
+
+	settings, err := h.quizSettings(http.Quiz{}, nil)
+	if err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
+	materialID, err := helper.GenerateNanoId()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generating nano ID",
+			Data:    nil,
+		})
+	}
+
+	for i := range questions {
+		if questions[i].ID, err = helper.GenerateNanoId(); err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: "Error generating nano ID",
+				Data:    nil,
+			})
+		}
+
+		for j := range questions[i].QuizAnswers {
+			if questions[i].QuizAnswers[j].ID, err = helper.GenerateNanoId(); err != nil {
+				return c.Status(500).JSON(&http.WebResponse{
+					Status:  "error",
+					Message: "Error generating nano ID",
+					Data:    nil,
+				})
+			}
+		}
+	}
+
+	description := c.FormValue("description")
+
+	result, err := h.QuizRepository.ImportQuiz(model.Quiz{
+		ID:             materialID,
+		Title:          title,
+		Description:    description,
+		ChapterID:      chapter.ID,
+		ScoringPolicy:  settings.ScoringPolicy,
+		LateSubmission: settings.LateSubmission,
+		ReviewPolicy:   settings.ReviewPolicy,
+		Material: model.Material{
+			ID:        materialID,
+			ChapterID: chapter.ID,
+			Title:     title,
+			Type:      "QUIZ",
+			Slug:      slug.Make(title),
+		},
+		Quizes: questions,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz", "import"),
+			Data:    nil,
+		})
+	}
+
+	quizzDataResponse := []http.QuizData{}
+	for _, question := range result.Quizes {
+		quizzData := h.bankQuestionResponse(question)
+		quizzData.QuizID = result.ID
+		quizzDataResponse = append(quizzDataResponse, quizzData)
+	}
+
+	typeOfMaterial := "QUIZ"
+
+	response := http.Quiz{
+		ID:          result.ID,
+		Title:       title,
+		Description: description,
+		ChapterID:   chapter.ID,
+		Type:        &typeOfMaterial,
+		Quizes:      quizzDataResponse,
+	}
+	h.quizSettingsResponse(&response, settings)
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Quiz", "imported"),
+		Data:    response,
+	})
+}
+
+// ExportQuizHandler downloads the questions of a quiz as a GIFT file or a QTI 2.1 package.
+// Questions drawn from question banks are not part of the quiz so they are not exported.
+func (h *Handlers) ExportQuizHandler(c *fiber.Ctx) error {
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUIZ, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Quiz", err)
+	}
+
+	quiz, err := h.QuizRepository.FindQuiz(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	questions, err := h.QuizRepository.GetQuizesByIdQuiz(quiz.ID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quizzes", "find"),
+			Data:    nil,
+		})
+	}
+
+	filename := slug.Make(quiz.Title)
+	if filename == "" {
+		filename = quiz.ID
+	}
+
+	switch strings.ToLower(c.Query("format", "gift")) {
+	case "gift":
+		c.Attachment(filename + ".gift")
+		c.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+		return c.Status(200).Send(helper.FormatGIFT(*quiz, *questions))
+	case "qti":
+		content, err := helper.FormatQTI(*quiz, *questions)
+		if err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorInternal("Quiz", "export"),
+				Data:    nil,
+			})
+		}
+
+		c.Attachment(filename + "-qti.zip")
+		return c.Status(200).Send(content)
+	}
+
+	return c.Status(400).JSON(&http.WebResponse{
+		Status:  "error",
+		Message: "format must be gift or qti",
+		Data:    nil,
+	})
+}
+
+// quizFileFormat guesses the format of an imported quiz file from its extension.
+func quizFileFormat(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".gift", ".txt":
+		return "gift"
+	case ".xml", ".zip":
+		return "qti"
+	}
+
+	return ""
+}
+
+// RegradeQuizHandler grades every finished attempt of a quiz again, used after the answer
+// key or the points of a question changed. Corrections of the answer key sent with it are
+// applied first, also on a published version the students are pinned to, and their reason
+// is kept in the audits. It responds with the changed results.
+func (h *Handlers) RegradeQuizHandler(c *fiber.Ctx) error {
+	userID := middleware.PrincipalFrom(c).UserID
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUIZ, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Quiz", err)
+	}
+
+	var request http.QuizRegrade
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorParseBodyRequest(),
+				Data:    nil,
+			})
+		}
+	}
+
+	request.Reason = strings.TrimSpace(request.Reason)
+	if len(request.Questions) > 0 && request.Reason == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "reason is required to correct the answer key",
+			Data:    nil,
+		})
+	}
+
+	quiz, err := h.QuizRepository.FindQuiz(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	questions, err := h.QuizRepository.GetQuizesByIdQuiz(quiz.ID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quizzes", "find"),
+			Data:    nil,
+		})
+	}
+
+	corrections, err := h.quizAnswerKeyCorrections(*questions, request.Questions)
+	if err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
+	audits, err := h.QuizRepository.RegradeQuiz(quiz.ID, corrections, request.Reason, userID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz", "regrade"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Quiz", "regraded"),
+		Data:    h.quizGradeAuditsResponse(*audits),
+	})
+}
+
+// quizAnswerKeyCorrections applies the corrections of the request to the questions of a quiz
+// and returns the corrected questions, each still a valid question of its type.
+func (h *Handlers) quizAnswerKeyCorrections(questions []model.Quizes, requests []http.QuizAnswerKeyCorrection) ([]model.Quizes, error) {
+	byID := make(map[string]model.Quizes, len(questions))
+	for _, question := range questions {
+		byID[question.ID] = question
+	}
+
+	corrections := []model.Quizes{}
+	for i, request := range requests {
+		question, ok := byID[request.ID]
+		if !ok {
+			return nil, fmt.Errorf("question %d: %s is not a question of this quiz", i+1, request.ID)
+		}
+
+		question.QuizAnswers = append([]model.QuizAnswer{}, question.QuizAnswers...)
+		if request.Points != nil {
+			question.Points = *request.Points
+		}
+
+		for _, answer := range request.Answers {
+			found := false
+			for j := range question.QuizAnswers {
+				if question.QuizAnswers[j].ID != answer.ID {
+					continue
+				}
+
+				question.QuizAnswers[j].IsCorrect = answer.IsCorrect
+				if answer.Tolerance != nil {
+					question.QuizAnswers[j].Tolerance = *answer.Tolerance
+				}
+				found = true
+			}
+
+			if !found {
+				return nil, fmt.Errorf("question %d: %s is not an answer of this question", i+1, answer.ID)
+			}
+		}
+
+		if err := helper.ValidateQuizQuestion(question); err != nil {
+			return nil, fmt.Errorf("question %d: %s", i+1, err.Error())
+		}
+
+		byID[question.ID] = question
+		corrections = append(corrections, question)
+	}
+
+	return corrections, nil
+}
+
+// OverrideQuizAttemptHandler sets the score of a finished attempt by hand with a reason.
+// A null score removes the override and restores the graded score.
+func (h *Handlers) OverrideQuizAttemptHandler(c *fiber.Ctx) error {
+	userID := middleware.PrincipalFrom(c).UserID
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUIZ_ATTEMPT, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Quiz Attempt", err)
+	}
+
+	var request http.QuizScoreOverride
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Reason == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "reason is required",
+			Data:    nil,
+		})
+	}
+
+	attempt, err := h.QuizRepository.FindQuizAttempt(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	if attempt.FinishedAt == nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Quiz attempt is not finished yet",
+			Data:    nil,
+		})
+	}
+
+	if request.Score != nil && (*request.Score < 0 || *request.Score > float64(attempt.TotalPoints)) {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("score must be between 0 and %d", attempt.TotalPoints),
+			Data:    nil,
+		})
+	}
+
+	result, err := h.QuizRepository.OverrideQuizAttemptScore(attempt.ID, request.Score, request.Reason, userID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz Attempt", "update"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Quiz Attempt score", "updated"),
+		Data:    h.quizAttemptResponse(result, nil),
+	})
+}
+
+// GetQuizGradeAuditsHandler lists the regrades and overrides of a quiz, newest first,
+// optionally of a single attempt or student.
+func (h *Handlers) GetQuizGradeAuditsHandler(c *fiber.Ctx) error {
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_QUIZ, c.Params("id")); err != nil {
+		return h.courseAccessError(c, "Quiz", err)
+	}
+
+	codd := map[string]interface{}{
+		"quiz_id": c.Params("id"),
+	}
+	if attemptID := c.Query("quiz_attempt_id"); attemptID != "" {
+		codd["quiz_attempt_id"] = attemptID
+	}
+	if activeStudentID := c.Query("active_student_id"); activeStudentID != "" {
+		codd["active_student_id"] = activeStudentID
+	}
+
+	audits, err := h.QuizRepository.GetQuizGradeAudits(codd)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("Quiz Grade Audit", "find"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "Get quiz grade audits successfully",
+		Data:    h.quizGradeAuditsResponse(*audits),
+	})
+}
+
+func (h *Handlers) quizGradeAuditsResponse(audits []model.QuizGradeAudit) []http.QuizGradeAuditResponseHTTP {
+	response := []http.QuizGradeAuditResponseHTTP{}
+	for _, audit := range audits {
+		response = append(response, http.QuizGradeAuditResponseHTTP{
+			ID:              audit.ID,
+			QuizID:          audit.QuizID,
+			QuizAttemptID:   audit.QuizAttemptID,
+			ActiveStudentID: audit.ActiveStudentID,
+			UserID:          audit.UserID,
+			Action:          string(audit.Action),
+			OldScore:        audit.OldScore,
+			NewScore:        audit.NewScore,
+			OldGrades:       audit.OldGrades,
+			NewGrades:       audit.NewGrades,
+			Reason:          audit.Reason,
+			CreatedAt:       audit.CreatedAt,
+		})
+	}
+
+	return response
 }
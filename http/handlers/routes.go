@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Routes registers every route group of the API on app.
+func (h *Handlers) Routes(app *fiber.App) {
+	// Setup Course Route
+	h.RouteCourses(app)
+
+	// Setup Course Template Route
+	h.RouteCourseTemplates(app)
+
+	// Setup Course Package Route
+	h.RouteCoursePackages(app)
+
+	// Setup SCORM Route
+	h.RouteScorm(app)
+
+	// Setup Auth Route
+	h.RouteAuth(app)
+
+	// Setup User Management Route
+	h.RouterUserManagemet(app)
+
+	// Setup Invitation Route
+	h.RouteInvitations(app)
+
+	// Setup Two-Factor Route
+	h.RouteTwoFactor(app)
+
+	// Setup Schools Route
+	h.RouterSchool(app)
+
+	// Setup Quiz Route
+	h.RouterQuiz(app)
+
+	// Setup Question Bank Route
+	h.RouterQuestionBank(app)
+
+	// R2 Storage Route
+	h.RouteStorage(app)
+
+	// grades router
+	h.RouteGrades(app)
+
+	app.Get("/api/v1", func(c *fiber.Ctx) error {
+		return c.Status(http.StatusOK).JSON(map[string]string{
+			"message": "E Learning API Version 1.0.0",
+		})
+	})
+}
@@ -9,14 +9,14 @@ import (
 
 func (h *Handlers) RouterSchool(c *fiber.App) {
 	v1 := c.Group("/api/v1/super-admin")
-	v1.Post("/schools", h.Middleware.Protected(), h.CreateSchoolHandler)
-	v1.Get("/schools", h.Middleware.Protected(), h.GetAllSchools)
-	v1.Get("/schools/:id", h.Middleware.Protected(), h.GetSchoolById)
-	v1.Put("/schools/:id", h.Middleware.Protected(), h.UpdateSchoolHandler)
-	v1.Delete("/schools/:id", h.Middleware.Protected(), h.DeleteSchoolById)
+	v1.Post("/schools", h.Middleware.Protected(), h.tenant((*Handlers).CreateSchoolHandler))
+	v1.Get("/schools", h.Middleware.Protected(), h.tenant((*Handlers).GetAllSchools))
+	v1.Get("/schools/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetSchoolById))
+	v1.Put("/schools/:id", h.Middleware.Protected(), h.tenant((*Handlers).UpdateSchoolHandler))
+	v1.Delete("/schools/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteSchoolById))
 
 	v1Base := c.Group("/api/v1")
-	v1Base.Get("/classes", h.Middleware.Protected(), h.FindClasses)
+	v1Base.Get("/classes", h.Middleware.Protected(), h.tenant((*Handlers).FindClasses))
 }
 
 func (h *Handlers) CreateSchoolHandler(c *fiber.Ctx) error {
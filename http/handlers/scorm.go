@@ -0,0 +1,576 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/cvzamannow/E-Learning-API/repository"
+	"github.com/cvzamannow/E-Learning-API/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gosimple/slug"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handlers) RouteScorm(app *fiber.App) {
+	v1 := app.Group("/api/v1")
+	v1.Post("/scorm", h.Middleware.Protected(), h.tenant((*Handlers).CreateScorm))
+	v1.Get("/scorm/:id", h.Middleware.Protected(), h.tenant((*Handlers).FindScorm))
+	v1.Put("/scorm/:id", h.Middleware.Protected(), h.tenant((*Handlers).ReplaceScorm))
+	v1.Get("/scorm/:id/registrations", h.Middleware.Protected(), h.tenant((*Handlers).GetScormRegistrations))
+
+	// Runtime API, dipanggil oleh adapter window.API SCORM 1.2 di frontend
+	v1.Post("/scorm/:id/initialize", h.Middleware.Protected(), h.tenant((*Handlers).InitializeScorm))
+	v1.Put("/scorm/:id/runtime", h.Middleware.Protected(), h.tenant((*Handlers).CommitScorm))
+}
+
+// CreateScorm creates a SCORM material from an uploaded SCORM 1.2 zip. The files of the package
+// are extracted to storage, the material launches the first SCO of the package.
+func (h *Handlers) CreateScorm(c *fiber.Ctx) error {
+	chapterID := c.FormValue("chapter_id")
+
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_CHAPTER, chapterID); err != nil {
+		return h.courseAccessError(c, "chapter", err)
+	}
+
+	manifest, pkg, err := h.uploadScormPackage(c)
+	if err != nil {
+		return h.scormPackageError(c, err)
+	}
+
+	title := strings.TrimSpace(c.FormValue("title"))
+	if title == "" {
+		title = manifest.Title
+	}
+
+	if title == "" {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("title"),
+			Data:    nil,
+		})
+	}
+
+	idMaterial, err := helper.GenerateNanoId()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Error generating nano ID",
+			Data:    nil,
+		})
+	}
+
+	m, err := h.CourseRepository.CreateMaterial(model.Material{
+		ID:        idMaterial,
+		ChapterID: chapterID,
+		Title:     title,
+		Slug:      slug.Make(title),
+		Type:      "SCORM",
+	})
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("material", "create"),
+			Data:    nil,
+		})
+	}
+
+	pkg.MaterialID = m.ID
+	created, err := h.CourseRepository.CreateScormPackage(*pkg)
+
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("SCORM package", "create"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("SCORM material", "created"),
+		Data:    h.scormMaterialResponse(m, created),
+	})
+}
+
+// ReplaceScorm uploads a new package for a SCORM material of a draft.
+func (h *Handlers) ReplaceScorm(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.authorizeDraftEdit(c, repository.RESOURCE_MATERIAL, id); err != nil {
+		return h.courseAccessError(c, "material", err)
+	}
+
+	material, err := h.findScormMaterial(id)
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find SCORM material because it's not found!",
+			Data:    nil,
+		})
+	}
+
+	_, pkg, err := h.uploadScormPackage(c)
+	if err != nil {
+		return h.scormPackageError(c, err)
+	}
+
+	replaced, err := h.CourseRepository.ReplaceScormPackage(material.ID, *pkg)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("SCORM package", "update"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("SCORM package", "updated"),
+		Data:    h.scormMaterialResponse(material, replaced),
+	})
+}
+
+// FindScorm returns the launch URL of a SCORM material, for students with their lesson status and grade.
+func (h *Handlers) FindScorm(c *fiber.Ctx) error {
+	material, err := h.findScormMaterial(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find SCORM material because it's not found!",
+			Data:    nil,
+		})
+	}
+
+	if err := h.authorizeStudentMaterial(c, material.ID); err != nil {
+		return h.courseAccessError(c, "SCORM material", err)
+	}
+
+	pkg, err := h.CourseRepository.FindScormPackage(map[string]interface{}{
+		"material_id": material.ID,
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find SCORM package because it's not found!",
+			Data:    nil,
+		})
+	}
+
+	response := h.scormMaterialResponse(material, pkg)
+	response.Next = h.nextMaterialOf(material)
+
+	if principal := middleware.PrincipalFrom(c); principal.ActiveStudentID != "" {
+		registrations, err := h.CourseRepository.FindScormRegistrations(map[string]interface{}{
+			"material_id":       material.ID,
+			"active_student_id": principal.ActiveStudentID,
+		})
+		if err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorInternal("SCORM registration", "retrieve"),
+				Data:    nil,
+			})
+		}
+
+		response.LessonStatus = string(model.SCORM_NOT_ATTEMPTED)
+		if len(registrations) > 0 {
+			response.LessonStatus = string(registrations[0].LessonStatus)
+			if grade, ok := helper.ScormGrade(registrations[0]); ok {
+				response.Grade = &grade
+			}
+		}
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("SCORM material", "retrieve"),
+		Data:    response,
+	})
+}
+
+// InitializeScorm starts a SCORM session of the student (LMSInitialize) and returns the data model
+// the SCO reads with LMSGetValue.
+func (h *Handlers) InitializeScorm(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+
+	material, err := h.findScormMaterial(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find SCORM material because it's not found!",
+			Data:    nil,
+		})
+	}
+
+	if err := h.authorizeStudentMaterial(c, material.ID); err != nil {
+		return h.courseAccessError(c, "SCORM material", err)
+	}
+
+	pkg, err := h.CourseRepository.FindScormPackage(map[string]interface{}{
+		"material_id": material.ID,
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find SCORM package because it's not found!",
+			Data:    nil,
+		})
+	}
+
+	registration, err := h.CourseRepository.StartScormRegistration(*material, principal.ActiveStudentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrScormNotAvailable) {
+			return c.Status(403).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: "This SCORM material is not part of the version of the course you follow",
+				Data:    nil,
+			})
+		}
+
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("SCORM session", "initialize"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("SCORM session", "initialized"),
+		Data:    h.scormRuntimeResponse(c, registration, *pkg, nil),
+	})
+}
+
+// CommitScorm stores the values the SCO set (LMSCommit, or LMSFinish with finish). Rejected values
+// are returned with their SCORM error code, the other values are still stored.
+func (h *Handlers) CommitScorm(c *fiber.Ctx) error {
+	var request http.ScormRuntime
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	material, err := h.findScormMaterial(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find SCORM material because it's not found!",
+			Data:    nil,
+		})
+	}
+
+	registration, errs, err := h.CourseRepository.CommitScormRegistration(
+		material.ID,
+		middleware.PrincipalFrom(c).ActiveStudentID,
+		request.Values,
+		request.Finish,
+	)
+	if err != nil {
+		if errors.Is(err, repository.ErrScormNotInitialized) {
+			return c.Status(409).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: "SCORM session is not initialized",
+				Data:    nil,
+			})
+		}
+
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("SCORM session", "commit"),
+			Data:    nil,
+		})
+	}
+
+	pkg, err := h.CourseRepository.FindScormPackage(map[string]interface{}{
+		"material_id": material.ID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("SCORM package", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("SCORM session", "committed"),
+		Data:    h.scormRuntimeResponse(c, registration, *pkg, errs),
+	})
+}
+
+// GetScormRegistrations lists the results of the students for a SCORM material, in every version of
+// the material.
+func (h *Handlers) GetScormRegistrations(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.authorizeCourseEdit(c, repository.RESOURCE_MATERIAL, id); err != nil {
+		return h.courseAccessError(c, "material", err)
+	}
+
+	material, err := h.findScormMaterial(id)
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Couldn't find SCORM material because it's not found!",
+			Data:    nil,
+		})
+	}
+
+	lineageID := material.LineageID
+	if lineageID == "" {
+		lineageID = material.ID
+	}
+
+	versions, err := h.CourseRepository.FindMaterials(map[string]interface{}{
+		"lineage_id": lineageID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("SCORM registrations", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	materialIDs := []string{material.ID}
+	for _, version := range versions {
+		if version.ID != material.ID {
+			materialIDs = append(materialIDs, version.ID)
+		}
+	}
+
+	registrations, err := h.CourseRepository.FindScormRegistrations(map[string]interface{}{
+		"material_id": materialIDs,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("SCORM registrations", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	response := []http.ScormRegistrationHTTP{}
+	for _, registration := range registrations {
+		item := http.ScormRegistrationHTTP{
+			ID:              registration.ID,
+			MaterialID:      registration.MaterialID,
+			ActiveStudentID: registration.ActiveStudentID,
+			Name:            registration.ActiveStudent.Student.Name,
+			Class:           registration.ActiveStudent.Class,
+			LessonStatus:    string(registration.LessonStatus),
+			TotalTime:       registration.TotalTime,
+			Sessions:        registration.Sessions,
+			CompletedAt:     registration.CompletedAt,
+			UpdatedAt:       registration.UpdatedAt,
+		}
+
+		if grade, ok := helper.ScormGrade(registration); ok {
+			item.Grade = &grade
+		}
+
+		response = append(response, item)
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("SCORM registrations", "retrieve"),
+		Data:    response,
+	})
+}
+
+func (h *Handlers) findScormMaterial(id string) (*model.Material, error) {
+	material, err := h.CourseRepository.FindMaterial(map[string]interface{}{
+		"id": id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if material.Type != "SCORM" {
+		return nil, errors.New("material is not a SCORM material")
+	}
+
+	return material, nil
+}
+
+// uploadScormPackage reads the SCORM zip of the request and extracts it to storage.
+func (h *Handlers) uploadScormPackage(c *fiber.Ctx) (*helper.ScormManifest, *model.ScormPackage, error) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return nil, nil, errScormUpload
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest, files, err := helper.ReadScormPackage(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if h.R2Cloudflare == nil {
+		return nil, nil, errScormStorage
+	}
+
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	baseURL, err := service.UploadScormPackage(h.R2Cloudflare, id, files)
+	if err != nil {
+		logrus.Warnln("[scorm-handlers] Failed to upload SCORM package because error:", err)
+		return nil, nil, errScormStorage
+	}
+
+	return manifest, &model.ScormPackage{
+		ID:           id,
+		Identifier:   manifest.Identifier,
+		Title:        manifest.Title,
+		LaunchPath:   manifest.LaunchPath,
+		LaunchURL:    service.ScormLaunchURL(baseURL, manifest.LaunchPath),
+		BaseURL:      baseURL,
+		MasteryScore: manifest.MasteryScore,
+		LaunchData:   manifest.LaunchData,
+		SHA256:       manifest.SHA256,
+		Size:         manifest.Size,
+		Files:        len(files),
+	}, nil
+}
+
+var (
+	errScormUpload  = errors.New("SCORM package file is required")
+	errScormStorage = errors.New("Couldn't store SCORM package")
+)
+
+func (h *Handlers) scormPackageError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errScormUpload),
+		errors.Is(err, helper.ErrInvalidScormPackage),
+		errors.Is(err, helper.ErrUnsupportedScormPackage):
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	case errors.Is(err, errScormStorage):
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	default:
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("SCORM package", "read"),
+			Data:    nil,
+		})
+	}
+}
+
+// nextMaterialOf is the material after material, in its chapter or the next chapter.
+func (h *Handlers) nextMaterialOf(material *model.Material) *http.NextMaterialHTTP {
+	next, err := h.CourseRepository.NextMaterial(&helper.NextMaterialArg{
+		ChapterID:         material.ChapterID,
+		CurrentMaterialID: material.ID,
+		CreatedAt:         material.CreatedAt,
+	}, false)
+
+	if err == nil && next.ChapterID == material.ChapterID {
+		return &http.NextMaterialHTTP{
+			ID:   next.ID,
+			Type: next.Type,
+		}
+	}
+
+	if next != nil {
+		return nil
+	}
+
+	chapter, err := h.CourseRepository.FindChapter(map[string]interface{}{
+		"id": material.ChapterID,
+	})
+	if err != nil {
+		return nil
+	}
+
+	nextChapter := h.CourseRepository.NextChapter(material.ChapterID, chapter.CourseID)
+	if nextChapter == nil {
+		return nil
+	}
+
+	doNext, err := h.CourseRepository.NextMaterial(&helper.NextMaterialArg{
+		ChapterID:         nextChapter.ID,
+		CurrentMaterialID: material.ID,
+		CreatedAt:         material.CreatedAt,
+	}, true)
+	if err != nil {
+		return nil
+	}
+
+	return &http.NextMaterialHTTP{
+		ID:   doNext.ID,
+		Type: doNext.Type,
+	}
+}
+
+func (h *Handlers) scormMaterialResponse(material *model.Material, pkg *model.ScormPackage) http.ScormMaterialHTTP {
+	typeOfMaterial := "SCORM"
+
+	return http.ScormMaterialHTTP{
+		ID:           material.ID,
+		ChapterID:    material.ChapterID,
+		Title:        material.Title,
+		Slug:         material.Slug,
+		Type:         &typeOfMaterial,
+		PackageTitle: pkg.Title,
+		LaunchURL:    pkg.LaunchURL,
+		MasteryScore: pkg.MasteryScore,
+		Files:        pkg.Files,
+		Size:         pkg.Size,
+		CreatedAt:    material.CreatedAt,
+		UpdatedAt:    material.UpdatedAt,
+	}
+}
+
+func (h *Handlers) scormRuntimeResponse(
+	c *fiber.Ctx,
+	registration *model.ScormRegistration,
+	pkg model.ScormPackage,
+	errs map[string]helper.SCORM_ERROR,
+) http.ScormRuntimeResponse {
+	principal := middleware.PrincipalFrom(c)
+
+	response := http.ScormRuntimeResponse{
+		Values:       helper.ScormRuntimeValues(registration, pkg, principal.StudentID, principal.Name),
+		Errors:       map[string]string{},
+		LessonStatus: string(registration.LessonStatus),
+		Completed:    helper.IsScormCompleted(registration.LessonStatus),
+	}
+
+	for element, code := range errs {
+		response.Errors[element] = string(code)
+	}
+
+	return response
+}
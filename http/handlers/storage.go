@@ -12,7 +12,7 @@ import (
 func (h *Handlers) RouteStorage(app *fiber.App) {
 	v1 := app.Group("/api/v1")
 
-	v1.Post("/storage", h.Middleware.Protected(), h.UploadViaHTTP)
+	v1.Post("/storage", h.Middleware.Protected(), h.tenant((*Handlers).UploadViaHTTP))
 }
 
 func (h *Handlers) UploadViaHTTP(c *fiber.Ctx) error {
@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	twoFactorIssuer = "E-Learning"
+	// twoFactorChallengeTTL is how long the user has to enter its code after the password.
+	twoFactorChallengeTTL = 5 * time.Minute
+	twoFactorPurpose      = "two_factor"
+	recoveryCodeCount     = 10
+)
+
+var errTwoFactorChallengeInvalid = errors.New("Two-factor token is invalid or has expired, please log in again!")
+
+// RouteTwoFactor defines the routes of the TOTP second factor of the login.
+func (h *Handlers) RouteTwoFactor(app *fiber.App) {
+	v1 := app.Group("/api/v1")
+
+	v1.Post("/login/2fa", h.LoginTwoFactorHandler)
+
+	v1.Get("/me/2fa", h.Middleware.Protected(), h.tenant((*Handlers).TwoFactorStatusHandler))
+	v1.Post("/me/2fa/enroll", h.Middleware.Protected(), h.tenant((*Handlers).EnrollTwoFactorHandler))
+	v1.Post("/me/2fa/confirm", h.Middleware.Protected(), h.tenant((*Handlers).ConfirmTwoFactorHandler))
+	v1.Delete("/me/2fa", h.Middleware.Protected(), h.tenant((*Handlers).DisableTwoFactorHandler))
+
+	v1.Post("/admin/users/:id/reset-2fa", h.Middleware.Protected(), h.tenant((*Handlers).ResetTwoFactorHandler))
+}
+
+// twoFactorChallenge returns the second login step of a user with a confirmed TOTP, nil when the
+// user logs in with its password only. The challenge token can't be used as access token, it
+// has no session.
+func (h *Handlers) twoFactorChallenge(user *model.User) (map[string]interface{}, error) {
+	totp, err := h.UserRepository.FindUserTOTP(user.ID)
+	if err != nil {
+		return nil, fiber.NewError(500, h.errorInternal("two-factor", "check"))
+	}
+
+	if totp == nil || totp.ConfirmedAt == nil {
+		return nil, nil
+	}
+
+	token, err := h.Middleware.Keys.Sign(jwt.MapClaims{
+		"user_id": user.ID,
+		"purpose": twoFactorPurpose,
+		"exp":     time.Now().Add(twoFactorChallengeTTL).Unix(),
+	})
+	if err != nil {
+		return nil, fiber.NewError(500, "Error generate jwt token")
+	}
+
+	return map[string]interface{}{
+		"two_factor_required": true,
+		"two_factor_token":    token,
+		"expires_in":          int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// LoginTwoFactorHandler finishes the login of a user with TOTP. It takes the challenge token of
+// the login and either a TOTP code or a recovery code. Wrong codes count as failed logins.
+func (h *Handlers) LoginTwoFactorHandler(c *fiber.Ctx) error {
+	var request http.LoginTwoFactor
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(request.TwoFactorToken, claims, h.Middleware.Keys.Keyfunc)
+	userID, _ := claims["user_id"].(string)
+
+	if err != nil || claims["purpose"] != twoFactorPurpose || userID == "" {
+		return c.Status(401).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: errTwoFactorChallengeInvalid.Error(),
+			Data:    nil,
+		})
+	}
+
+	user, err := h.UserRepository.FindUser(map[string]interface{}{
+		"id": userID,
+	})
+	if err != nil || user.Status != model.USER_ACTIVE {
+		return c.Status(401).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: errTwoFactorChallengeInvalid.Error(),
+			Data:    nil,
+		})
+	}
+
+	ip := c.IP()
+	wait, err := h.Middleware.LoginGuard.Check(user.Username, ip)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("login attempt", "check"),
+			Data:    nil,
+		})
+	}
+
+	if wait > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		return c.Status(429).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Too many failed login attempts, please try again later!",
+			Data:    nil,
+		})
+	}
+
+	valid, err := h.verifySecondFactor(user.ID, request.Code, request.RecoveryCode)
+	if err != nil {
+		return h.authErrorResponse(c, err)
+	}
+
+	if !valid {
+		h.Middleware.LoginGuard.Fail(user.Username, ip, "wrong_two_factor_code")
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Two-factor code is incorrect!",
+			Data:    nil,
+		})
+	}
+
+	if request.RecoveryCode != "" {
+		logrus.WithFields(logrus.Fields{
+			"event":   "recovery_code_used",
+			"user_id": user.ID,
+			"ip":      ip,
+		}).Warnln("[auth] Login with a recovery code")
+	}
+
+	tokens, err := h.createSession(c, user)
+	if err != nil {
+		return h.authErrorResponse(c, err)
+	}
+
+	h.Middleware.LoginGuard.Succeed(user.Username)
+
+	return c.JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "Credentials is valid and Login successfuly!",
+		Data:    tokens,
+	})
+}
+
+// verifySecondFactor checks a TOTP code, or a recovery code when code is empty, of a user with a
+// confirmed TOTP. Both can only be used once.
+func (h *Handlers) verifySecondFactor(userID, code, recoveryCode string) (bool, error) {
+	totp, err := h.UserRepository.FindUserTOTP(userID)
+	if err != nil {
+		return false, fiber.NewError(500, h.errorInternal("two-factor", "check"))
+	}
+
+	if totp == nil || totp.ConfirmedAt == nil {
+		return false, nil
+	}
+
+	if code == "" && recoveryCode != "" {
+		used, err := h.UserRepository.UseRecoveryCode(userID, helper.HashRecoveryCode(recoveryCode))
+		if err != nil {
+			return false, fiber.NewError(500, h.errorInternal("recovery code", "use"))
+		}
+
+		return used, nil
+	}
+
+	step, ok := helper.ValidateTOTP(totp.Secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	used, err := h.UserRepository.UseTOTPStep(userID, step)
+	if err != nil {
+		return false, fiber.NewError(500, h.errorInternal("two-factor", "check"))
+	}
+
+	return used, nil
+}
+
+// TwoFactorStatusHandler tells whether the TOTP of the logged in user is enabled or required.
+func (h *Handlers) TwoFactorStatusHandler(c *fiber.Ctx) error {
+	userID := middleware.PrincipalFrom(c).UserID
+
+	totp, err := h.UserRepository.FindUserTOTP(userID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("two-factor", "retrieve"),
+			Data:    nil,
+		})
+	}
+
+	response := http.TwoFactorStatusHTTP{
+		Required: h.TwoFactorRoles[middleware.PrincipalFrom(c).Role],
+	}
+
+	if totp != nil && totp.ConfirmedAt != nil {
+		response.Enabled = true
+		response.ConfirmedAt = totp.ConfirmedAt
+
+		response.RecoveryCodesLeft, err = h.UserRepository.CountRecoveryCodes(userID)
+		if err != nil {
+			return c.Status(500).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: h.errorInternal("two-factor", "retrieve"),
+				Data:    nil,
+			})
+		}
+	}
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Two-factor", "retrieved"),
+		Data:    response,
+	})
+}
+
+// EnrollTwoFactorHandler generates a new TOTP secret for the logged in user. The secret is only
+// used at login after it is confirmed with a code, enrolling again replaces an unconfirmed secret.
+func (h *Handlers) EnrollTwoFactorHandler(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	userID := principal.UserID
+
+	totp, err := h.UserRepository.FindUserTOTP(userID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("two-factor", "enroll"),
+			Data:    nil,
+		})
+	}
+
+	if totp != nil && totp.ConfirmedAt != nil {
+		return c.Status(409).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Two-factor authentication is already enabled",
+			Data:    nil,
+		})
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("two-factor", "generate secret"),
+			Data:    nil,
+		})
+	}
+
+	if err := h.UserRepository.SaveUserTOTP(model.UserTOTP{
+		UserID: userID,
+		Secret: secret,
+	}); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("two-factor", "enroll"),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(201).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: "Scan the QR code with an authenticator app and confirm it with a code",
+		Data: http.TwoFactorEnrollHTTP{
+			Secret:          secret,
+			ProvisioningURI: helper.TOTPProvisioningURI(twoFactorIssuer, principal.Username, secret),
+		},
+	})
+}
+
+// ConfirmTwoFactorHandler enables the enrolled TOTP with a first code and returns the recovery
+// codes, they are only shown once. The user gets a new session without must_enroll_two_factor.
+func (h *Handlers) ConfirmTwoFactorHandler(c *fiber.Ctx) error {
+	var request http.TwoFactorCode
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	userID := middleware.PrincipalFrom(c).UserID
+
+	totp, err := h.UserRepository.FindUserTOTP(userID)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("two-factor", "confirm"),
+			Data:    nil,
+		})
+	}
+
+	if totp == nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Two-factor authentication is not enrolled",
+			Data:    nil,
+		})
+	}
+
+	if totp.ConfirmedAt != nil {
+		return c.Status(409).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Two-factor authentication is already enabled",
+			Data:    nil,
+		})
+	}
+
+	step, ok := helper.ValidateTOTP(totp.Secret, request.Code, time.Now())
+	if !ok {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Two-factor code is incorrect!",
+			Data:    nil,
+		})
+	}
+
+	codes, err := helper.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("recovery code", "generate"),
+			Data:    nil,
+		})
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, helper.HashRecoveryCode(code))
+	}
+
+	if err := h.UserRepository.ConfirmUserTOTP(userID, step, hashes); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("two-factor", "confirm"),
+			Data:    nil,
+		})
+	}
+
+	user, err := h.UserRepository.FindUser(map[string]interface{}{
+		"id": userID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("two-factor", "confirm"),
+			Data:    nil,
+		})
+	}
+
+	tokens, err := h.createSession(c, user)
+	if err != nil {
+		return h.authErrorResponse(c, err)
+	}
+
+	tokens["recovery_codes"] = codes
+
+	logrus.WithFields(logrus.Fields{
+		"event":   "two_factor_enabled",
+		"user_id": userID,
+	}).Infoln("[auth] Two-factor authentication enabled")
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Two-factor authentication", "enabled"),
+		Data:    tokens,
+	})
+}
+
+// DisableTwoFactorHandler disables the TOTP of the logged in user, it needs the password and a
+// TOTP or recovery code. Users of a role requiring 2FA can't disable it.
+func (h *Handlers) DisableTwoFactorHandler(c *fiber.Ctx) error {
+	var request http.DisableTwoFactor
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorParseBodyRequest(),
+			Data:    nil,
+		})
+	}
+
+	if h.TwoFactorRoles[middleware.PrincipalFrom(c).Role] {
+		return c.Status(401).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Two-factor authentication is required for your role",
+			Data:    nil,
+		})
+	}
+
+	user, err := h.UserRepository.FindUser(map[string]interface{}{
+		"id": middleware.PrincipalFrom(c).UserID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("two-factor", "disable"),
+			Data:    nil,
+		})
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password)); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Password is incorrect!",
+			Data:    nil,
+		})
+	}
+
+	valid, err := h.verifySecondFactor(user.ID, request.Code, request.RecoveryCode)
+	if err != nil {
+		return h.authErrorResponse(c, err)
+	}
+
+	if !valid {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "Two-factor code is incorrect!",
+			Data:    nil,
+		})
+	}
+
+	if err := h.UserRepository.DeleteUserTOTP(user.ID); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("two-factor", "disable"),
+			Data:    nil,
+		})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"event":   "two_factor_disabled",
+		"user_id": user.ID,
+	}).Infoln("[auth] Two-factor authentication disabled")
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Two-factor authentication", "disabled"),
+		Data:    nil,
+	})
+}
+
+// ResetTwoFactorHandler disables the TOTP of a user who lost its authenticator and its recovery
+// codes, the user can enroll again at the next login. ADMIN can only reset the students and
+// teachers of its school.
+func (h *Handlers) ResetTwoFactorHandler(c *fiber.Ctx) error {
+	user, err := h.UserRepository.FindUser(map[string]interface{}{
+		"id": c.Params("id"),
+	})
+	if err != nil {
+		return c.Status(404).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorSpecifyResource("id"),
+			Data:    nil,
+		})
+	}
+
+	if !middleware.PrincipalFrom(c).IsSuperAdmin() && user.Role != model.STUDENT && user.Role != model.TEACHER {
+		return c.Status(401).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: "ADMIN can only reset the two-factor authentication of students and teachers",
+			Data:    nil,
+		})
+	}
+
+	if err := h.UserRepository.DeleteUserTOTP(user.ID); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: h.errorInternal("two-factor", "reset"),
+			Data:    nil,
+		})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"event":    "two_factor_reset",
+		"user_id":  user.ID,
+		"reset_by": middleware.PrincipalFrom(c).UserID,
+	}).Infoln("[auth] Two-factor authentication reset")
+
+	return c.Status(200).JSON(&http.WebResponse{
+		Status:  "success",
+		Message: h.successResponse("Two-factor authentication", "reset"),
+		Data: map[string]interface{}{
+			"user_id":  user.ID,
+			"username": user.Username,
+		},
+	})
+}
+
+// ParseTwoFactorRoles parses the comma separated roles of TWO_FACTOR_REQUIRED_ROLES.
+func ParseTwoFactorRoles(value string) (map[model.ROLE]bool, error) {
+	roles := make(map[model.ROLE]bool)
+
+	for _, role := range strings.Split(value, ",") {
+		role = strings.ToUpper(strings.TrimSpace(role))
+		if role == "" {
+			continue
+		}
+
+		switch model.ROLE(role) {
+		case model.SUPER_ADMIN, model.ADMIN, model.TEACHER, model.STUDENT:
+			roles[model.ROLE(role)] = true
+		default:
+			return nil, errors.New("unknown role " + role)
+		}
+	}
+
+	return roles, nil
+}
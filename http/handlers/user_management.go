@@ -3,6 +3,7 @@ package handlers
 import (
 	"github.com/cvzamannow/E-Learning-API/helper"
 	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/middleware"
 	"github.com/cvzamannow/E-Learning-API/model"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gosimple/slug"
@@ -17,50 +18,40 @@ func (h *Handlers) RouterUserManagemet(app *fiber.App) {
 	v1Base := app.Group("/api/v1")
 
 	// CRUD operations for students
-	v1.Post("/students", h.Middleware.Protected(), h.CreateStudent)
-	v1.Get("/students", h.Middleware.Protected(), h.GetAllStudents)
-	v1.Get("/students/:id", h.Middleware.Protected(), h.GetStudentById)
-	v1.Put("/students/:id", h.Middleware.Protected(), h.UpdateStudentById)
-	v1.Delete("/students/:id", h.Middleware.Protected(), h.DeleteStudentById)
+	v1.Post("/students", h.Middleware.Protected(), h.tenant((*Handlers).CreateStudent))
+	v1.Get("/students", h.Middleware.Protected(), h.tenant((*Handlers).GetAllStudents))
+	v1.Get("/students/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetStudentById))
+	v1.Put("/students/:id", h.Middleware.Protected(), h.tenant((*Handlers).UpdateStudentById))
+	v1.Delete("/students/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteStudentById))
 
 	// CRUD operations for teachers
-	v1.Post("/teachers", h.Middleware.Protected(), h.CreateTeacher)
-	v1.Get("/teachers", h.Middleware.Protected(), h.GetAllTeachers)
-	v1.Get("/teachers/:id", h.Middleware.Protected(), h.GetTeacherById)
-	v1.Put("/teachers/:id", h.Middleware.Protected(), h.UpdateTeacherById)
-	v1.Delete("/teachers/:id", h.Middleware.Protected(), h.DeleteTeacherById)
+	v1.Post("/teachers", h.Middleware.Protected(), h.tenant((*Handlers).CreateTeacher))
+	v1.Get("/teachers", h.Middleware.Protected(), h.tenant((*Handlers).GetAllTeachers))
+	v1.Get("/teachers/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetTeacherById))
+	v1.Put("/teachers/:id", h.Middleware.Protected(), h.tenant((*Handlers).UpdateTeacherById))
+	v1.Delete("/teachers/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteTeacherById))
 
-	v1Base.Get("/placeholder/teachers", h.Middleware.Protected(), h.GetAllTeachersHandler)
+	v1Base.Get("/placeholder/teachers", h.Middleware.Protected(), h.tenant((*Handlers).GetAllTeachersHandler))
 
 	// CRUD operations for active students
-	v1.Post("/active-students", h.Middleware.Protected(), h.CreateActiveStudent)
-	v1.Get("/active-students", h.Middleware.Protected(), h.GetAllActiveStudents)
-	v1.Get("/active-students/:id", h.Middleware.Protected(), h.GetActiveStudentById)
-	v1.Put("/active-students/:id", h.Middleware.Protected(), h.UpdateActiveStudentsById)
-	v1.Delete("/active-students/:id", h.Middleware.Protected(), h.DeleteActiveStudentById)
+	v1.Post("/active-students", h.Middleware.Protected(), h.tenant((*Handlers).CreateActiveStudent))
+	v1.Get("/active-students", h.Middleware.Protected(), h.tenant((*Handlers).GetAllActiveStudents))
+	v1.Get("/active-students/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetActiveStudentById))
+	v1.Put("/active-students/:id", h.Middleware.Protected(), h.tenant((*Handlers).UpdateActiveStudentsById))
+	v1.Delete("/active-students/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteActiveStudentById))
 
 	// CRUD operations for Admin School
-	v1.Post("/super-admin/admin-schools", h.Middleware.Protected(), h.CreateAdminSchool)
-	v1.Get("/super-admin/admin-schools", h.Middleware.Protected(), h.GetAllAdminSchool)
-	v1.Get("/super-admin/admin-schools/:id", h.Middleware.Protected(), h.GetAdminSchoolById)
-	v1.Put("/super-admin/admin-schools/:id", h.Middleware.Protected(), h.UpdateAdminSchool)
-	v1.Delete("/super-admin/admin-schools/:id", h.Middleware.Protected(), h.DeleteAdminSchool)
+	v1.Post("/super-admin/admin-schools", h.Middleware.Protected(), h.tenant((*Handlers).CreateAdminSchool))
+	v1.Get("/super-admin/admin-schools", h.Middleware.Protected(), h.tenant((*Handlers).GetAllAdminSchool))
+	v1.Get("/super-admin/admin-schools/:id", h.Middleware.Protected(), h.tenant((*Handlers).GetAdminSchoolById))
+	v1.Put("/super-admin/admin-schools/:id", h.Middleware.Protected(), h.tenant((*Handlers).UpdateAdminSchool))
+	v1.Delete("/super-admin/admin-schools/:id", h.Middleware.Protected(), h.tenant((*Handlers).DeleteAdminSchool))
 }
 
 // CreateStudent handles the creation of a new student.
 func (h *Handlers) CreateStudent(c *fiber.Ctx) error {
 	var createStudentRequest http.Student
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	// Parse request body into createStudentRequest struct
 	if err := c.BodyParser(&createStudentRequest); err != nil {
 		return c.JSON(&http.WebResponse{
@@ -80,6 +71,14 @@ func (h *Handlers) CreateStudent(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := helper.ValidatePassword(createStudentRequest.Password, createStudentRequest.Username); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
 	// Create a hash for the password
 	hashString, err := bcrypt.GenerateFromPassword(
 		[]byte(createStudentRequest.Password),
@@ -103,8 +102,10 @@ func (h *Handlers) CreateStudent(c *fiber.Ctx) error {
 			ID:       id,
 			Username: createStudentRequest.Username,
 			Password: string(hashString),
-			Status:   "ACTIVE",
-			Role:     "STUDENT",
+			// Password dibuat oleh admin, user wajib menggantinya saat login pertama
+			MustChangePassword: true,
+			Status:             "ACTIVE",
+			Role:               "STUDENT",
 		},
 	})
 
@@ -127,16 +128,6 @@ func (h *Handlers) CreateStudent(c *fiber.Ctx) error {
 func (h *Handlers) GetAllStudents(c *fiber.Ctx) error {
 	result, err := h.UserRepository.GetAllStudent()
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	if err != nil {
 		return c.JSON(&http.WebResponse{
 			Status:  "error",
@@ -176,16 +167,6 @@ func (h *Handlers) GetStudentById(c *fiber.Ctx) error {
 		"id": c.Params("id"),
 	})
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	if err != nil {
 		return c.JSON(&http.WebResponse{
 			Status:  "error",
@@ -218,26 +199,35 @@ func (h *Handlers) GetStudentById(c *fiber.Ctx) error {
 func (h *Handlers) UpdateStudentById(c *fiber.Ctx) error {
 	var requestUpdate http.Student
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
+	// Parse request body into requestUpdate struct
+	if err := c.BodyParser(&requestUpdate); err != nil {
+		return c.JSON(&http.WebResponse{
 			Status:  "error",
-			Message: "You are not authorized to perform this action",
+			Message: "Error parsing request to update student",
 			Data:    nil,
 		})
 	}
 
-	// Parse request body into requestUpdate struct
-	if err := c.BodyParser(&requestUpdate); err != nil {
-		return c.JSON(&http.WebResponse{
+	if !validUserStatus(requestUpdate.Status) {
+		return c.Status(400).JSON(&http.WebResponse{
 			Status:  "error",
-			Message: "Error parsing request to update student",
+			Message: "status is invalid",
 			Data:    nil,
 		})
 	}
 
-	hashString, err := bcrypt.GenerateFromPassword([]byte(requestUpdate.Password), bcrypt.DefaultCost)
+	// Password kosong berarti password lama tetap dipakai
+	if requestUpdate.Password != "" {
+		if err := helper.ValidatePassword(requestUpdate.Password, requestUpdate.Username); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			})
+		}
+	}
+
+	hashString, err := hashUpdatedPassword(requestUpdate.Password)
 
 	if err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
@@ -248,7 +238,7 @@ func (h *Handlers) UpdateStudentById(c *fiber.Ctx) error {
 	}
 
 	// Update student record in the database
-	_, err = h.UserRepository.UpdateStudent(
+	result, err := h.UserRepository.UpdateStudent(
 		map[string]interface{}{
 			"id": c.Params("id"),
 		},
@@ -259,6 +249,7 @@ func (h *Handlers) UpdateStudentById(c *fiber.Ctx) error {
 			User: model.User{
 				Username: requestUpdate.Username,
 				Password: string(hashString),
+				Status:   requestUpdate.Status,
 			},
 		},
 	)
@@ -271,6 +262,8 @@ func (h *Handlers) UpdateStudentById(c *fiber.Ctx) error {
 		})
 	}
 
+	h.Middleware.SessionCache.InvalidateUser(result.UserID)
+
 	return c.JSON(&http.WebResponse{
 		Status:  "success",
 		Message: "Student updated successfully",
@@ -281,20 +274,10 @@ func (h *Handlers) UpdateStudentById(c *fiber.Ctx) error {
 // DeleteStudentById handles the deletion of a student by ID.
 func (h *Handlers) DeleteStudentById(c *fiber.Ctx) error {
 	// Delete student record from the database
-	_, err := h.UserRepository.DeleteStudent(map[string]interface{}{
+	result, err := h.UserRepository.DeleteStudent(map[string]interface{}{
 		"id": c.Params("id"),
 	})
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	if err != nil {
 		return c.JSON(&http.WebResponse{
 			Status:  "error",
@@ -303,6 +286,8 @@ func (h *Handlers) DeleteStudentById(c *fiber.Ctx) error {
 		})
 	}
 
+	h.Middleware.SessionCache.InvalidateUser(result.UserID)
+
 	return c.JSON(&http.WebResponse{
 		Status:  "success",
 		Message: "Student deleted successfully",
@@ -314,16 +299,6 @@ func (h *Handlers) DeleteStudentById(c *fiber.Ctx) error {
 func (h *Handlers) CreateTeacher(c *fiber.Ctx) error {
 	var requestBody http.Teacher
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	// Parse request body into requestBody struct
 	if err := c.BodyParser(&requestBody); err != nil {
 		logrus.Warnln("[handlers] Error parsing payload:", err)
@@ -354,6 +329,14 @@ func (h *Handlers) CreateTeacher(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := helper.ValidatePassword(requestBody.Password, requestBody.Username); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
 	// Create a hash for the password
 	hashString, err := bcrypt.GenerateFromPassword([]byte(requestBody.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -374,8 +357,10 @@ func (h *Handlers) CreateTeacher(c *fiber.Ctx) error {
 			ID:       id,
 			Username: requestBody.Username,
 			Password: string(hashString),
-			Status:   "ACTIVE",
-			Role:     "TEACHER",
+			// Password dibuat oleh admin, user wajib menggantinya saat login pertama
+			MustChangePassword: true,
+			Status:             "ACTIVE",
+			Role:               "TEACHER",
 		},
 	})
 
@@ -398,16 +383,6 @@ func (h *Handlers) CreateTeacher(c *fiber.Ctx) error {
 func (h *Handlers) GetAllTeachers(c *fiber.Ctx) error {
 	result, err := h.UserRepository.GetAllTeacher()
 
-	role := c.Locals("role").(string)
-
-	if role == "STUDENT" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	if err != nil {
 		return c.JSON(&http.WebResponse{
 			Status:  "error",
@@ -446,16 +421,6 @@ func (h *Handlers) GetTeacherById(c *fiber.Ctx) error {
 		"id": c.Params("id"),
 	})
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	if err != nil {
 		return c.JSON(&http.WebResponse{
 			Status:  "error",
@@ -493,17 +458,26 @@ func (h *Handlers) UpdateTeacherById(c *fiber.Ctx) error {
 		})
 	}
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
+	if !validUserStatus(requestBody.Status) {
+		return c.Status(400).JSON(&http.WebResponse{
 			Status:  "error",
-			Message: "You are not authorized to perform this action",
+			Message: "status is invalid",
 			Data:    nil,
 		})
 	}
 
-	hashString, err := bcrypt.GenerateFromPassword([]byte(requestBody.Password), bcrypt.DefaultCost)
+	// Password kosong berarti password lama tetap dipakai
+	if requestBody.Password != "" {
+		if err := helper.ValidatePassword(requestBody.Password, requestBody.Username); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			})
+		}
+	}
+
+	hashString, err := hashUpdatedPassword(requestBody.Password)
 
 	if err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
@@ -513,7 +487,7 @@ func (h *Handlers) UpdateTeacherById(c *fiber.Ctx) error {
 		})
 	}
 
-	_, err = h.UserRepository.UpdateTeacher(map[string]interface{}{
+	result, err := h.UserRepository.UpdateTeacher(map[string]interface{}{
 		"id": c.Params("id"),
 	}, model.Teacher{
 		Name:     requestBody.Name,
@@ -521,6 +495,7 @@ func (h *Handlers) UpdateTeacherById(c *fiber.Ctx) error {
 		User: model.User{
 			Username: requestBody.Username,
 			Password: string(hashString),
+			Status:   requestBody.Status,
 		},
 	})
 
@@ -532,6 +507,8 @@ func (h *Handlers) UpdateTeacherById(c *fiber.Ctx) error {
 		})
 	}
 
+	h.Middleware.SessionCache.InvalidateUser(result.UserID)
+
 	return c.JSON(&http.WebResponse{
 		Status:  "success",
 		Message: "Teacher updated successfully",
@@ -542,20 +519,10 @@ func (h *Handlers) UpdateTeacherById(c *fiber.Ctx) error {
 
 // DeleteTeacherById handles the deletion of a teacher by ID.
 func (h *Handlers) DeleteTeacherById(c *fiber.Ctx) error {
-	_, err := h.UserRepository.DeleteTeacher(map[string]interface{}{
+	result, err := h.UserRepository.DeleteTeacher(map[string]interface{}{
 		"id": c.Params("id"),
 	})
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	if err != nil {
 		return c.JSON(&http.WebResponse{
 			Status:  "error",
@@ -564,6 +531,8 @@ func (h *Handlers) DeleteTeacherById(c *fiber.Ctx) error {
 		})
 	}
 
+	h.Middleware.SessionCache.InvalidateUser(result.UserID)
+
 	return c.JSON(&http.WebResponse{
 		Status:  "success",
 		Message: "Teacher deleted successfully",
@@ -586,16 +555,6 @@ func (h *Handlers) CreateActiveStudent(c *fiber.Ctx) error {
 		})
 	}
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	// Generate a nano ID string for active student ID
 	id, err := helper.GenerateNanoId()
 	if err != nil {
@@ -674,16 +633,6 @@ func (h *Handlers) GetAllActiveStudents(c *fiber.Ctx) error {
 		})
 	}
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	var response []map[string]interface{}
 
 	for _, items := range *result {
@@ -714,16 +663,6 @@ func (h *Handlers) DeleteActiveStudentById(c *fiber.Ctx) error {
 		"id": c.Params("id"),
 	})
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	if err != nil {
 		return c.JSON(&http.WebResponse{
 			Status:  "error",
@@ -750,16 +689,6 @@ func (h *Handlers) UpdateActiveStudentsById(c *fiber.Ctx) error {
 		})
 	}
 
-	role := c.Locals("role").(string)
-
-	if role != "ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	// Update active student record in the database
 	_, err := h.UserRepository.UpdateActiveStudent(map[string]interface{}{
 		"id": c.Params("id"),
@@ -788,16 +717,6 @@ func (h *Handlers) UpdateActiveStudentsById(c *fiber.Ctx) error {
 // CRUD ADMIN
 func (h *Handlers) CreateAdminSchool(c *fiber.Ctx) error {
 
-	role := c.Locals("role").(string)
-
-	if role != "SUPER_ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	var request http.Admin
 
 	if err := c.BodyParser(&request); err != nil {
@@ -819,6 +738,14 @@ func (h *Handlers) CreateAdminSchool(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := helper.ValidatePassword(request.Password, request.Username); err != nil {
+		return c.Status(400).JSON(&http.WebResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		})
+	}
+
 	// Create a hash for the password
 	hashString, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -836,8 +763,10 @@ func (h *Handlers) CreateAdminSchool(c *fiber.Ctx) error {
 			ID:       string(userId),
 			Username: request.Username,
 			Password: string(hashString),
-			Status:   "ACTIVE",
-			Role:     "ADMIN",
+			// Password dibuat oleh admin, user wajib menggantinya saat login pertama
+			MustChangePassword: true,
+			Status:             "ACTIVE",
+			Role:               "ADMIN",
 		},
 	})
 
@@ -870,16 +799,6 @@ func (h *Handlers) CreateAdminSchool(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) GetAllAdminSchool(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-
-	if role != "SUPER_ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	result, err := h.UserRepository.GetAllAdminSchool()
 
 	if err != nil {
@@ -917,16 +836,6 @@ func (h *Handlers) GetAllAdminSchool(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) GetAdminSchoolById(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-
-	if role != "SUPER_ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
 	result, err := h.UserRepository.FindAdminSchool(map[string]interface{}{
 		"id": c.Params("id"),
 	})
@@ -963,27 +872,36 @@ func (h *Handlers) GetAdminSchoolById(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) UpdateAdminSchool(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
+	var request http.Admin
 
-	if role != "SUPER_ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(500).JSON(&http.WebResponse{
 			Status:  "error",
-			Message: "You are not authorized to perform this action",
+			Message: h.errorParseBodyRequest(),
 			Data:    nil,
 		})
 	}
 
-	var request http.Admin
-
-	if err := c.BodyParser(&request); err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
+	if !validUserStatus(request.Status) {
+		return c.Status(400).JSON(&http.WebResponse{
 			Status:  "error",
-			Message: h.errorParseBodyRequest(),
+			Message: "status is invalid",
 			Data:    nil,
 		})
 	}
 
-	hashString, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	// Password kosong berarti password lama tetap dipakai
+	if request.Password != "" {
+		if err := helper.ValidatePassword(request.Password, request.Username); err != nil {
+			return c.Status(400).JSON(&http.WebResponse{
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			})
+		}
+	}
+
+	hashString, err := hashUpdatedPassword(request.Password)
 
 	if err != nil {
 		return c.Status(500).JSON(&http.WebResponse{
@@ -994,7 +912,7 @@ func (h *Handlers) UpdateAdminSchool(c *fiber.Ctx) error {
 	}
 
 	// Panggil fungsi UpdateAdminSchool dengan menggunakan transaksi
-	_, err = h.UserRepository.UpdateAdminSchool(map[string]interface{}{
+	result, err := h.UserRepository.UpdateAdminSchool(map[string]interface{}{
 		"id": c.Params("id"),
 	},
 		model.AdminSchool{
@@ -1002,6 +920,7 @@ func (h *Handlers) UpdateAdminSchool(c *fiber.Ctx) error {
 			User: model.User{
 				Username: request.Username,
 				Password: string(hashString),
+				Status:   request.Status,
 			},
 		},
 	)
@@ -1014,6 +933,8 @@ func (h *Handlers) UpdateAdminSchool(c *fiber.Ctx) error {
 		})
 	}
 
+	h.Middleware.SessionCache.InvalidateUser(result.UserID)
+
 	return c.Status(200).JSON(&http.WebResponse{
 		Status:  "success",
 		Message: h.successResponse("Admin", "updated"),
@@ -1022,17 +943,7 @@ func (h *Handlers) UpdateAdminSchool(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) DeleteAdminSchool(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-
-	if role != "SUPER_ADMIN" {
-		return c.Status(401).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "You are not authorized to perform this action",
-			Data:    nil,
-		})
-	}
-
-	_, err := h.UserRepository.DeleteAdminSchool(map[string]interface{}{
+	result, err := h.UserRepository.DeleteAdminSchool(map[string]interface{}{
 		"id": c.Params("id"),
 	})
 
@@ -1044,6 +955,8 @@ func (h *Handlers) DeleteAdminSchool(c *fiber.Ctx) error {
 		})
 	}
 
+	h.Middleware.SessionCache.InvalidateUser(result.UserID)
+
 	return c.Status(200).JSON(&http.WebResponse{
 		Status:  "success",
 		Message: h.successResponse("Admin", "deleted"),
@@ -1055,23 +968,9 @@ func (h *Handlers) DeleteAdminSchool(c *fiber.Ctx) error {
 
 func (h *Handlers) GetAllTeachersHandler(c *fiber.Ctx) error {
 
-	userID := c.Locals("user_id").(string)
-
-	// mencari siswa
-	findStundet, err := h.UserRepository.FindStudent(map[string]interface{}{
-		"user_id": userID,
-	})
-
-	if err != nil {
-		return c.Status(500).JSON(&http.WebResponse{
-			Status:  "error",
-			Message: "Error getting student",
-			Data:    nil,
-		})
-	}
-
+	// Guru di sekolah siswa yang login
 	result, err := h.UserRepository.FindTeachers(map[string]interface{}{
-		"schools_id": findStundet.SchoolsID,
+		"schools_id": middleware.PrincipalFrom(c).SchoolID,
 	})
 
 	if err != nil {
@@ -1090,7 +989,7 @@ func (h *Handlers) GetAllTeachersHandler(c *fiber.Ctx) error {
 			Name:     item.Name,
 			IDNumber: item.IdNumber,
 			Username: item.User.Username,
-			SchoolID: &findStundet.SchoolsID,
+			SchoolID: item.SchoolsID,
 		})
 
 	}
@@ -1101,3 +1000,18 @@ func (h *Handlers) GetAllTeachersHandler(c *fiber.Ctx) error {
 		Data:    response,
 	})
 }
+
+// hashUpdatedPassword hashes the password of an update, an empty password stays empty so the
+// repository keeps the current one.
+func hashUpdatedPassword(password string) ([]byte, error) {
+	if password == "" {
+		return nil, nil
+	}
+
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// validUserStatus reports whether status can be set on a user, an empty status keeps the current one.
+func validUserStatus(status string) bool {
+	return status == "" || status == model.USER_ACTIVE || status == model.USER_INACTIVE
+}
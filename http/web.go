@@ -50,6 +50,101 @@ type Login struct {
 	Password string `json:"password"`
 }
 
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ResetPasswordHTTP struct {
+	UserID            string `json:"user_id"`
+	Username          string `json:"username"`
+	TemporaryPassword string `json:"temporary_password"`
+}
+
+type UnlockLogin struct {
+	Username  string `json:"username"`
+	IPAddress string `json:"ip_address"`
+}
+
+// VerifyResponseHTTP is the user of a verified access token.
+type VerifyResponseHTTP struct {
+	UserID          string     `json:"user_id"`
+	Username        string     `json:"username"`
+	Name            string     `json:"name"`
+	Role            model.ROLE `json:"role"`
+	SchoolID        string     `json:"school_id,omitempty"`
+	TeacherID       string     `json:"teacher_id,omitempty"`
+	StudentID       string     `json:"student_id,omitempty"`
+	ActiveStudentID string     `json:"active_student_id,omitempty"`
+}
+
+// Invitation Request
+type Invitation struct {
+	SchoolID       string     `json:"school_id"`
+	Role           model.ROLE `json:"role"`
+	ExpiresInHours int        `json:"expires_in_hours"`
+}
+
+type AcceptInvitation struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	IdNumber int    `json:"id_number"`
+}
+
+type VerifyInvitation struct {
+	Token string `json:"token"`
+}
+
+type InvitationHTTP struct {
+	ID           string     `json:"id"`
+	SchoolID     string     `json:"school_id"`
+	SchoolName   string     `json:"school_name,omitempty"`
+	Role         model.ROLE `json:"role"`
+	Status       string     `json:"status,omitempty"`
+	Token        string     `json:"token,omitempty"`
+	Link         string     `json:"link,omitempty"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	UsedByUserID *string    `json:"used_by_user_id,omitempty"`
+	CreatedBy    string     `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Two-Factor Request
+type LoginTwoFactor struct {
+	TwoFactorToken string `json:"two_factor_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code"`
+}
+
+type DisableTwoFactor struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TwoFactorEnrollHTTP struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorStatusHTTP struct {
+	Enabled           bool       `json:"enabled"`
+	Required          bool       `json:"required"`
+	ConfirmedAt       *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesLeft int64      `json:"recovery_codes_left"`
+}
+
+type RefreshToken struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type Register struct {
 	Name     string     `json:"name"`
 	Username string     `json:"username"`
@@ -75,6 +170,7 @@ type ChapterHTTP struct {
 	CourseID  string         `json:"course_id"`
 	Title     string         `json:"title"`
 	Slug      string         `json:"slug"`
+	Position  int            `json:"position"`
 	Materials []MaterialHTTP `json:"materials,omitempty"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -85,6 +181,7 @@ type MaterialHTTP struct {
 	ChapterID  string    `json:"chapter_id"`
 	Title      string    `json:"title"`
 	Slug       string    `json:"slug"`
+	Position   int       `json:"position"`
 	IsLock     *bool     `json:"is_lock,omitempty"`
 	IsComplete *bool     `json:"is_complete,omitempty"`
 	Type       string    `json:"type"`
@@ -116,6 +213,7 @@ type Student struct {
 	SchoolsID string `json:"school_id"`
 	Username  string `json:"username"`
 	Password  string `json:"password"`
+	Status    string `json:"status"`
 }
 
 type Teacher struct {
@@ -125,6 +223,7 @@ type Teacher struct {
 	Username string  `json:"username"`
 	SchoolID *string `json:"school_id"`
 	Password string  `json:"password"`
+	Status   string  `json:"status"`
 }
 
 type TeacherHTTP struct {
@@ -161,30 +260,115 @@ type Certificate struct {
 
 // quizz
 type Quiz struct {
+	ID               string         `json:"id"`
+	Title            string         `json:"title"`
+	CourseID         string         `json:"course_id"`
+	ChapterID        string         `json:"chapter_id"`
+	Description      string         `json:"description"`
+	Type             *string        `json:"type,omitempty"`
+	MaxAttempts      *int           `json:"max_attempts,omitempty"`
+	ScoringPolicy    *string        `json:"scoring_policy,omitempty"`
+	TimeLimit        *int           `json:"time_limit,omitempty"`
+	OpenAt           *time.Time     `json:"open_at,omitempty"`
+	CloseAt          *time.Time     `json:"close_at,omitempty"`
+	LateSubmission   *string        `json:"late_submission,omitempty"`
+	ReviewPolicy     *string        `json:"review_policy,omitempty"`
+	ShuffleQuestions *bool          `json:"shuffle_questions,omitempty"`
+	ShuffleAnswers   *bool          `json:"shuffle_answers,omitempty"`
+	DrawRules        []QuizDrawRule `json:"draw_rules,omitempty"`
+	Quizes           []QuizData     `json:"quizzes"`
+}
+
+// QuizDrawRule draws count random questions of a question bank into every attempt,
+// topic and difficulty are optional filters.
+type QuizDrawRule struct {
+	ID             string `json:"id"`
+	QuestionBankID string `json:"question_bank_id"`
+	Topic          string `json:"topic,omitempty"`
+	Difficulty     string `json:"difficulty,omitempty"`
+	Count          int    `json:"count"`
+}
+
+type QuestionBank struct {
 	ID          string     `json:"id"`
-	Title       string     `json:"title"`
 	CourseID    string     `json:"course_id"`
-	ChapterID   string     `json:"chapter_id"`
+	ChapterID   *string    `json:"chapter_id,omitempty"`
+	Title       string     `json:"title"`
 	Description string     `json:"description"`
-	Type        *string    `json:"type,omitempty"`
-	Quizes      []QuizData `json:"quizzes"`
+	Questions   []QuizData `json:"questions,omitempty"`
 }
 
 type QuizData struct {
-	ID      string       `json:"id"`
-	Quiz    string       `json:"quiz"`
-	QuizID  string       `json:"quiz_id"`
-	ImgURL  *string      `json:"img_url,omitempty"`
-	Delete  *bool        `json:"delete,omitempty"`
-	Answers []QuizAnswer `json:"answers"`
+	ID         string       `json:"id"`
+	Quiz       string       `json:"quiz"`
+	QuizID     string       `json:"quiz_id"`
+	ImgURL     *string      `json:"img_url,omitempty"`
+	Type       *string      `json:"type,omitempty"`
+	Points     *int         `json:"points,omitempty"`
+	Topic      string       `json:"topic,omitempty"`
+	Difficulty string       `json:"difficulty,omitempty"`
+	Delete     *bool        `json:"delete,omitempty"`
+	Answers    []QuizAnswer `json:"answers"`
+}
+
+// QuizAnswer is an option, an accepted answer or an ordering item depending on the question type.
+// The items of an ORDERING question are sent in the correct order.
+type QuizAnswer struct {
+	ID        string  `json:"id"`
+	Answer    string  `json:"answer"`
+	QuizesID  string  `json:"quizes_id"`
+	Delete    *bool   `json:"delete,omitempty"`
+	IsCorrect bool    `json:"is_correct"`
+	Tolerance float64 `json:"tolerance,omitempty"`
 }
 
-type QuizAnswer struct {
-	ID        string `json:"id"`
-	Answer    string `json:"answer"`
-	QuizesID  string `json:"quizes_id"`
-	Delete    *bool  `json:"delete,omitempty"`
-	IsCorrect bool   `json:"is_correct"`
+// QuizImportErrorHTTP is a problem found in an imported quiz file, file is set for QTI packages.
+type QuizImportErrorHTTP struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// QuizScoreOverride sets the score of an attempt by hand, a null score removes the override.
+type QuizScoreOverride struct {
+	Score  *float64 `json:"score"`
+	Reason string   `json:"reason"`
+}
+
+// QuizRegrade corrects the answer key of questions of a quiz before its attempts are graded
+// again, the reason is required with corrections.
+type QuizRegrade struct {
+	Reason    string                    `json:"reason"`
+	Questions []QuizAnswerKeyCorrection `json:"questions"`
+}
+
+// QuizAnswerKeyCorrection changes the points of a question and whether its options are correct.
+// Fields that are not sent keep their value.
+type QuizAnswerKeyCorrection struct {
+	ID      string                 `json:"id"`
+	Points  *int                   `json:"points,omitempty"`
+	Answers []QuizAnswerCorrection `json:"answers"`
+}
+
+type QuizAnswerCorrection struct {
+	ID        string   `json:"id"`
+	IsCorrect bool     `json:"is_correct"`
+	Tolerance *float64 `json:"tolerance,omitempty"`
+}
+
+type QuizGradeAuditResponseHTTP struct {
+	ID              string    `json:"id"`
+	QuizID          string    `json:"quiz_id"`
+	QuizAttemptID   string    `json:"quiz_attempt_id"`
+	ActiveStudentID string    `json:"active_student_id"`
+	UserID          string    `json:"user_id"`
+	Action          string    `json:"action"`
+	OldScore        float64   `json:"old_score"`
+	NewScore        float64   `json:"new_score"`
+	OldGrades       int       `json:"old_grades"`
+	NewGrades       int       `json:"new_grades"`
+	Reason          string    `json:"reason,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 // End Quizz
@@ -195,6 +379,7 @@ type Admin struct {
 	SchoolID string `json:"school_id"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Status   string `json:"status"`
 }
 
 type ClassHTTP struct {
@@ -327,9 +512,14 @@ type StudentInfo struct {
 }
 
 // Request quiz answers student
+// Answer is the response to one question. SINGLE_CHOICE and TRUE_FALSE use quiz_answer_id,
+// MULTIPLE_CHOICE and ORDERING use quiz_answer_ids (in the student's order for ORDERING),
+// SHORT_ANSWER and NUMERIC use answer_text.
 type Answer struct {
-	QuizAnswerID string `json:"quiz_answer_id"`
-	QuizesID     string `json:"quizes_id"`
+	QuizAnswerID  string   `json:"quiz_answer_id"`
+	QuizAnswerIDs []string `json:"quiz_answer_ids"`
+	AnswerText    string   `json:"answer_text"`
+	QuizesID      string   `json:"quizes_id"`
 }
 type QuizAnswerStudent struct {
 	ID     string   `json:"id"`
@@ -338,42 +528,81 @@ type QuizAnswerStudent struct {
 
 // response
 
+// QuizAnswerStudentResponseHTTP is the result of one question of an attempt,
+// unanswered questions are included with answered false and 0 points.
 type QuizAnswerStudentResponseHTTP struct {
 	ID            string  `json:"id"`
 	QuizID        string  `json:"quiz_id"`
 	QuizesID      string  `json:"quizes_id"`
 	Quiz          string  `json:"quiz"`
+	Type          string  `json:"type"`
 	Answer        string  `json:"answer"`
+	Answered      bool    `json:"answered"`
+	IsCorrect     bool    `json:"is_correct"`
+	Points        float64 `json:"points"`
+	MaxPoints     int     `json:"max_points"`
 	AnswerCorrect *string `json:"answer_correct"`
 }
 
+type QuizAttemptResponseHTTP struct {
+	ID              string                          `json:"id"`
+	QuizID          string                          `json:"quiz_id"`
+	Score           float64                         `json:"score"`
+	Grades          int                             `json:"grades"`
+	TotalQuestions  int                             `json:"total_questions"`
+	TotalPoints     int                             `json:"total_points"`
+	StartedAt       time.Time                       `json:"started_at"`
+	DeadlineAt      *time.Time                      `json:"deadline_at"`
+	FinishedAt      *time.Time                      `json:"finished_at"`
+	IsLate          bool                            `json:"is_late"`
+	ScoreOverridden bool                            `json:"score_overridden"`
+	ReviewAvailable bool                            `json:"review_available"`
+	Questions       []QuizesResponseHTTP            `json:"questions,omitempty"`
+	Answers         []QuizAnswerStudentResponseHTTP `json:"answers,omitempty"`
+}
+
 // response get quiz detail
 
 type QuizResponseHTTP struct {
-	ID          string               `json:"id"`
-	Title       string               `json:"title"`
-	Description string               `json:"description"`
-	Grades      int                  `json:"grades"`
-	Score       int                  `json:"score"`
-	Quiz        []QuizesResponseHTTP `json:"quizes"`
-	Next        *NextMaterialHTTP    `json:"next"`
-	CreatedAt   *time.Time           `json:"created_at"`
-	UpdatedAt   *time.Time           `json:"updated_at"`
+	ID               string               `json:"id"`
+	Title            string               `json:"title"`
+	Description      string               `json:"description"`
+	Grades           int                  `json:"grades"`
+	Score            float64              `json:"score"`
+	MaxAttempts      int                  `json:"max_attempts"`
+	AttemptsUsed     int                  `json:"attempts_used"`
+	ScoringPolicy    string               `json:"scoring_policy"`
+	TimeLimit        int                  `json:"time_limit"`
+	OpenAt           *time.Time           `json:"open_at"`
+	CloseAt          *time.Time           `json:"close_at"`
+	ShuffleQuestions bool                 `json:"shuffle_questions"`
+	ReviewPolicy     string               `json:"review_policy"`
+	ReviewAvailable  bool                 `json:"review_available"`
+	ShuffleAnswers   bool                 `json:"shuffle_answers"`
+	DrawRules        []QuizDrawRule       `json:"draw_rules"`
+	Quiz             []QuizesResponseHTTP `json:"quizes"`
+	Next             *NextMaterialHTTP    `json:"next"`
+	CreatedAt        *time.Time           `json:"created_at"`
+	UpdatedAt        *time.Time           `json:"updated_at"`
 }
 
 type QuizesResponseHTTP struct {
 	ID            string                      `json:"id"`
 	Quiz          string                      `json:"quiz"`
+	Type          string                      `json:"type"`
+	Points        int                         `json:"points"`
 	ImgURL        *string                     `json:"img_url,omitempty"`
 	Answer        []QuizAnswerHTTP            `json:"answer"`
 	AnswerStudent []AnswerStuedntResponseHTTP `json:"answer_student"`
 	CreatedAt     *time.Time                  `json:"created_at"`
 	UpdatedAt     *time.Time                  `json:"updated_at"`
 }
+
+// QuizAnswerHTTP is an option of a question, is_correct is only sent when the answer key may be shown.
 type QuizAnswerHTTP struct {
 	ID        string     `json:"id"`
 	Answer    string     `json:"answer"`
-	IsCorrect bool       `json:"is_correct"`
+	IsCorrect *bool      `json:"is_correct,omitempty"`
 	CreatedAt *time.Time `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
 }
@@ -386,6 +615,47 @@ type AnswerStuedntResponseHTTP struct {
 	UpdatedAt *time.Time `json:"updated_at"`
 }
 
+// response quiz item analysis
+
+type QuizAnalysisResponseHTTP struct {
+	QuizID            string                             `json:"quiz_id"`
+	Class             string                             `json:"class,omitempty"`
+	SchoolYear        string                             `json:"school_year,omitempty"`
+	Attempts          int                                `json:"attempts"`
+	Students          int                                `json:"students"`
+	AverageGrades     float64                            `json:"average_grades"`
+	AverageSeconds    float64                            `json:"average_seconds"`
+	Questions         []QuizQuestionAnalysisResponseHTTP `json:"questions"`
+	ScoreDistribution []QuizScoreBucketResponseHTTP      `json:"score_distribution"`
+}
+
+type QuizQuestionAnalysisResponseHTTP struct {
+	ID             string                               `json:"id"`
+	Quiz           string                               `json:"quiz"`
+	Type           string                               `json:"type"`
+	Points         int                                  `json:"points"`
+	Given          int                                  `json:"given"`
+	Answered       int                                  `json:"answered"`
+	Correct        int                                  `json:"correct"`
+	PercentCorrect float64                              `json:"percent_correct"`
+	AverageScore   float64                              `json:"average_score"`
+	Discrimination float64                              `json:"discrimination"`
+	Distractors    []QuizDistractorAnalysisResponseHTTP `json:"distractors,omitempty"`
+}
+
+type QuizDistractorAnalysisResponseHTTP struct {
+	ID        string `json:"id"`
+	Answer    string `json:"answer"`
+	IsCorrect bool   `json:"is_correct"`
+	Selected  int    `json:"selected"`
+}
+
+type QuizScoreBucketResponseHTTP struct {
+	MinGrades int `json:"min_grades"`
+	MaxGrades int `json:"max_grades"`
+	Total     int `json:"total"`
+}
+
 // response student class
 type StudentClassHTTP struct {
 	Classes string `json:"classes"`
@@ -493,3 +763,157 @@ type TSubmissionPlaceholder struct {
 	Course       TCoursePlaceholder     `json:"course"`
 	ListMaterial []TMaterialPlaceholder `json:"list_material"`
 }
+
+type CourseTeacher struct {
+	TeacherID string `json:"teacher_id"`
+}
+
+type CourseTeacherHTTP struct {
+	ID        string    `json:"id"`
+	CourseID  string    `json:"course_id"`
+	TeacherID string    `json:"teacher_id"`
+	Name      string    `json:"name"`
+	IsOwner   bool      `json:"is_owner"`
+	GrantedBy string    `json:"granted_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CourseOrder is the drag-and-drop order of a course, every chapter with the IDs of its materials from top to bottom.
+type CourseOrder struct {
+	Chapters []ChapterOrder `json:"chapters"`
+}
+
+type ChapterOrder struct {
+	ID        string   `json:"id"`
+	Materials []string `json:"materials"`
+}
+
+type MaterialPrerequisites struct {
+	Prerequisites []MaterialPrerequisite `json:"prerequisites"`
+}
+
+// MaterialPrerequisite is a rule to unlock a material, MaterialID is the required material.
+// MinGrades is only used by QUIZ_SCORE.
+type MaterialPrerequisite struct {
+	Type       string `json:"type"`
+	MaterialID string `json:"material_id"`
+	MinGrades  int    `json:"min_grades"`
+}
+
+type MaterialPrerequisiteHTTP struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	MaterialID    string `json:"material_id"`
+	MaterialTitle string `json:"material_title"`
+	MaterialType  string `json:"material_type"`
+	MinGrades     int    `json:"min_grades,omitempty"`
+}
+
+type PublishCourse struct {
+	Note string `json:"note"`
+}
+
+type CourseVersionHTTP struct {
+	Version      int       `json:"version"`
+	Note         string    `json:"note"`
+	PublishedBy  string    `json:"published_by"`
+	PublishedAt  time.Time `json:"published_at"`
+	IsPublished  bool      `json:"is_published"`
+	TotalStudent int       `json:"total_student"`
+}
+
+type CourseVersionsResponse struct {
+	DraftVersion     int                 `json:"draft_version"`
+	PublishedVersion int                 `json:"published_version"`
+	Versions         []CourseVersionHTTP `json:"versions"`
+}
+
+type CourseChangeHTTP struct {
+	Resource  string   `json:"resource"`
+	LineageID string   `json:"lineage_id"`
+	Title     string   `json:"title"`
+	Change    string   `json:"change"`
+	Fields    []string `json:"fields,omitempty"`
+}
+
+type CourseDiffResponse struct {
+	From    int                `json:"from"`
+	To      int                `json:"to"`
+	Changes []CourseChangeHTTP `json:"changes"`
+}
+
+type MigrateCourseEnrollments struct {
+	ActiveStudentIDs []string `json:"active_student_ids"`
+}
+
+type MigrateCourseEnrollmentsResponse struct {
+	Version  int `json:"version"`
+	Migrated int `json:"migrated"`
+}
+
+type CloneCourse struct {
+	Title   string   `json:"title"`
+	Classes []string `json:"classes"`
+}
+
+type CourseTemplate struct {
+	CourseID    string `json:"course_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type CourseTemplateHTTP struct {
+	ID               string    `json:"id"`
+	CourseID         string    `json:"course_id"`
+	Title            string    `json:"title"`
+	Description      string    `json:"description"`
+	ThumbnailImg     string    `json:"thumbnail_img"`
+	EstimationHour   string    `json:"estimation_hour"`
+	EstimationMinute string    `json:"estimation_minute"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type ScormMaterialHTTP struct {
+	ID           string            `json:"id"`
+	ChapterID    string            `json:"chapter_id"`
+	Title        string            `json:"title"`
+	Slug         string            `json:"slug"`
+	Type         *string           `json:"type,omitempty"`
+	PackageTitle string            `json:"package_title"`
+	LaunchURL    string            `json:"launch_url"`
+	MasteryScore *float64          `json:"mastery_score"`
+	Files        int               `json:"files"`
+	Size         int64             `json:"size"`
+	LessonStatus string            `json:"lesson_status,omitempty"`
+	Grade        *int              `json:"grade,omitempty"`
+	Next         *NextMaterialHTTP `json:"next"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// ScormRuntime is a batch of LMSSetValue calls sent on LMSCommit, Finish is set for LMSFinish.
+type ScormRuntime struct {
+	Values map[string]string `json:"values"`
+	Finish bool              `json:"finish"`
+}
+
+type ScormRuntimeResponse struct {
+	Values       map[string]string `json:"values"`
+	Errors       map[string]string `json:"errors"`
+	LessonStatus string            `json:"lesson_status"`
+	Completed    bool              `json:"completed"`
+}
+
+type ScormRegistrationHTTP struct {
+	ID              string     `json:"id"`
+	MaterialID      string     `json:"material_id"`
+	ActiveStudentID string     `json:"active_student_id"`
+	Name            string     `json:"name"`
+	Class           string     `json:"class"`
+	LessonStatus    string     `json:"lesson_status"`
+	Grade           *int       `json:"grade"`
+	TotalTime       string     `json:"total_time"`
+	Sessions        int        `json:"sessions"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
@@ -25,6 +25,7 @@ func main() {
 	app.Commands = []cli.Command{
 		cmd.HTTPGatewayServerCMD(),
 		cmd.DoMigrateUpCMD(),
+		cmd.CoursePackageCMD(),
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -2,34 +2,115 @@ package middleware
 
 import (
 	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/repository"
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Middleware struct {
-	JwtSecret []byte
+	// Keys verifies the access tokens, the handlers sign them with it too.
+	Keys *KeySet
+	// Sessions checks the "sid" claim of access tokens, tokens without a session are rejected.
+	Sessions     SessionChecker
+	SessionCache *SessionCache
+	// Permissions is checked for every protected route after the token is verified.
+	Permissions Permissions
+	// LoginGuard throttles failed logins, it is used by the login handler.
+	LoginGuard *LoginGuard
+	// PasswordChangeRoutes are the only routes ("METHOD /path") a user who must change
+	// its password can call.
+	PasswordChangeRoutes map[string]bool
+	// TwoFactorEnrollRoutes are the only routes a user of a role requiring 2FA can call
+	// until it enabled TOTP.
+	TwoFactorEnrollRoutes map[string]bool
 }
 
 func (m *Middleware) Protected() func(*fiber.Ctx) error {
 	return jwtware.New(jwtware.Config{
-		SigningKey: jwtware.SigningKey{Key: m.JwtSecret},
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			return c.Status(401).JSON(&http.WebResponse{
-				Status:  "error",
-				Message: "Couldn't access resource because unauthorized request!",
-				Data:    nil,
-			})
-		},
+		KeyFunc:      m.Keys.Keyfunc,
+		ErrorHandler: unauthorized,
 		SuccessHandler: func(c *fiber.Ctx) error {
-			user := c.Locals("user").(*jwt.Token)
-			claims := user.Claims.(jwt.MapClaims)
-			c.Locals("name", claims["name"])
-			c.Locals("username", claims["username"])
-			c.Locals("user_id", claims["user_id"])
-			c.Locals("role", claims["role"])
+			user, _ := c.Locals("user").(*jwt.Token)
+			if user == nil {
+				return unauthorized(c, nil)
+			}
+
+			claims, _ := user.Claims.(jwt.MapClaims)
+			principal, err := principalFromClaims(claims)
+			if err != nil {
+				return unauthorized(c, err)
+			}
+
+			if !m.sessionActive(principal.SessionID, principal.UserID) {
+				return unauthorized(c, nil)
+			}
+
+			// Sekolah pemilik token membatasi query repository, hanya SUPER_ADMIN yang lintas sekolah
+			ctx := WithPrincipal(c.UserContext(), principal)
+			c.SetUserContext(repository.WithTenant(ctx, repository.Tenant{
+				SchoolID:   principal.SchoolID,
+				AllSchools: principal.IsSuperAdmin(),
+			}))
+
+			route := c.Route().Method + " " + c.Route().Path
+			if mustChange, _ := claims["must_change_password"].(bool); mustChange && !m.PasswordChangeRoutes[route] {
+				return passwordChangeRequired(c)
+			}
+
+			if mustEnroll, _ := claims["must_enroll_two_factor"].(bool); mustEnroll && !m.TwoFactorEnrollRoutes[route] {
+				return twoFactorEnrollRequired(c)
+			}
+
+			if !m.authorize(c) {
+				return forbidden(c)
+			}
 
 			return c.Next()
 		},
 	})
 }
+
+// sessionActive reports whether the session is still valid, using the local cache first.
+func (m *Middleware) sessionActive(sessionID, userID string) bool {
+	if m.Sessions == nil {
+		return true
+	}
+
+	if active, ok := m.SessionCache.get(sessionID, userID); ok {
+		return active
+	}
+
+	active, err := m.Sessions.IsSessionActive(sessionID, userID)
+	if err != nil {
+		// Hasil error tidak di-cache supaya request berikutnya mencoba lagi
+		return false
+	}
+
+	m.SessionCache.set(sessionID, userID, active)
+	return active
+}
+
+func unauthorized(c *fiber.Ctx, err error) error {
+	return c.Status(401).JSON(&http.WebResponse{
+		Status:  "error",
+		Message: "Couldn't access resource because unauthorized request!",
+		Data:    nil,
+	})
+}
+
+func passwordChangeRequired(c *fiber.Ctx) error {
+	return c.Status(401).JSON(&http.WebResponse{
+		Status:  "error",
+		Message: "Password must be changed before continuing",
+		Data:    nil,
+	})
+}
+
+func twoFactorEnrollRequired(c *fiber.Ctx) error {
+	return c.Status(401).JSON(&http.WebResponse{
+		Status:  "error",
+		Message: "Two-factor authentication must be enabled before continuing",
+		Data:    nil,
+	})
+}
@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey = errors.New("[jwt] There is no active signing key")
+	ErrUnknownKey   = errors.New("[jwt] Token is signed with an unknown key")
+)
+
+// SigningKey is an RS256 or EdDSA key of a KeySet. A key loaded from a public key file can only
+// verify tokens, e.g. a retired key whose tokens are still valid.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	// SignFrom schedules the rotation, the latest key whose SignFrom has passed signs the tokens,
+	// the later key of the list when they are equal.
+	SignFrom time.Time
+}
+
+// KeySet signs and verifies the access tokens. Every key verifies the tokens with its ID in the
+// "kid" header and is published in the JWKS, so other services can verify tokens without a secret.
+// Secret is the legacy HS256 key, it only signs when there is no active key and it is never published.
+type KeySet struct {
+	Keys   []*SigningKey
+	Secret []byte
+	// LegacyUntil keeps HS256 tokens valid after a key started signing, until the tokens signed
+	// with the secret expired. Once a key signs, HS256 tokens are rejected when it is zero.
+	LegacyUntil time.Time
+}
+
+// LoadKeySet reads the keys of spec, a comma separated list of "kid=path" with an optional
+// "@RFC3339" time the key starts signing, e.g.
+//
+//	2026-09=/run/keys/2026-09.pem,2026-10=/run/keys/2026-10.pem@2026-10-01T00:00:00Z
+//
+// The files are PEM encoded PKCS#8 RSA or Ed25519 private keys, or PKIX public keys.
+func LoadKeySet(spec string, secret []byte) (*KeySet, error) {
+	keySet := &KeySet{Secret: secret}
+	seen := make(map[string]bool)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, path, ok := strings.Cut(entry, "=")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("[jwt] Invalid key %q, expected kid=path[@time]", entry)
+		}
+
+		if seen[kid] {
+			return nil, fmt.Errorf("[jwt] Duplicate key id %q", kid)
+		}
+		seen[kid] = true
+
+		var signFrom time.Time
+		if i := strings.LastIndex(path, "@"); i >= 0 {
+			t, err := time.Parse(time.RFC3339, path[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("[jwt] Invalid sign time of key %q: %w", kid, err)
+			}
+			path, signFrom = path[:i], t
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("[jwt] Couldn't read key %q: %w", kid, err)
+		}
+
+		key, err := ParseSigningKey(kid, data)
+		if err != nil {
+			return nil, err
+		}
+
+		key.SignFrom = signFrom
+		keySet.Keys = append(keySet.Keys, key)
+	}
+
+	if len(keySet.Keys) == 0 && len(secret) == 0 {
+		return nil, ErrNoSigningKey
+	}
+
+	return keySet, nil
+}
+
+// ParseSigningKey parses a PEM encoded PKCS#8 private key or PKIX public key.
+func ParseSigningKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("[jwt] Key %q is not PEM encoded", kid)
+	}
+
+	key := &SigningKey{ID: kid}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("[jwt] Couldn't parse key %q: %w", kid, err)
+		}
+
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("[jwt] Key %q is not a signing key", kid)
+		}
+
+		key.PrivateKey = signer
+		key.PublicKey = signer.Public()
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("[jwt] Couldn't parse key %q: %w", kid, err)
+		}
+
+		key.PublicKey = parsed
+	default:
+		return nil, fmt.Errorf("[jwt] Key %q has unsupported PEM type %q", kid, block.Type)
+	}
+
+	switch public := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("[jwt] RSA key %q must have at least 2048 bits", kid)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("[jwt] Key %q must be an RSA or Ed25519 key", kid)
+	}
+
+	return key, nil
+}
+
+// current returns the key signing at now, nil when the legacy secret signs.
+func (ks *KeySet) current(now time.Time) *SigningKey {
+	var current *SigningKey
+
+	for _, key := range ks.Keys {
+		if key.PrivateKey == nil || key.SignFrom.After(now) {
+			continue
+		}
+
+		if current == nil || !key.SignFrom.Before(current.SignFrom) {
+			current = key
+		}
+	}
+
+	return current
+}
+
+// Sign signs claims with the current key and puts its ID in the "kid" header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := ks.current(time.Now())
+
+	if key == nil {
+		if len(ks.Secret) == 0 {
+			return "", ErrNoSigningKey
+		}
+
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.Secret)
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.PrivateKey)
+}
+
+// Keyfunc returns the key verifying token. The algorithm of the token must match the key,
+// a token can't pick HS256 to be verified with a public key.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	if kid == "" {
+		if token.Method != jwt.SigningMethodHS256 || !ks.acceptsLegacy(time.Now()) {
+			return nil, ErrUnknownKey
+		}
+
+		return ks.Secret, nil
+	}
+
+	for _, key := range ks.Keys {
+		if key.ID != kid {
+			continue
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, ErrUnknownKey
+		}
+
+		return key.PublicKey, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// acceptsLegacy reports whether HS256 tokens are valid at now: while the secret signs, and
+// after a key took over only until LegacyUntil.
+func (ks *KeySet) acceptsLegacy(now time.Time) bool {
+	if len(ks.Secret) == 0 {
+		return false
+	}
+
+	return ks.current(now) == nil || now.Before(ks.LegacyUntil)
+}
+
+// JWK is a public key of the JWKS, see RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the key set, including the keys scheduled to sign later
+// so verifiers already know them when the rotation happens.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range ks.Keys {
+		jwk := JWK{
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+		}
+
+		switch public := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+)
+
+// MemoryLoginAttempts is a LoginAttemptStore for a single instance, the failures are lost
+// on restart and are not shared with other instances.
+type MemoryLoginAttempts struct {
+	mu       sync.Mutex
+	attempts map[string]model.LoginAttempt
+}
+
+func NewMemoryLoginAttempts() *MemoryLoginAttempts {
+	return &MemoryLoginAttempts{
+		attempts: make(map[string]model.LoginAttempt),
+	}
+}
+
+// FindLoginAttempt implements LoginAttemptStore.
+func (m *MemoryLoginAttempts) FindLoginAttempt(key string) (*model.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempt, ok := m.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return &attempt, nil
+}
+
+// RecordLoginFailure implements LoginAttemptStore.
+func (m *MemoryLoginAttempts) RecordLoginFailure(key string, now time.Time, window time.Duration) (*model.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Membersihkan percobaan lama yang tidak terkunci agar map tidak terus membesar
+	if len(m.attempts) >= 10000 {
+		for k, attempt := range m.attempts {
+			if now.Sub(attempt.LastFailedAt) >= window && (attempt.LockedUntil == nil || attempt.LockedUntil.Before(now)) {
+				delete(m.attempts, k)
+			}
+		}
+	}
+
+	attempt, ok := m.attempts[key]
+	if !ok {
+		attempt = model.LoginAttempt{Key: key, CreatedAt: now}
+	}
+
+	if now.Sub(attempt.LastFailedAt) >= window {
+		attempt.Failures = 0
+	}
+
+	attempt.Failures++
+	attempt.LastFailedAt = now
+	attempt.UpdatedAt = now
+	m.attempts[key] = attempt
+
+	return &attempt, nil
+}
+
+// LockLogin implements LoginAttemptStore.
+func (m *MemoryLoginAttempts) LockLogin(key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempt, ok := m.attempts[key]
+	if !ok {
+		attempt = model.LoginAttempt{Key: key, CreatedAt: time.Now()}
+	}
+
+	attempt.LockedUntil = &until
+	attempt.UpdatedAt = time.Now()
+	m.attempts[key] = attempt
+
+	return nil
+}
+
+// ResetLoginAttempts implements LoginAttemptStore.
+func (m *MemoryLoginAttempts) ResetLoginAttempts(key string) error {
+	m.mu.Lock()
+	delete(m.attempts, key)
+	m.mu.Unlock()
+
+	return nil
+}
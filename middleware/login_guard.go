@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/sirupsen/logrus"
+)
+
+// LoginAttemptStore keeps the failed logins counted by LoginGuard. MemoryLoginAttempts is
+// enough for a single instance, repository.UserRepository shares them through Postgres.
+type LoginAttemptStore interface {
+	// FindLoginAttempt returns nil when the key has no failed login.
+	FindLoginAttempt(key string) (*model.LoginAttempt, error)
+	// RecordLoginFailure adds a failure to the key, counting from zero again when the
+	// last failure is older than window.
+	RecordLoginFailure(key string, now time.Time, window time.Duration) (*model.LoginAttempt, error)
+	LockLogin(key string, until time.Time) error
+	ResetLoginAttempts(key string) error
+}
+
+// LoginGuard slows down and locks out repeated failed logins of a username and of an IP address.
+// Every failure of a username doubles the wait before its next login, starting at BaseDelay up to
+// MaxDelay. A username or an IP address reaching its maximum failures is locked for LockoutDuration.
+// IP addresses only get locked out without backoff, students of a school often share one.
+type LoginGuard struct {
+	Store               LoginAttemptStore
+	MaxUsernameFailures int
+	MaxIPFailures       int
+	BaseDelay           time.Duration
+	MaxDelay            time.Duration
+	LockoutDuration     time.Duration
+	FailureWindow       time.Duration
+}
+
+func NewLoginGuard(store LoginAttemptStore) *LoginGuard {
+	return &LoginGuard{
+		Store:               store,
+		MaxUsernameFailures: 5,
+		MaxIPFailures:       50,
+		BaseDelay:           time.Second,
+		MaxDelay:            30 * time.Second,
+		LockoutDuration:     15 * time.Minute,
+		FailureWindow:       15 * time.Minute,
+	}
+}
+
+type loginLimit struct {
+	name        string
+	key         string
+	maxFailures int
+	backoff     bool
+}
+
+func (g *LoginGuard) limits(username, ip string) []loginLimit {
+	var limits []loginLimit
+
+	if username = strings.ToLower(strings.TrimSpace(username)); username != "" {
+		limits = append(limits, loginLimit{
+			name:        "username",
+			key:         "username:" + username,
+			maxFailures: g.MaxUsernameFailures,
+			backoff:     true,
+		})
+	}
+
+	if ip != "" {
+		limits = append(limits, loginLimit{
+			name:        "ip",
+			key:         "ip:" + ip,
+			maxFailures: g.MaxIPFailures,
+		})
+	}
+
+	return limits
+}
+
+// delay is the wait after the given number of failures of a username.
+func (g *LoginGuard) delay(failures int) time.Duration {
+	delay := g.BaseDelay
+	for i := 1; i < failures && delay < g.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > g.MaxDelay {
+		return g.MaxDelay
+	}
+
+	return delay
+}
+
+// Check returns how long the username and the IP address have to wait before they may try
+// to log in again, zero when they may log in now.
+func (g *LoginGuard) Check(username, ip string) (time.Duration, error) {
+	if g == nil || g.Store == nil {
+		return 0, nil
+	}
+
+	now := time.Now()
+	var wait time.Duration
+
+	for _, limit := range g.limits(username, ip) {
+		attempt, err := g.Store.FindLoginAttempt(limit.key)
+		if err != nil {
+			return 0, err
+		}
+
+		if attempt == nil {
+			continue
+		}
+
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) && attempt.LockedUntil.Sub(now) > wait {
+			wait = attempt.LockedUntil.Sub(now)
+		}
+
+		if limit.backoff && attempt.Failures > 0 && now.Sub(attempt.LastFailedAt) < g.FailureWindow {
+			next := attempt.LastFailedAt.Add(g.delay(attempt.Failures))
+			if next.After(now) && next.Sub(now) > wait {
+				wait = next.Sub(now)
+			}
+		}
+	}
+
+	if wait > 0 {
+		logrus.WithFields(logrus.Fields{
+			"event":       "login_throttled",
+			"username":    username,
+			"ip":          ip,
+			"retry_after": wait.String(),
+		}).Warnln("[auth] Login rejected because of too many failed attempts")
+	}
+
+	return wait, nil
+}
+
+// Fail records a failed login of the username from the IP address and locks the ones
+// reaching their maximum failures.
+func (g *LoginGuard) Fail(username, ip, reason string) {
+	if g == nil || g.Store == nil {
+		return
+	}
+
+	now := time.Now()
+	fields := logrus.Fields{
+		"event":    "login_failed",
+		"username": username,
+		"ip":       ip,
+		"reason":   reason,
+	}
+
+	for _, limit := range g.limits(username, ip) {
+		attempt, err := g.Store.RecordLoginFailure(limit.key, now, g.FailureWindow)
+		if err != nil {
+			logrus.Warnln("[auth] Couldn't record failed login because error:", err)
+			continue
+		}
+
+		fields[limit.name+"_failures"] = attempt.Failures
+
+		if attempt.Failures < limit.maxFailures || (attempt.LockedUntil != nil && attempt.LockedUntil.After(now)) {
+			continue
+		}
+
+		until := now.Add(g.LockoutDuration)
+		if err := g.Store.LockLogin(limit.key, until); err != nil {
+			logrus.Warnln("[auth] Couldn't lock login because error:", err)
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"event":        "login_locked",
+			"key":          limit.key,
+			"failures":     attempt.Failures,
+			"locked_until": until,
+		}).Warnln("[auth] Login locked because of too many failed attempts")
+	}
+
+	logrus.WithFields(fields).Warnln("[auth] Login failed")
+}
+
+// Succeed clears the failed logins of the username. Failures of the IP address are kept,
+// one valid account must not reset the count of an IP guessing other passwords.
+func (g *LoginGuard) Succeed(username string) {
+	if g == nil || g.Store == nil {
+		return
+	}
+
+	for _, limit := range g.limits(username, "") {
+		if err := g.Store.ResetLoginAttempts(limit.key); err != nil {
+			logrus.Warnln("[auth] Couldn't reset failed logins because error:", err)
+		}
+	}
+}
+
+// Unlock clears the failed logins and the lockout of the username and the IP address,
+// either of them may be empty.
+func (g *LoginGuard) Unlock(username, ip string) error {
+	if g == nil || g.Store == nil {
+		return nil
+	}
+
+	for _, limit := range g.limits(username, ip) {
+		if err := g.Store.ResetLoginAttempts(limit.key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
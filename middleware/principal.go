@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var errInvalidClaims = errors.New("[jwt] Token is missing required claims")
+
+// Principal is the user of an authenticated request, built once by Protected from the claims of
+// the access token. The teacher, student and active student of the user are resolved when the
+// token is issued, so handlers don't have to look them up again.
+type Principal struct {
+	UserID    string
+	Username  string
+	Name      string
+	Role      model.ROLE
+	SchoolID  string
+	SessionID string
+	// TeacherID is set for TEACHER.
+	TeacherID string
+	// StudentID and ActiveStudentID are set for STUDENT.
+	StudentID       string
+	ActiveStudentID string
+}
+
+// IsSuperAdmin reports whether the principal can access every school.
+func (p *Principal) IsSuperAdmin() bool {
+	return p.Role == model.SUPER_ADMIN
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of ctx, ok is false when the request is not authenticated.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// PrincipalFrom returns the principal of a request handled after Protected. Public routes get an
+// empty principal, which has no role.
+func PrincipalFrom(c *fiber.Ctx) *Principal {
+	if principal, ok := PrincipalFromContext(c.UserContext()); ok {
+		return principal
+	}
+
+	return &Principal{}
+}
+
+// principalFromClaims builds the principal of an access token. Tokens without a user, a known
+// role, a session, or the teacher or student of their role are rejected.
+func principalFromClaims(claims jwt.MapClaims) (*Principal, error) {
+	str := func(key string) string {
+		value, _ := claims[key].(string)
+		return value
+	}
+
+	principal := &Principal{
+		UserID:          str("user_id"),
+		Username:        str("username"),
+		Name:            str("name"),
+		Role:            model.ROLE(str("role")),
+		SchoolID:        str("school_id"),
+		SessionID:       str("sid"),
+		TeacherID:       str("teacher_id"),
+		StudentID:       str("student_id"),
+		ActiveStudentID: str("active_student_id"),
+	}
+
+	if principal.UserID == "" || principal.SessionID == "" {
+		return nil, errInvalidClaims
+	}
+
+	switch principal.Role {
+	case model.SUPER_ADMIN, model.ADMIN:
+	case model.TEACHER:
+		if principal.TeacherID == "" {
+			return nil, errInvalidClaims
+		}
+	case model.STUDENT:
+		if principal.StudentID == "" || principal.ActiveStudentID == "" {
+			return nil, errInvalidClaims
+		}
+	default:
+		return nil, errInvalidClaims
+	}
+
+	return principal, nil
+}
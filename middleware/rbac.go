@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cvzamannow/E-Learning-API/http"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Permissions is a permission matrix, it maps "METHOD /path" of a route to the roles allowed
+// to call it. Public routes are listed without roles and must not use Protected.
+type Permissions map[string][]model.ROLE
+
+// Roles returns the roles allowed on a route and whether the route is in the matrix.
+func (p Permissions) Roles(method, path string) ([]model.ROLE, bool) {
+	// Fiber mendaftarkan route HEAD untuk setiap route GET
+	if method == fiber.MethodHead {
+		method = fiber.MethodGet
+	}
+
+	roles, ok := p[method+" "+path]
+	return roles, ok
+}
+
+// Check returns an error listing every route that is not in the matrix.
+func (p Permissions) Check(routes []fiber.Route) error {
+	var missing []string
+
+	for _, route := range routes {
+		if _, ok := p.Roles(route.Method, route.Path); !ok {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	return fmt.Errorf("routes without permission: %s", strings.Join(missing, ", "))
+}
+
+// authorize checks the role of the request against the route in Permissions.
+// Routes missing from the matrix are rejected.
+func (m *Middleware) authorize(c *fiber.Ctx) bool {
+	if m.Permissions == nil {
+		return true
+	}
+
+	roles, ok := m.Permissions.Roles(c.Route().Method, c.Route().Path)
+	return ok && hasRole(c, roles)
+}
+
+func hasRole(c *fiber.Ctx, roles []model.ROLE) bool {
+	role := PrincipalFrom(c).Role
+
+	for _, allowed := range roles {
+		if role == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func forbidden(c *fiber.Ctx) error {
+	return c.Status(401).JSON(&http.WebResponse{
+		Status:  "error",
+		Message: "You are not authorized to perform this action",
+		Data:    nil,
+	})
+}
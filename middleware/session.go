@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// SessionChecker reports whether the session of an access token can still be used.
+// It is implemented by repository.UserRepository.
+type SessionChecker interface {
+	IsSessionActive(sessionID, userID string) (bool, error)
+}
+
+type sessionCacheEntry struct {
+	userID    string
+	active    bool
+	expiresAt time.Time
+}
+
+// SessionCache keeps the result of SessionChecker for a short time so Protected does not
+// query the database on every request. Entries of a user are dropped as soon as the user
+// is deleted or its status changes, other instances catch up once the TTL has passed.
+type SessionCache struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]sessionCacheEntry
+}
+
+func NewSessionCache(ttl time.Duration) *SessionCache {
+	return &SessionCache{
+		ttl:     ttl,
+		entries: make(map[string]sessionCacheEntry),
+	}
+}
+
+func (s *SessionCache) get(sessionID, userID string) (active bool, ok bool) {
+	if s == nil {
+		return false, false
+	}
+
+	s.mu.RLock()
+	entry, ok := s.entries[sessionID]
+	s.mu.RUnlock()
+
+	if !ok || entry.userID != userID || time.Now().After(entry.expiresAt) {
+		return false, false
+	}
+
+	return entry.active, true
+}
+
+func (s *SessionCache) set(sessionID, userID string, active bool) {
+	if s == nil {
+		return
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Membersihkan entry yang sudah kedaluwarsa agar map tidak terus membesar
+	if len(s.entries) >= 10000 {
+		for id, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, id)
+			}
+		}
+	}
+
+	s.entries[sessionID] = sessionCacheEntry{
+		userID:    userID,
+		active:    active,
+		expiresAt: now.Add(s.ttl),
+	}
+}
+
+// InvalidateSession drops the cached result of a session, e.g. after logout.
+func (s *SessionCache) InvalidateSession(sessionID string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.entries, sessionID)
+	s.mu.Unlock()
+}
+
+// InvalidateUser drops the cached results of every session of a user.
+func (s *SessionCache) InvalidateUser(userID string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	for id, entry := range s.entries {
+		if entry.userID == userID {
+			delete(s.entries, id)
+		}
+	}
+	s.mu.Unlock()
+}
@@ -19,6 +19,20 @@ const (
 	PENDING STATUS = "PENDNG"
 )
 
+type PREREQUISITE_TYPE string
+
+const (
+	// PREREQUISITE_COMPLETED unlocks after the student completed the required material.
+	PREREQUISITE_COMPLETED PREREQUISITE_TYPE = "COMPLETED"
+	// PREREQUISITE_QUIZ_SCORE unlocks after the recorded grades of the required quiz reach MinGrades.
+	PREREQUISITE_QUIZ_SCORE PREREQUISITE_TYPE = "QUIZ_SCORE"
+	// PREREQUISITE_SUBMISSION_APPROVED unlocks after a teacher approved the submission of the student.
+	PREREQUISITE_SUBMISSION_APPROVED PREREQUISITE_TYPE = "SUBMISSION_APPROVED"
+)
+
+// Course content (chapters, materials, theory, submissions and quizzes) is versioned. Teachers
+// edit the rows of DraftVersion and publishing freezes them as PublishedVersion. Courses created
+// before versioning have both versions 0 and are edited live until they are published once.
 type Course struct {
 	ID               string `gorm:"primaryKey"`
 	TeacherID        string
@@ -29,6 +43,9 @@ type Course struct {
 	EstimationMinute string
 	Slug             string
 	IsDraft          bool
+	DraftVersion     int `gorm:"default:0"`
+	PublishedVersion int `gorm:"default:0"`
+	PublishedAt      *time.Time
 	ThumbnailImg     string
 	CompleteCourses  []CompleteCourse `gorm:"foreignKey:CourseID"`
 	CourseClasses    []CourseClass    `gorm:"foreignKey:CourseID"`
@@ -38,31 +55,61 @@ type Course struct {
 	DeletedAt        gorm.DeletedAt `gorm:"index"`
 }
 
+// Chapter belongs to one Version of its course. LineageID is the ID of the chapter it was
+// first created as, it is kept by the copies of the chapter in later versions.
 type Chapter struct {
 	ID        string `gorm:"primaryKey"`
 	CourseID  string
+	Version   int    `gorm:"default:0;index"`
+	LineageID string `gorm:"index"`
 	Title     string
 	Slug      string
 	IsDraft   bool
+	Position  int        `gorm:"default:0"`
 	Materials []Material `gorm:"foreignKey:ChapterID"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
+// Material is ordered by Position in its chapter, materials with the same Position by CreatedAt.
+// Version and LineageID follow its chapter. IsLocked is only set by FindCourse for the student POV.
 type Material struct {
-	ID         string `gorm:"primaryKey"            json:"id"`
-	ChapterID  string `                             json:"chapter_id"`
-	CourseID   string
-	Title      string                `                             json:"title"`
-	Type       string                `                             json:"type"`
-	Slug       string                `                             json:"slug"`
-	Theory     Theory                `gorm:"foreignKey:MaterialID" json:"theory"`
-	Submission Submission            `gorm:"foreignKey:MaterialID" json:"submission"`
-	Progress   []ActiveStudentCourse `gorm:"foreignKey:MaterialID"`
-	CreatedAt  time.Time             `                             json:"created_at"`
-	UpdatedAt  time.Time             `                             json:"updated_at"`
-	DeletedAt  gorm.DeletedAt        `gorm:"index"                 json:"deleted_at"`
+	ID            string `gorm:"primaryKey"            json:"id"`
+	ChapterID     string `                             json:"chapter_id"`
+	CourseID      string
+	Version       int                    `gorm:"default:0;index"       json:"version"`
+	LineageID     string                 `gorm:"index"                 json:"lineage_id"`
+	Title         string                 `                             json:"title"`
+	Type          string                 `                             json:"type"`
+	Slug          string                 `                             json:"slug"`
+	Position      int                    `gorm:"default:0"             json:"position"`
+	Theory        Theory                 `gorm:"foreignKey:MaterialID" json:"theory"`
+	Submission    Submission             `gorm:"foreignKey:MaterialID" json:"submission"`
+	Scorm         ScormPackage           `gorm:"foreignKey:MaterialID" json:"scorm"`
+	Progress      []ActiveStudentCourse  `gorm:"foreignKey:MaterialID"`
+	Prerequisites []MaterialPrerequisite `gorm:"foreignKey:MaterialID" json:"prerequisites"`
+	IsLocked      bool                   `gorm:"-"                     json:"-"`
+	CreatedAt     time.Time              `                             json:"created_at"`
+	UpdatedAt     time.Time              `                             json:"updated_at"`
+	DeletedAt     gorm.DeletedAt         `gorm:"index"                 json:"deleted_at"`
+}
+
+// MaterialPrerequisite is a rule the student must meet before the material unlocks.
+// A material with prerequisites unlocks when all of them are met, instead of after the
+// material before it. RequiredMaterialID is the material the rule checks, a QUIZ material
+// for PREREQUISITE_QUIZ_SCORE and a SUBMISSION material for PREREQUISITE_SUBMISSION_APPROVED.
+type MaterialPrerequisite struct {
+	ID                 string            `gorm:"primaryKey"`
+	MaterialID         string            `gorm:"index"`
+	Material           Material          `gorm:"foreignKey:MaterialID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Type               PREREQUISITE_TYPE `gorm:"type:varchar(30)"`
+	RequiredMaterialID string            `gorm:"index"`
+	RequiredMaterial   Material          `gorm:"foreignKey:RequiredMaterialID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	MinGrades          int
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeletedAt          gorm.DeletedAt `gorm:"index"`
 }
 
 type Theory struct {
@@ -83,6 +130,118 @@ type Submission struct {
 	DeletedAt  gorm.DeletedAt `gorm:"index"`
 }
 
+// ScormPackage is the SCORM 1.2 package of a SCORM material. The files of the package are
+// extracted to storage under BaseURL, LaunchURL is the SCO students open. Copies of the material
+// in other versions share the files of the package.
+type ScormPackage struct {
+	ID           string `gorm:"primaryKey"`
+	MaterialID   string `gorm:"index"`
+	Identifier   string // identifier manifest
+	Title        string
+	LaunchPath   string
+	LaunchURL    string
+	BaseURL      string
+	MasteryScore *float64
+	LaunchData   string // adlcp:datafromlms
+	SHA256       string // hash zip package
+	Size         int64
+	Files        int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+type SCORM_STATUS string
+
+const (
+	SCORM_PASSED        SCORM_STATUS = "passed"
+	SCORM_COMPLETED     SCORM_STATUS = "completed"
+	SCORM_FAILED        SCORM_STATUS = "failed"
+	SCORM_INCOMPLETE    SCORM_STATUS = "incomplete"
+	SCORM_BROWSED       SCORM_STATUS = "browsed"
+	SCORM_NOT_ATTEMPTED SCORM_STATUS = "not attempted"
+)
+
+// ScormRegistration is the SCORM runtime data of a student for a SCORM material. The cmi.core
+// elements have their own columns, the other elements set by the SCO (objectives, interactions
+// and preferences) are kept in Data by their name.
+type ScormRegistration struct {
+	ID              string        `gorm:"primaryKey"`
+	MaterialID      string        `gorm:"uniqueIndex:idx_scorm_registration"`
+	Material        Material      `gorm:"foreignKey:MaterialID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ActiveStudentID string        `gorm:"uniqueIndex:idx_scorm_registration;index"`
+	ActiveStudent   ActiveStudent `gorm:"foreignKey:ActiveStudentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CourseID        string        `gorm:"index"`
+	Course          Course        `gorm:"foreignKey:CourseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	LessonStatus    SCORM_STATUS  `gorm:"type:varchar(20)"`
+	LessonLocation  string
+	Entry           string
+	Exit            string
+	ScoreRaw        *float64
+	ScoreMin        *float64
+	ScoreMax        *float64
+	SuspendData     string
+	TotalTime       string
+	Data            map[string]string `gorm:"serializer:json"`
+	Sessions        int
+	CompletedAt     *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// CourseVersion is a published version of a course. Its chapters and materials are the rows of
+// the version, copied from the draft when it was published and never edited again.
+type CourseVersion struct {
+	ID          string `gorm:"primaryKey"`
+	CourseID    string `gorm:"uniqueIndex:idx_course_version"`
+	Course      Course `gorm:"foreignKey:CourseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Version     int    `gorm:"uniqueIndex:idx_course_version"`
+	Note        string
+	PublishedBy string // teacher yang mempublish
+	PublishedAt time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// CourseEnrollment pins a student to the version of a course they enrolled in, the student
+// keeps that version until a teacher migrates them to a newer one.
+type CourseEnrollment struct {
+	ID              string        `gorm:"primaryKey"`
+	CourseID        string        `gorm:"uniqueIndex:idx_course_enrollment"`
+	Course          Course        `gorm:"foreignKey:CourseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ActiveStudentID string        `gorm:"uniqueIndex:idx_course_enrollment;index"`
+	ActiveStudent   ActiveStudent `gorm:"foreignKey:ActiveStudentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Version         int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// CourseTemplate is a course of the template library curated by SUPER_ADMIN, teachers of every
+// school can clone it into their own school.
+type CourseTemplate struct {
+	ID          string `gorm:"primaryKey"`
+	CourseID    string `gorm:"index"`
+	Course      Course `gorm:"foreignKey:CourseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Title       string
+	Description string
+	CreatedBy   string // user super admin
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+// CourseTeacher grants a teacher other than the owner of the course edit rights on it.
+type CourseTeacher struct {
+	ID        string  `gorm:"primaryKey"`
+	CourseID  string  `gorm:"uniqueIndex:idx_course_teacher"`
+	Course    Course  `gorm:"foreignKey:CourseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	TeacherID string  `gorm:"uniqueIndex:idx_course_teacher;index"`
+	Teacher   Teacher `gorm:"foreignKey:TeacherID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	GrantedBy string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type CourseClass struct {
 	ID        string `gorm:"primaryKey"`
 	CourseID  string
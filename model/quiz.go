@@ -6,53 +6,227 @@ import (
 	"gorm.io/gorm"
 )
 
+type SCORING_POLICY string
+
+const (
+	SCORE_BEST    SCORING_POLICY = "BEST"
+	SCORE_LAST    SCORING_POLICY = "LAST"
+	SCORE_AVERAGE SCORING_POLICY = "AVERAGE"
+)
+
+type QUESTION_TYPE string
+
+const (
+	SINGLE_CHOICE   QUESTION_TYPE = "SINGLE_CHOICE"
+	MULTIPLE_CHOICE QUESTION_TYPE = "MULTIPLE_CHOICE"
+	TRUE_FALSE      QUESTION_TYPE = "TRUE_FALSE"
+	SHORT_ANSWER    QUESTION_TYPE = "SHORT_ANSWER"
+	NUMERIC         QUESTION_TYPE = "NUMERIC"
+	ORDERING        QUESTION_TYPE = "ORDERING"
+)
+
+type QUESTION_DIFFICULTY string
+
+const (
+	DIFFICULTY_EASY   QUESTION_DIFFICULTY = "EASY"
+	DIFFICULTY_MEDIUM QUESTION_DIFFICULTY = "MEDIUM"
+	DIFFICULTY_HARD   QUESTION_DIFFICULTY = "HARD"
+)
+
+type REVIEW_POLICY string
+
+const (
+	REVIEW_NEVER        REVIEW_POLICY = "NEVER"
+	REVIEW_AFTER_SUBMIT REVIEW_POLICY = "AFTER_SUBMIT"
+	REVIEW_AFTER_CLOSE  REVIEW_POLICY = "AFTER_CLOSE"
+)
+
+type LATE_SUBMISSION string
+
+const (
+	LATE_REJECT LATE_SUBMISSION = "REJECT"
+	LATE_GRADE  LATE_SUBMISSION = "GRADE"
+)
+
+type GRADE_CHANGE string
+
+const (
+	GRADE_REGRADE  GRADE_CHANGE = "REGRADE"
+	GRADE_OVERRIDE GRADE_CHANGE = "OVERRIDE"
+)
+
 type Quiz struct {
-	ID          string `gorm:"primaryKey"`
-	ChapterID   string
-	Chapter     Chapter `gorm:"foreignKey:ChapterID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	MaterialID  string
-	Material    Material `gorm:"foreignKey:MaterialID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ID               string `gorm:"primaryKey"`
+	ChapterID        string
+	Chapter          Chapter `gorm:"foreignKey:ChapterID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	MaterialID       string
+	Material         Material `gorm:"foreignKey:MaterialID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Title            string
+	Description      string
+	MaxAttempts      int            // 0 means unlimited
+	ScoringPolicy    SCORING_POLICY `gorm:"type:varchar(20);default:'LAST'"`
+	TimeLimit        int            // minutes, 0 means no time limit
+	OpenAt           *time.Time
+	CloseAt          *time.Time
+	LateSubmission   LATE_SUBMISSION `gorm:"type:varchar(20);default:'REJECT'"`
+	ReviewPolicy     REVIEW_POLICY   `gorm:"type:varchar(20);default:'AFTER_SUBMIT'"`
+	ShuffleQuestions bool
+	ShuffleAnswers   bool
+	Quizes           []Quizes       `gorm:"foreignKey:QuizID"`
+	DrawRules        []QuizDrawRule `gorm:"foreignKey:QuizID"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+}
+
+// Quizes is a question of a quiz, or of a question bank when QuestionBankID is set
+// and QuizID is empty. Topic and Difficulty are used to draw bank questions.
+type Quizes struct {
+	ID             string `gorm:"primaryKey"`
+	Quiz           string
+	QuizID         string              `gorm:"default:null"`
+	QuestionBankID *string             `gorm:"index"`
+	Topic          string              `gorm:"index"`
+	Difficulty     QUESTION_DIFFICULTY `gorm:"type:varchar(10)"`
+	ImgURL         string
+	Type           QUESTION_TYPE `gorm:"type:varchar(20);default:'SINGLE_CHOICE'"`
+	Points         int           `gorm:"default:1"`
+	QuizAnswers    []QuizAnswer  `gorm:"foreignKey:QuizesID"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+// QuestionBank is a reusable collection of questions of a course, or of one of its chapters.
+type QuestionBank struct {
+	ID          string  `gorm:"primaryKey"`
+	CourseID    string  `gorm:"index"`
+	Course      Course  `gorm:"foreignKey:CourseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ChapterID   *string `gorm:"index"`
 	Title       string
 	Description string
-	Quizes      []Quizes `gorm:"foreignKey:QuizID"`
+	Questions   []Quizes `gorm:"foreignKey:QuestionBankID"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	DeletedAt   gorm.DeletedAt `gorm:"index"`
 }
 
-type Quizes struct {
-	ID          string `gorm:"primaryKey"`
-	Quiz        string
-	QuizID      string
-	ImgURL      string
-	QuizAnswers []QuizAnswer `gorm:"foreignKey:QuizesID"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+// QuizDrawRule draws Count random questions of a question bank into every attempt of a quiz.
+// An empty Topic or Difficulty matches every question of the bank.
+type QuizDrawRule struct {
+	ID             string `gorm:"primaryKey"`
+	QuizID         string `gorm:"index"`
+	QuestionBankID string
+	QuestionBank   QuestionBank `gorm:"foreignKey:QuestionBankID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Topic          string
+	Difficulty     QUESTION_DIFFICULTY `gorm:"type:varchar(10)"`
+	Count          int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
 }
 
+// QuizAnswer is an option of a choice question, an accepted answer of a
+// SHORT_ANSWER or NUMERIC question, or an item of an ORDERING question
+// where Position is its place in the correct order.
 type QuizAnswer struct {
 	ID                 string `gorm:"primaryKey"`
 	Answer             string
 	QuizesID           string
 	QuizAnswerStudents []QuizAnswerStudent `gorm:"foreignKey:QuizAnswerID"`
 	IsCorrect          bool
+	Tolerance          float64
+	Position           int
 	CreatedAt          time.Time
 	UpdatedAt          time.Time
 	DeletedAt          gorm.DeletedAt `gorm:"index"`
 }
 
+// QuizAnswerStudent is the response of a student to one question of an attempt.
+// Choice questions keep the picked option in QuizAnswerID, MULTIPLE_CHOICE and
+// ORDERING keep the options in the student's order in SelectedAnswerIDs and
+// SHORT_ANSWER and NUMERIC keep what the student typed in AnswerText.
+// Points is the score earned for the question.
 type QuizAnswerStudent struct {
-	ID              string `gorm:"primaryKey"`
-	QuizID          string // quiz nya
-	Quiz            Quiz   `gorm:"foreignKey:QuizID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	QuizAnswerID    string
-	QuizAnswer      QuizAnswer `gorm:"foreignKey:QuizAnswerID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	QuizesID        string     `gorm:"foreignKey:QuizesID"`
-	ActiveStudentID string
+	ID                string `gorm:"primaryKey"`
+	QuizID            string // quiz nya
+	Quiz              Quiz   `gorm:"foreignKey:QuizID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	QuizAttemptID     string `gorm:"index"`
+	QuizAnswerID      *string
+	QuizAnswer        QuizAnswer `gorm:"foreignKey:QuizAnswerID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	QuizesID          string     `gorm:"foreignKey:QuizesID"`
+	ActiveStudentID   string
+	ActiveStudent     ActiveStudent `gorm:"foreignKey:ActiveStudentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	SelectedAnswerIDs []string      `gorm:"serializer:json"`
+	AnswerText        string
+	Points            float64
+	IsCorrect         bool
+	Grades            int
+	Score             int
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+}
+
+// QuizAttempt is a single graded submission of a quiz by a student.
+// Score and Grades are the authoritative result of the attempt,
+// the per-question responses are stored as QuizAnswerStudent rows.
+// TotalQuestions and TotalPoints describe the whole quiz when it was graded,
+// unanswered questions count as 0 points.
+// An attempt without FinishedAt is still in progress.
+// ScoreOverridden marks a Score set by hand by a teacher, a regrade keeps it.
+type QuizAttempt struct {
+	ID              string        `gorm:"primaryKey"`
+	QuizID          string        `gorm:"index"`
+	Quiz            Quiz          `gorm:"foreignKey:QuizID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ActiveStudentID string        `gorm:"index"`
 	ActiveStudent   ActiveStudent `gorm:"foreignKey:ActiveStudentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Score           float64
 	Grades          int
-	Score           int
+	TotalQuestions  int
+	TotalPoints     int
+	StartedAt       time.Time
+	DeadlineAt      *time.Time
+	FinishedAt      *time.Time
+	IsLate          bool
+	ScoreOverridden bool
+	Questions       []QuizAttemptQuestion `gorm:"foreignKey:QuizAttemptID"`
+	Responses       []QuizAnswerStudent   `gorm:"foreignKey:QuizAttemptID"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+// QuizAttemptQuestion is a question given in an attempt. Position is its place in the
+// attempt and AnswerOrder the order its options were shown, so every attempt is graded
+// and reviewed exactly as the student got it.
+type QuizAttemptQuestion struct {
+	ID            string `gorm:"primaryKey"`
+	QuizAttemptID string `gorm:"index"`
+	QuizesID      string
+	Question      Quizes `gorm:"foreignKey:QuizesID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Position      int
+	AnswerOrder   []string `gorm:"serializer:json"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+// QuizGradeAudit records a change of the result of a graded attempt, made by a regrade
+// of the quiz or by a teacher overriding the score of a student.
+type QuizGradeAudit struct {
+	ID              string       `gorm:"primaryKey"`
+	QuizID          string       `gorm:"index"`
+	QuizAttemptID   string       `gorm:"index"`
+	QuizAttempt     QuizAttempt  `gorm:"foreignKey:QuizAttemptID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ActiveStudentID string       `gorm:"index"`
+	UserID          string       // teacher who made the change
+	Action          GRADE_CHANGE `gorm:"type:varchar(20)"`
+	OldScore        float64
+	NewScore        float64
+	OldGrades       int
+	NewGrades       int
+	Reason          string
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 	DeletedAt       gorm.DeletedAt `gorm:"index"`
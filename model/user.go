@@ -15,15 +15,35 @@ const (
 	STUDENT     ROLE = "STUDENT"
 )
 
+// Status of a user, only ACTIVE users can log in and keep their sessions.
+const (
+	USER_ACTIVE   = "ACTIVE"
+	USER_INACTIVE = "INACTIVE"
+)
+
+// Reason of a revoked UserSession.
+const (
+	SESSION_LOGOUT           = "LOGOUT"
+	SESSION_TOKEN_REUSED     = "TOKEN_REUSED"
+	SESSION_USER_DELETED     = "USER_DELETED"
+	SESSION_STATUS_CHANGED   = "STATUS_CHANGED"
+	SESSION_PASSWORD_CHANGED = "PASSWORD_CHANGED"
+)
+
 type User struct {
-	ID        string `gorm:"primaryKey"`
-	Username  string
-	Password  string
-	Status    string `gorm:"type:varchar(20)"`
-	Role      ROLE
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID       string `gorm:"primaryKey"`
+	Username string
+	Password string
+	// PasswordChangedAt invalidates the sessions created before it.
+	PasswordChangedAt *time.Time
+	// MustChangePassword is set for passwords given by an admin, the user can only change
+	// its password until it does.
+	MustChangePassword bool
+	Status             string `gorm:"type:varchar(20)"`
+	Role               ROLE
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeletedAt          gorm.DeletedAt `gorm:"index"`
 }
 
 type Student struct {
@@ -74,3 +94,83 @@ type AdminSchool struct {
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
+
+// UserSession is a login of a user. Access tokens carry its ID in the "sid" claim
+// and stop working as soon as the session is revoked.
+type UserSession struct {
+	ID            string `gorm:"primaryKey"`
+	UserID        string `gorm:"index"`
+	User          User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserAgent     string
+	IPAddress     string `gorm:"type:varchar(64)"`
+	LastUsedAt    time.Time
+	RevokedAt     *time.Time
+	RevokedReason string `gorm:"type:varchar(50)"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+// RefreshToken is a single use token of a session, only its SHA-256 hash is stored.
+// Every refresh marks the token as used and issues the next one of the same session.
+type RefreshToken struct {
+	ID            string      `gorm:"primaryKey"`
+	UserSessionID string      `gorm:"index"`
+	UserSession   UserSession `gorm:"foreignKey:UserSessionID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	TokenHash     string      `gorm:"type:varchar(64);uniqueIndex"`
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// LoginAttempt counts the failed logins of a key, e.g. "username:budi" or "ip:10.0.0.1".
+// Failures start again from zero when the last failure is older than the failure window.
+type LoginAttempt struct {
+	Key          string `gorm:"primaryKey;type:varchar(255)"`
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Invitation lets the invitee create its own user with Role in the school and set its password
+// with a one-time token. Only the hash of the token is stored, a deleted invitation is revoked.
+type Invitation struct {
+	ID           string  `gorm:"primaryKey"`
+	SchoolID     string  `gorm:"index"`
+	Schools      Schools `gorm:"foreignKey:SchoolID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Role         ROLE    `gorm:"type:varchar(20)"`
+	TokenHash    string  `gorm:"type:varchar(64);uniqueIndex"`
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	UsedByUserID *string
+	CreatedBy    string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+// UserTOTP is the TOTP second factor of a user, the login only asks for a code once it is confirmed.
+// LastUsedStep is the time step of the last accepted code, a code can't be used twice.
+type UserTOTP struct {
+	UserID       string `gorm:"primaryKey"`
+	User         User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Secret       string `gorm:"type:varchar(64)"`
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// RecoveryCode replaces a TOTP code once when the user lost its authenticator,
+// only its SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        string `gorm:"primaryKey"`
+	UserID    string `gorm:"index"`
+	User      User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CodeHash  string `gorm:"type:varchar(64)"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
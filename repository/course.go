@@ -1,10 +1,60 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/cvzamannow/E-Learning-API/helper"
 	"github.com/cvzamannow/E-Learning-API/model"
 )
 
+// CourseResource is a record that belongs to a course, see FindCourseIDOf.
+type CourseResource string
+
+const (
+	RESOURCE_COURSE             CourseResource = "COURSE"
+	RESOURCE_CHAPTER            CourseResource = "CHAPTER"
+	RESOURCE_MATERIAL           CourseResource = "MATERIAL"
+	RESOURCE_QUIZ               CourseResource = "QUIZ"
+	RESOURCE_QUESTION_BANK      CourseResource = "QUESTION_BANK"
+	RESOURCE_QUIZ_ATTEMPT       CourseResource = "QUIZ_ATTEMPT"
+	RESOURCE_SUBMISSION_STUDENT CourseResource = "SUBMISSION_STUDENT"
+)
+
+// ChapterOrder is the place of a chapter and its materials in a new order of a course, see ReorderCourse.
+type ChapterOrder struct {
+	ChapterID   string
+	MaterialIDs []string
+}
+
+// COURSE_CHANGE is how a chapter or material changed between two versions of a course.
+type COURSE_CHANGE string
+
+const (
+	CHANGE_ADDED   COURSE_CHANGE = "ADDED"
+	CHANGE_REMOVED COURSE_CHANGE = "REMOVED"
+	CHANGE_CHANGED COURSE_CHANGE = "CHANGED"
+)
+
+// CourseChange is a chapter or material that differs between two versions of a course, see
+// DiffCourseVersions. Fields lists what a CHANGE_CHANGED item changed: title, position,
+// chapter or content.
+type CourseChange struct {
+	Resource  CourseResource
+	LineageID string
+	Title     string
+	Change    COURSE_CHANGE
+	Fields    []string
+	position  int
+}
+
+// CourseTree is the content of a course moved by course packages, see FindCourseTree. The chapters
+// of Course have their materials with theory, submission and prerequisites.
+type CourseTree struct {
+	Course        model.Course
+	Quizzes       []model.Quiz
+	QuestionBanks []model.QuestionBank
+}
+
 /*
 * This folder handler logic in persistance layer.
 * Simply, this is where our query database is doing their job.
@@ -13,6 +63,10 @@ import (
 * Because it has potential error 'POINTER DEFERENCE' when its value is nil.
  */
 type CourseRepository interface {
+	// WithContext returns the repository running its queries with ctx, the queries are
+	// limited to the school of the Tenant in ctx.
+	WithContext(ctx context.Context) CourseRepository
+
 	// Course
 	CreateCourse(data model.Course) (*model.Course, error)
 	CreateCourseClass(data model.CourseClass) (*model.CourseClass, error)
@@ -32,6 +86,13 @@ type CourseRepository interface {
 	DeleteCourse(cond map[string]interface{}) (*model.Course, error)
 	EditCourse(cond map[string]interface{}, data model.Course) (*model.Course, error)
 
+	// Course Ownership
+	FindCourseIDOf(resource CourseResource, id string) (string, error)
+	CanEditCourse(courseID string, teacherID string) (bool, error)
+	CreateCourseTeacher(data model.CourseTeacher) (*model.CourseTeacher, error)
+	FindCourseTeachers(codd map[string]interface{}) ([]model.CourseTeacher, error)
+	DeleteCourseTeacher(codd map[string]interface{}) (*model.CourseTeacher, error)
+
 	// Chapter
 	CreateChapter(data model.Chapter) (*model.Chapter, error)
 	FindChapter(cond map[string]interface{}) (*model.Chapter, error)
@@ -46,6 +107,42 @@ type CourseRepository interface {
 	UpdateMaterial(cond map[string]interface{}, data model.Material) (*model.Material, error)
 	DeleteMaterial(cond map[string]interface{}) (*model.Material, error)
 
+	// Order and Prerequisites
+	ReorderCourse(courseID string, chapters []ChapterOrder) error
+	FindMaterialPrerequisites(materialID string) ([]model.MaterialPrerequisite, error)
+	SaveMaterialPrerequisites(materialID string, data []model.MaterialPrerequisite) ([]model.MaterialPrerequisite, error)
+	CheckStudentMaterial(materialID string, activeStudentID string) error
+
+	// Versions
+	IsDraftResource(resource CourseResource, id string) (bool, error)
+	PublishCourse(courseID string, publishedBy string, note string) (*model.CourseVersion, error)
+	FindCourseVersions(courseID string) ([]model.CourseVersion, error)
+	DiffCourseVersions(courseID string, from int, to int) ([]CourseChange, error)
+	RollbackCourse(courseID string, version int) error
+	FindCourseEnrollments(codd map[string]interface{}) ([]model.CourseEnrollment, error)
+	MigrateCourseEnrollments(courseID string, version int, activeStudentIDs []string) (int, error)
+
+	// Cloning and Templates
+	CloneCourse(sourceID string, data model.Course) (*model.Course, error)
+	CloneCourseTemplate(templateID string, data model.Course) (*model.Course, error)
+	CreateCourseTemplate(data model.CourseTemplate) (*model.CourseTemplate, error)
+	FindCourseTemplates() ([]model.CourseTemplate, error)
+	FindCourseTemplate(cond map[string]interface{}) (*model.CourseTemplate, error)
+	DeleteCourseTemplate(id string) (*model.CourseTemplate, error)
+
+	// Export and Import
+	FindCourseTree(courseID string) (*CourseTree, error)
+	ImportCourseTree(tree CourseTree) (*model.Course, error)
+	CourseSlugExists(slug string) (bool, error)
+
+	// SCORM
+	CreateScormPackage(data model.ScormPackage) (*model.ScormPackage, error)
+	FindScormPackage(cond map[string]interface{}) (*model.ScormPackage, error)
+	ReplaceScormPackage(materialID string, data model.ScormPackage) (*model.ScormPackage, error)
+	StartScormRegistration(material model.Material, activeStudentID string) (*model.ScormRegistration, error)
+	CommitScormRegistration(materialID string, activeStudentID string, values map[string]string, finish bool) (*model.ScormRegistration, map[string]helper.SCORM_ERROR, error)
+	FindScormRegistrations(cond map[string]interface{}) ([]model.ScormRegistration, error)
+
 	// Study Material
 	CreateTheory(
 		data model.Theory,
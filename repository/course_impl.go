@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 
 	"github.com/cvzamannow/E-Learning-API/helper"
@@ -9,6 +10,23 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrCourseResourceNotFound is returned when the course of a resource couldn't be resolved.
+	ErrCourseResourceNotFound = errors.New("[DATABASE] Course of the resource not found")
+	// ErrInvalidCourseOrder is returned when a new order doesn't list every chapter and material of the course once.
+	ErrInvalidCourseOrder = errors.New("[DATABASE] Order must list every chapter and material of the course once")
+	// ErrPrerequisiteCycle is returned when prerequisites would make materials wait for each other.
+	ErrPrerequisiteCycle = errors.New("[DATABASE] Prerequisites of the course form a cycle")
+	// ErrMaterialNotAvailable is returned for a material outside the version of the course the student follows.
+	ErrMaterialNotAvailable = errors.New("[DATABASE] Material is not available to the student")
+	// ErrMaterialLocked is returned for a material whose prerequisites the student hasn't met yet.
+	ErrMaterialLocked = errors.New("[DATABASE] Material is locked until its prerequisites are met")
+)
+
+// positionOrder orders chapters and materials, the records created before positions
+// existed share Position 0 and keep their creation order.
+const positionOrder = "position ASC, created_at ASC"
+
 type courseImpl struct {
 	DB *gorm.DB
 }
@@ -19,7 +37,17 @@ func NewCourseRepository(db *gorm.DB) CourseRepository {
 	}
 }
 
+// WithContext implements CourseRepository.
+func (repos *courseImpl) WithContext(ctx context.Context) CourseRepository {
+	return &courseImpl{
+		DB: repos.DB.WithContext(ctx),
+	}
+}
+
 func (repos *courseImpl) CreateCourse(data model.Course) (*model.Course, error) {
+	// Course baru langsung punya draft, siswa melihatnya setelah dipublish
+	data.DraftVersion = 1
+
 	result := repos.DB.Create(&data)
 
 	if result.Error != nil {
@@ -67,7 +95,8 @@ func (repos *courseImpl) FindCourses(
 	}
 
 	if teacherPOV != "" {
-		tx.Where("teacher_id = ?", teacherPOV)
+		tx.Where("courses.teacher_id = ? OR courses.id IN (?)", teacherPOV,
+			repos.DB.Model(&model.CourseTeacher{}).Select("course_id").Where("teacher_id = ?", teacherPOV))
 	}
 
 	if activeStudentID != "" {
@@ -92,6 +121,14 @@ func (repos *courseImpl) FindCourses(
 
 	tx.Where("courses.is_draft = ?", false)
 
+	// Chapters of the draft for teachers, of the published version for the others
+	version := "published_version"
+	if teacherPOV != "" {
+		version = "draft_version"
+	} else {
+		tx.Where("courses.published_at IS NOT NULL OR courses.draft_version = courses.published_version")
+	}
+
 	logrus.Infoln("[repository] Course  Classes:", queryClass)
 
 	// Counting rows
@@ -101,7 +138,9 @@ func (repos *courseImpl) FindCourses(
 
 	pagination, txPaginator := helper.Paginator(page, limit, tx, c)
 
-	txPaginator.Preload("CourseClasses").Preload("Chapters").Order("created_at ASC").Find(&courses)
+	txPaginator.Preload("CourseClasses").
+		Preload("Chapters", "chapters.version = (SELECT courses."+version+" FROM courses WHERE courses.id = chapters.course_id)").
+		Order("created_at ASC").Find(&courses)
 	if txPaginator.Error != nil {
 		logrus.Warnln("[database] There is error:", txPaginator.Error.Error())
 		return nil, nil
@@ -119,14 +158,7 @@ func (repos *courseImpl) FindCourse(cond map[string]interface{}, isStudentPOV bo
 	tx := repos.DB.Model(&model.Course{})
 
 	if isStudentPOV && activeStudentID != "" {
-		logrus.Infoln("[repository] Current POV: Student")
-		tx.Preload("Chapters.Materials", func(d *gorm.DB) *gorm.DB {
-			return d.Order("created_at ASC").Preload("Progress", "active_student_id = ?", activeStudentID)
-		}).Preload("CompleteCourses")
-	} else {
-		tx.Preload("Chapters.Materials", func(d *gorm.DB) *gorm.DB {
-			return d.Order("created_at ASC")
-		})
+		tx.Preload("CompleteCourses")
 	}
 
 	tx.Preload("CourseClasses").
@@ -137,6 +169,45 @@ func (repos *courseImpl) FindCourse(cond map[string]interface{}, isStudentPOV bo
 		return nil, tx.Error
 	}
 
+	// Teacher melihat draft, siswa melihat versi yang diikutinya
+	version := course.DraftVersion
+	if isStudentPOV {
+		studentVersion, ok, err := repos.studentVersion(&course, activeStudentID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return &course, nil
+		}
+		version = studentVersion
+	}
+
+	chapters := repos.DB.Where("course_id = ? AND version = ?", course.ID, version).Order(positionOrder)
+
+	if isStudentPOV && activeStudentID != "" {
+		logrus.Infoln("[repository] Current POV: Student")
+		chapters.Preload("Materials", func(d *gorm.DB) *gorm.DB {
+			return d.Order(positionOrder).
+				Preload("Progress", "active_student_id = ?", activeStudentID).
+				Preload("Prerequisites")
+		})
+	} else {
+		chapters.Preload("Materials", func(d *gorm.DB) *gorm.DB {
+			return d.Order(positionOrder)
+		})
+	}
+
+	if err := chapters.Find(&course.Chapters).Error; err != nil {
+		return nil, err
+	}
+
+	if isStudentPOV && activeStudentID != "" {
+		if err := repos.lockMaterials(&course, activeStudentID); err != nil {
+			return nil, err
+		}
+	}
+
 	return &course, nil
 }
 
@@ -227,19 +298,143 @@ func (repos *courseImpl) EditCourse(
 }
 
 
+// FindCourseIDOf resolves the course a resource belongs to,
+// e.g. material -> chapter -> course.
+func (repos *courseImpl) FindCourseIDOf(resource CourseResource, id string) (string, error) {
+	var tx *gorm.DB
+	var column string
+
+	switch resource {
+	case RESOURCE_COURSE:
+		tx, column = repos.DB.Model(&model.Course{}).Where("courses.id = ?", id), "courses.id"
+	case RESOURCE_CHAPTER:
+		tx, column = repos.DB.Model(&model.Chapter{}).Where("chapters.id = ?", id), "chapters.course_id"
+	case RESOURCE_MATERIAL:
+		tx, column = repos.DB.Model(&model.Material{}).
+			Joins("inner join chapters on chapters.id = materials.chapter_id").
+			Where("materials.id = ?", id), "chapters.course_id"
+	case RESOURCE_QUIZ:
+		tx, column = repos.DB.Model(&model.Quiz{}).
+			Joins("inner join chapters on chapters.id = quizzes.chapter_id").
+			Where("quizzes.id = ?", id), "chapters.course_id"
+	case RESOURCE_QUESTION_BANK:
+		tx, column = repos.DB.Model(&model.QuestionBank{}).Where("question_banks.id = ?", id), "question_banks.course_id"
+	case RESOURCE_QUIZ_ATTEMPT:
+		tx, column = repos.DB.Model(&model.QuizAttempt{}).
+			Joins("inner join quizzes on quizzes.id = quiz_attempts.quiz_id").
+			Joins("inner join chapters on chapters.id = quizzes.chapter_id").
+			Where("quiz_attempts.id = ?", id), "chapters.course_id"
+	case RESOURCE_SUBMISSION_STUDENT:
+		tx, column = repos.DB.Model(&model.SubmissionStudent{}).Where("submission_students.id = ?", id), "submission_students.course_id"
+	default:
+		return "", ErrCourseResourceNotFound
+	}
+
+	var ids []string
+	if err := tx.Limit(1).Pluck(column, &ids).Error; err != nil {
+		logrus.Warnln("[database] Couldn't resolve course of", resource, "because error:", err)
+		return "", err
+	}
+
+	if len(ids) == 0 || ids[0] == "" {
+		return "", ErrCourseResourceNotFound
+	}
+
+	return ids[0], nil
+}
+
+// CanEditCourse reports whether the teacher owns the course or was granted edit rights on it.
+func (repos *courseImpl) CanEditCourse(courseID string, teacherID string) (bool, error) {
+	var count int64
+
+	err := repos.DB.Model(&model.Course{}).
+		Where("courses.id = ?", courseID).
+		Where("courses.teacher_id = ? OR courses.id IN (?)", teacherID,
+			repos.DB.Model(&model.CourseTeacher{}).Select("course_id").Where("teacher_id = ?", teacherID)).
+		Count(&count).Error
+
+	if err != nil {
+		logrus.Warnln("[database] Couldn't check course editor because error:", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (repos *courseImpl) CreateCourseTeacher(data model.CourseTeacher) (*model.CourseTeacher, error) {
+	err := repos.DB.Create(&data).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+func (repos *courseImpl) FindCourseTeachers(codd map[string]interface{}) ([]model.CourseTeacher, error) {
+	var courseTeachers []model.CourseTeacher
+
+	err := repos.DB.Preload("Teacher").Where(codd).Order("created_at ASC").Find(&courseTeachers).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return courseTeachers, nil
+}
+
+func (repos *courseImpl) DeleteCourseTeacher(codd map[string]interface{}) (*model.CourseTeacher, error) {
+	var courseTeacher model.CourseTeacher
+
+	err := repos.DB.Where(codd).First(&courseTeacher).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = repos.DB.Delete(&courseTeacher).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &courseTeacher, nil
+}
+
 func (repos *courseImpl) CreateChapter(data model.Chapter) (*model.Chapter, error) {
+	var course model.Course
+	if err := repos.DB.Where("id = ?", data.CourseID).First(&course).Error; err != nil {
+		return nil, err
+	}
+
+	// Chapter baru masuk ke draft
+	data.Version = course.DraftVersion
+	data.LineageID = data.ID
+
+	// Chapter baru ditaruh paling akhir
+	var last []int
+	if err := repos.DB.Model(&model.Chapter{}).Where("course_id = ? AND version = ?", data.CourseID, data.Version).Pluck("COALESCE(MAX(position), -1)", &last).Error; err != nil {
+		return nil, err
+	}
+
+	if len(last) > 0 {
+		data.Position = last[0] + 1
+	}
+
 	err := repos.DB.Create(&data).Error
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Undo completed progress
-	var completedCourse []model.CompleteCourse 
-	tx := repos.DB.Where("course_id", data.CourseID).Find(&completedCourse)
+	// Undo completed progress, hanya jika course diedit langsung tanpa draft
+	if data.Version == course.PublishedVersion {
+		var completedCourse []model.CompleteCourse
+		tx := repos.DB.Where("course_id", data.CourseID).Find(&completedCourse)
 
-	if tx.RowsAffected > 0 {
-		repos.DB.Delete(&completedCourse)
+		if tx.RowsAffected > 0 {
+			repos.DB.Delete(&completedCourse)
+		}
 	}
 
 	return &data, nil
@@ -260,7 +455,10 @@ func (repos *courseImpl) FindChapter(cond map[string]interface{}) (*model.Chapte
 func (repos *courseImpl) FindChapters(course_id string) ([]model.Chapter, error) {
 	var chapters []model.Chapter
 
-	err := repos.DB.Where("course_id = ?", course_id).Find(&chapters).Error
+	err := repos.DB.Where("course_id = ?", course_id).
+		Where("version = (?)", repos.DB.Model(&model.Course{}).Select("draft_version").Where("id = ?", course_id)).
+		Order(positionOrder).
+		Find(&chapters).Error
 
 	if err != nil {
 		return nil, err
@@ -280,6 +478,7 @@ func (repos *courseImpl) UpdateChapter(id string, data model.Chapter) (*model.Ch
 
 	data.ID = chapter.ID
 	data.CourseID = chapter.CourseID
+	data.Position = chapter.Position
 	data.CreatedAt = chapter.CreatedAt
 
 	err = repos.DB.Save(&data).Error
@@ -317,6 +516,8 @@ func (repos *courseImpl) DeleteChapter(
 		repos.DB.Delete(&materials)
 
 		for _, el := range materials {
+			repos.deletePrerequisitesOf(el.ID)
+
 			if el.Type == "SUBMISSION" {
 				var s []model.SubmissionStudent
 				sTx := repos.DB.Where("material_id = ?", el.ID).Find(&s)
@@ -342,19 +543,32 @@ func (repos *courseImpl) CreateMaterial(data model.Material) (*model.Material, e
 	}
 
 	data.CourseID = chapter.CourseID
+	data.Version = chapter.Version
+	data.LineageID = data.ID
 
-	err := repos.DB.Create(&data).Error
+	position, err := nextMaterialPosition(repos.DB, data.ChapterID)
+	if err != nil {
+		return nil, err
+	}
+	data.Position = position
+
+	err = repos.DB.Create(&data).Error
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Undo completed course student
-	var completedCourse []model.CompleteCourse
-	tx = repos.DB.Where("course_id = ?", data.CourseID).Find(&completedCourse)
+	// Undo completed course student, hanya jika course diedit langsung tanpa draft
+	var published []int
+	repos.DB.Model(&model.Course{}).Where("id = ?", data.CourseID).Pluck("published_version", &published)
 
-	if tx.RowsAffected > 0 {
-		repos.DB.Delete(&completedCourse)
+	if len(published) > 0 && published[0] == data.Version {
+		var completedCourse []model.CompleteCourse
+		tx = repos.DB.Where("course_id = ?", data.CourseID).Find(&completedCourse)
+
+		if tx.RowsAffected > 0 {
+			repos.DB.Delete(&completedCourse)
+		}
 	}
 
 
@@ -389,6 +603,7 @@ func (repos *courseImpl) UpdateMaterial(
 	data.ChapterID = material.ChapterID
 	data.CourseID = material.CourseID
 	data.Type = material.Type
+	data.Position = material.Position
 	data.CreatedAt = material.CreatedAt
 
 	err = repos.DB.Save(&data).Error
@@ -415,6 +630,13 @@ func (repos *courseImpl) DeleteMaterial(cond map[string]interface{}) (*model.Mat
 		return nil, err
 	}
 
+	repos.deletePrerequisitesOf(material.ID)
+
+	if material.Type == "SCORM" {
+		repos.DB.Where("material_id = ?", material.ID).Delete(&model.ScormRegistration{})
+		repos.DB.Where("material_id = ?", material.ID).Delete(&model.ScormPackage{})
+	}
+
 	if material.Type == "SUBMISSION" {
 		// Delete Submission Student
 		var submissionStudent []model.SubmissionStudent
@@ -434,7 +656,7 @@ func (repos *courseImpl) FindMaterials(cond map[string]interface{}) ([]model.Mat
 	var materials []model.Material
 
 	err := repos.DB.Where(cond).
-		Order("created_at ASC").
+		Order(positionOrder).
 		Find(&materials).
 		Error
 
@@ -762,7 +984,7 @@ func (repos *courseImpl) NextMaterial(v *helper.NextMaterialArg, isOtherChapter
 	// Placeholder data
 	var m model.Material
 
-	tx := repos.DB.Order("created_at ASC").Where("id = ?", v.CurrentMaterialID).First(&m)
+	tx := repos.DB.Where("id = ?", v.CurrentMaterialID).First(&m)
 
 	if tx.RowsAffected == 0 {
 		return nil, errors.New("no record")
@@ -773,9 +995,11 @@ func (repos *courseImpl) NextMaterial(v *helper.NextMaterialArg, isOtherChapter
 	var result model.Material
 
 	if isOtherChapter {
-		tx = repos.DB.Order("created_at ASC").Where("chapter_id = ?", v.ChapterID).First(&result)
+		tx = repos.DB.Order(positionOrder).Where("chapter_id = ?", v.ChapterID).First(&result)
 	} else {
-		tx = repos.DB.Order("created_at ASC").Where("chapter_id = ?", v.ChapterID).Where("created_at > ?", m.CreatedAt).First(&result)
+		tx = repos.DB.Order(positionOrder).Where("chapter_id = ?", v.ChapterID).
+			Where("position > ? OR (position = ? AND created_at > ?)", m.Position, m.Position, m.CreatedAt).
+			First(&result)
 	}
 
 	if tx.Error != nil {
@@ -836,7 +1060,7 @@ func (repos *courseImpl) FindSubmissionPreload(teacher_id string, active_student
 func (repos *courseImpl) NextChapter(chapter_id string, course_id string) *model.Chapter {
 	var previousChapter model.Chapter
 
-	tx := repos.DB.Model(&model.Chapter{}).Order("created_at ASC").Where("id = ?", chapter_id).First(&previousChapter)
+	tx := repos.DB.Model(&model.Chapter{}).Where("id = ?", chapter_id).First(&previousChapter)
 
 	if tx.RowsAffected == 0 {
 		logrus.Warnln("[repository] Couldn't find previous Chapter")
@@ -844,7 +1068,9 @@ func (repos *courseImpl) NextChapter(chapter_id string, course_id string) *model
 	}
 
 	var nextChapter model.Chapter
-	tx = repos.DB.Model(&model.Chapter{}).Order("created_at ASC").Where("course_id = ?", course_id).Where("created_at > ?", previousChapter.CreatedAt).First(&nextChapter)
+	tx = repos.DB.Model(&model.Chapter{}).Order(positionOrder).Where("course_id = ? AND version = ?", course_id, previousChapter.Version).
+		Where("position > ? OR (position = ? AND created_at > ?)", previousChapter.Position, previousChapter.Position, previousChapter.CreatedAt).
+		First(&nextChapter)
 
 	if tx.RowsAffected == 0 {
 		logrus.Warnln("[repository] Couldn't find next chapter")
@@ -878,3 +1104,343 @@ func (repos *courseImpl) PlaceholderFilterSubmission() ([]model.SubmissionStuden
 
 }
 
+
+// ReorderCourse moves the chapters and materials of the draft of a course to the order of chapters, a
+// material can be moved to another chapter of the course. The order must list every chapter and material once.
+func (repos *courseImpl) ReorderCourse(courseID string, chapters []ChapterOrder) error {
+	return repos.DB.Transaction(func(tx *gorm.DB) error {
+		var chapterIDs []string
+		if err := tx.Model(&model.Chapter{}).Where("course_id = ?", courseID).
+			Where("version = (?)", tx.Model(&model.Course{}).Select("draft_version").Where("id = ?", courseID)).
+			Pluck("id", &chapterIDs).Error; err != nil {
+			return err
+		}
+
+		var materials []model.Material
+		if err := tx.Select("id", "chapter_id").Where("chapter_id IN ?", chapterIDs).Find(&materials).Error; err != nil {
+			return err
+		}
+
+		remainingChapters := make(map[string]bool, len(chapterIDs))
+		for _, id := range chapterIDs {
+			remainingChapters[id] = true
+		}
+
+		chapterOf := make(map[string]string, len(materials))
+		for _, material := range materials {
+			chapterOf[material.ID] = material.ChapterID
+		}
+
+		moved := 0
+		for _, chapter := range chapters {
+			if !remainingChapters[chapter.ChapterID] {
+				return ErrInvalidCourseOrder
+			}
+			delete(remainingChapters, chapter.ChapterID)
+			moved += len(chapter.MaterialIDs)
+		}
+
+		if len(remainingChapters) > 0 || moved != len(materials) {
+			return ErrInvalidCourseOrder
+		}
+
+		for position, chapter := range chapters {
+			if err := tx.Model(&model.Chapter{}).Where("id = ?", chapter.ChapterID).Update("position", position).Error; err != nil {
+				return err
+			}
+
+			for materialPosition, materialID := range chapter.MaterialIDs {
+				previousChapterID, ok := chapterOf[materialID]
+				if !ok {
+					return ErrInvalidCourseOrder
+				}
+				// Material yang sama tidak boleh muncul dua kali
+				delete(chapterOf, materialID)
+
+				if err := tx.Model(&model.Material{}).Where("id = ?", materialID).Updates(map[string]interface{}{
+					"chapter_id": chapter.ChapterID,
+					"position":   materialPosition,
+				}).Error; err != nil {
+					return err
+				}
+
+				// Quiz ikut pindah chapter bersama material nya
+				if previousChapterID != chapter.ChapterID {
+					if err := tx.Model(&model.Quiz{}).Where("material_id = ?", materialID).Update("chapter_id", chapter.ChapterID).Error; err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// FindMaterialPrerequisites implements CourseRepository.
+func (repos *courseImpl) FindMaterialPrerequisites(materialID string) ([]model.MaterialPrerequisite, error) {
+	var prerequisites []model.MaterialPrerequisite
+
+	err := repos.DB.Where("material_id = ?", materialID).
+		Preload("RequiredMaterial").
+		Order("created_at ASC").
+		Find(&prerequisites).
+		Error
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to retrieve Material Prerequisites because error:", err)
+		return nil, err
+	}
+
+	return prerequisites, nil
+}
+
+// SaveMaterialPrerequisites replaces the prerequisites of a material. It fails with
+// ErrPrerequisiteCycle when the materials of the course would wait for each other.
+func (repos *courseImpl) SaveMaterialPrerequisites(materialID string, data []model.MaterialPrerequisite) ([]model.MaterialPrerequisite, error) {
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		var material model.Material
+		if err := tx.Where("id = ?", materialID).First(&material).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("material_id = ?", materialID).Delete(&model.MaterialPrerequisite{}).Error; err != nil {
+			return err
+		}
+
+		for i := range data {
+			data[i].MaterialID = materialID
+			if err := tx.Omit("Material", "RequiredMaterial").Create(&data[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		var chapter model.Chapter
+		if err := tx.Where("id = ?", material.ChapterID).First(&chapter).Error; err != nil {
+			return err
+		}
+
+		var rules []model.MaterialPrerequisite
+		if err := tx.Where("material_id IN (?)", tx.Model(&model.Material{}).Select("materials.id").
+			Joins("inner join chapters on chapters.id = materials.chapter_id").
+			Where("chapters.course_id = ? AND chapters.version = ?", chapter.CourseID, chapter.Version)).
+			Find(&rules).Error; err != nil {
+			return err
+		}
+
+		if hasPrerequisiteCycle(rules) {
+			return ErrPrerequisiteCycle
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repos.FindMaterialPrerequisites(materialID)
+}
+
+// nextMaterialPosition returns the position placing a new material at the end of its chapter.
+func nextMaterialPosition(db *gorm.DB, chapterID string) (int, error) {
+	var last []int
+	if err := db.Model(&model.Material{}).Where("chapter_id = ?", chapterID).Pluck("COALESCE(MAX(position), -1)", &last).Error; err != nil {
+		return 0, err
+	}
+
+	if len(last) == 0 {
+		return 0, nil
+	}
+
+	return last[0] + 1, nil
+}
+
+// deletePrerequisitesOf deletes the prerequisites of a deleted material and the prerequisites
+// requiring it, which could never be met anymore.
+func (repos *courseImpl) deletePrerequisitesOf(materialID string) {
+	err := repos.DB.Where("material_id = ? OR required_material_id = ?", materialID, materialID).
+		Delete(&model.MaterialPrerequisite{}).Error
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to delete Material Prerequisites because error:", err)
+	}
+}
+
+// hasPrerequisiteCycle reports whether a material requires itself through its prerequisites.
+func hasPrerequisiteCycle(rules []model.MaterialPrerequisite) bool {
+	required := make(map[string][]string)
+	for _, rule := range rules {
+		required[rule.MaterialID] = append(required[rule.MaterialID], rule.RequiredMaterialID)
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		switch state[id] {
+		case visiting:
+			return true
+		case visited:
+			return false
+		}
+
+		state[id] = visiting
+		for _, next := range required[id] {
+			if visit(next) {
+				return true
+			}
+		}
+		state[id] = visited
+
+		return false
+	}
+
+	for id := range required {
+		if visit(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lockMaterials sets IsLocked of the materials of a course loaded for the student POV.
+// A material with prerequisites unlocks when the student meets all of them, any other
+// material when the student completed the material before it. The first material and
+// the completed materials are never locked.
+func (repos *courseImpl) lockMaterials(course *model.Course, activeStudentID string) error {
+	completed := make(map[string]bool)
+	var quizMaterialIDs, submissionMaterialIDs []string
+
+	for _, chapter := range course.Chapters {
+		for _, material := range chapter.Materials {
+			completed[material.ID] = len(material.Progress) > 0
+
+			for _, rule := range material.Prerequisites {
+				switch rule.Type {
+				case model.PREREQUISITE_QUIZ_SCORE:
+					quizMaterialIDs = append(quizMaterialIDs, rule.RequiredMaterialID)
+				case model.PREREQUISITE_SUBMISSION_APPROVED:
+					submissionMaterialIDs = append(submissionMaterialIDs, rule.RequiredMaterialID)
+				}
+			}
+		}
+	}
+
+	// Nilai quiz dihitung sesuai scoring policy quiz nya, sama seperti di grades
+	quizGrades := make(map[string]int)
+	if len(quizMaterialIDs) > 0 {
+		var quizzes []model.Quiz
+		if err := repos.DB.Where("material_id IN ?", quizMaterialIDs).Find(&quizzes).Error; err != nil {
+			return err
+		}
+
+		for _, quiz := range quizzes {
+			var attempts []model.QuizAttempt
+			if err := repos.DB.Where("quiz_id = ? AND active_student_id = ?", quiz.ID, activeStudentID).
+				Where("finished_at IS NOT NULL").
+				Order("created_at DESC").
+				Find(&attempts).Error; err != nil {
+				return err
+			}
+
+			recorded := helper.RecordedQuizAttempt(quiz.ScoringPolicy, attempts)
+			if recorded == nil {
+				continue
+			}
+
+			if grades, ok := quizGrades[quiz.MaterialID]; !ok || recorded.Grades > grades {
+				quizGrades[quiz.MaterialID] = recorded.Grades
+			}
+		}
+	}
+
+	approved := make(map[string]bool)
+	if len(submissionMaterialIDs) > 0 {
+		var materialIDs []string
+		if err := repos.DB.Model(&model.SubmissionStudent{}).
+			Where("active_student_id = ? AND material_id IN ?", activeStudentID, submissionMaterialIDs).
+			Where("status IN ?", []string{string(model.APPROVE), "APPROVED"}).
+			Pluck("material_id", &materialIDs).Error; err != nil {
+			return err
+		}
+
+		for _, id := range materialIDs {
+			approved[id] = true
+		}
+	}
+
+	met := func(rule model.MaterialPrerequisite) bool {
+		switch rule.Type {
+		case model.PREREQUISITE_COMPLETED:
+			return completed[rule.RequiredMaterialID]
+		case model.PREREQUISITE_QUIZ_SCORE:
+			grades, ok := quizGrades[rule.RequiredMaterialID]
+			return ok && grades >= rule.MinGrades
+		case model.PREREQUISITE_SUBMISSION_APPROVED:
+			return approved[rule.RequiredMaterialID]
+		}
+
+		return false
+	}
+
+	previousCompleted := true
+	for i := range course.Chapters {
+		for j := range course.Chapters[i].Materials {
+			material := &course.Chapters[i].Materials[j]
+
+			unlocked := previousCompleted
+			if len(material.Prerequisites) > 0 {
+				unlocked = true
+				for _, rule := range material.Prerequisites {
+					unlocked = unlocked && met(rule)
+				}
+			}
+
+			material.IsLocked = !unlocked && !completed[material.ID]
+			previousCompleted = completed[material.ID]
+		}
+	}
+
+	return nil
+}
+
+// CheckStudentMaterial checks that a student can open a material, the material must be in the
+// version of the course the student is pinned to and unlocked by its prerequisites.
+func (repos *courseImpl) CheckStudentMaterial(materialID string, activeStudentID string) error {
+	var material model.Material
+	if err := repos.DB.Where("id = ?", materialID).First(&material).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCourseResourceNotFound
+		}
+		return err
+	}
+
+	course, err := repos.FindCourse(map[string]interface{}{
+		"id": material.CourseID,
+	}, true, activeStudentID)
+	if err != nil {
+		logrus.Warnln("[database] Couldn't find course of material because error:", err)
+		return err
+	}
+
+	for _, chapter := range course.Chapters {
+		for _, m := range chapter.Materials {
+			if m.ID != material.ID {
+				continue
+			}
+
+			if m.IsLocked {
+				return ErrMaterialLocked
+			}
+			return nil
+		}
+	}
+
+	return ErrMaterialNotAvailable
+}
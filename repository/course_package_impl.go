@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindCourseTree loads the draft of a course with everything a course package needs.
+func (repos *courseImpl) FindCourseTree(courseID string) (*CourseTree, error) {
+	var tree CourseTree
+
+	if err := repos.DB.Where("id = ?", courseID).Preload("CourseClasses").First(&tree.Course).Error; err != nil {
+		return nil, err
+	}
+
+	createdOrder := func(d *gorm.DB) *gorm.DB {
+		return d.Order("created_at ASC")
+	}
+
+	err := repos.DB.Where("course_id = ? AND version = ?", courseID, tree.Course.DraftVersion).
+		Order(positionOrder).
+		Preload("Materials", func(d *gorm.DB) *gorm.DB {
+			return d.Order(positionOrder)
+		}).
+		Preload("Materials.Theory").
+		Preload("Materials.Submission").
+		Preload("Materials.Scorm").
+		Preload("Materials.Prerequisites", createdOrder).
+		Find(&tree.Course.Chapters).Error
+	if err != nil {
+		logrus.Warnln("[database] Failed to retrieve course tree because error:", err)
+		return nil, err
+	}
+
+	var materialIDs []string
+	for _, chapter := range tree.Course.Chapters {
+		for _, material := range chapter.Materials {
+			materialIDs = append(materialIDs, material.ID)
+		}
+	}
+
+	err = repos.DB.Where("material_id IN ?", materialIDs).
+		Preload("Quizes", createdOrder).
+		Preload("Quizes.QuizAnswers", createdOrder).
+		Preload("DrawRules", createdOrder).
+		Find(&tree.Quizzes).Error
+	if err != nil {
+		logrus.Warnln("[database] Failed to retrieve course tree because error:", err)
+		return nil, err
+	}
+
+	err = repos.DB.Where("course_id = ?", courseID).
+		Order("created_at ASC").
+		Preload("Questions", createdOrder).
+		Preload("Questions.QuizAnswers", createdOrder).
+		Find(&tree.QuestionBanks).Error
+	if err != nil {
+		logrus.Warnln("[database] Failed to retrieve course tree because error:", err)
+		return nil, err
+	}
+
+	return &tree, nil
+}
+
+// ImportCourseTree creates a course with its whole content in one transaction. Every record of
+// tree must already have its ID, the content is created as version 1, the draft of the course.
+func (repos *courseImpl) ImportCourseTree(tree CourseTree) (*model.Course, error) {
+	course := tree.Course
+	chapters := course.Chapters
+	course.Chapters = nil
+	course.DraftVersion = 1
+	course.PublishedVersion = 0
+	course.PublishedAt = nil
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Chapters", "CompleteCourses").Create(&course).Error; err != nil {
+			return err
+		}
+
+		var prerequisites []model.MaterialPrerequisite
+
+		for _, chapter := range chapters {
+			materials := chapter.Materials
+			chapter.Materials = nil
+			chapter.CourseID = course.ID
+			chapter.Version = course.DraftVersion
+
+			if err := tx.Omit(clause.Associations).Create(&chapter).Error; err != nil {
+				return err
+			}
+
+			for _, material := range materials {
+				theory, submission, scorm := material.Theory, material.Submission, material.Scorm
+				prerequisites = append(prerequisites, material.Prerequisites...)
+
+				material.ChapterID = chapter.ID
+				material.CourseID = course.ID
+				material.Version = course.DraftVersion
+
+				if err := tx.Omit(clause.Associations).Create(&material).Error; err != nil {
+					return err
+				}
+
+				if theory.ID != "" {
+					theory.MaterialID = material.ID
+					if err := tx.Create(&theory).Error; err != nil {
+						return err
+					}
+				}
+
+				if submission.ID != "" {
+					submission.MaterialID = material.ID
+					if err := tx.Create(&submission).Error; err != nil {
+						return err
+					}
+				}
+
+				if scorm.ID != "" {
+					scorm.MaterialID = material.ID
+					if err := tx.Create(&scorm).Error; err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		for _, bank := range tree.QuestionBanks {
+			questions := bank.Questions
+			bank.CourseID = course.ID
+
+			if err := tx.Omit(clause.Associations).Create(&bank).Error; err != nil {
+				return err
+			}
+
+			for _, question := range questions {
+				question.QuestionBankID = &bank.ID
+				if err := createImportedQuestion(tx, question); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, quiz := range tree.Quizzes {
+			questions, drawRules := quiz.Quizes, quiz.DrawRules
+
+			if err := tx.Omit(clause.Associations).Create(&quiz).Error; err != nil {
+				return err
+			}
+
+			for _, question := range questions {
+				question.QuizID = quiz.ID
+				if err := createImportedQuestion(tx, question); err != nil {
+					return err
+				}
+			}
+
+			for _, rule := range drawRules {
+				rule.QuizID = quiz.ID
+				if err := tx.Omit(clause.Associations).Create(&rule).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, prerequisite := range prerequisites {
+			if err := tx.Omit(clause.Associations).Create(&prerequisite).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to import course because error:", err)
+		return nil, err
+	}
+
+	return &course, nil
+}
+
+// createImportedQuestion creates a question with its answers, one by one so they keep their order.
+func createImportedQuestion(tx *gorm.DB, question model.Quizes) error {
+	answers := question.QuizAnswers
+	question.QuizAnswers = nil
+
+	if err := tx.Omit(clause.Associations).Create(&question).Error; err != nil {
+		return err
+	}
+
+	for _, answer := range answers {
+		answer.QuizesID = question.ID
+		if err := tx.Omit(clause.Associations).Create(&answer).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CourseSlugExists reports whether a course of the school already uses slug.
+func (repos *courseImpl) CourseSlugExists(slug string) (bool, error) {
+	var count int64
+
+	if err := repos.DB.Model(&model.Course{}).Where("slug = ?", slug).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
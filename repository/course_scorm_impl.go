@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrScormNotInitialized is returned when a SCO commits before LMSInitialize started its session.
+	ErrScormNotInitialized = errors.New("[DATABASE] SCORM session is not initialized")
+	// ErrScormNotAvailable is returned for a SCORM material outside the version of the course the student follows.
+	ErrScormNotAvailable = errors.New("[DATABASE] SCORM material is not available to the student")
+)
+
+// CreateScormPackage implements CourseRepository.
+func (repos *courseImpl) CreateScormPackage(data model.ScormPackage) (*model.ScormPackage, error) {
+	if err := repos.DB.Create(&data).Error; err != nil {
+		logrus.Warnln("[database] Failed to create SCORM package because error:", err)
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// FindScormPackage implements CourseRepository.
+func (repos *courseImpl) FindScormPackage(cond map[string]interface{}) (*model.ScormPackage, error) {
+	var pkg model.ScormPackage
+
+	if err := repos.DB.Where(cond).First(&pkg).Error; err != nil {
+		return nil, err
+	}
+
+	return &pkg, nil
+}
+
+// ReplaceScormPackage replaces the package of a SCORM material. The files of the old package stay
+// in storage, copies of the material in other versions still use them.
+func (repos *courseImpl) ReplaceScormPackage(materialID string, data model.ScormPackage) (*model.ScormPackage, error) {
+	data.MaterialID = materialID
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("material_id = ?", materialID).Delete(&model.ScormPackage{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&data).Error
+	})
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to replace SCORM package because error:", err)
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// StartScormRegistration starts a SCORM session of the student for LMSInitialize, creating the
+// registration on the first launch. The material must be in the version of the course the
+// student is pinned to.
+func (repos *courseImpl) StartScormRegistration(material model.Material, activeStudentID string) (*model.ScormRegistration, error) {
+	var course model.Course
+	if err := repos.DB.Where("id = ?", material.CourseID).First(&course).Error; err != nil {
+		return nil, err
+	}
+
+	version, ok, err := repos.studentVersion(&course, activeStudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok || version != material.Version {
+		return nil, ErrScormNotAvailable
+	}
+
+	var registration model.ScormRegistration
+
+	err = repos.DB.Transaction(func(tx *gorm.DB) error {
+		id, err := helper.GenerateNanoId()
+		if err != nil {
+			return err
+		}
+
+		// LMSInitialize bersamaan tidak boleh membuat dua registration
+		err = tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&model.ScormRegistration{
+			ID:              id,
+			MaterialID:      material.ID,
+			ActiveStudentID: activeStudentID,
+			CourseID:        material.CourseID,
+			LessonStatus:    model.SCORM_NOT_ATTEMPTED,
+			Data:            map[string]string{},
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := lockScormRegistration(tx, material.ID, activeStudentID, &registration); err != nil {
+			return err
+		}
+
+		helper.StartScormSession(&registration)
+
+		return tx.Omit(clause.Associations).Save(&registration).Error
+	})
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to start SCORM session because error:", err)
+		return nil, err
+	}
+
+	return &registration, nil
+}
+
+// CommitScormRegistration stores the values a SCO set since its last commit, for LMSCommit and,
+// with finish, LMSFinish. It returns the registration with the elements that were rejected. A
+// registration that completes the material records the progress of the student on it.
+func (repos *courseImpl) CommitScormRegistration(
+	materialID string,
+	activeStudentID string,
+	values map[string]string,
+	finish bool,
+) (*model.ScormRegistration, map[string]helper.SCORM_ERROR, error) {
+	var registration model.ScormRegistration
+	var errs map[string]helper.SCORM_ERROR
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockScormRegistration(tx, materialID, activeStudentID, &registration); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrScormNotInitialized
+			}
+			return err
+		}
+
+		var pkg model.ScormPackage
+		if err := tx.Where("material_id = ?", materialID).First(&pkg).Error; err != nil {
+			return err
+		}
+
+		errs = helper.SetScormValues(&registration, values)
+		helper.CommitScormSession(&registration, pkg, finish, time.Now())
+
+		if err := tx.Omit(clause.Associations).Save(&registration).Error; err != nil {
+			return err
+		}
+
+		if !helper.IsScormCompleted(registration.LessonStatus) {
+			return nil
+		}
+
+		var done int64
+		if err := tx.Model(&model.ActiveStudentCourse{}).
+			Where("active_student_id = ? AND material_id = ?", activeStudentID, materialID).
+			Count(&done).Error; err != nil {
+			return err
+		}
+
+		if done > 0 {
+			return nil
+		}
+
+		id, err := helper.GenerateNanoId()
+		if err != nil {
+			return err
+		}
+
+		return tx.Omit(clause.Associations).Create(&model.ActiveStudentCourse{
+			ID:              id,
+			ActiveStudentID: activeStudentID,
+			CourseID:        registration.CourseID,
+			MaterialID:      materialID,
+		}).Error
+	})
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to commit SCORM session because error:", err)
+		return nil, nil, err
+	}
+
+	return &registration, errs, nil
+}
+
+func lockScormRegistration(tx *gorm.DB, materialID string, activeStudentID string, registration *model.ScormRegistration) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("material_id = ? AND active_student_id = ?", materialID, activeStudentID).
+		First(registration).Error
+}
+
+// FindScormRegistrations implements CourseRepository.
+func (repos *courseImpl) FindScormRegistrations(cond map[string]interface{}) ([]model.ScormRegistration, error) {
+	var registrations []model.ScormRegistration
+
+	err := repos.DB.Where(cond).
+		Preload("ActiveStudent.Student").
+		Order("updated_at DESC").
+		Find(&registrations).Error
+	if err != nil {
+		logrus.Warnln("[database] Failed to retrieve SCORM registrations because error:", err)
+		return nil, err
+	}
+
+	return registrations, nil
+}
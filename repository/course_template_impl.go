@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrCourseTemplateExists is returned when a course is added to the template library twice.
+var ErrCourseTemplateExists = errors.New("[DATABASE] Course is already a template")
+
+// CloneCourse copies the draft of a course, with its classes, chapters, materials, theory,
+// submissions, quizzes and question banks, into the new course data. See cloneCourse.
+func (repos *courseImpl) CloneCourse(sourceID string, data model.Course) (*model.Course, error) {
+	var course *model.Course
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		course, err = cloneCourse(tx, tx, sourceID, true, data)
+		return err
+	})
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to clone course because error:", err)
+		return nil, err
+	}
+
+	return course, nil
+}
+
+// CloneCourseTemplate copies the course of a template into the new course data. The template
+// can belong to another school, the clone belongs to the school of the teacher of data.
+func (repos *courseImpl) CloneCourseTemplate(templateID string, data model.Course) (*model.Course, error) {
+	var course *model.Course
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		var template model.CourseTemplate
+		if err := tx.Where("id = ?", templateID).First(&template).Error; err != nil {
+			return err
+		}
+
+		var err error
+		course, err = cloneCourse(allSchools(tx), tx, template.CourseID, false, data)
+		return err
+	})
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to clone course template because error:", err)
+		return nil, err
+	}
+
+	return course, nil
+}
+
+// cloneCourse creates data as a copy of a course read with src, in version 1 of its draft. The
+// draft of the course is copied when fromDraft is set, the published version otherwise. Fields
+// of the course left empty in data are copied, the clone starts as a draft.
+func cloneCourse(src, dst *gorm.DB, sourceID string, fromDraft bool, data model.Course) (*model.Course, error) {
+	var source model.Course
+	if err := src.Where("id = ?", sourceID).Preload("CourseClasses").First(&source).Error; err != nil {
+		return nil, err
+	}
+
+	version := source.DraftVersion
+	if !fromDraft && isCoursePublished(&source) {
+		version = source.PublishedVersion
+	}
+
+	if data.Title == "" {
+		data.Title = source.Title
+	}
+	if data.Description == "" {
+		data.Description = source.Description
+	}
+	if data.Detail == "" {
+		data.Detail = source.Detail
+	}
+	if data.EstimationHour == "" {
+		data.EstimationHour = source.EstimationHour
+	}
+	if data.EstimationMinute == "" {
+		data.EstimationMinute = source.EstimationMinute
+	}
+	if data.ThumbnailImg == "" {
+		data.ThumbnailImg = source.ThumbnailImg
+	}
+
+	data.IsDraft = true
+	data.DraftVersion = 1
+	data.PublishedVersion = 0
+	data.PublishedAt = nil
+
+	if len(data.CourseClasses) == 0 {
+		for _, class := range source.CourseClasses {
+			id, err := helper.GenerateNanoId()
+			if err != nil {
+				return nil, err
+			}
+
+			data.CourseClasses = append(data.CourseClasses, model.CourseClass{
+				ID:    id,
+				Class: class.Class,
+				Slug:  class.Slug,
+			})
+		}
+	}
+
+	if err := dst.Create(&data).Error; err != nil {
+		return nil, err
+	}
+
+	if _, err := copyCourseContent(src, dst,
+		courseVersionRef{source.ID, version},
+		courseVersionRef{data.ID, data.DraftVersion}); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// CreateCourseTemplate implements CourseRepository.
+func (repos *courseImpl) CreateCourseTemplate(data model.CourseTemplate) (*model.CourseTemplate, error) {
+	var count int64
+	if err := repos.DB.Model(&model.CourseTemplate{}).Where("course_id = ?", data.CourseID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+
+	if count > 0 {
+		return nil, ErrCourseTemplateExists
+	}
+
+	if err := repos.DB.Omit("Course").Create(&data).Error; err != nil {
+		logrus.Warnln("[database] Failed to create Course Template because error:", err)
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// FindCourseTemplates lists the template library, the courses of every school are preloaded.
+func (repos *courseImpl) FindCourseTemplates() ([]model.CourseTemplate, error) {
+	var templates []model.CourseTemplate
+
+	err := repos.DB.Preload("Course", func(d *gorm.DB) *gorm.DB {
+		return allSchools(d)
+	}).Order("created_at DESC").Find(&templates).Error
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to retrieve Course Templates because error:", err)
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// FindCourseTemplate implements CourseRepository.
+func (repos *courseImpl) FindCourseTemplate(cond map[string]interface{}) (*model.CourseTemplate, error) {
+	var template model.CourseTemplate
+
+	err := repos.DB.Where(cond).Preload("Course", func(d *gorm.DB) *gorm.DB {
+		return allSchools(d)
+	}).First(&template).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+// DeleteCourseTemplate removes a course from the template library, the course itself is kept.
+func (repos *courseImpl) DeleteCourseTemplate(id string) (*model.CourseTemplate, error) {
+	template, err := repos.FindCourseTemplate(map[string]interface{}{
+		"id": id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repos.DB.Delete(&model.CourseTemplate{}, "id = ?", id).Error; err != nil {
+		logrus.Warnln("[database] Failed to delete Course Template because error:", err)
+		return nil, err
+	}
+
+	return template, nil
+}
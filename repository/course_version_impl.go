@@ -0,0 +1,858 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrCourseVersionNotFound is returned for a version that is neither published nor the draft of the course.
+	ErrCourseVersionNotFound = errors.New("[DATABASE] Course version not found")
+	// ErrCourseWithoutDraft is returned when a course still edited live is rolled back.
+	ErrCourseWithoutDraft = errors.New("[DATABASE] Course has no draft, publish it first")
+)
+
+// isCoursePublished reports whether students can see a version of the course. Courses created
+// before versioning have no separate draft and are always visible.
+func isCoursePublished(course *model.Course) bool {
+	return course.PublishedAt != nil || course.DraftVersion == course.PublishedVersion
+}
+
+// studentVersion returns the version of the course the student is pinned to, pinning the student
+// to the published version on their first visit. ok is false when the course was never published.
+func (repos *courseImpl) studentVersion(course *model.Course, activeStudentID string) (int, bool, error) {
+	if !isCoursePublished(course) {
+		return 0, false, nil
+	}
+
+	if activeStudentID == "" {
+		return course.PublishedVersion, true, nil
+	}
+
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Request bersamaan tidak boleh membuat dua enrollment
+	err = repos.DB.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&model.CourseEnrollment{
+		ID:              id,
+		CourseID:        course.ID,
+		ActiveStudentID: activeStudentID,
+		Version:         course.PublishedVersion,
+	}).Error
+	if err != nil {
+		logrus.Warnln("[database] Failed to enroll student because error:", err)
+		return 0, false, err
+	}
+
+	var enrollment model.CourseEnrollment
+	if err := repos.DB.Where("course_id = ? AND active_student_id = ?", course.ID, activeStudentID).First(&enrollment).Error; err != nil {
+		return 0, false, err
+	}
+
+	return enrollment.Version, true, nil
+}
+
+// IsDraftResource reports whether a chapter, material or quiz belongs to the draft of its course.
+// Other resources are not versioned.
+func (repos *courseImpl) IsDraftResource(resource CourseResource, id string) (bool, error) {
+	var tx *gorm.DB
+
+	switch resource {
+	case RESOURCE_CHAPTER:
+		tx = repos.DB.Model(&model.Chapter{}).Where("chapters.id = ?", id)
+	case RESOURCE_MATERIAL:
+		tx = repos.DB.Model(&model.Material{}).
+			Joins("inner join chapters on chapters.id = materials.chapter_id").
+			Where("materials.id = ?", id)
+	case RESOURCE_QUIZ:
+		tx = repos.DB.Model(&model.Quiz{}).
+			Joins("inner join chapters on chapters.id = quizzes.chapter_id").
+			Where("quizzes.id = ?", id)
+	default:
+		return true, nil
+	}
+
+	var count int64
+	err := tx.Joins("inner join courses on courses.id = chapters.course_id").
+		Where("chapters.version = courses.draft_version").
+		Count(&count).Error
+
+	if err != nil {
+		logrus.Warnln("[database] Couldn't check draft of", resource, "because error:", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// PublishCourse freezes the draft of a course as its published version and copies it into
+// a new draft, in one transaction. Students already enrolled keep their version.
+func (repos *courseImpl) PublishCourse(courseID string, publishedBy string, note string) (*model.CourseVersion, error) {
+	var version model.CourseVersion
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		var course model.Course
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", courseID).First(&course).Error; err != nil {
+			return err
+		}
+
+		id, err := helper.GenerateNanoId()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		version = model.CourseVersion{
+			ID:          id,
+			CourseID:    course.ID,
+			Version:     course.DraftVersion,
+			Note:        note,
+			PublishedBy: publishedBy,
+			PublishedAt: now,
+		}
+
+		if err := tx.Omit(clause.Associations).Create(&version).Error; err != nil {
+			return err
+		}
+
+		draft := course.DraftVersion + 1
+		if _, err := copyCourseVersion(tx, course.ID, course.DraftVersion, draft); err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Course{}).Where("id = ?", course.ID).Updates(map[string]interface{}{
+			"published_version": course.DraftVersion,
+			"draft_version":     draft,
+			"published_at":      now,
+			"is_draft":          false,
+		}).Error
+	})
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to publish course because error:", err)
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// FindCourseVersions lists the published versions of a course from the newest.
+func (repos *courseImpl) FindCourseVersions(courseID string) ([]model.CourseVersion, error) {
+	var versions []model.CourseVersion
+
+	err := repos.DB.Where("course_id = ?", courseID).Order("version DESC").Find(&versions).Error
+	if err != nil {
+		logrus.Warnln("[database] Failed to retrieve Course Versions because error:", err)
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// RollbackCourse replaces the draft of a course with a copy of a published version.
+// The published version and the students pinned to it are not changed.
+func (repos *courseImpl) RollbackCourse(courseID string, version int) error {
+	return repos.DB.Transaction(func(tx *gorm.DB) error {
+		var course model.Course
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", courseID).First(&course).Error; err != nil {
+			return err
+		}
+
+		if course.DraftVersion == course.PublishedVersion {
+			return ErrCourseWithoutDraft
+		}
+
+		var count int64
+		if err := tx.Model(&model.CourseVersion{}).Where("course_id = ? AND version = ?", course.ID, version).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return ErrCourseVersionNotFound
+		}
+
+		if err := deleteCourseVersion(tx, course.ID, course.DraftVersion); err != nil {
+			return err
+		}
+
+		_, err := copyCourseVersion(tx, course.ID, version, course.DraftVersion)
+		return err
+	})
+}
+
+// DiffCourseVersions compares the chapters and materials of two versions of a course,
+// matching them by LineageID. The draft can be compared as well.
+func (repos *courseImpl) DiffCourseVersions(courseID string, from int, to int) ([]CourseChange, error) {
+	var course model.Course
+	if err := repos.DB.Where("id = ?", courseID).First(&course).Error; err != nil {
+		return nil, err
+	}
+
+	for _, version := range []int{from, to} {
+		if version == course.DraftVersion {
+			continue
+		}
+
+		var count int64
+		if err := repos.DB.Model(&model.CourseVersion{}).Where("course_id = ? AND version = ?", course.ID, version).Count(&count).Error; err != nil {
+			return nil, err
+		}
+
+		if count == 0 {
+			return nil, ErrCourseVersionNotFound
+		}
+	}
+
+	before, err := repos.versionContent(course.ID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := repos.versionContent(course.ID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffVersionContent(RESOURCE_CHAPTER, before.chapters, after.chapters)
+	changes = append(changes, diffVersionContent(RESOURCE_MATERIAL, before.materials, after.materials)...)
+
+	return changes, nil
+}
+
+// MigrateCourseEnrollments moves students to a published version of a course, all the enrolled
+// students when activeStudentIDs is empty. The progress of a student is carried over to the
+// materials of the version with the same LineageID. It returns the number of migrated students.
+func (repos *courseImpl) MigrateCourseEnrollments(courseID string, version int, activeStudentIDs []string) (int, error) {
+	migrated := 0
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.CourseVersion{}).Where("course_id = ? AND version = ?", courseID, version).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return ErrCourseVersionNotFound
+		}
+
+		query := tx.Where("course_id = ? AND version <> ?", courseID, version)
+		if len(activeStudentIDs) > 0 {
+			query = query.Where("active_student_id IN ?", activeStudentIDs)
+		}
+
+		var enrollments []model.CourseEnrollment
+		if err := query.Find(&enrollments).Error; err != nil {
+			return err
+		}
+
+		target, err := versionMaterialLineages(tx, courseID, version)
+		if err != nil {
+			return err
+		}
+
+		materialByLineage := make(map[string]string, len(target))
+		for materialID, lineageID := range target {
+			materialByLineage[lineageID] = materialID
+		}
+
+		for _, enrollment := range enrollments {
+			previous, err := versionMaterialLineages(tx, courseID, enrollment.Version)
+			if err != nil {
+				return err
+			}
+
+			previousIDs := make([]string, 0, len(previous))
+			for materialID := range previous {
+				previousIDs = append(previousIDs, materialID)
+			}
+
+			var progress []model.ActiveStudentCourse
+			if err := tx.Where("active_student_id = ? AND material_id IN ?", enrollment.ActiveStudentID, previousIDs).
+				Find(&progress).Error; err != nil {
+				return err
+			}
+
+			for _, done := range progress {
+				materialID, ok := materialByLineage[previous[done.MaterialID]]
+				if !ok {
+					continue
+				}
+
+				var exists int64
+				if err := tx.Model(&model.ActiveStudentCourse{}).
+					Where("active_student_id = ? AND material_id = ?", enrollment.ActiveStudentID, materialID).
+					Count(&exists).Error; err != nil {
+					return err
+				}
+
+				if exists > 0 {
+					continue
+				}
+
+				id, err := helper.GenerateNanoId()
+				if err != nil {
+					return err
+				}
+
+				if err := tx.Omit(clause.Associations).Create(&model.ActiveStudentCourse{
+					ID:              id,
+					ActiveStudentID: enrollment.ActiveStudentID,
+					CourseID:        done.CourseID,
+					MaterialID:      materialID,
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Model(&model.CourseEnrollment{}).Where("id = ?", enrollment.ID).Update("version", version).Error; err != nil {
+				return err
+			}
+
+			migrated++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logrus.Warnln("[database] Failed to migrate Course Enrollments because error:", err)
+		return 0, err
+	}
+
+	return migrated, nil
+}
+
+// FindCourseEnrollments implements CourseRepository.
+func (repos *courseImpl) FindCourseEnrollments(codd map[string]interface{}) ([]model.CourseEnrollment, error) {
+	var enrollments []model.CourseEnrollment
+
+	err := repos.DB.Where(codd).Preload("ActiveStudent.Student").Order("created_at ASC").Find(&enrollments).Error
+	if err != nil {
+		logrus.Warnln("[database] Failed to retrieve Course Enrollments because error:", err)
+		return nil, err
+	}
+
+	return enrollments, nil
+}
+
+func lineageOf(id string, lineageID string) string {
+	if lineageID == "" {
+		return id
+	}
+
+	return lineageID
+}
+
+// versionMaterialLineages returns the LineageID of the materials of a version by their ID.
+func versionMaterialLineages(tx *gorm.DB, courseID string, version int) (map[string]string, error) {
+	var materials []model.Material
+	err := tx.Select("materials.id", "materials.lineage_id").
+		Joins("inner join chapters on chapters.id = materials.chapter_id").
+		Where("chapters.course_id = ? AND chapters.version = ?", courseID, version).
+		Find(&materials).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lineages := make(map[string]string, len(materials))
+	for _, material := range materials {
+		lineages[material.ID] = lineageOf(material.ID, material.LineageID)
+	}
+
+	return lineages, nil
+}
+
+// courseVersionRef is a version of a course copied by copyCourseContent.
+type courseVersionRef struct {
+	CourseID string
+	Version  int
+}
+
+// copyCourseVersion copies the content of a version of a course into another version of the
+// same course, see copyCourseContent.
+func copyCourseVersion(tx *gorm.DB, courseID string, from int, to int) (map[string]string, error) {
+	return copyCourseContent(tx, tx, courseVersionRef{courseID, from}, courseVersionRef{courseID, to})
+}
+
+// copyCourseContent copies the chapters, materials, theory, submissions, quizzes and prerequisites
+// of a version of a course into another version and returns the new material IDs by the old ones.
+// The content is read with src and created with dst, which can have different tenants.
+// The copies keep their CreatedAt, so they are ordered like the originals. Copies in the same course
+// keep their LineageID, copies in another course start a new lineage and get a copy of the question
+// banks of the course, so the draw rules of the quizzes keep working.
+func copyCourseContent(src, dst *gorm.DB, from, to courseVersionRef) (map[string]string, error) {
+	var chapters []model.Chapter
+	err := src.Where("course_id = ? AND version = ?", from.CourseID, from.Version).
+		Preload("Materials.Theory").
+		Preload("Materials.Submission").
+		Preload("Materials.Scorm").
+		Preload("Materials.Prerequisites").
+		Find(&chapters).Error
+	if err != nil {
+		return nil, err
+	}
+
+	newID := func() (string, error) { return helper.GenerateNanoId() }
+	lineage := func(id, lineageID, copyID string) string {
+		if from.CourseID == to.CourseID {
+			return lineageOf(id, lineageID)
+		}
+		return copyID
+	}
+
+	chapterIDs := make(map[string]string)
+	materialIDs := make(map[string]string)
+	var prerequisites []model.MaterialPrerequisite
+
+	for _, chapter := range chapters {
+		materials := chapter.Materials
+
+		copied := chapter
+		copied.Materials = nil
+		copied.CourseID = to.CourseID
+		copied.Version = to.Version
+		if copied.ID, err = newID(); err != nil {
+			return nil, err
+		}
+		copied.LineageID = lineage(chapter.ID, chapter.LineageID, copied.ID)
+
+		if err := dst.Omit(clause.Associations).Create(&copied).Error; err != nil {
+			return nil, err
+		}
+		chapterIDs[chapter.ID] = copied.ID
+
+		for _, material := range materials {
+			copiedMaterial := material
+			copiedMaterial.ChapterID = copied.ID
+			copiedMaterial.CourseID = to.CourseID
+			copiedMaterial.Version = to.Version
+			copiedMaterial.Theory = model.Theory{}
+			copiedMaterial.Submission = model.Submission{}
+			copiedMaterial.Scorm = model.ScormPackage{}
+			copiedMaterial.Progress = nil
+			copiedMaterial.Prerequisites = nil
+			if copiedMaterial.ID, err = newID(); err != nil {
+				return nil, err
+			}
+			copiedMaterial.LineageID = lineage(material.ID, material.LineageID, copiedMaterial.ID)
+
+			if err := dst.Omit(clause.Associations).Create(&copiedMaterial).Error; err != nil {
+				return nil, err
+			}
+			materialIDs[material.ID] = copiedMaterial.ID
+
+			if material.Theory.ID != "" {
+				theory := material.Theory
+				theory.MaterialID = copiedMaterial.ID
+				if theory.ID, err = newID(); err != nil {
+					return nil, err
+				}
+
+				if err := dst.Create(&theory).Error; err != nil {
+					return nil, err
+				}
+			}
+
+			if material.Submission.ID != "" {
+				submission := material.Submission
+				submission.MaterialID = copiedMaterial.ID
+				if submission.ID, err = newID(); err != nil {
+					return nil, err
+				}
+
+				if err := dst.Create(&submission).Error; err != nil {
+					return nil, err
+				}
+			}
+
+			if material.Scorm.ID != "" {
+				scorm := material.Scorm
+				scorm.MaterialID = copiedMaterial.ID
+				if scorm.ID, err = newID(); err != nil {
+					return nil, err
+				}
+
+				if err := dst.Create(&scorm).Error; err != nil {
+					return nil, err
+				}
+			}
+
+			prerequisites = append(prerequisites, material.Prerequisites...)
+		}
+	}
+
+	oldMaterialIDs := make([]string, 0, len(materialIDs))
+	for id := range materialIDs {
+		oldMaterialIDs = append(oldMaterialIDs, id)
+	}
+
+	bankIDs := make(map[string]string)
+	if from.CourseID != to.CourseID {
+		if bankIDs, err = copyQuestionBanks(src, dst, from.CourseID, to.CourseID, chapterIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	var quizzes []model.Quiz
+	if err := src.Where("material_id IN ?", oldMaterialIDs).
+		Preload("Quizes.QuizAnswers").
+		Preload("DrawRules").
+		Find(&quizzes).Error; err != nil {
+		return nil, err
+	}
+
+	for _, quiz := range quizzes {
+		questions, drawRules := quiz.Quizes, quiz.DrawRules
+
+		copied := quiz
+		copied.Quizes = nil
+		copied.DrawRules = nil
+		copied.ChapterID = chapterIDs[quiz.ChapterID]
+		copied.MaterialID = materialIDs[quiz.MaterialID]
+		// Quiz dan material nya memakai ID yang sama
+		if quiz.ID == quiz.MaterialID {
+			copied.ID = copied.MaterialID
+		} else if copied.ID, err = newID(); err != nil {
+			return nil, err
+		}
+
+		if err := dst.Omit(clause.Associations).Create(&copied).Error; err != nil {
+			return nil, err
+		}
+
+		for _, question := range questions {
+			answers := question.QuizAnswers
+
+			copiedQuestion := question
+			copiedQuestion.QuizAnswers = nil
+			copiedQuestion.QuizID = copied.ID
+			if copiedQuestion.ID, err = newID(); err != nil {
+				return nil, err
+			}
+
+			if err := dst.Omit(clause.Associations).Create(&copiedQuestion).Error; err != nil {
+				return nil, err
+			}
+
+			for _, answer := range answers {
+				answer.QuizesID = copiedQuestion.ID
+				answer.QuizAnswerStudents = nil
+				if answer.ID, err = newID(); err != nil {
+					return nil, err
+				}
+
+				if err := dst.Omit(clause.Associations).Create(&answer).Error; err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for _, rule := range drawRules {
+			rule.QuizID = copied.ID
+			if bankID, ok := bankIDs[rule.QuestionBankID]; ok {
+				rule.QuestionBankID = bankID
+			}
+			if rule.ID, err = newID(); err != nil {
+				return nil, err
+			}
+
+			if err := dst.Omit(clause.Associations).Create(&rule).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, prerequisite := range prerequisites {
+		requiredID, ok := materialIDs[prerequisite.RequiredMaterialID]
+		if !ok {
+			continue
+		}
+
+		prerequisite.MaterialID = materialIDs[prerequisite.MaterialID]
+		prerequisite.RequiredMaterialID = requiredID
+		if prerequisite.ID, err = newID(); err != nil {
+			return nil, err
+		}
+
+		if err := dst.Omit(clause.Associations).Create(&prerequisite).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return materialIDs, nil
+}
+
+// copyQuestionBanks copies the question banks of a course with their questions into another course
+// and returns the new bank IDs by the old ones. Banks of a chapter that was not copied belong to the
+// whole course.
+func copyQuestionBanks(src, dst *gorm.DB, fromCourseID string, toCourseID string, chapterIDs map[string]string) (map[string]string, error) {
+	var banks []model.QuestionBank
+	if err := src.Where("course_id = ?", fromCourseID).Preload("Questions.QuizAnswers").Find(&banks).Error; err != nil {
+		return nil, err
+	}
+
+	bankIDs := make(map[string]string, len(banks))
+
+	for _, bank := range banks {
+		questions := bank.Questions
+
+		copied := bank
+		copied.Questions = nil
+		copied.CourseID = toCourseID
+		copied.ChapterID = nil
+		if bank.ChapterID != nil {
+			if chapterID, ok := chapterIDs[*bank.ChapterID]; ok {
+				copied.ChapterID = &chapterID
+			}
+		}
+
+		id, err := helper.GenerateNanoId()
+		if err != nil {
+			return nil, err
+		}
+		copied.ID = id
+
+		if err := dst.Omit(clause.Associations).Create(&copied).Error; err != nil {
+			return nil, err
+		}
+		bankIDs[bank.ID] = copied.ID
+
+		for _, question := range questions {
+			answers := question.QuizAnswers
+
+			copiedQuestion := question
+			copiedQuestion.QuizAnswers = nil
+			copiedQuestion.QuestionBankID = &copied.ID
+			if copiedQuestion.ID, err = helper.GenerateNanoId(); err != nil {
+				return nil, err
+			}
+
+			if err := dst.Omit(clause.Associations).Create(&copiedQuestion).Error; err != nil {
+				return nil, err
+			}
+
+			for _, answer := range answers {
+				answer.QuizesID = copiedQuestion.ID
+				answer.QuizAnswerStudents = nil
+				if answer.ID, err = helper.GenerateNanoId(); err != nil {
+					return nil, err
+				}
+
+				if err := dst.Omit(clause.Associations).Create(&answer).Error; err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return bankIDs, nil
+}
+
+// deleteCourseVersion deletes the content of a version of a course.
+func deleteCourseVersion(tx *gorm.DB, courseID string, version int) error {
+	var chapterIDs, materialIDs, quizIDs, questionIDs []string
+
+	if err := tx.Model(&model.Chapter{}).Where("course_id = ? AND version = ?", courseID, version).Pluck("id", &chapterIDs).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&model.Material{}).Where("chapter_id IN ?", chapterIDs).Pluck("id", &materialIDs).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&model.Quiz{}).Where("material_id IN ?", materialIDs).Pluck("id", &quizIDs).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&model.Quizes{}).Where("quiz_id IN ?", quizIDs).Pluck("id", &questionIDs).Error; err != nil {
+		return err
+	}
+
+	deletes := []struct {
+		model interface{}
+		query string
+		args  []interface{}
+	}{
+		{&model.QuizAnswer{}, "quizes_id IN ?", []interface{}{questionIDs}},
+		{&model.Quizes{}, "id IN ?", []interface{}{questionIDs}},
+		{&model.QuizDrawRule{}, "quiz_id IN ?", []interface{}{quizIDs}},
+		{&model.Quiz{}, "id IN ?", []interface{}{quizIDs}},
+		{&model.MaterialPrerequisite{}, "material_id IN ? OR required_material_id IN ?", []interface{}{materialIDs, materialIDs}},
+		{&model.Theory{}, "material_id IN ?", []interface{}{materialIDs}},
+		{&model.Submission{}, "material_id IN ?", []interface{}{materialIDs}},
+		{&model.ScormRegistration{}, "material_id IN ?", []interface{}{materialIDs}},
+		{&model.ScormPackage{}, "material_id IN ?", []interface{}{materialIDs}},
+		{&model.Material{}, "id IN ?", []interface{}{materialIDs}},
+		{&model.Chapter{}, "id IN ?", []interface{}{chapterIDs}},
+	}
+
+	for _, d := range deletes {
+		if err := tx.Where(d.query, d.args...).Delete(d.model).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// versionItem is a chapter or material compared by DiffCourseVersions.
+type versionItem struct {
+	title    string
+	position int
+	parent   string // lineage chapter dari material
+	content  string
+}
+
+type versionContent struct {
+	chapters  map[string]versionItem
+	materials map[string]versionItem
+}
+
+// versionContent loads the chapters and materials of a version by their LineageID.
+func (repos *courseImpl) versionContent(courseID string, version int) (*versionContent, error) {
+	var chapters []model.Chapter
+	err := repos.DB.Where("course_id = ? AND version = ?", courseID, version).
+		Preload("Materials.Theory").
+		Preload("Materials.Submission").
+		Preload("Materials.Scorm").
+		Find(&chapters).Error
+	if err != nil {
+		return nil, err
+	}
+
+	content := &versionContent{
+		chapters:  make(map[string]versionItem),
+		materials: make(map[string]versionItem),
+	}
+
+	materialLineages := make(map[string]string)
+	var materialIDs []string
+
+	for _, chapter := range chapters {
+		chapterLineage := lineageOf(chapter.ID, chapter.LineageID)
+		content.chapters[chapterLineage] = versionItem{
+			title:    chapter.Title,
+			position: chapter.Position,
+		}
+
+		for _, material := range chapter.Materials {
+			lineage := lineageOf(material.ID, material.LineageID)
+			materialLineages[material.ID] = lineage
+			materialIDs = append(materialIDs, material.ID)
+
+			content.materials[lineage] = versionItem{
+				title:    material.Title,
+				position: material.Position,
+				parent:   chapterLineage,
+				content:  material.Theory.Content + "\x00" + material.Submission.Content + "\x00" + material.Scorm.SHA256,
+			}
+		}
+	}
+
+	var quizzes []model.Quiz
+	if err := repos.DB.Where("material_id IN ?", materialIDs).
+		Preload("Quizes.QuizAnswers").
+		Preload("DrawRules").
+		Find(&quizzes).Error; err != nil {
+		return nil, err
+	}
+
+	for _, quiz := range quizzes {
+		lineage := materialLineages[quiz.MaterialID]
+		item := content.materials[lineage]
+		item.content += "\x00" + quizFingerprint(quiz)
+		content.materials[lineage] = item
+	}
+
+	return content, nil
+}
+
+// quizFingerprint hashes the settings, questions and answers of a quiz, ignoring their IDs.
+func quizFingerprint(quiz model.Quiz) string {
+	hash := sha256.New()
+
+	fmt.Fprintf(hash, "%s|%s|%d|%s|%d|%v|%v|%s|%s|%v|%v\n",
+		quiz.Title, quiz.Description, quiz.MaxAttempts, quiz.ScoringPolicy, quiz.TimeLimit,
+		quiz.OpenAt, quiz.CloseAt, quiz.LateSubmission, quiz.ReviewPolicy, quiz.ShuffleQuestions, quiz.ShuffleAnswers)
+
+	questions := append([]model.Quizes(nil), quiz.Quizes...)
+	sort.SliceStable(questions, func(i, j int) bool { return questions[i].CreatedAt.Before(questions[j].CreatedAt) })
+
+	for _, question := range questions {
+		fmt.Fprintf(hash, "Q|%s|%s|%s|%d|%s|%s\n", question.Quiz, question.Type, question.ImgURL, question.Points, question.Topic, question.Difficulty)
+
+		answers := append([]model.QuizAnswer(nil), question.QuizAnswers...)
+		sort.SliceStable(answers, func(i, j int) bool { return answers[i].CreatedAt.Before(answers[j].CreatedAt) })
+
+		for _, answer := range answers {
+			fmt.Fprintf(hash, "A|%s|%v|%v|%d\n", answer.Answer, answer.IsCorrect, answer.Tolerance, answer.Position)
+		}
+	}
+
+	rules := append([]model.QuizDrawRule(nil), quiz.DrawRules...)
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].CreatedAt.Before(rules[j].CreatedAt) })
+
+	for _, rule := range rules {
+		fmt.Fprintf(hash, "R|%s|%s|%s|%d\n", rule.QuestionBankID, rule.Topic, rule.Difficulty, rule.Count)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// diffVersionContent compares the items of two versions by LineageID, sorted by position.
+func diffVersionContent(resource CourseResource, before, after map[string]versionItem) []CourseChange {
+	var changes []CourseChange
+
+	for lineage, item := range after {
+		previous, ok := before[lineage]
+		if !ok {
+			changes = append(changes, CourseChange{Resource: resource, LineageID: lineage, Title: item.title, Change: CHANGE_ADDED, position: item.position})
+			continue
+		}
+
+		var fields []string
+		if previous.title != item.title {
+			fields = append(fields, "title")
+		}
+		if previous.position != item.position {
+			fields = append(fields, "position")
+		}
+		if previous.parent != item.parent {
+			fields = append(fields, "chapter")
+		}
+		if previous.content != item.content {
+			fields = append(fields, "content")
+		}
+
+		if len(fields) > 0 {
+			changes = append(changes, CourseChange{Resource: resource, LineageID: lineage, Title: item.title, Change: CHANGE_CHANGED, Fields: fields, position: item.position})
+		}
+	}
+
+	for lineage, item := range before {
+		if _, ok := after[lineage]; !ok {
+			changes = append(changes, CourseChange{Resource: resource, LineageID: lineage, Title: item.title, Change: CHANGE_REMOVED, position: item.position})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].position != changes[j].position {
+			return changes[i].position < changes[j].position
+		}
+		return changes[i].LineageID < changes[j].LineageID
+	})
+
+	return changes
+}
@@ -1,12 +1,20 @@
 package repository
 
-import "github.com/cvzamannow/E-Learning-API/model"
+import (
+	"context"
+
+	"github.com/cvzamannow/E-Learning-API/model"
+)
 
 type GradesRepository interface {
+	// WithContext returns the repository running its queries with ctx, the queries are
+	// limited to the school of the Tenant in ctx.
+	WithContext(ctx context.Context) GradesRepository
 
 	// Student
 	GetGradesStudent(codd map[string]interface{}, class, schoolYear, schoolID string) (*[]model.SubmissionStudent, error)
-	GetQuizGradesStudent(codd map[string]interface{}) (*[]model.QuizAnswerStudent, error)
+	GetQuizGradesStudent(codd map[string]interface{}) (*[]model.QuizAttempt, error)
+	GetScormGradesStudent(codd map[string]interface{}) (*[]model.ScormRegistration, error)
 
 	// Teacher
 	GetGradesTeacher(schoolYear, class, schoolID string) (*[]model.SubmissionStudent, error)
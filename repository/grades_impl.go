@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
 	"github.com/cvzamannow/E-Learning-API/model"
 	"gorm.io/gorm"
 )
@@ -15,6 +18,13 @@ func NewGradesRepository(db *gorm.DB) GradesRepository {
 	}
 }
 
+// WithContext implements GradesRepository.
+func (repos *gradesImpl) WithContext(ctx context.Context) GradesRepository {
+	return &gradesImpl{
+		DB: repos.DB.WithContext(ctx),
+	}
+}
+
 // GetGradesStudent implements GradesRepository.
 func (repos *gradesImpl) GetGradesStudent(codd map[string]interface{}, class, schoolYear, schoolID string) (*[]model.SubmissionStudent, error) {
 
@@ -47,26 +57,54 @@ func (repos *gradesImpl) GetGradesStudent(codd map[string]interface{}, class, sc
 
 }
 
-func (repos *gradesImpl) GetQuizGradesStudent(codd map[string]interface{}) (*[]model.QuizAnswerStudent, error) {
-	var quizAnswerStudent []model.QuizAnswerStudent
+func (repos *gradesImpl) GetQuizGradesStudent(codd map[string]interface{}) (*[]model.QuizAttempt, error) {
+	var quizAttempts []model.QuizAttempt
 
-	// Subquery untuk mencari entri terbaru
-	subquery := repos.DB.Model(&model.QuizAnswerStudent{}).
-		Select("MAX(created_at)").
-		Where(codd)
-
-	// Query utama untuk mendapatkan data berdasarkan subquery
-	if err := repos.DB.Where("created_at = (?)", subquery).
-		Preload("QuizAnswer").
+	if err := repos.DB.Where(codd).
+		Where("finished_at IS NOT NULL").
 		Preload("Quiz").
 		Preload("Quiz.Material").
 		Preload("ActiveStudent").
-		Find(&quizAnswerStudent).
+		Order("created_at DESC").
+		Find(&quizAttempts).
+		Error; err != nil {
+		return nil, err
+	}
+
+	// Kelompokkan attempt per quiz, lalu ambil nilai sesuai scoring policy quiz nya
+	var quizIDs []string
+	attemptsByQuiz := make(map[string][]model.QuizAttempt)
+	for _, attempt := range quizAttempts {
+		if _, ok := attemptsByQuiz[attempt.QuizID]; !ok {
+			quizIDs = append(quizIDs, attempt.QuizID)
+		}
+		attemptsByQuiz[attempt.QuizID] = append(attemptsByQuiz[attempt.QuizID], attempt)
+	}
+
+	result := []model.QuizAttempt{}
+	for _, quizID := range quizIDs {
+		attempts := attemptsByQuiz[quizID]
+		result = append(result, *helper.RecordedQuizAttempt(attempts[0].Quiz.ScoringPolicy, attempts))
+	}
+
+	return &result, nil
+}
+
+// GetScormGradesStudent returns the SCORM registrations with a score reported by the SCO.
+func (repos *gradesImpl) GetScormGradesStudent(codd map[string]interface{}) (*[]model.ScormRegistration, error) {
+	var registrations []model.ScormRegistration
+
+	if err := repos.DB.Where(codd).
+		Where("score_raw IS NOT NULL").
+		Preload("Material").
+		Preload("Course").
+		Order("updated_at DESC").
+		Find(&registrations).
 		Error; err != nil {
 		return nil, err
 	}
 
-	return &quizAnswerStudent, nil
+	return &registrations, nil
 }
 
 // GetGradesTeacher implements GradesRepository.
@@ -1,9 +1,18 @@
 package repository
 
-import "github.com/cvzamannow/E-Learning-API/model"
+import (
+	"context"
+
+	"github.com/cvzamannow/E-Learning-API/entity"
+	"github.com/cvzamannow/E-Learning-API/model"
+)
 
 // QuizRepository represents the interface for interacting with quiz-related data in the repository.
 type QuizRepository interface {
+	// WithContext returns the repository running its queries with ctx, the queries are
+	// limited to the school of the Tenant in ctx.
+	WithContext(ctx context.Context) QuizRepository
+
 	// CRUD Quiz
 
 	// CreateQuiz creates a new quiz.
@@ -16,10 +25,14 @@ type QuizRepository interface {
 	UpdateQuiz(codd map[string]interface{}, request model.Quiz) (*model.Quiz, error)
 	// GetQuiz by submaterial id
 	GetQuizByMaterialId(id string) (*model.Quiz, error)
+	// UpdateQuizSettings updates the attempt policy and the time settings of a quiz.
+	UpdateQuizSettings(id string, settings model.Quiz) error
+	// ImportQuiz creates a quiz with its material, questions and answers in one transaction.
+	ImportQuiz(request model.Quiz) (*model.Quiz, error)
 
 	// CRUD Quizes
 
-	// CreateQuizes creates multiple quizes.
+	// CreateQuizes creates a question of a quiz, or of a question bank when QuestionBankID is set.
 	CreateQuizes(request model.Quizes) (*model.Quizes, error)
 	// FindQuizes finds multiple quizes based on provided conditions.
 	FindQuizes(cond map[string]interface{}) (*model.Quizes, error)
@@ -49,4 +62,60 @@ type QuizRepository interface {
 	CreateQuizAnswerStudent(request model.QuizAnswerStudent) (*model.QuizAnswerStudent, error)
 	FindQuizAnswerStudent(codd map[string]interface{}) (*model.QuizAnswerStudent, error)
 	GetQuizAnswerStudentByIdQuiz(id string) (*[]model.QuizAnswerStudent, error)
+
+	// Quiz Attempt
+
+	// StartQuizAttempt creates the server side start record of an attempt, or returns the attempt in progress.
+	StartQuizAttempt(request model.QuizAttempt) (*model.QuizAttempt, error)
+	// SubmitQuizAttempt grades the responses of an attempt and stores the attempt with its responses in one transaction.
+	// It returns ErrQuizAttemptsExhausted when the student has used every attempt of the quiz,
+	// and ErrQuizDeadlinePassed together with the finished attempt when a late submission is rejected.
+	SubmitQuizAttempt(request model.QuizAttempt) (*model.QuizAttempt, error)
+	// FindQuizAttempt finds an attempt with its responses based on provided conditions.
+	FindQuizAttempt(codd map[string]interface{}) (*model.QuizAttempt, error)
+	// FindQuizAttempts finds all attempts based on provided conditions, newest first.
+	FindQuizAttempts(codd map[string]interface{}) (*[]model.QuizAttempt, error)
+	// FindLatestQuizAttempt finds the most recent attempt of a student for a quiz.
+	FindLatestQuizAttempt(quizID string, activeStudentID string) (*model.QuizAttempt, error)
+	// FindBestQuizAttempt finds the highest graded attempt of a student for a quiz.
+	FindBestQuizAttempt(quizID string, activeStudentID string) (*model.QuizAttempt, error)
+	// FindRecordedQuizAttempt finds the result of a student for a quiz according to the quiz scoring policy.
+	FindRecordedQuizAttempt(quizID string, activeStudentID string) (*model.QuizAttempt, error)
+	// RegradeQuiz applies the answer key corrections to the questions of a quiz, also of a published
+	// version, grades every finished attempt again and returns the audits of the attempts whose
+	// result changed, with reason.
+	RegradeQuiz(quizID string, corrections []model.Quizes, reason string, userID string) (*[]model.QuizGradeAudit, error)
+	// OverrideQuizAttemptScore sets the score of a finished attempt by hand, a nil score removes the override.
+	OverrideQuizAttemptScore(attemptID string, score *float64, reason string, userID string) (*model.QuizAttempt, error)
+	// GetQuizGradeAudits finds the grade audits based on provided conditions, newest first.
+	GetQuizGradeAudits(codd map[string]interface{}) (*[]model.QuizGradeAudit, error)
+	// GetQuizAttemptQuestions finds the questions of an attempt in the order they were given, with their options in the order they were shown.
+	GetQuizAttemptQuestions(attemptID string) (*[]model.Quizes, error)
+
+	// Question Bank
+
+	// CreateQuestionBank creates a new question bank.
+	CreateQuestionBank(request model.QuestionBank) (*model.QuestionBank, error)
+	// FindQuestionBank finds a question bank based on provided conditions.
+	FindQuestionBank(codd map[string]interface{}) (*model.QuestionBank, error)
+	// GetQuestionBanks finds question banks based on provided conditions.
+	GetQuestionBanks(codd map[string]interface{}) (*[]model.QuestionBank, error)
+	// UpdateQuestionBank updates the title and description of a question bank.
+	UpdateQuestionBank(id string, request model.QuestionBank) (*model.QuestionBank, error)
+	// DeleteQuestionBank deletes a question bank with its questions and the draw rules using it.
+	DeleteQuestionBank(id string) error
+	// FindBankQuestions finds the questions of question banks based on provided conditions.
+	FindBankQuestions(codd map[string]interface{}) (*[]model.Quizes, error)
+	// CountBankQuestions counts the questions of question banks based on provided conditions.
+	CountBankQuestions(codd map[string]interface{}) (int64, error)
+	// ReplaceQuizDrawRules replaces every draw rule of a quiz.
+	ReplaceQuizDrawRules(quizID string, rules []model.QuizDrawRule) error
+	// GetQuizDrawRules finds the draw rules of a quiz.
+	GetQuizDrawRules(quizID string) (*[]model.QuizDrawRule, error)
+
+	// Item Analysis
+
+	// GetQuizItemAnalysis aggregates the finished attempts of a quiz into its item analysis.
+	// codd filters the attempts by the columns of their active student, such as class and school_year.
+	GetQuizItemAnalysis(quizID string, codd map[string]interface{}) (*entity.QuizItemAnalysis, error)
 }
@@ -2,11 +2,30 @@ package repository
 
 // Import necessary packages and libraries
 import (
+	"context"
 	"errors"
+	"math"
+	"time"
 
+	"github.com/cvzamannow/E-Learning-API/entity"
+	"github.com/cvzamannow/E-Learning-API/helper"
 	"github.com/cvzamannow/E-Learning-API/model"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrQuizAttemptsExhausted is returned when a student submits a quiz more than its MaxAttempts.
+	ErrQuizAttemptsExhausted = errors.New("[DATABASE] Quiz attempts exhausted")
+	// ErrQuizNotOpen is returned when a quiz is started before its OpenAt.
+	ErrQuizNotOpen = errors.New("[DATABASE] Quiz is not open yet")
+	// ErrQuizClosed is returned when a quiz is started after its CloseAt.
+	ErrQuizClosed = errors.New("[DATABASE] Quiz is closed")
+	// ErrQuizNotStarted is returned when a timed quiz is submitted without a started attempt.
+	ErrQuizNotStarted = errors.New("[DATABASE] Quiz attempt has not been started")
+	// ErrQuizDeadlinePassed is returned when a late submission is rejected by the quiz.
+	ErrQuizDeadlinePassed = errors.New("[DATABASE] Quiz attempt deadline has passed")
 )
 
 // quizImpl implements the QuizRepository interface.
@@ -21,15 +40,32 @@ func NewQuizRepository(db *gorm.DB) QuizRepository {
 	}
 }
 
+// WithContext implements QuizRepository.
+func (repos *quizImpl) WithContext(ctx context.Context) QuizRepository {
+	return &quizImpl{
+		DB: repos.DB.WithContext(ctx),
+	}
+}
+
 // CreateQuiz creates a new quiz.
 func (repos *quizImpl) CreateQuiz(request model.Quiz) (*model.Quiz, error) {
 	// Check if Chapter exists
 	var subChapter model.Chapter
 	if err := repos.DB.Model(&subChapter).Where("id = ?", request.ChapterID).First(&subChapter).Error; err != nil {
-		logrus.Warningln("[DATABASE] SubMaterial not found", err.Error)
+		logrus.Warningln("[DATABASE] SubMaterial not found", err.Error())
 		return nil, errors.New("[DATABASE] SubMaterial not found")
 	}
 
+	position, err := nextMaterialPosition(repos.DB, subChapter.ID)
+	if err != nil {
+		logrus.Warningln("[DATABASE] Error creating Quiz")
+		return nil, errors.New("[DATABASE] Error creating Quiz")
+	}
+	request.Material.CourseID = subChapter.CourseID
+	request.Material.Version = subChapter.Version
+	request.Material.LineageID = request.Material.ID
+	request.Material.Position = position
+
 	// Create Quiz
 	if err := repos.DB.Create(&request).Error; err != nil {
 		logrus.Warningln("[DATABASE] Error creating Quiz")
@@ -40,6 +76,59 @@ func (repos *quizImpl) CreateQuiz(request model.Quiz) (*model.Quiz, error) {
 
 }
 
+// ImportQuiz creates a quiz with its questions and answers. Questions and answers are
+// created one by one so they keep the order they were imported in.
+func (repos *quizImpl) ImportQuiz(request model.Quiz) (*model.Quiz, error) {
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		var chapter model.Chapter
+		if err := tx.Where("id = ?", request.ChapterID).First(&chapter).Error; err != nil {
+			logrus.Warningln("[DATABASE] SubMaterial not found", err.Error())
+			return errors.New("[DATABASE] SubMaterial not found")
+		}
+
+		position, err := nextMaterialPosition(tx, chapter.ID)
+		if err != nil {
+			logrus.Warningln("[DATABASE] Error creating Quiz")
+			return errors.New("[DATABASE] Error creating Quiz")
+		}
+		request.Material.CourseID = chapter.CourseID
+		request.Material.Version = chapter.Version
+		request.Material.LineageID = request.Material.ID
+		request.Material.Position = position
+
+		if err := tx.Omit("Quizes", "DrawRules").Create(&request).Error; err != nil {
+			logrus.Warningln("[DATABASE] Error creating Quiz")
+			return errors.New("[DATABASE] Error creating Quiz")
+		}
+
+		for i := range request.Quizes {
+			question := &request.Quizes[i]
+			question.QuizID = request.ID
+
+			if err := tx.Omit("QuizAnswers").Create(question).Error; err != nil {
+				logrus.Warningln("[DATABASE] Error creating Quizes")
+				return errors.New("[DATABASE] Error creating Quizes")
+			}
+
+			for j := range question.QuizAnswers {
+				question.QuizAnswers[j].QuizesID = question.ID
+
+				if err := tx.Create(&question.QuizAnswers[j]).Error; err != nil {
+					logrus.Warningln("[DATABASE] Error creating QuizAnswer")
+					return errors.New("[DATABASE] Error creating QuizAnswer")
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
 func (repos *quizImpl) UpdateQuiz(codd map[string]interface{}, request model.Quiz) (*model.Quiz, error) {
 	// Update Quiz
 	if err := repos.DB.Model(&request).Where(codd).Updates(&request).Error; err != nil {
@@ -50,10 +139,31 @@ func (repos *quizImpl) UpdateQuiz(codd map[string]interface{}, request model.Qui
 	return &request, nil
 }
 
+// UpdateQuizSettings updates the attempt and time settings of a quiz.
+// A map is used so zero values such as unlimited attempts or an empty window are saved too.
+func (repos *quizImpl) UpdateQuizSettings(id string, settings model.Quiz) error {
+	if err := repos.DB.Model(&model.Quiz{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"max_attempts":      settings.MaxAttempts,
+		"scoring_policy":    settings.ScoringPolicy,
+		"time_limit":        settings.TimeLimit,
+		"open_at":           settings.OpenAt,
+		"close_at":          settings.CloseAt,
+		"late_submission":   settings.LateSubmission,
+		"review_policy":     settings.ReviewPolicy,
+		"shuffle_questions": settings.ShuffleQuestions,
+		"shuffle_answers":   settings.ShuffleAnswers,
+	}).Error; err != nil {
+		logrus.Warningln("[DATABASE] Error updating Quiz settings")
+		return errors.New("[DATABASE] Error updating Quiz settings")
+	}
+
+	return nil
+}
+
 func (repos *quizImpl) GetQuizByMaterialId(id string) (*model.Quiz, error) {
 	var quiz model.Quiz
 	if err := repos.DB.Where("material_id = ?", id).First(&quiz).Error; err != nil {
-		logrus.Warningln("[DATABASE] Quizes not found", err.Error)
+		logrus.Warningln("[DATABASE] Quizes not found", err.Error())
 		return nil, errors.New("[DATABASE] Quizes not found")
 	}
 
@@ -106,13 +216,21 @@ func (repos *quizImpl) DeleteQuiz(codd map[string]interface{}) error {
 }
 
 // CRUD Quizes
-// CreateQuizes creates multiple quizes.
+// CreateQuizes creates a question of a quiz, or of a question bank when QuestionBankID is set.
 func (repos *quizImpl) CreateQuizes(request model.Quizes) (*model.Quizes, error) {
 
-	var quiz model.Quiz
-	if err := repos.DB.Model(&model.Quiz{}).Where("id = ?", request.QuizID).First(&quiz).Error; err != nil {
-		logrus.Warningln("[DATABASE] Quiz not found")
-		return nil, errors.New("[DATABASE] Quiz not found")
+	if request.QuestionBankID != nil {
+		var bank model.QuestionBank
+		if err := repos.DB.Where("id = ?", *request.QuestionBankID).First(&bank).Error; err != nil {
+			logrus.Warningln("[DATABASE] QuestionBank not found")
+			return nil, errors.New("[DATABASE] QuestionBank not found")
+		}
+	} else {
+		var quiz model.Quiz
+		if err := repos.DB.Model(&model.Quiz{}).Where("id = ?", request.QuizID).First(&quiz).Error; err != nil {
+			logrus.Warningln("[DATABASE] Quiz not found")
+			return nil, errors.New("[DATABASE] Quiz not found")
+		}
 	}
 
 	if err := repos.DB.Create(&request).Error; err != nil {
@@ -230,6 +348,8 @@ func (repos *quizImpl) UpdateQuizAnswer(codd map[string]interface{}, request mod
 	}
 	quizAnswer.Answer = request.Answer
 	quizAnswer.IsCorrect = request.IsCorrect
+	quizAnswer.Tolerance = request.Tolerance
+	quizAnswer.Position = request.Position
 	if err := repos.DB.Save(&quizAnswer).Error; err != nil {
 		return nil, err
 	}
@@ -291,3 +411,1017 @@ func (repos *quizImpl) GetQuizAnswerStudentByIdQuiz(id string) (*[]model.QuizAns
 
 	return &quizAnswerStudent, nil
 }
+
+// StartQuizAttempt issues the server side start record of an attempt. An attempt
+// still in progress is returned as is, an expired one is graded with no answers
+// before a new attempt is started.
+func (repos *quizImpl) StartQuizAttempt(request model.QuizAttempt) (*model.QuizAttempt, error) {
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		quiz, err := repos.lockQuiz(tx, request.QuizID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		current, err := repos.findQuizAttemptInProgress(tx, quiz.ID, request.ActiveStudentID)
+		if err != nil {
+			return err
+		}
+
+		if current != nil {
+			if !helper.IsLateSubmission(current.DeadlineAt, now) {
+				request = *current
+				return nil
+			}
+
+			if err := repos.expireQuizAttempt(tx, current); err != nil {
+				return err
+			}
+		}
+
+		if err := repos.checkQuizAvailability(tx, quiz, request.ActiveStudentID, now); err != nil {
+			return err
+		}
+
+		request.StartedAt = now
+		request.DeadlineAt = helper.QuizAttemptDeadline(*quiz, now)
+
+		if err := tx.Create(&request).Error; err != nil {
+			logrus.Warningln("[DATABASE] Error creating QuizAttempt")
+			return errors.New("[DATABASE] Error creating QuizAttempt")
+		}
+
+		questions, err := repos.drawQuizAttemptQuestions(tx, quiz, request.ID)
+		if err != nil {
+			return err
+		}
+
+		request.Questions = questions
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// SubmitQuizAttempt grades every response against the answer key and stores
+// the attempt together with its responses. Only the first response of each
+// question is counted, responses to questions outside the quiz are rejected.
+// The quiz row is locked so concurrent submissions can't exceed MaxAttempts.
+//
+// The attempt in progress is finished when there is one, otherwise a new attempt
+// is created unless the quiz has a time limit or is outside its open window.
+// Lateness is decided with the server clock, a rejected late submission still
+// finishes the attempt with no answers.
+func (repos *quizImpl) SubmitQuizAttempt(request model.QuizAttempt) (*model.QuizAttempt, error) {
+	var rejected bool
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		quiz, err := repos.lockQuiz(tx, request.QuizID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		attempt, err := repos.findQuizAttemptInProgress(tx, quiz.ID, request.ActiveStudentID)
+		if err != nil {
+			return err
+		}
+
+		if attempt == nil {
+			// Quiz yang mengambil soal dari bank soal harus dimulai agar student mendapat soalnya
+			var drawRules int64
+			if err := tx.Model(&model.QuizDrawRule{}).Where("quiz_id = ?", quiz.ID).Count(&drawRules).Error; err != nil {
+				return err
+			}
+
+			if quiz.TimeLimit > 0 || drawRules > 0 {
+				return ErrQuizNotStarted
+			}
+
+			// Quiz yang sudah ditutup hanya menerima attempt yang sedang berjalan
+			if err := repos.checkQuizAvailability(tx, quiz, request.ActiveStudentID, now); err != nil {
+				return err
+			}
+
+			attempt = &model.QuizAttempt{
+				ID:              request.ID,
+				QuizID:          quiz.ID,
+				ActiveStudentID: request.ActiveStudentID,
+				StartedAt:       now,
+				DeadlineAt:      helper.QuizAttemptDeadline(*quiz, now),
+			}
+
+			if err := tx.Create(attempt).Error; err != nil {
+				logrus.Warningln("[DATABASE] Error creating QuizAttempt")
+				return errors.New("[DATABASE] Error creating QuizAttempt")
+			}
+		}
+
+		questions, err := repos.attemptQuestions(tx, quiz, attempt.ID)
+		if err != nil {
+			return err
+		}
+
+		attempt.IsLate = helper.IsLateSubmission(attempt.DeadlineAt, now)
+
+		var responses []model.QuizAnswerStudent
+		if attempt.IsLate && quiz.LateSubmission != model.LATE_GRADE {
+			rejected = true
+		} else {
+			responses = request.Responses
+		}
+
+		graded, score, err := repos.gradeQuizResponses(quiz, attempt, questions, responses)
+		if err != nil {
+			return err
+		}
+
+		// Nilai dihitung dari total poin seluruh soal attempt, soal yang tidak dijawab bernilai 0
+		attempt.Score = score
+		attempt.TotalQuestions = len(questions)
+		attempt.TotalPoints = quizTotalPoints(questions)
+		attempt.Grades = helper.QuizGrades(score, attempt.TotalPoints)
+		attempt.FinishedAt = &now
+
+		if err := tx.Omit("Responses", "Questions").Save(attempt).Error; err != nil {
+			logrus.Warningln("[DATABASE] Error saving QuizAttempt")
+			return errors.New("[DATABASE] Error saving QuizAttempt")
+		}
+
+		if len(graded) > 0 {
+			if err := tx.Create(&graded).Error; err != nil {
+				logrus.Warningln("[DATABASE] Error creating QuizAnswerStudent")
+				return errors.New("[DATABASE] Error creating QuizAnswerStudent")
+			}
+		}
+
+		attempt.Responses = graded
+		request = *attempt
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rejected {
+		return &request, ErrQuizDeadlinePassed
+	}
+
+	return &request, nil
+}
+
+// lockQuiz finds a quiz and locks its row until the transaction ends.
+func (repos *quizImpl) lockQuiz(tx *gorm.DB, id string) (*model.Quiz, error) {
+	var quiz model.Quiz
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&quiz).Error; err != nil {
+		logrus.Warningln("[DATABASE] Quiz not found")
+		return nil, errors.New("[DATABASE] Quiz not found")
+	}
+
+	return &quiz, nil
+}
+
+// findQuizAttemptInProgress finds the unfinished attempt of a student, nil when there is none.
+func (repos *quizImpl) findQuizAttemptInProgress(tx *gorm.DB, quizID string, activeStudentID string) (*model.QuizAttempt, error) {
+	var attempts []model.QuizAttempt
+	if err := tx.Where("quiz_id = ?", quizID).
+		Where("active_student_id = ?", activeStudentID).
+		Where("finished_at IS NULL").
+		Order("created_at DESC").
+		Limit(1).
+		Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+
+	if len(attempts) == 0 {
+		return nil, nil
+	}
+
+	return &attempts[0], nil
+}
+
+// expireQuizAttempt finishes an attempt whose deadline has passed without any answer.
+func (repos *quizImpl) expireQuizAttempt(tx *gorm.DB, attempt *model.QuizAttempt) error {
+	finishedAt := time.Now()
+	if attempt.DeadlineAt != nil {
+		finishedAt = *attempt.DeadlineAt
+	}
+
+	questions, err := repos.findQuizAttemptQuestions(tx, attempt.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(attempt).Updates(map[string]interface{}{
+		"score":           0,
+		"grades":          0,
+		"total_questions": len(questions),
+		"total_points":    quizTotalPoints(questions),
+		"is_late":         true,
+		"finished_at":     finishedAt,
+	}).Error
+}
+
+// checkQuizAvailability checks the open window and the attempt limit before starting an attempt.
+func (repos *quizImpl) checkQuizAvailability(tx *gorm.DB, quiz *model.Quiz, activeStudentID string, now time.Time) error {
+	if quiz.OpenAt != nil && now.Before(*quiz.OpenAt) {
+		return ErrQuizNotOpen
+	}
+
+	if quiz.CloseAt != nil && now.After(*quiz.CloseAt) {
+		return ErrQuizClosed
+	}
+
+	return repos.checkQuizAttempts(tx, quiz, activeStudentID)
+}
+
+// checkQuizAttempts returns ErrQuizAttemptsExhausted when every attempt of the quiz has been used.
+func (repos *quizImpl) checkQuizAttempts(tx *gorm.DB, quiz *model.Quiz, activeStudentID string) error {
+	if quiz.MaxAttempts == 0 {
+		return nil
+	}
+
+	var used int64
+	if err := tx.Model(&model.QuizAttempt{}).
+		Where("quiz_id = ?", quiz.ID).
+		Where("active_student_id = ?", activeStudentID).
+		Count(&used).Error; err != nil {
+		return err
+	}
+
+	if used >= int64(quiz.MaxAttempts) {
+		return ErrQuizAttemptsExhausted
+	}
+
+	return nil
+}
+
+// gradeQuizResponses checks every response against the answer key of its question
+// and returns the responses to store with the points earned. Only questions given
+// in the attempt can be answered.
+func (repos *quizImpl) gradeQuizResponses(quiz *model.Quiz, attempt *model.QuizAttempt, questions []model.Quizes, responses []model.QuizAnswerStudent) ([]model.QuizAnswerStudent, float64, error) {
+	given := make(map[string]model.Quizes)
+	for _, question := range questions {
+		given[question.ID] = question
+	}
+
+	answered := make(map[string]bool)
+	var graded []model.QuizAnswerStudent
+	var score float64
+
+	for _, response := range responses {
+		if answered[response.QuizesID] {
+			continue
+		}
+
+		question, ok := given[response.QuizesID]
+		if !ok {
+			logrus.Warningln("[DATABASE] Quizes not found in QuizAttempt")
+			return nil, 0, errors.New("[DATABASE] Quizes not found in QuizAttempt")
+		}
+
+		selected := quizResponseSelection(response)
+
+		// Pilihan jawaban harus milik soal yang dijawab
+		options := make(map[string]bool)
+		for _, answer := range question.QuizAnswers {
+			options[answer.ID] = true
+		}
+		for _, answerID := range selected {
+			if !options[answerID] {
+				logrus.Warningln("[DATABASE] QuizAnswer not found in Quiz")
+				return nil, 0, errors.New("[DATABASE] QuizAnswer not found in Quiz")
+			}
+		}
+
+		id, err := helper.GenerateNanoId()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		credit := helper.GradeQuizQuestion(question, selected, response.AnswerText)
+
+		result := model.QuizAnswerStudent{
+			ID:              id,
+			QuizID:          quiz.ID,
+			QuizAttemptID:   attempt.ID,
+			QuizesID:        question.ID,
+			ActiveStudentID: attempt.ActiveStudentID,
+			Points:          credit * float64(question.Points),
+			IsCorrect:       credit == 1,
+		}
+
+		switch question.Type {
+		case model.MULTIPLE_CHOICE, model.ORDERING:
+			result.SelectedAnswerIDs = selected
+		case model.SHORT_ANSWER, model.NUMERIC:
+			result.AnswerText = response.AnswerText
+		default:
+			if len(selected) > 0 {
+				result.QuizAnswerID = &selected[0]
+			}
+		}
+
+		answered[question.ID] = true
+		score += result.Points
+		graded = append(graded, result)
+	}
+
+	return graded, score, nil
+}
+
+// quizResponseSelection returns the options picked in a response, the option of a
+// choice question first.
+func quizResponseSelection(response model.QuizAnswerStudent) []string {
+	selected := response.SelectedAnswerIDs
+	if response.QuizAnswerID != nil && *response.QuizAnswerID != "" {
+		selected = append([]string{*response.QuizAnswerID}, selected...)
+	}
+
+	return selected
+}
+
+// quizTotalPoints sums the points of the questions of an attempt.
+func quizTotalPoints(questions []model.Quizes) int {
+	var total int
+	for _, question := range questions {
+		total += question.Points
+	}
+
+	return total
+}
+
+// attemptQuestions returns the questions given in an attempt. Attempts started before
+// questions were recorded get their questions drawn now.
+func (repos *quizImpl) attemptQuestions(tx *gorm.DB, quiz *model.Quiz, attemptID string) ([]model.Quizes, error) {
+	questions, err := repos.findQuizAttemptQuestions(tx, attemptID)
+	if err != nil || len(questions) > 0 {
+		return questions, err
+	}
+
+	if _, err := repos.drawQuizAttemptQuestions(tx, quiz, attemptID); err != nil {
+		return nil, err
+	}
+
+	return repos.findQuizAttemptQuestions(tx, attemptID)
+}
+
+// drawQuizAttemptQuestions records the questions of a new attempt: every question of
+// the quiz and the questions drawn at random by each draw rule, laid out by
+// helper.QuizAttemptQuestions. A bank question is drawn at most once per attempt.
+func (repos *quizImpl) drawQuizAttemptQuestions(tx *gorm.DB, quiz *model.Quiz, attemptID string) ([]model.QuizAttemptQuestion, error) {
+	var questions []model.Quizes
+	if err := tx.Preload("QuizAnswers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Where("quiz_id = ?", quiz.ID).Order("created_at ASC").Find(&questions).Error; err != nil {
+		return nil, err
+	}
+
+	var rules []model.QuizDrawRule
+	if err := tx.Where("quiz_id = ?", quiz.ID).Order("created_at ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	drawn := []string{""}
+	for _, rule := range rules {
+		var picked []model.Quizes
+		query := tx.Preload("QuizAnswers", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).Where("question_bank_id = ?", rule.QuestionBankID).Where("id NOT IN ?", drawn)
+
+		if rule.Topic != "" {
+			query = query.Where("topic = ?", rule.Topic)
+		}
+		if rule.Difficulty != "" {
+			query = query.Where("difficulty = ?", rule.Difficulty)
+		}
+
+		if err := query.Order("RANDOM()").Limit(rule.Count).Find(&picked).Error; err != nil {
+			return nil, err
+		}
+
+		if len(picked) < rule.Count {
+			logrus.Warningln("[DATABASE] QuestionBank has fewer questions than the draw rule count", rule.ID)
+		}
+
+		for _, question := range picked {
+			drawn = append(drawn, question.ID)
+		}
+		questions = append(questions, picked...)
+	}
+
+	result := helper.QuizAttemptQuestions(*quiz, questions)
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	for i := range result {
+		id, err := helper.GenerateNanoId()
+		if err != nil {
+			return nil, err
+		}
+
+		result[i].ID = id
+		result[i].QuizAttemptID = attemptID
+	}
+
+	if err := tx.Omit("Question").Create(&result).Error; err != nil {
+		logrus.Warningln("[DATABASE] Error creating QuizAttemptQuestion")
+		return nil, errors.New("[DATABASE] Error creating QuizAttemptQuestion")
+	}
+
+	return result, nil
+}
+
+// findQuizAttemptQuestions loads the questions of an attempt in the order they were given,
+// with their options in the order they were shown. Questions deleted after the attempt
+// are still included so the attempt can be reviewed.
+func (repos *quizImpl) findQuizAttemptQuestions(tx *gorm.DB, attemptID string) ([]model.Quizes, error) {
+	var recorded []model.QuizAttemptQuestion
+	if err := tx.Preload("Question", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("Question.QuizAnswers").
+		Where("quiz_attempt_id = ?", attemptID).
+		Order("position ASC").
+		Find(&recorded).Error; err != nil {
+		return nil, err
+	}
+
+	var questions []model.Quizes
+	for _, item := range recorded {
+		questions = append(questions, helper.ArrangeQuizAnswers(item.Question, item.AnswerOrder))
+	}
+
+	return questions, nil
+}
+
+// GetQuizAttemptQuestions loads the questions of an attempt as they were given to the student.
+func (repos *quizImpl) GetQuizAttemptQuestions(attemptID string) (*[]model.Quizes, error) {
+	questions, err := repos.findQuizAttemptQuestions(repos.DB, attemptID)
+	if err != nil {
+		logrus.Warningln("[DATABASE] QuizAttemptQuestion not found")
+		return nil, errors.New("[DATABASE] QuizAttemptQuestion not found")
+	}
+
+	return &questions, nil
+}
+
+// ReplaceQuizDrawRules replaces every draw rule of a quiz.
+func (repos *quizImpl) ReplaceQuizDrawRules(quizID string, rules []model.QuizDrawRule) error {
+	return repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("quiz_id = ?", quizID).Delete(&model.QuizDrawRule{}).Error; err != nil {
+			logrus.Warningln("[DATABASE] Error deleting QuizDrawRule")
+			return errors.New("[DATABASE] Error deleting QuizDrawRule")
+		}
+
+		if len(rules) == 0 {
+			return nil
+		}
+
+		if err := tx.Omit("QuestionBank").Create(&rules).Error; err != nil {
+			logrus.Warningln("[DATABASE] Error creating QuizDrawRule")
+			return errors.New("[DATABASE] Error creating QuizDrawRule")
+		}
+
+		return nil
+	})
+}
+
+// GetQuizDrawRules finds the draw rules of a quiz.
+func (repos *quizImpl) GetQuizDrawRules(quizID string) (*[]model.QuizDrawRule, error) {
+	var rules []model.QuizDrawRule
+	if err := repos.DB.Where("quiz_id = ?", quizID).Order("created_at ASC").Find(&rules).Error; err != nil {
+		logrus.Warningln("[DATABASE] QuizDrawRule not found")
+		return nil, errors.New("[DATABASE] QuizDrawRule not found")
+	}
+
+	return &rules, nil
+}
+
+// CRUD QuestionBank
+
+// CreateQuestionBank creates a new question bank.
+func (repos *quizImpl) CreateQuestionBank(request model.QuestionBank) (*model.QuestionBank, error) {
+	if err := repos.DB.Omit("Course", "Questions").Create(&request).Error; err != nil {
+		logrus.Warningln("[DATABASE] Error creating QuestionBank")
+		return nil, errors.New("[DATABASE] Error creating QuestionBank")
+	}
+
+	return &request, nil
+}
+
+// FindQuestionBank finds a question bank.
+func (repos *quizImpl) FindQuestionBank(codd map[string]interface{}) (*model.QuestionBank, error) {
+	var bank model.QuestionBank
+	if err := repos.DB.Where(codd).First(&bank).Error; err != nil {
+		logrus.Warningln("[DATABASE] QuestionBank not found")
+		return nil, errors.New("[DATABASE] QuestionBank not found")
+	}
+
+	return &bank, nil
+}
+
+// GetQuestionBanks finds question banks based on conditions.
+func (repos *quizImpl) GetQuestionBanks(codd map[string]interface{}) (*[]model.QuestionBank, error) {
+	var banks []model.QuestionBank
+	if err := repos.DB.Where(codd).Order("created_at ASC").Find(&banks).Error; err != nil {
+		logrus.Warningln("[DATABASE] QuestionBank not found")
+		return nil, errors.New("[DATABASE] QuestionBank not found")
+	}
+
+	return &banks, nil
+}
+
+// UpdateQuestionBank updates the title and description of a question bank.
+func (repos *quizImpl) UpdateQuestionBank(id string, request model.QuestionBank) (*model.QuestionBank, error) {
+	if err := repos.DB.Model(&model.QuestionBank{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"title":       request.Title,
+		"description": request.Description,
+	}).Error; err != nil {
+		logrus.Warningln("[DATABASE] Error updating QuestionBank")
+		return nil, errors.New("[DATABASE] Error updating QuestionBank")
+	}
+
+	return &request, nil
+}
+
+// DeleteQuestionBank deletes a question bank with its questions and the draw rules using it.
+func (repos *quizImpl) DeleteQuestionBank(id string) error {
+	return repos.DB.Transaction(func(tx *gorm.DB) error {
+		questions := tx.Model(&model.Quizes{}).Select("id").Where("question_bank_id = ?", id)
+
+		if err := tx.Where("quizes_id IN (?)", questions).Delete(&model.QuizAnswer{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("question_bank_id = ?", id).Delete(&model.Quizes{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("question_bank_id = ?", id).Delete(&model.QuizDrawRule{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ?", id).Delete(&model.QuestionBank{}).Error; err != nil {
+			logrus.Warningln("[DATABASE] Error deleting QuestionBank")
+			return errors.New("[DATABASE] Error deleting QuestionBank")
+		}
+
+		return nil
+	})
+}
+
+// FindBankQuestions finds the questions of question banks based on conditions such as topic and difficulty.
+func (repos *quizImpl) FindBankQuestions(codd map[string]interface{}) (*[]model.Quizes, error) {
+	var questions []model.Quizes
+	if err := repos.DB.Preload("QuizAnswers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Where(codd).Where("question_bank_id IS NOT NULL").Order("created_at ASC").Find(&questions).Error; err != nil {
+		logrus.Warningln("[DATABASE] Quizes not found")
+		return nil, errors.New("[DATABASE] Quizes not found")
+	}
+
+	return &questions, nil
+}
+
+// CountBankQuestions counts the questions of question banks based on conditions.
+func (repos *quizImpl) CountBankQuestions(codd map[string]interface{}) (int64, error) {
+	var count int64
+	if err := repos.DB.Model(&model.Quizes{}).Where(codd).Where("question_bank_id IS NOT NULL").Count(&count).Error; err != nil {
+		logrus.Warningln("[DATABASE] Error counting Quizes")
+		return 0, errors.New("[DATABASE] Error counting Quizes")
+	}
+
+	return count, nil
+}
+
+// FindQuizAttempt finds an attempt with its responses based on conditions.
+func (repos *quizImpl) FindQuizAttempt(codd map[string]interface{}) (*model.QuizAttempt, error) {
+	var attempt model.QuizAttempt
+	if err := repos.DB.Preload("Responses").Where(codd).First(&attempt).Error; err != nil {
+		logrus.Warningln("[DATABASE] QuizAttempt not found")
+		return nil, errors.New("[DATABASE] QuizAttempt not found")
+	}
+
+	return &attempt, nil
+}
+
+// FindQuizAttempts finds attempts based on conditions, newest first.
+func (repos *quizImpl) FindQuizAttempts(codd map[string]interface{}) (*[]model.QuizAttempt, error) {
+	var attempts []model.QuizAttempt
+	if err := repos.DB.Where(codd).Order("created_at DESC").Find(&attempts).Error; err != nil {
+		logrus.Warningln("[DATABASE] QuizAttempt not found")
+		return nil, errors.New("[DATABASE] QuizAttempt not found")
+	}
+
+	return &attempts, nil
+}
+
+// FindLatestQuizAttempt finds the most recent finished attempt of a student for a quiz.
+func (repos *quizImpl) FindLatestQuizAttempt(quizID string, activeStudentID string) (*model.QuizAttempt, error) {
+	return repos.findQuizAttempt(quizID, activeStudentID, "created_at DESC")
+}
+
+// FindBestQuizAttempt finds the highest graded finished attempt of a student for a quiz,
+// the earliest one wins when grades are equal.
+func (repos *quizImpl) FindBestQuizAttempt(quizID string, activeStudentID string) (*model.QuizAttempt, error) {
+	return repos.findQuizAttempt(quizID, activeStudentID, "grades DESC, created_at ASC")
+}
+
+// FindRecordedQuizAttempt finds the result of a student for a quiz according to
+// the quiz scoring policy, see helper.RecordedQuizAttempt.
+func (repos *quizImpl) FindRecordedQuizAttempt(quizID string, activeStudentID string) (*model.QuizAttempt, error) {
+	var quiz model.Quiz
+	if err := repos.DB.Where("id = ?", quizID).First(&quiz).Error; err != nil {
+		return nil, err
+	}
+
+	var attempts []model.QuizAttempt
+	if err := repos.DB.Where("quiz_id = ?", quizID).
+		Where("active_student_id = ?", activeStudentID).
+		Where("finished_at IS NOT NULL").
+		Order("created_at DESC").
+		Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+
+	recorded := helper.RecordedQuizAttempt(quiz.ScoringPolicy, attempts)
+	if recorded == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return recorded, nil
+}
+
+func (repos *quizImpl) findQuizAttempt(quizID string, activeStudentID string, order string) (*model.QuizAttempt, error) {
+	var attempt model.QuizAttempt
+	if err := repos.DB.Preload("Responses").
+		Preload("Responses.QuizAnswer").
+		Where("quiz_id = ?", quizID).
+		Where("active_student_id = ?", activeStudentID).
+		Where("finished_at IS NOT NULL").
+		Order(order).
+		First(&attempt).Error; err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// quizAnalysisGroup is the size of the upper and lower groups of the discrimination index.
+const quizAnalysisGroup = 0.27
+
+// GetQuizItemAnalysis computes the item analysis of a quiz in the database. Only the
+// questions recorded in an attempt are analyzed, so drawn bank questions are included
+// and every student is measured against the questions they were given.
+func (repos *quizImpl) GetQuizItemAnalysis(quizID string, codd map[string]interface{}) (*entity.QuizItemAnalysis, error) {
+	attempts := repos.DB.Table("quiz_attempts").
+		Select("quiz_attempts.id, quiz_attempts.active_student_id, quiz_attempts.grades, quiz_attempts.started_at, quiz_attempts.finished_at, "+
+			"ROW_NUMBER() OVER (ORDER BY quiz_attempts.grades DESC, quiz_attempts.id) AS rank, "+
+			"GREATEST(ROUND(COUNT(*) OVER () * ?), 1) AS group_size, "+
+			"COUNT(*) OVER () AS total", quizAnalysisGroup).
+		Joins("JOIN active_students ON active_students.id = quiz_attempts.active_student_id").
+		Where("quiz_attempts.quiz_id = ?", quizID).
+		Where("quiz_attempts.finished_at IS NOT NULL").
+		Where("quiz_attempts.deleted_at IS NULL")
+
+	for column, value := range codd {
+		attempts = attempts.Where(clause.Eq{Column: clause.Column{Table: "active_students", Name: column}, Value: value})
+	}
+
+	var analysis entity.QuizItemAnalysis
+
+	if err := repos.DB.Raw(`WITH attempts AS (?)
+		SELECT COUNT(*) AS attempts,
+			COUNT(DISTINCT active_student_id) AS students,
+			COALESCE(ROUND(AVG(grades), 2), 0) AS average_grades,
+			COALESCE(ROUND(AVG(EXTRACT(EPOCH FROM finished_at - started_at))::numeric, 2), 0) AS average_seconds
+		FROM attempts`, attempts).Scan(&analysis.Summary).Error; err != nil {
+		logrus.Warningln("[DATABASE] Failed to analyze QuizAttempt")
+		return nil, errors.New("[DATABASE] Failed to analyze QuizAttempt")
+	}
+
+	if err := repos.DB.Raw(`WITH attempts AS (?)
+		SELECT q.id AS quizes_id, q.quiz, q.type, q.points,
+			COUNT(*) AS given,
+			COUNT(r.id) AS answered,
+			COUNT(*) FILTER (WHERE r.is_correct) AS correct,
+			ROUND(AVG(CASE WHEN r.is_correct THEN 100 ELSE 0 END), 2) AS percent_correct,
+			ROUND(AVG(COALESCE(r.points, 0))::numeric, 2) AS average_score,
+			ROUND(COALESCE(AVG(CASE WHEN r.is_correct THEN 1 ELSE 0 END) FILTER (WHERE a.rank <= a.group_size), 0)
+				- COALESCE(AVG(CASE WHEN r.is_correct THEN 1 ELSE 0 END) FILTER (WHERE a.rank > a.total - a.group_size), 0), 2) AS discrimination
+		FROM quiz_attempt_questions aq
+		JOIN attempts a ON a.id = aq.quiz_attempt_id
+		JOIN quizes q ON q.id = aq.quizes_id
+		LEFT JOIN quiz_answer_students r ON r.quiz_attempt_id = aq.quiz_attempt_id AND r.quizes_id = aq.quizes_id AND r.deleted_at IS NULL
+		GROUP BY q.id, q.quiz, q.type, q.points, q.created_at
+		ORDER BY q.created_at ASC`, attempts).Scan(&analysis.Questions).Error; err != nil {
+		logrus.Warningln("[DATABASE] Failed to analyze Quizes")
+		return nil, errors.New("[DATABASE] Failed to analyze Quizes")
+	}
+
+	// Pilihan yang dipilih disimpan di quiz_answer_id untuk SINGLE_CHOICE dan TRUE_FALSE,
+	// dan di selected_answer_ids (json) untuk MULTIPLE_CHOICE
+	if err := repos.DB.Raw(`WITH attempts AS (?),
+		responses AS (
+			SELECT r.quiz_answer_id, r.selected_answer_ids
+			FROM quiz_answer_students r
+			JOIN attempts a ON a.id = r.quiz_attempt_id
+			WHERE r.deleted_at IS NULL
+		),
+		selections AS (
+			SELECT quiz_answer_id FROM responses WHERE quiz_answer_id IS NOT NULL
+			UNION ALL
+			SELECT s.quiz_answer_id
+			FROM responses
+			CROSS JOIN LATERAL jsonb_array_elements_text(
+				CASE WHEN jsonb_typeof(NULLIF(selected_answer_ids, '')::jsonb) = 'array'
+					THEN selected_answer_ids::jsonb ELSE '[]'::jsonb END
+			) AS s(quiz_answer_id)
+		)
+		SELECT o.quizes_id, o.id AS quiz_answer_id, o.answer, o.is_correct, COUNT(s.quiz_answer_id) AS selected
+		FROM quiz_answers o
+		JOIN quizes q ON q.id = o.quizes_id
+		LEFT JOIN selections s ON s.quiz_answer_id = o.id
+		WHERE o.deleted_at IS NULL
+			AND q.type IN ?
+			AND o.quizes_id IN (SELECT aq.quizes_id FROM quiz_attempt_questions aq JOIN attempts a ON a.id = aq.quiz_attempt_id)
+		GROUP BY o.quizes_id, o.id, o.answer, o.is_correct, o.created_at
+		ORDER BY o.created_at ASC`, attempts, []model.QUESTION_TYPE{model.SINGLE_CHOICE, model.MULTIPLE_CHOICE, model.TRUE_FALSE}).Scan(&analysis.Distractors).Error; err != nil {
+		logrus.Warningln("[DATABASE] Failed to analyze QuizAnswer")
+		return nil, errors.New("[DATABASE] Failed to analyze QuizAnswer")
+	}
+
+	if err := repos.DB.Raw(`WITH attempts AS (?)
+		SELECT b.bucket * 10 AS min_grades,
+			CASE WHEN b.bucket = 9 THEN 100 ELSE b.bucket * 10 + 9 END AS max_grades,
+			COUNT(a.id) AS total
+		FROM generate_series(0, 9) AS b(bucket)
+		LEFT JOIN attempts a ON LEAST(GREATEST(a.grades, 0) / 10, 9) = b.bucket
+		GROUP BY b.bucket
+		ORDER BY b.bucket ASC`, attempts).Scan(&analysis.ScoreDistribution).Error; err != nil {
+		logrus.Warningln("[DATABASE] Failed to analyze QuizAttempt")
+		return nil, errors.New("[DATABASE] Failed to analyze QuizAttempt")
+	}
+
+	return &analysis, nil
+}
+
+// RegradeQuiz corrects the answer key of the questions of a quiz, then grades the responses of
+// every finished attempt again against the current answer key and points of the questions the
+// attempt was given. Corrections are allowed on a published version, they are the only way to
+// fix the questions students pinned to it were graded against. Attempts whose result changed
+// get a REGRADE audit with the reason, the score of an overridden attempt is kept.
+func (repos *quizImpl) RegradeQuiz(quizID string, corrections []model.Quizes, reason string, userID string) (*[]model.QuizGradeAudit, error) {
+	audits := []model.QuizGradeAudit{}
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		quiz, err := repos.lockQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+
+		if err := correctQuizAnswerKey(tx, quiz.ID, corrections); err != nil {
+			return err
+		}
+
+		var attempts []model.QuizAttempt
+		if err := tx.Preload("Responses").
+			Where("quiz_id = ?", quiz.ID).
+			Where("finished_at IS NOT NULL").
+			Order("created_at ASC").
+			Find(&attempts).Error; err != nil {
+			logrus.Warningln("[DATABASE] QuizAttempt not found")
+			return errors.New("[DATABASE] QuizAttempt not found")
+		}
+
+		for i := range attempts {
+			attempt := &attempts[i]
+
+			questions, err := repos.regradeQuestions(tx, quiz, attempt.ID)
+			if err != nil {
+				return err
+			}
+
+			given := make(map[string]model.Quizes)
+			for _, question := range questions {
+				given[question.ID] = question
+			}
+
+			var score float64
+			for _, response := range attempt.Responses {
+				question, ok := given[response.QuizesID]
+				if !ok {
+					score += response.Points
+					continue
+				}
+
+				credit := helper.GradeQuizQuestion(question, quizResponseSelection(response), response.AnswerText)
+				points := credit * float64(question.Points)
+				isCorrect := credit == 1
+
+				if !sameQuizScore(points, response.Points) || isCorrect != response.IsCorrect {
+					if err := tx.Model(&model.QuizAnswerStudent{}).Where("id = ?", response.ID).Updates(map[string]interface{}{
+						"points":     points,
+						"is_correct": isCorrect,
+					}).Error; err != nil {
+						logrus.Warningln("[DATABASE] Error updating QuizAnswerStudent")
+						return errors.New("[DATABASE] Error updating QuizAnswerStudent")
+					}
+				}
+
+				score += points
+			}
+
+			if attempt.ScoreOverridden {
+				continue
+			}
+
+			totalPoints := quizTotalPoints(questions)
+			grades := helper.QuizGrades(score, totalPoints)
+
+			if sameQuizScore(score, attempt.Score) && grades == attempt.Grades && totalPoints == attempt.TotalPoints {
+				continue
+			}
+
+			audit, err := newQuizGradeAudit(attempt, model.GRADE_REGRADE, userID, score, grades, reason)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Model(&model.QuizAttempt{}).Where("id = ?", attempt.ID).Updates(map[string]interface{}{
+				"score":        score,
+				"grades":       grades,
+				"total_points": totalPoints,
+			}).Error; err != nil {
+				logrus.Warningln("[DATABASE] Error updating QuizAttempt")
+				return errors.New("[DATABASE] Error updating QuizAttempt")
+			}
+
+			if err := tx.Create(&audit).Error; err != nil {
+				logrus.Warningln("[DATABASE] Error creating QuizGradeAudit")
+				return errors.New("[DATABASE] Error creating QuizGradeAudit")
+			}
+
+			audits = append(audits, audit)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &audits, nil
+}
+
+// correctQuizAnswerKey stores the points of the corrected questions of a quiz and whether their
+// options are correct.
+func correctQuizAnswerKey(tx *gorm.DB, quizID string, corrections []model.Quizes) error {
+	for _, question := range corrections {
+		result := tx.Model(&model.Quizes{}).
+			Where("id = ? AND quiz_id = ?", question.ID, quizID).
+			Update("points", question.Points)
+		if result.Error != nil || result.RowsAffected == 0 {
+			logrus.Warningln("[DATABASE] Error correcting Quizes")
+			return errors.New("[DATABASE] Error correcting Quizes")
+		}
+
+		for _, answer := range question.QuizAnswers {
+			if err := tx.Model(&model.QuizAnswer{}).
+				Where("id = ? AND quizes_id = ?", answer.ID, question.ID).
+				Updates(map[string]interface{}{
+					"is_correct": answer.IsCorrect,
+					"tolerance":  answer.Tolerance,
+				}).Error; err != nil {
+				logrus.Warningln("[DATABASE] Error correcting QuizAnswer")
+				return errors.New("[DATABASE] Error correcting QuizAnswer")
+			}
+		}
+	}
+
+	return nil
+}
+
+// regradeQuestions returns the questions an attempt was given. Attempts finished before
+// questions were recorded are graded against the questions of the quiz.
+func (repos *quizImpl) regradeQuestions(tx *gorm.DB, quiz *model.Quiz, attemptID string) ([]model.Quizes, error) {
+	questions, err := repos.findQuizAttemptQuestions(tx, attemptID)
+	if err != nil || len(questions) > 0 {
+		return questions, err
+	}
+
+	if err := tx.Preload("QuizAnswers").Where("quiz_id = ?", quiz.ID).Order("created_at ASC").Find(&questions).Error; err != nil {
+		return nil, err
+	}
+
+	return questions, nil
+}
+
+// OverrideQuizAttemptScore sets the score of a finished attempt by hand and records it
+// with the reason in an OVERRIDE audit. A nil score removes the override, the attempt
+// gets back the points of its graded responses.
+func (repos *quizImpl) OverrideQuizAttemptScore(attemptID string, score *float64, reason string, userID string) (*model.QuizAttempt, error) {
+	var attempt model.QuizAttempt
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", attemptID).
+			Where("finished_at IS NOT NULL").
+			First(&attempt).Error; err != nil {
+			logrus.Warningln("[DATABASE] QuizAttempt not found")
+			return errors.New("[DATABASE] QuizAttempt not found")
+		}
+
+		var newScore float64
+		if score != nil {
+			newScore = *score
+		} else if err := tx.Model(&model.QuizAnswerStudent{}).
+			Where("quiz_attempt_id = ?", attempt.ID).
+			Select("COALESCE(SUM(points), 0)").
+			Scan(&newScore).Error; err != nil {
+			return err
+		}
+
+		grades := helper.QuizGrades(newScore, attempt.TotalPoints)
+
+		audit, err := newQuizGradeAudit(&attempt, model.GRADE_OVERRIDE, userID, newScore, grades, reason)
+		if err != nil {
+			return err
+		}
+
+		attempt.Score = newScore
+		attempt.Grades = grades
+		attempt.ScoreOverridden = score != nil
+
+		if err := tx.Model(&model.QuizAttempt{}).Where("id = ?", attempt.ID).Updates(map[string]interface{}{
+			"score":            attempt.Score,
+			"grades":           attempt.Grades,
+			"score_overridden": attempt.ScoreOverridden,
+		}).Error; err != nil {
+			logrus.Warningln("[DATABASE] Error updating QuizAttempt")
+			return errors.New("[DATABASE] Error updating QuizAttempt")
+		}
+
+		if err := tx.Create(&audit).Error; err != nil {
+			logrus.Warningln("[DATABASE] Error creating QuizGradeAudit")
+			return errors.New("[DATABASE] Error creating QuizGradeAudit")
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// GetQuizGradeAudits finds grade audits based on conditions, newest first.
+func (repos *quizImpl) GetQuizGradeAudits(codd map[string]interface{}) (*[]model.QuizGradeAudit, error) {
+	var audits []model.QuizGradeAudit
+	if err := repos.DB.Where(codd).Order("created_at DESC").Find(&audits).Error; err != nil {
+		logrus.Warningln("[DATABASE] QuizGradeAudit not found")
+		return nil, errors.New("[DATABASE] QuizGradeAudit not found")
+	}
+
+	return &audits, nil
+}
+
+// newQuizGradeAudit records the change of the result of an attempt to score and grades.
+func newQuizGradeAudit(attempt *model.QuizAttempt, action model.GRADE_CHANGE, userID string, score float64, grades int, reason string) (model.QuizGradeAudit, error) {
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return model.QuizGradeAudit{}, err
+	}
+
+	return model.QuizGradeAudit{
+		ID:              id,
+		QuizID:          attempt.QuizID,
+		QuizAttemptID:   attempt.ID,
+		ActiveStudentID: attempt.ActiveStudentID,
+		UserID:          userID,
+		Action:          action,
+		OldScore:        attempt.Score,
+		NewScore:        score,
+		OldGrades:       attempt.Grades,
+		NewGrades:       grades,
+		Reason:          reason,
+	}, nil
+}
+
+// sameQuizScore compares scores summed in a different order.
+func sameQuizScore(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -1,11 +1,17 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/cvzamannow/E-Learning-API/entity"
 	"github.com/cvzamannow/E-Learning-API/model"
 )
 
 type SchoolRepository interface {
+	// WithContext returns the repository running its queries with ctx, the queries are
+	// limited to the school of the Tenant in ctx.
+	WithContext(ctx context.Context) SchoolRepository
+
 	CreateSchool(request model.Schools) (*model.Schools, error)
 	FindSchool(codd map[string]interface{}) (*model.Schools, error)
 	GetAllSchool() (*[]model.Schools, error)
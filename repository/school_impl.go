@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 
 	"github.com/cvzamannow/E-Learning-API/entity"
@@ -19,6 +20,13 @@ func NewSchoolRepository(db *gorm.DB) SchoolRepository {
 	}
 }
 
+// WithContext implements SchoolRepository.
+func (repos *schoolImpl) WithContext(ctx context.Context) SchoolRepository {
+	return &schoolImpl{
+		DB: repos.DB.WithContext(ctx),
+	}
+}
+
 func (repos *schoolImpl) CreateSchool(request model.Schools) (*model.Schools, error) {
 	var existingSchool model.Schools
 
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTenantMismatch is returned when a record of another school is created.
+var ErrTenantMismatch = errors.New("[DATABASE] Record belongs to another school")
+
+// Tenant is the school a request is limited to. Only SUPER_ADMIN has AllSchools,
+// a user without a school is limited to the records without a school.
+type Tenant struct {
+	SchoolID   string
+	AllSchools bool
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant. Repositories created with
+// WithContext(ctx) only see the records of its school.
+func WithTenant(ctx context.Context, tenant Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant of ctx, ok is false when the request is not scoped.
+func TenantFromContext(ctx context.Context) (Tenant, bool) {
+	if ctx == nil {
+		return Tenant{}, false
+	}
+
+	tenant, ok := ctx.Value(tenantKey{}).(Tenant)
+	return tenant, ok
+}
+
+// allSchools returns db reading the records of every school. It is only meant for the records
+// shared across schools, like the courses of the template library.
+func allSchools(db *gorm.DB) *gorm.DB {
+	return db.WithContext(WithTenant(db.Statement.Context, Tenant{AllSchools: true}))
+}
+
+// tenantScopes returns the condition limiting a table to a school, t is the quoted name of the
+// table in the statement. Tables of the auth session and certificates are not scoped.
+func tenantScopes(school func(column string) string) map[string]func(t string) string {
+	students := "SELECT id FROM students WHERE " + school("schools_id")
+	teachers := "SELECT id FROM teachers WHERE " + school("schools_id")
+	admins := "SELECT user_id FROM admin_schools WHERE " + school("school_id")
+	activeStudents := "SELECT id FROM active_students WHERE student_id IN (" + students + ")"
+	courses := "SELECT id FROM courses WHERE teacher_id IN (" + teachers + ")"
+	chapters := "SELECT id FROM chapters WHERE course_id IN (" + courses + ")"
+	// Material quiz dibuat tanpa course_id, jadi material dibatasi lewat chapter nya
+	materials := "SELECT id FROM materials WHERE chapter_id IN (" + chapters + ")"
+	quizzes := "SELECT id FROM quizzes WHERE chapter_id IN (" + chapters + ")"
+	banks := "SELECT id FROM question_banks WHERE course_id IN (" + courses + ")"
+	questions := "SELECT id FROM quizes WHERE quiz_id IN (" + quizzes + ") OR question_bank_id IN (" + banks + ")"
+	attempts := "SELECT id FROM quiz_attempts WHERE quiz_id IN (" + quizzes + ")"
+
+	in := func(column, subquery string) func(t string) string {
+		return func(t string) string {
+			return fmt.Sprintf("%s.%s IN (%s)", t, column, subquery)
+		}
+	}
+
+	return map[string]func(t string) string{
+		"schools":       func(t string) string { return school(t + ".id") },
+		"students":      func(t string) string { return school(t + ".schools_id") },
+		"teachers":      func(t string) string { return school(t + ".schools_id") },
+		"admin_schools": func(t string) string { return school(t + ".school_id") },
+		"invitations":   func(t string) string { return school(t + ".school_id") },
+		"users": func(t string) string {
+			return fmt.Sprintf("%s.id IN (SELECT user_id FROM students WHERE %s UNION SELECT user_id FROM teachers WHERE %s UNION %s)",
+				t, school("schools_id"), school("schools_id"), admins)
+		},
+		"active_students":        in("student_id", students),
+		"courses":                in("teacher_id", teachers),
+		"chapters":               in("course_id", courses),
+		"materials":              in("chapter_id", chapters),
+		"material_prerequisites": in("material_id", materials),
+		"theories":               in("material_id", materials),
+		"submissions":            in("material_id", materials),
+		"scorm_packages":         in("material_id", materials),
+		"scorm_registrations":    in("active_student_id", activeStudents),
+		"course_classes":         in("course_id", courses),
+		"course_teachers":        in("course_id", courses),
+		"course_versions":        in("course_id", courses),
+		"course_enrollments":     in("active_student_id", activeStudents),
+		"submission_students":    func(t string) string { return school(t + ".school_id") },
+		"active_student_courses": in("active_student_id", activeStudents),
+		"complete_courses":       in("active_student_id", activeStudents),
+		"quizzes":                in("chapter_id", chapters),
+		"question_banks":         in("course_id", courses),
+		"quizes": func(t string) string {
+			return fmt.Sprintf("(%s.quiz_id IN (%s) OR %s.question_bank_id IN (%s))", t, quizzes, t, banks)
+		},
+		"quiz_draw_rules":        in("quiz_id", quizzes),
+		"quiz_answers":           in("quizes_id", questions),
+		"quiz_answer_students":   in("quiz_id", quizzes),
+		"quiz_attempts":          in("quiz_id", quizzes),
+		"quiz_attempt_questions": in("quiz_attempt_id", attempts),
+		"quiz_grade_audits":      in("quiz_id", quizzes),
+	}
+}
+
+// tenantColumns are the school columns set on created records.
+var tenantColumns = map[string]string{
+	"students":            "schools_id",
+	"teachers":            "schools_id",
+	"admin_schools":       "school_id",
+	"invitations":         "school_id",
+	"submission_students": "school_id",
+}
+
+// RegisterTenantScope registers the GORM callbacks limiting queries, updates and deletes
+// to the tenant of the statement context. Raw queries are not scoped, build their subqueries
+// with the query builder so they are.
+func RegisterTenantScope(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("tenant:query", scopeTenant); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Row().Before("gorm:row").Register("tenant:row", scopeTenant); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Update().Before("gorm:update").Register("tenant:update", scopeTenant); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", scopeTenant); err != nil {
+		return err
+	}
+
+	return db.Callback().Create().Before("gorm:create").Register("tenant:create", checkTenant)
+}
+
+func scopeTenant(db *gorm.DB) {
+	tenant, ok := TenantFromContext(db.Statement.Context)
+	if !ok || tenant.AllSchools || db.Statement.Table == "" {
+		return
+	}
+
+	school := func(column string) string {
+		if tenant.SchoolID == "" {
+			return column + " IS NULL"
+		}
+		return column + " = @school"
+	}
+
+	scope, ok := tenantScopes(school)[db.Statement.Table]
+	if !ok {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.NamedExpr{
+			SQL:  scope(db.Statement.Quote(db.Statement.Table)),
+			Vars: []interface{}{sql.Named("school", tenant.SchoolID)},
+		},
+	}})
+}
+
+// checkTenant fills the school of created records and rejects records of another school.
+func checkTenant(db *gorm.DB) {
+	tenant, ok := TenantFromContext(db.Statement.Context)
+	if !ok || tenant.AllSchools || db.Statement.Schema == nil {
+		return
+	}
+
+	column, ok := tenantColumns[db.Statement.Table]
+	if !ok {
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField(column)
+	if field == nil {
+		return
+	}
+
+	check := func(rv reflect.Value) {
+		value, zero := field.ValueOf(db.Statement.Context, rv)
+		if zero {
+			if tenant.SchoolID != "" {
+				db.AddError(field.Set(db.Statement.Context, rv, tenant.SchoolID))
+			}
+			return
+		}
+
+		schoolID := reflect.Indirect(reflect.ValueOf(value))
+		if !schoolID.IsValid() || schoolID.String() != tenant.SchoolID {
+			db.AddError(ErrTenantMismatch)
+		}
+	}
+
+	switch db.Statement.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+			check(reflect.Indirect(db.Statement.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		check(db.Statement.ReflectValue)
+	}
+}
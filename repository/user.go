@@ -1,10 +1,17 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/cvzamannow/E-Learning-API/model"
 )
 
 type UserRepository interface {
+	// WithContext returns the repository running its queries with ctx, the queries are
+	// limited to the school of the Tenant in ctx.
+	WithContext(ctx context.Context) UserRepository
+
 	// User
 	CreateUser(request model.User) (*model.User, error)
 	FindUser(cond map[string]interface{}) (*model.User, error)
@@ -43,4 +50,48 @@ type UserRepository interface {
 	GetAllAdminSchool() (*[]model.AdminSchool, error)
 	UpdateAdminSchool(codd map[string]interface{}, request model.AdminSchool) (*model.AdminSchool, error)
 	DeleteAdminSchool(codd map[string]interface{}) (*model.AdminSchool, error)
+
+	// Session
+	// CreateUserSession stores a new login session together with its first refresh token.
+	CreateUserSession(session model.UserSession, token model.RefreshToken) (*model.UserSession, error)
+	// RotateRefreshToken marks the refresh token with the given hash as used and stores next
+	// in the same session. Reusing a used token revokes the whole session.
+	RotateRefreshToken(tokenHash string, next model.RefreshToken) (*model.UserSession, error)
+	RevokeUserSession(codd map[string]interface{}, reason string) error
+	// IsSessionActive reports whether the session is not revoked and its user still exists and is ACTIVE.
+	IsSessionActive(sessionID, userID string) (bool, error)
+	// ChangePassword stores a new password hash and revokes every session of the user.
+	ChangePassword(userID string, passwordHash string, mustChange bool) (*model.User, error)
+
+	// Login Attempt
+	// The failed logins shared by every instance, see middleware.LoginAttemptStore.
+	FindLoginAttempt(key string) (*model.LoginAttempt, error)
+	RecordLoginFailure(key string, now time.Time, window time.Duration) (*model.LoginAttempt, error)
+	LockLogin(key string, until time.Time) error
+	ResetLoginAttempts(key string) error
+
+	// Invitation
+	CreateInvitation(data model.Invitation) (*model.Invitation, error)
+	FindInvitations(codd map[string]interface{}) ([]model.Invitation, error)
+	// FindInvitationByToken returns the invitation of the token hash when it can still be accepted.
+	FindInvitationByToken(tokenHash string) (*model.Invitation, error)
+	DeleteInvitation(codd map[string]interface{}) (*model.Invitation, error)
+	// AcceptInvitation creates user with the role of the invitation together with its student,
+	// teacher or admin of the school, and marks the invitation as used.
+	AcceptInvitation(tokenHash string, user model.User, name string, idNumber int) (*model.Invitation, error)
+
+	// Two-Factor
+	// FindUserTOTP returns nil when the user has not enrolled TOTP.
+	FindUserTOTP(userID string) (*model.UserTOTP, error)
+	// SaveUserTOTP stores the secret of a new enrollment, replacing an unconfirmed one.
+	SaveUserTOTP(data model.UserTOTP) error
+	// ConfirmUserTOTP enables the TOTP of the user and replaces its recovery codes.
+	ConfirmUserTOTP(userID string, step int64, recoveryCodeHashes []string) error
+	// UseTOTPStep marks a time step as used, it returns false when the step or a later one was used.
+	UseTOTPStep(userID string, step int64) (bool, error)
+	// UseRecoveryCode marks a recovery code as used, it returns false when it is unknown or used.
+	UseRecoveryCode(userID string, codeHash string) (bool, error)
+	CountRecoveryCodes(userID string) (int64, error)
+	// DeleteUserTOTP disables the TOTP of the user and deletes its recovery codes.
+	DeleteUserTOTP(userID string) error
 }
@@ -1,12 +1,27 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"time"
 
+	"github.com/cvzamannow/E-Learning-API/helper"
 	"github.com/cvzamannow/E-Learning-API/model"
 	"github.com/sirupsen/logrus"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned when a refresh token is unknown, expired or its session is revoked.
+	ErrRefreshTokenInvalid = errors.New("[DATABASE] Refresh token is invalid")
+	// ErrRefreshTokenReused is returned when a used refresh token is presented again.
+	ErrRefreshTokenReused = errors.New("[DATABASE] Refresh token has already been used")
+	// ErrInvitationInvalid is returned when an invitation token is unknown, revoked, used or expired.
+	ErrInvitationInvalid = errors.New("[DATABASE] Invitation is invalid")
+	// ErrUsernameTaken is returned when an invitation is accepted with a username that is already registered.
+	ErrUsernameTaken = errors.New("[DATABASE] Username is already registered")
 )
 
 type userImpl struct {
@@ -19,6 +34,13 @@ func NewAuthRepository(db *gorm.DB) UserRepository {
 	}
 }
 
+// WithContext implements UserRepository.
+func (repos *userImpl) WithContext(ctx context.Context) UserRepository {
+	return &userImpl{
+		DB: repos.DB.WithContext(ctx),
+	}
+}
+
 // FindUser implements AuthRepository.
 // Use map[string]interface{} for flexbility query.
 func (a *userImpl) FindUser(cond map[string]interface{}) (*model.User, error) {
@@ -93,7 +115,20 @@ func (repos *userImpl) FindTeacher(codd map[string]interface{}) (*model.Teacher,
 func (repos *userImpl) DeleteTeacher(codd map[string]interface{}) (*model.Teacher, error) {
 	var teacher model.Teacher
 
-	if err := repos.DB.Preload("User").Where(codd).Delete(&teacher).Error; err != nil {
+	if err := repos.DB.Where(codd).First(&teacher).Error; err != nil {
+		return nil, err
+	}
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&teacher).Error; err != nil {
+			return err
+		}
+
+		return deleteUserAccount(tx, teacher.UserID)
+	})
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to delete teacher:", err)
 		return nil, err
 	}
 
@@ -130,7 +165,15 @@ func (repos *userImpl) UpdateTeacher(codd map[string]interface{}, request model.
 	user := &teacher.User
 
 	user.Username = request.User.Username
-	user.Password = request.User.Password
+	if err := changeUserPassword(tx, user, request.User.Password, true); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := changeUserStatus(tx, user, request.User.Status); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	if err := tx.Save(&user).Error; err != nil {
 		tx.Rollback()
@@ -140,6 +183,8 @@ func (repos *userImpl) UpdateTeacher(codd map[string]interface{}, request model.
 	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
+
+	request.UserID = teacher.UserID
 	return &request, nil
 }
 
@@ -168,7 +213,20 @@ func (repos *userImpl) CreateStudent(request model.Student) (*model.Student, err
 func (repos *userImpl) DeleteStudent(codd map[string]interface{}) (*model.Student, error) {
 	var student model.Student
 
-	if err := repos.DB.Preload("User").Where(codd).Delete(&student).Error; err != nil {
+	if err := repos.DB.Where(codd).First(&student).Error; err != nil {
+		return nil, err
+	}
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&student).Error; err != nil {
+			return err
+		}
+
+		return deleteUserAccount(tx, student.UserID)
+	})
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to delete student:", err)
 		return nil, err
 	}
 
@@ -221,7 +279,16 @@ func (repos *userImpl) UpdateStudent(codd map[string]interface{}, request model.
 	user := &student.User
 
 	user.Username = request.User.Username
-	user.Password = request.User.Password
+	if err := changeUserPassword(tx, user, request.User.Password, true); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := changeUserStatus(tx, user, request.User.Status); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := tx.Save(&user).Error; err != nil {
 		tx.Rollback()
 		return nil, err
@@ -231,6 +298,7 @@ func (repos *userImpl) UpdateStudent(codd map[string]interface{}, request model.
 		return nil, err
 	}
 
+	request.UserID = student.UserID
 	return &request, nil
 }
 
@@ -390,8 +458,16 @@ func (repos *userImpl) UpdateAdminSchool(codd map[string]interface{}, request mo
 	user := &admin.User
 
 	user.Username = request.User.Username
-	user.Password = request.User.Password
-	user.Status = request.User.Status
+	if err := changeUserPassword(tx, user, request.User.Password, true); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := changeUserStatus(tx, user, request.User.Status); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := tx.Save(&user).Error; err != nil {
 		tx.Rollback()
 		return nil, err
@@ -401,6 +477,7 @@ func (repos *userImpl) UpdateAdminSchool(codd map[string]interface{}, request mo
 		return nil, err
 	}
 
+	request.UserID = admin.UserID
 	return &request, nil
 }
 
@@ -412,7 +489,15 @@ func (repos *userImpl) DeleteAdminSchool(codd map[string]interface{}) (*model.Ad
 		return nil, err
 	}
 
-	if err := repos.DB.Delete(&admin).Error; err != nil {
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&admin).Error; err != nil {
+			return err
+		}
+
+		return deleteUserAccount(tx, admin.UserID)
+	})
+
+	if err != nil {
 		logrus.Warningln("[database] Failed to delete admin school")
 		return nil, err
 	}
@@ -429,3 +514,541 @@ func (repos *userImpl) FindTeachers(codd map[string]interface{}) (*[]model.Teach
 
 	return &teachers, nil
 }
+
+// CreateUserSession implements UserRepository.
+func (repos *userImpl) CreateUserSession(session model.UserSession, token model.RefreshToken) (*model.UserSession, error) {
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		token.UserSessionID = session.ID
+		return tx.Create(&token).Error
+	})
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to create user session:", err)
+		return nil, errors.New("[DATABASE] Error creating UserSession")
+	}
+
+	return &session, nil
+}
+
+// RotateRefreshToken implements UserRepository.
+func (repos *userImpl) RotateRefreshToken(tokenHash string, next model.RefreshToken) (*model.UserSession, error) {
+	var session model.UserSession
+	reused := false
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		var token model.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+			return ErrRefreshTokenInvalid
+		}
+
+		if err := tx.Preload("User").Where("id = ?", token.UserSessionID).First(&session).Error; err != nil {
+			return ErrRefreshTokenInvalid
+		}
+
+		if session.RevokedAt != nil {
+			return ErrRefreshTokenInvalid
+		}
+
+		// Token yang sudah pernah dipakai dikirim lagi, anggap token bocor dan cabut seluruh sesi.
+		// Pencabutan tetap di-commit sehingga error dikembalikan di luar transaksi.
+		if token.UsedAt != nil {
+			reused = true
+			return revokeUserSessions(tx, map[string]interface{}{"id": session.ID}, model.SESSION_TOKEN_REUSED)
+		}
+
+		now := time.Now()
+		if token.ExpiresAt.Before(now) {
+			return ErrRefreshTokenInvalid
+		}
+
+		// User yang sudah dihapus tidak ikut ter-preload
+		if session.User.ID == "" || session.User.Status != model.USER_ACTIVE {
+			return ErrRefreshTokenInvalid
+		}
+
+		if err := tx.Model(&token).Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		next.UserSessionID = session.ID
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+
+		session.LastUsedAt = now
+		return tx.Model(&session).Update("last_used_at", now).Error
+	})
+
+	if reused {
+		logrus.Warningln("[database] Refresh token reused, session revoked:", session.ID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	if err != nil {
+		if !errors.Is(err, ErrRefreshTokenInvalid) {
+			logrus.Warningln("[database] Failed to rotate refresh token:", err)
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// RevokeUserSession implements UserRepository.
+func (repos *userImpl) RevokeUserSession(codd map[string]interface{}, reason string) error {
+	if err := revokeUserSessions(repos.DB, codd, reason); err != nil {
+		logrus.Warningln("[database] Failed to revoke user session:", err)
+		return errors.New("[DATABASE] Error revoking UserSession")
+	}
+
+	return nil
+}
+
+// IsSessionActive implements UserRepository.
+func (repos *userImpl) IsSessionActive(sessionID, userID string) (bool, error) {
+	var total int64
+
+	err := repos.DB.Model(&model.UserSession{}).
+		Joins("JOIN users ON users.id = user_sessions.user_id AND users.deleted_at IS NULL").
+		Where("user_sessions.id = ? AND user_sessions.user_id = ?", sessionID, userID).
+		Where("user_sessions.revoked_at IS NULL AND users.status = ?", model.USER_ACTIVE).
+		Where("users.password_changed_at IS NULL OR user_sessions.created_at >= users.password_changed_at").
+		Count(&total).Error
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to check user session:", err)
+		return false, errors.New("[DATABASE] Error checking UserSession")
+	}
+
+	return total > 0, nil
+}
+
+// FindLoginAttempt implements UserRepository.
+func (repos *userImpl) FindLoginAttempt(key string) (*model.LoginAttempt, error) {
+	var attempt model.LoginAttempt
+
+	err := repos.DB.Where("key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to find login attempt:", err)
+		return nil, errors.New("[DATABASE] Error finding LoginAttempt")
+	}
+
+	return &attempt, nil
+}
+
+// RecordLoginFailure implements UserRepository. The failure is counted with a single upsert
+// so concurrent logins on several instances don't lose failures.
+func (repos *userImpl) RecordLoginFailure(key string, now time.Time, window time.Duration) (*model.LoginAttempt, error) {
+	attempt := model.LoginAttempt{
+		Key:          key,
+		Failures:     1,
+		LastFailedAt: now,
+	}
+
+	err := repos.DB.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures":       gorm.Expr("CASE WHEN login_attempts.last_failed_at <= ? THEN 1 ELSE login_attempts.failures + 1 END", now.Add(-window)),
+				"last_failed_at": now,
+				"updated_at":     now,
+			}),
+		},
+		clause.Returning{},
+	).Create(&attempt).Error
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to record login failure:", err)
+		return nil, errors.New("[DATABASE] Error recording LoginAttempt")
+	}
+
+	return &attempt, nil
+}
+
+// LockLogin implements UserRepository.
+func (repos *userImpl) LockLogin(key string, until time.Time) error {
+	err := repos.DB.Model(&model.LoginAttempt{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to lock login:", err)
+		return errors.New("[DATABASE] Error locking LoginAttempt")
+	}
+
+	return nil
+}
+
+// ResetLoginAttempts implements UserRepository.
+func (repos *userImpl) ResetLoginAttempts(key string) error {
+	err := repos.DB.Where("key = ?", key).Delete(&model.LoginAttempt{}).Error
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to reset login attempts:", err)
+		return errors.New("[DATABASE] Error deleting LoginAttempt")
+	}
+
+	return nil
+}
+
+// ChangePassword implements UserRepository.
+func (repos *userImpl) ChangePassword(userID string, passwordHash string, mustChange bool) (*model.User, error) {
+	var user model.User
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+
+		if err := changeUserPassword(tx, &user, passwordHash, mustChange); err != nil {
+			return err
+		}
+
+		return tx.Model(&user).Select("password", "password_changed_at", "must_change_password").Updates(&user).Error
+	})
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to change password:", err)
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// CreateInvitation implements UserRepository.
+func (repos *userImpl) CreateInvitation(data model.Invitation) (*model.Invitation, error) {
+	if err := repos.DB.Create(&data).Error; err != nil {
+		logrus.Warnln("[database] Failed to insert invitation because:", err)
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// FindInvitations implements UserRepository.
+func (repos *userImpl) FindInvitations(codd map[string]interface{}) ([]model.Invitation, error) {
+	var invitations []model.Invitation
+
+	if err := repos.DB.Preload("Schools").Where(codd).Order("created_at DESC").Find(&invitations).Error; err != nil {
+		logrus.Warnln("[database] Failed to find invitations because:", err)
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// FindInvitationByToken implements UserRepository.
+func (repos *userImpl) FindInvitationByToken(tokenHash string) (*model.Invitation, error) {
+	var invitation model.Invitation
+
+	err := repos.DB.Preload("Schools").
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		First(&invitation).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvitationInvalid
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// DeleteInvitation implements UserRepository.
+func (repos *userImpl) DeleteInvitation(codd map[string]interface{}) (*model.Invitation, error) {
+	var invitation model.Invitation
+
+	if err := repos.DB.Where(codd).First(&invitation).Error; err != nil {
+		return nil, err
+	}
+
+	if err := repos.DB.Delete(&invitation).Error; err != nil {
+		logrus.Warningln("[database] Failed to delete invitation:", err)
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// AcceptInvitation implements UserRepository.
+func (repos *userImpl) AcceptInvitation(tokenHash string, user model.User, name string, idNumber int) (*model.Invitation, error) {
+	var invitation model.Invitation
+
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		// Mengunci undangan agar token yang sama tidak bisa dipakai dua kali secara bersamaan
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&invitation).Error
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvitationInvalid
+		}
+
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if invitation.UsedAt != nil || !invitation.ExpiresAt.After(now) {
+			return ErrInvitationInvalid
+		}
+
+		var total int64
+		if err := tx.Model(&model.User{}).Where("username = ?", user.Username).Count(&total).Error; err != nil {
+			return err
+		}
+
+		if total > 0 {
+			return ErrUsernameTaken
+		}
+
+		user.Role = invitation.Role
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		switch invitation.Role {
+		case model.STUDENT:
+			err = tx.Create(&model.Student{
+				ID:        user.ID,
+				Name:      name,
+				IdNumber:  idNumber,
+				UserID:    user.ID,
+				SchoolsID: invitation.SchoolID,
+			}).Error
+		case model.TEACHER:
+			err = tx.Create(&model.Teacher{
+				ID:        user.ID,
+				Name:      name,
+				IdNumber:  idNumber,
+				UserID:    user.ID,
+				SchoolsID: &invitation.SchoolID,
+			}).Error
+		case model.ADMIN:
+			err = tx.Create(&model.AdminSchool{
+				ID:       user.ID,
+				UserID:   user.ID,
+				SchoolID: invitation.SchoolID,
+			}).Error
+		default:
+			return ErrInvitationInvalid
+		}
+
+		if err != nil {
+			return err
+		}
+
+		invitation.UsedAt = &now
+		invitation.UsedByUserID = &user.ID
+
+		return tx.Model(&invitation).Select("used_at", "used_by_user_id").Updates(&invitation).Error
+	})
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to accept invitation:", err)
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// FindUserTOTP implements UserRepository.
+func (repos *userImpl) FindUserTOTP(userID string) (*model.UserTOTP, error) {
+	var totp model.UserTOTP
+
+	err := repos.DB.Where("user_id = ?", userID).First(&totp).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to find user TOTP:", err)
+		return nil, errors.New("[DATABASE] Error finding UserTOTP")
+	}
+
+	return &totp, nil
+}
+
+// SaveUserTOTP implements UserRepository. A confirmed TOTP is never replaced.
+func (repos *userImpl) SaveUserTOTP(data model.UserTOTP) error {
+	err := repos.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "last_used_step", "created_at", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "user_totps.confirmed_at IS NULL"},
+		}},
+	}).Create(&data).Error
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to save user TOTP:", err)
+		return errors.New("[DATABASE] Error saving UserTOTP")
+	}
+
+	return nil
+}
+
+// ConfirmUserTOTP implements UserRepository.
+func (repos *userImpl) ConfirmUserTOTP(userID string, step int64, recoveryCodeHashes []string) error {
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.UserTOTP{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"confirmed_at":   time.Now(),
+				"last_used_step": step,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]model.RecoveryCode, 0, len(recoveryCodeHashes))
+		for _, hash := range recoveryCodeHashes {
+			id, err := helper.GenerateNanoId()
+			if err != nil {
+				return err
+			}
+
+			codes = append(codes, model.RecoveryCode{
+				ID:       id,
+				UserID:   userID,
+				CodeHash: hash,
+			})
+		}
+
+		if len(codes) == 0 {
+			return nil
+		}
+
+		return tx.Create(&codes).Error
+	})
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to confirm user TOTP:", err)
+		return errors.New("[DATABASE] Error confirming UserTOTP")
+	}
+
+	return nil
+}
+
+// UseTOTPStep implements UserRepository. The step is checked and stored with one update so
+// a code sent twice at the same time is only accepted once.
+func (repos *userImpl) UseTOTPStep(userID string, step int64) (bool, error) {
+	result := repos.DB.Model(&model.UserTOTP{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+
+	if result.Error != nil {
+		logrus.Warningln("[database] Failed to use TOTP step:", result.Error)
+		return false, errors.New("[DATABASE] Error updating UserTOTP")
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// UseRecoveryCode implements UserRepository.
+func (repos *userImpl) UseRecoveryCode(userID string, codeHash string) (bool, error) {
+	result := repos.DB.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		logrus.Warningln("[database] Failed to use recovery code:", result.Error)
+		return false, errors.New("[DATABASE] Error updating RecoveryCode")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// CountRecoveryCodes implements UserRepository, only the unused codes are counted.
+func (repos *userImpl) CountRecoveryCodes(userID string) (int64, error) {
+	var total int64
+
+	err := repos.DB.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&total).Error
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to count recovery codes:", err)
+		return 0, errors.New("[DATABASE] Error counting RecoveryCode")
+	}
+
+	return total, nil
+}
+
+// DeleteUserTOTP implements UserRepository.
+func (repos *userImpl) DeleteUserTOTP(userID string) error {
+	err := repos.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&model.UserTOTP{}).Error
+	})
+
+	if err != nil {
+		logrus.Warningln("[database] Failed to delete user TOTP:", err)
+		return errors.New("[DATABASE] Error deleting UserTOTP")
+	}
+
+	return nil
+}
+
+// revokeUserSessions revokes every session matching codd that is not revoked yet.
+func revokeUserSessions(tx *gorm.DB, codd map[string]interface{}, reason string) error {
+	return tx.Model(&model.UserSession{}).
+		Where(codd).
+		Where("revoked_at IS NULL").
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error
+}
+
+// deleteUserAccount deletes the user of a deleted teacher, student or admin so it can no longer
+// log in, and revokes its sessions.
+func deleteUserAccount(tx *gorm.DB, userID string) error {
+	if err := tx.Delete(&model.User{ID: userID}).Error; err != nil {
+		return err
+	}
+
+	return revokeUserSessions(tx, map[string]interface{}{"user_id": userID}, model.SESSION_USER_DELETED)
+}
+
+// changeUserStatus sets the status of user when status is given and revokes its sessions
+// when the status is changed.
+func changeUserStatus(tx *gorm.DB, user *model.User, status string) error {
+	if status == "" || status == user.Status {
+		return nil
+	}
+
+	user.Status = status
+	return revokeUserSessions(tx, map[string]interface{}{"user_id": user.ID}, model.SESSION_STATUS_CHANGED)
+}
+
+// changeUserPassword stores a new password hash and revokes the sessions of the user, an empty
+// hash keeps the current password. mustChange forces the user to change it at the next login.
+func changeUserPassword(tx *gorm.DB, user *model.User, passwordHash string, mustChange bool) error {
+	if passwordHash == "" {
+		return nil
+	}
+
+	now := time.Now()
+	user.Password = passwordHash
+	user.PasswordChangedAt = &now
+	user.MustChangePassword = mustChange
+
+	return revokeUserSessions(tx, map[string]interface{}{"user_id": user.ID}, model.SESSION_PASSWORD_CHANGED)
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/cvzamannow/E-Learning-API/helper"
+	"github.com/cvzamannow/E-Learning-API/model"
+	"github.com/cvzamannow/E-Learning-API/repository"
+	"github.com/gosimple/slug"
+	"github.com/sirupsen/logrus"
+)
+
+// CONFLICT_POLICY is what an import does when the school already has a course with the slug of the package.
+type CONFLICT_POLICY string
+
+const (
+	CONFLICT_FAIL   CONFLICT_POLICY = "FAIL"
+	CONFLICT_RENAME CONFLICT_POLICY = "RENAME"
+)
+
+// maxCourseAssetSize limits an asset downloaded into a course package.
+const maxCourseAssetSize = 50 << 20
+
+var ErrCoursePackageConflict = errors.New("a course with the same slug already exists")
+
+// CoursePackager exports courses to course packages and imports them, see helper.CoursePackage.
+type CoursePackager struct {
+	Courses repository.CourseRepository
+	// Storage uploads the assets of imported courses, without it the assets keep their original URL.
+	Storage *R2Stub
+	// AssetPrefixes are the URL prefixes of the uploaded assets bundled into exported packages.
+	AssetPrefixes []string
+	Client        *http.Client
+}
+
+func NewCoursePackager(courses repository.CourseRepository, storage *R2Stub) *CoursePackager {
+	packager := &CoursePackager{
+		Courses: courses,
+		Storage: storage,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if storage != nil && storage.PubBucketUrl != "" {
+		packager.AssetPrefixes = append(packager.AssetPrefixes, storage.PubBucketUrl)
+	}
+
+	return packager
+}
+
+// Export writes the package of the draft of a course to w. Assets that can't be downloaded are
+// left out of the package and keep their URL.
+func (p *CoursePackager) Export(courseID string, w io.Writer) (*helper.CoursePackage, error) {
+	tree, err := p.Courses.FindCourseTree(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := helper.NewCoursePackage(tree.Course, tree.Quizzes, tree.QuestionBanks)
+
+	assets := make(map[string][]byte)
+	for _, url := range pkg.AssetURLs(p.AssetPrefixes...) {
+		data, err := p.download(url)
+		if err != nil {
+			logrus.Warnln("[course-package] Skipping asset", url, "because error:", err)
+			continue
+		}
+
+		asset := pkg.AddAsset(url, data)
+		assets[asset.Path] = data
+	}
+
+	if err := helper.WriteCoursePackage(w, pkg, assets); err != nil {
+		return nil, err
+	}
+
+	return pkg, nil
+}
+
+func (p *CoursePackager) download(url string) ([]byte, error) {
+	res, err := p.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxCourseAssetSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxCourseAssetSize {
+		return nil, errors.New("asset is too large")
+	}
+
+	return data, nil
+}
+
+// Import creates a course of the teacher from a package with new IDs. The slug of the package is
+// checked against the courses of the school of the repository, on conflict the import fails or,
+// with CONFLICT_RENAME, the course gets the next free slug. The course is imported as a draft.
+func (p *CoursePackager) Import(data []byte, teacherID string, policy CONFLICT_POLICY) (*model.Course, error) {
+	pkg, assets, err := helper.ReadCoursePackage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	title, courseSlug, err := p.freeSlug(pkg.Course.Title, pkg.Course.Slug, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make(map[string]string, len(pkg.Assets))
+	if p.Storage == nil && len(pkg.Assets) > 0 {
+		logrus.Warnln("[course-package] Storage is not configured, assets keep their original URL")
+	}
+
+	for _, asset := range pkg.Assets {
+		if p.Storage == nil {
+			break
+		}
+
+		url, err := p.upload(asset, assets[asset.Path])
+		if err != nil {
+			return nil, err
+		}
+		urls[asset.URL] = url
+	}
+	pkg.ReplaceURLs(urls)
+
+	course, quizzes, banks, err := helper.CoursePackageModels(pkg, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	course.Title = title
+	course.Slug = courseSlug
+
+	return p.Courses.ImportCourseTree(repository.CourseTree{
+		Course:        *course,
+		Quizzes:       quizzes,
+		QuestionBanks: banks,
+	})
+}
+
+// freeSlug returns the title and slug of an imported course, see Import.
+func (p *CoursePackager) freeSlug(title string, courseSlug string, policy CONFLICT_POLICY) (string, string, error) {
+	if courseSlug == "" {
+		courseSlug = slug.Make(title)
+	}
+
+	exists, err := p.Courses.CourseSlugExists(courseSlug)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !exists {
+		return title, courseSlug, nil
+	}
+
+	if policy != CONFLICT_RENAME {
+		return "", "", fmt.Errorf("%w: %s", ErrCoursePackageConflict, courseSlug)
+	}
+
+	for n := 2; n <= 100; n++ {
+		candidate := fmt.Sprintf("%s-%d", courseSlug, n)
+
+		exists, err := p.Courses.CourseSlugExists(candidate)
+		if err != nil {
+			return "", "", err
+		}
+
+		if !exists {
+			return fmt.Sprintf("%s (%d)", title, n), candidate, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: %s", ErrCoursePackageConflict, courseSlug)
+}
+
+// upload uploads an asset of a package under a new random name with the extension of the
+// asset and returns its new URL.
+func (p *CoursePackager) upload(asset helper.CoursePackageAsset, data []byte) (string, error) {
+	id, err := helper.GenerateNanoId()
+	if err != nil {
+		return "", err
+	}
+
+	return p.Storage.PutObject(id+path.Ext(asset.Path), data)
+}
+
+// ExportBytes is Export into memory, for the HTTP handler.
+func (p *CoursePackager) ExportBytes(courseID string) (*helper.CoursePackage, []byte, error) {
+	var buf bytes.Buffer
+
+	pkg, err := p.Export(courseID, &buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pkg, buf.Bytes(), nil
+}
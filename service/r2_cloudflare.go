@@ -1,8 +1,10 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"mime"
 	"os"
 	"path"
 	"strings"
@@ -108,3 +110,25 @@ func (r2 *R2Stub) Upload(file string) (string, error) {
 	return fmt.Sprintf("%s/%s", r2.PubBucketUrl, object), nil
 
 }
+
+// PutObject uploads data as the object key and returns its public URL. The content type is taken
+// from the extension of key.
+func (r2 *R2Stub) PutObject(key string, data []byte) (string, error) {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	_, err := r2.Client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket:      aws.String(r2.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s", r2.PubBucketUrl, key), nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// scormUploadWorkers is the number of files of a SCORM package uploaded at the same time.
+const scormUploadWorkers = 8
+
+// UploadScormPackage uploads the extracted files of a SCORM package under scorm/<packageID>/, keeping
+// their paths so the relative links of the package keep working, and returns the URL of the folder.
+func UploadScormPackage(storage *R2Stub, packageID string, files map[string][]byte) (string, error) {
+	prefix := "scorm/" + packageID
+
+	names := make(chan string)
+	errs := make(chan error, len(files))
+
+	var wg sync.WaitGroup
+	for i := 0; i < scormUploadWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				if _, err := storage.PutObject(prefix+"/"+name, files[name]); err != nil {
+					errs <- fmt.Errorf("%s: %w", name, err)
+				}
+			}
+		}()
+	}
+
+	for name := range files {
+		names <- name
+	}
+	close(names)
+
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s", storage.PubBucketUrl, prefix), nil
+}
+
+// ScormLaunchURL is the URL of the launched file of a package uploaded to baseURL. The path is
+// escaped, the parameters of the launch are kept as they are.
+func ScormLaunchURL(baseURL string, launchPath string) string {
+	file, parameters := launchPath, ""
+	if i := strings.IndexAny(launchPath, "?#"); i >= 0 {
+		file, parameters = launchPath[:i], launchPath[i:]
+	}
+
+	segments := strings.Split(file, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return baseURL + "/" + strings.Join(segments, "/") + parameters
+}